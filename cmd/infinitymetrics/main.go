@@ -2,17 +2,29 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"infinity-metrics-installer/internal/admin"
 	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/cron"
+	"infinity-metrics-installer/internal/database"
+	"infinity-metrics-installer/internal/docker"
 	"infinity-metrics-installer/internal/errors"
+	"infinity-metrics-installer/internal/fleet"
 	"infinity-metrics-installer/internal/installer"
+	"infinity-metrics-installer/internal/license"
 	"infinity-metrics-installer/internal/logging"
+	"infinity-metrics-installer/internal/requirements"
+	"infinity-metrics-installer/internal/runresult"
+	"infinity-metrics-installer/internal/telemetry"
 	"infinity-metrics-installer/internal/updater"
 	"infinity-metrics-installer/internal/validation"
 
@@ -41,30 +53,207 @@ func main() {
 	logger.Debug("Working directory: %s", workingDirectory)
 
 	inst := installer.NewInstaller(logger)
+	defer handleCrash(logger, inst)
 
 	// Update environment variables with current version
-	os.Setenv("INFINITY_METRICS_VERSION", currentInstallerVersion)
+	os.Setenv(config.InstallerVersionEnvVar, currentInstallerVersion)
 
 	switch os.Args[1] {
 	case "install":
+		if hasFlag("--check") {
+			if err := runPreflight(logger); err != nil {
+				printError(logger, err)
+				os.Exit(1)
+			}
+			return
+		}
 		runInstall(inst, logger, startTime)
 	case "update":
 		runUpdate(inst, logger, startTime)
 	case "reload":
 		runReload(logger, startTime)
+	case "switch-slot":
+		err := runSwitchSlot(logger)
+		recordRun(logger, "switch-slot", startTime, err, nil)
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "refresh-config":
+		if err := runRefreshConfig(logger, startTime); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "update-history":
+		if err := runUpdateHistory(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
 	case "restore-db":
 		runRestoreDB(inst, logger, startTime)
+	case "backup":
+		backupPath, err := runBackup(inst, logger)
+		recordRun(logger, "backup", startTime, err, map[string]string{"backup": backupPath})
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "enable-backup-cron":
+		schedule, _ := flagValue("--schedule")
+		if err := cron.NewManager(logger).SetupBackupCronJob(schedule); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "disable-backup-cron":
+		if err := cron.NewManager(logger).RemoveBackupCronJob(); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "enable-watchdog":
+		if err := cron.NewManager(logger).SetupWatchdogJob(); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "disable-watchdog":
+		if err := cron.NewManager(logger).RemoveWatchdogJob(); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "check-cron":
+		if err := runCheckCron(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "list-backups":
+		if err := runListBackups(inst, logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "verify-backups":
+		if err := runVerifyBackups(inst, logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "test-backup-restore":
+		if err := runTestBackupRestore(inst, logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "fleet-update":
+		if err := runFleetUpdate(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "exec":
+		code, err := runExec(logger)
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+		os.Exit(code)
+	case "logs":
+		if err := runLogs(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "maintenance":
+		err := runMaintenance(logger)
+		recordRun(logger, "maintenance", startTime, err, nil)
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "repair-permissions":
+		err := runRepairPermissions(logger)
+		recordRun(logger, "repair-permissions", startTime, err, nil)
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "renew-cert":
+		err := runRenewCert(logger)
+		recordRun(logger, "renew-cert", startTime, err, nil)
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "cert-status":
+		if err := runCertStatus(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "doctor":
+		err := runDoctor(logger)
+		if hasFlag("--fix") {
+			recordRun(logger, "doctor", startTime, err, nil)
+		}
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "network-diagnostics":
+		if err := runNetworkDiagnostics(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "diff-env":
+		if err := runDiffEnv(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "explain-pull":
+		if err := runExplainPull(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "reconcile":
+		if err := updater.NewWatchdog(logger).Reconcile(); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "watch":
+		if err := runWatch(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "config":
+		err := runConfigCommand(logger)
+		if len(os.Args) >= 3 && os.Args[2] == "import" {
+			recordRun(logger, "config-import", startTime, err, nil)
+		}
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+	case "dump-env-template":
+		config.WriteEnvTemplate(os.Stdout)
+	case "configure-backups":
+		err := runConfigureBackups(logger)
+		recordRun(logger, "configure-backups", startTime, err, nil)
+		if err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
 	case "change-admin-password":
-		if err := runAdminPasswordChange(logger); err != nil {
-			fmt.Printf("Error: %v\n", err)
+		err := runAdminPasswordChange(logger)
+		recordRun(logger, "change-admin-password", startTime, err, nil)
+		if err != nil {
+			printError(logger, err)
 			os.Exit(1)
 		}
 	case "update-license-key":
-		if err := runUpdateLicenseKey(logger, startTime); err != nil {
-			fmt.Printf("Error: %v\n", err)
+		err := runUpdateLicenseKey(logger, startTime)
+		if !hasFlag("--validate-only") {
+			recordRun(logger, "update-license-key", startTime, err, nil)
+		}
+		if err != nil {
+			printError(logger, err)
 			os.Exit(1)
 		}
 	case "version", "--version", "-v":
+		if hasFlag("--check") {
+			os.Exit(runVersionCheck(logger))
+		}
 		printVersion()
 	case "help", "--help", "-h":
 		printUsage()
@@ -73,144 +262,1504 @@ func main() {
 		printUsage()
 		os.Exit(1)
 	}
-}
+}
+
+func initLogging() *logging.Logger {
+	logLevel := "info"
+	if envLevel := os.Getenv("LOG_LEVEL"); envLevel != "" {
+		logLevel = envLevel
+	}
+
+	verbose := false
+	if os.Getenv("VERBOSE") == "true" {
+		verbose = true
+	}
+
+	quiet := false
+	if os.Getenv("QUIET") == "true" {
+		quiet = true
+	}
+
+	config := logging.Config{
+		Level:   logLevel,
+		Verbose: verbose,
+		Quiet:   quiet,
+	}
+
+	// Also log to the CLI log file once an install created the log directory
+	// and it is writable, so full error chains are kept for debugging
+	logDir := os.Getenv("LOG_DIR")
+	if logDir == "" {
+		logDir = "/opt/infinity-metrics/logs"
+	}
+	if f, err := os.OpenFile(filepath.Join(logDir, "infinity-metrics-cli.log"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600); err == nil {
+		f.Close()
+		return logging.NewFileLogger(config)
+	}
+
+	// Configure the main logger to log to stdout
+	return logging.NewLogger(config)
+}
+
+func runInstall(inst *installer.Installer, logger *logging.Logger, startTime time.Time) {
+	logger.Debug("Initializing installation environment")
+
+	// Run the complete installation process
+	inst.SetResume(hasFlag("--resume"))
+	inst.SetAssumeYes(hasFlag("--assume-yes") || hasFlag("-y"))
+	inst.SetTUI(hasFlag("--tui"))
+	inst.SetCleanOrphans(hasFlag("--clean-orphans"))
+	if envFile, ok := flagValue("--env-file"); ok {
+		inst.SetEnvFile(envFile)
+	}
+	if domain, ok := flagValue("--confirm-domain"); ok {
+		inst.SetConfirmDomain(domain)
+	}
+	if value, ok := flagValue("--dns-wait"); ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			logger.Error("Invalid --dns-wait value %q: must be a positive duration such as 10m", value)
+			os.Exit(1)
+		}
+		inst.SetDNSWait(timeout)
+	}
+	reportPath, _ := flagValue("--report")
+	if err := inst.RunCompleteInstallation(); err != nil {
+		logFailure(logger, "Installation failed", err)
+		inst.LogTimingSummary()
+		writeInstallReport(inst, logger, reportPath, startTime, err)
+		reportTelemetry(logger, "install", false, inst.CurrentStep(), startTime)
+		recordRun(logger, "install", startTime, err, nil)
+		if installer.IsDatabaseCorrupted(err) {
+			offerDatabaseRestore(inst, logger)
+		} else {
+			offerCrashReport(logger, err, inst.GetConfig().GetData())
+		}
+		os.Exit(1)
+	}
+	reportTelemetry(logger, "install", true, "", startTime)
+	recordRun(logger, "install", startTime, nil, deployOutputs(inst.GetConfig().GetData()))
+
+	// Calculate and display completion time
+	elapsedTime := time.Since(startTime).Round(time.Second)
+	inst.LogTimingSummary()
+	logger.Success("Installation completed in %s", elapsedTime)
+	writeInstallReport(inst, logger, reportPath, startTime, nil)
+
+	// Display final success message and access information
+	inst.DisplayCompletionMessage()
+
+	if hasFlag("--install-systemd") {
+		if err := cron.NewManager(logger).SetupBootService(); err != nil {
+			logger.Error("Failed to install systemd service: %v", err)
+		}
+	}
+
+	if hasFlag("--smoke-load") {
+		logger.Info("Running smoke load test against the new installation")
+		report, err := inst.RunSmokeLoad(installer.SmokeLoadRequests, installer.SmokeLoadConcurrency)
+		if err != nil {
+			logger.Warn("Smoke load test failed: %v", err)
+		} else {
+			inst.LogLoadReport(report)
+		}
+	}
+
+	os.Stdout.Sync() // Force flush to ensure output is captured
+}
+
+// offerDatabaseRestore asks whether to replace a corrupted database with the
+// newest backup that passes the integrity check
+func offerDatabaseRestore(inst *installer.Installer, logger *logging.Logger) {
+	if os.Getenv("NONINTERACTIVE") == "1" {
+		logger.Info("Restore the latest good backup by running: sudo infinity-metrics restore-db")
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("The database is corrupted. Restore the latest good backup? (yes/no): ")
+	confirmation, err := reader.ReadString('\n')
+	if err != nil {
+		logger.Error("Failed to read confirmation: %v", err)
+		return
+	}
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "yes" && confirmation != "y" {
+		logger.Info("Restore skipped. You can restore later with: sudo infinity-metrics restore-db")
+		return
+	}
+
+	// Keeps the watchdog from restarting the app while the database is swapped
+	lock, err := updater.AcquireLock(inst.GetConfig().GetData().InstallDir, "restore-db")
+	if err != nil {
+		logger.Error("Restore failed: %v", err)
+		return
+	}
+	defer lock.Release()
+
+	backupPath, err := inst.RestoreLatestGoodBackup()
+	if err != nil {
+		logger.Error("Restore failed: %v", err)
+		return
+	}
+	logger.Success("Restored database from %s", backupPath)
+	logger.Info("The app was stopped for the restore. Re-run the installer to start it and finish the installation: sudo infinity-metrics install")
+}
+
+// runPreflight runs the read-only install checks and reports each one
+func runPreflight(logger *logging.Logger) error {
+	domain, _ := flagValue("--domain")
+	if domain == "" {
+		domain = os.Getenv("DOMAIN")
+	}
+
+	// An existing .env tells which registry and release source to check
+	cfg := config.NewConfig(logger)
+	envFile := filepath.Join(installer.DefaultInstallDir, ".env")
+	if _, err := os.Stat(envFile); err == nil {
+		if err := cfg.LoadFromFile(envFile); err != nil {
+			logger.Warn("Ignoring %s, which cannot be loaded: %v", envFile, err)
+			cfg = config.NewConfig(logger)
+		}
+	}
+	data := cfg.GetData()
+	data.InstallDir = installer.DefaultInstallDir
+	if domain != "" {
+		data.Domain = domain
+	}
+	if offline, err := strconv.ParseBool(os.Getenv("OFFLINE_MODE")); err == nil {
+		data.OfflineMode = offline
+	}
+
+	fmt.Println("🔍 Running pre-flight checks (no changes will be made)...")
+	fmt.Println()
+	results := requirements.NewChecker(logger).Preflight(data)
+
+	failed := 0
+	for _, result := range results {
+		icon := "✅"
+		if !result.Passed {
+			icon = "❌"
+			failed++
+		} else if result.Warning {
+			icon = "⚠️ "
+		}
+		line := fmt.Sprintf("%s %s", icon, result.Name)
+		if result.Detail != "" {
+			line += ": " + result.Detail
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d pre-flight checks failed", failed, len(results))
+	}
+	logger.Success("All pre-flight checks passed, this server is ready for installation")
+	return nil
+}
+
+func runUpdate(inst *installer.Installer, logger *logging.Logger, startTime time.Time) {
+	logger.Debug("Initializing update environment")
+
+	u := updater.NewUpdater(logger)
+	u.SetSkipBackup(hasFlag("--skip-backup"))
+	u.SetOnlyIfHealthy(hasFlag("--only-if-healthy"))
+	u.SetForce(hasFlag("--force"))
+	logger.Info("Running update...")
+	err := u.Run(currentInstallerVersion)
+	if err != nil {
+		reportTelemetry(logger, "update", false, u.CurrentStep(), startTime)
+		recordRun(logger, "update", startTime, err, nil)
+		if updater.IsUnhealthy(err) {
+			// Already explained by the updater, and not an installer bug
+			logger.Error("Update skipped because the current installation is unhealthy")
+			os.Exit(1)
+		}
+		logFailure(logger, "Update failed", err)
+		offerCrashReport(logger, err, u.GetConfig().GetData())
+		os.Exit(1)
+	}
+	reportTelemetry(logger, "update", true, "", startTime)
+	outputs := deployOutputs(u.GetConfig().GetData())
+	if backup := u.BackupPath(); backup != "" {
+		outputs["backup"] = backup
+	}
+	if u.UpToDate() {
+		outputs["up_to_date"] = "true"
+	}
+	recordRun(logger, "update", startTime, nil, outputs)
+
+	elapsedTime := time.Since(startTime).Round(time.Second)
+	if u.UpToDate() {
+		logger.Success("Already up to date, checked in %s", elapsedTime)
+		return
+	}
+	logger.Success("Update completed in %s", elapsedTime)
+}
+
+// reportTelemetry sends an anonymized outcome report when TELEMETRY_ENABLED=1.
+// Failures are only logged; telemetry never affects the command's result.
+func reportTelemetry(logger *logging.Logger, event string, success bool, failedStep string, startTime time.Time) {
+	report := telemetry.NewReport(event, currentInstallerVersion, success, failedStep, time.Since(startTime))
+	if err := telemetry.NewClient(logger).Send(report); err != nil {
+		logger.Debug("Telemetry report not sent: %v", err)
+	}
+}
+
+// handleCrash turns a panic in the running command into a short error and an
+// offer to send a crash report, instead of a bare stack trace
+func handleCrash(logger *logging.Logger, inst *installer.Installer) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := errors.NewPanicError(r)
+	logger.Error("The installer crashed: %v", err)
+	logger.Detail("%s", err.Stack)
+	offerCrashReport(logger, err, inst.GetConfig().GetData())
+	os.Exit(2)
+}
+
+// offerCrashReport sends a sanitized report of err when CRASH_REPORTS_ENABLED=1
+// and CRASH_REPORT_ENDPOINT are set. Interactive sessions are shown the report
+// and asked first.
+func offerCrashReport(logger *logging.Logger, err error, data config.ConfigData) {
+	reporter := telemetry.NewCrashReporter(logger)
+	if !reporter.Enabled() {
+		return
+	}
+	report := telemetry.NewCrashReport(os.Args[1], currentInstallerVersion, err, crashSecrets(data))
+
+	if os.Getenv("NONINTERACTIVE") != "1" && term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Println("\nThis crash report can be sent to help fix the problem:")
+		if printErr := printJSON(report); printErr != nil {
+			return
+		}
+		fmt.Printf("Send it to %s? (yes/no): ", reporter.Endpoint())
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.TrimSpace(strings.ToLower(answer))
+		if answer != "yes" && answer != "y" {
+			logger.Info("Crash report not sent")
+			return
+		}
+	}
+	if sendErr := reporter.Send(report); sendErr != nil {
+		logger.Warn("%v", sendErr)
+		return
+	}
+	logger.Info("Crash report sent, thank you")
+}
+
+// crashSecrets lists the values a crash report must never contain: the
+// domain, keys, admin email and registry credentials from the running
+// command and from .env
+func crashSecrets(data config.ConfigData) []string {
+	secrets := []string{os.Getenv("DOMAIN"), data.Domain, data.PrivateKey, data.LicenseKey, data.User, data.RegistryUsername, data.RegistryPassword}
+	cfg := config.NewConfig(logging.NewLogger(logging.Config{Level: "error"}))
+	if err := cfg.LoadFromFile(filepath.Join(installer.DefaultInstallDir, ".env")); err == nil {
+		saved := cfg.GetData()
+		secrets = append(secrets, saved.Domain, saved.PrivateKey, saved.LicenseKey, saved.User, saved.RegistryUsername, saved.RegistryPassword)
+	}
+	return secrets
+}
+
+// recordRun writes last-run.json for commands that change the installation, so
+// monitoring can read the outcome of the last operation however it was launched.
+// Read-only commands (logs, diff-env, watch, version) leave it untouched.
+func recordRun(logger *logging.Logger, command string, startTime time.Time, err error, outputs map[string]string) {
+	if !runresult.Enabled() {
+		return
+	}
+	result := runresult.New(command, currentInstallerVersion, startTime, err, outputs)
+	if writeErr := runresult.Write(installer.DefaultInstallDir, result); writeErr != nil {
+		logger.Detail("Run result not written: %v", writeErr)
+	}
+}
+
+// writeInstallReport writes the --report file, if one was requested. A report
+// that cannot be written is logged, it does not change the install outcome.
+func writeInstallReport(inst *installer.Installer, logger *logging.Logger, path string, startTime time.Time, err error) {
+	if path == "" {
+		return
+	}
+	if writeErr := installer.WriteReport(path, inst.BuildReport(startTime, err)); writeErr != nil {
+		logger.Warn("Failed to write install report: %v", writeErr)
+		return
+	}
+	logger.Info("Install report written to %s", path)
+}
+
+// deployOutputs lists what an install or update deployed
+func deployOutputs(data config.ConfigData) map[string]string {
+	return map[string]string{
+		"version":     data.Version,
+		"app_image":   data.AppImage,
+		"caddy_image": data.CaddyImage,
+	}
+}
+
+func runRestoreDB(inst *installer.Installer, logger *logging.Logger, startTime time.Time) {
+	logger.Info("Starting database restore...")
+
+	if err := inst.LoadConfig("/opt/infinity-metrics/.env"); err != nil {
+		printError(logger, err)
+		recordRun(logger, "restore-db", startTime, err, nil)
+		os.Exit(1)
+	}
+	backupDir := inst.GetBackupDir()
+	mainDBPath := inst.GetMainDBPath()
+
+	// List available backups
+	backups, err := inst.ListBackups()
+	if err != nil {
+		logger.Error("Failed to list backups: %v", err)
+		recordRun(logger, "restore-db", startTime, err, nil)
+		os.Exit(1)
+	}
+
+	if len(backups) == 0 {
+		logger.Error("No backups found in %s", backupDir)
+		recordRun(logger, "restore-db", startTime, fmt.Errorf("no backups found in %s", backupDir), nil)
+		os.Exit(1)
+	}
+
+	// Let user select a backup
+	selectedBackup, err := inst.PromptBackupSelection(backups)
+	if err != nil {
+		logger.Error("Backup selection failed: %v", err)
+		recordRun(logger, "restore-db", startTime, err, nil)
+		os.Exit(1)
+	}
+
+	// Validate the selected backup
+	if err := inst.ValidateBackup(selectedBackup); err != nil {
+		logger.Error("Backup validation failed: %v", err)
+		recordRun(logger, "restore-db", startTime, err, map[string]string{"backup": selectedBackup})
+		os.Exit(1)
+	}
+
+	// Confirmation prompt
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("⚠️  This will replace your current database with the selected backup.\n")
+	fmt.Printf("   Current database: %s\n", mainDBPath)
+	fmt.Printf("   Selected backup: %s\n", selectedBackup)
+	printRestoreComparison(inst, mainDBPath, selectedBackup)
+	fmt.Print("Are you sure you want to continue? (yes/no): ")
+
+	confirmation, err := reader.ReadString('\n')
+	if err != nil {
+		logger.Error("Failed to read confirmation: %v", err)
+		os.Exit(1)
+	}
+
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "yes" && confirmation != "y" {
+		logger.Info("Restore cancelled by user")
+		os.Exit(0)
+	}
+
+	// Perform the restore, keeping the watchdog from restarting the app meanwhile
+	lock, err := updater.AcquireLock(inst.GetConfig().GetData().InstallDir, "restore-db")
+	if err == nil {
+		err = inst.RestoreFromBackup(selectedBackup)
+		lock.Release()
+	}
+	recordRun(logger, "restore-db", startTime, err, map[string]string{"backup": selectedBackup})
+	if err != nil {
+		logger.Error("Restore failed: %v", err)
+		os.Exit(1)
+	}
+
+	elapsedTime := time.Since(startTime).Round(time.Second)
+	logger.Success("Database restored successfully in %s", elapsedTime)
+	logger.Info("Verify the installation by running: sudo docker ps | grep infinity-metrics")
+}
+
+// printRestoreComparison shows the current database next to the selected backup
+func printRestoreComparison(inst *installer.Installer, mainDBPath, backupPath string) {
+	current, currentErr := inst.SummarizeDatabase(mainDBPath)
+	backup, backupErr := inst.SummarizeDatabase(backupPath)
+
+	describe := func(summary database.DatabaseSummary, err error) (string, string) {
+		if err != nil {
+			return "not found", "-"
+		}
+		return fmt.Sprintf("%d bytes", summary.Size), summary.ModTime.Format("2006-01-02 15:04:05")
+	}
+	currentSize, currentModified := describe(current, currentErr)
+	backupSize, backupModified := describe(backup, backupErr)
+	backupCreated := "-"
+	if !backup.CreatedAt.IsZero() {
+		backupCreated = backup.CreatedAt.Format("2006-01-02 15:04:05")
+	}
+
+	fmt.Println()
+	fmt.Printf("   %-16s %-22s %-22s\n", "", "Current", "Backup")
+	fmt.Printf("   %-16s %-22s %-22s\n", "Size", currentSize, backupSize)
+	fmt.Printf("   %-16s %-22s %-22s\n", "Last modified", currentModified, backupModified)
+	fmt.Printf("   %-16s %-22s %-22s\n", "Created", "-", backupCreated)
+
+	if current.RowCounts == nil && backup.RowCounts == nil {
+		fmt.Println()
+		return
+	}
+	rows := map[string][2]string{}
+	var tables []string
+	for i, counts := range [][]database.TableCount{current.RowCounts, backup.RowCounts} {
+		for _, count := range counts {
+			entry, seen := rows[count.Table]
+			if !seen {
+				tables = append(tables, count.Table)
+				entry = [2]string{"-", "-"}
+			}
+			entry[i] = strconv.FormatInt(count.Rows, 10)
+			rows[count.Table] = entry
+		}
+	}
+	sort.Strings(tables)
+	fmt.Println("   Rows per table:")
+	for _, table := range tables {
+		fmt.Printf("     %-14s %-22s %-22s\n", table, rows[table][0], rows[table][1])
+	}
+	fmt.Println()
+}
+
+// runBackup backs up the database with the retention settings from .env. It
+// holds the operation lock so it never copies a database an update or
+// restore is replacing. The backup goes to the directory list-backups and
+// restore-db read.
+func runBackup(inst *installer.Installer, logger *logging.Logger) (string, error) {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return "", fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+	if err := inst.LoadConfig(envFile); err != nil {
+		return "", fmt.Errorf("failed to load current configuration: %w", err)
+	}
+	data := inst.GetConfig().GetData()
+
+	lock, err := updater.AcquireLock(data.InstallDir, "backup")
+	if err != nil {
+		return "", err
+	}
+	defer lock.Release()
+
+	db := database.NewDatabase(logger)
+	db.SetRetentionConfig(database.RetentionConfigFromDays(
+		data.BackupDailyRetentionDays, data.BackupWeeklyRetentionDays, data.BackupMonthlyRetentionDays))
+	backupPath, err := db.BackupDatabase(inst.GetMainDBPath(), inst.GetBackupDir())
+	if err != nil {
+		return "", fmt.Errorf("backup failed: %w", err)
+	}
+	fmt.Printf("Backup created at %s\n", backupPath)
+	return backupPath, nil
+}
+
+func runListBackups(inst *installer.Installer, logger *logging.Logger) error {
+	jsonOutput := hasFlag("--json")
+	if jsonOutput {
+		logger.SetOutput(os.Stderr) // keep stdout clean for the JSON
+	}
+	if err := inst.LoadConfig("/opt/infinity-metrics/.env"); err != nil {
+		return err
+	}
+	// Logged to stderr with --json, so monitoring can tell which directory it saw
+	logger.Info("Reading backups from %s", inst.GetBackupDir())
+
+	backups, err := inst.ListBackups()
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+	if jsonOutput {
+		if backups == nil {
+			backups = []database.BackupFile{}
+		}
+		return printJSON(backups)
+	}
+
+	if len(backups) == 0 {
+		fmt.Printf("No backups found in %s\n", inst.GetBackupDir())
+		return nil
+	}
+	fmt.Printf("%-28s %-8s %-20s %s\n", "Name", "Type", "Created", "Size")
+	for _, backup := range backups {
+		fmt.Printf("%-28s %-8s %-20s %d bytes\n", backup.Name, backup.BackupType, backup.CreatedAt.Format("2006-01-02 15:04:05"), backup.Size)
+	}
+	return nil
+}
+
+func runUpdateHistory(logger *logging.Logger) error {
+	jsonOutput := hasFlag("--json")
+	if jsonOutput {
+		logger.SetOutput(os.Stderr) // keep stdout clean for the JSON
+	}
+
+	limit := 20
+	if value, ok := flagValue("--limit"); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid --limit value %q: must be a non-negative number", value)
+		}
+		limit = n
+	}
+
+	entries, err := updater.ReadHistory(installer.DefaultInstallDir, limit)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		if entries == nil {
+			entries = []updater.HistoryEntry{}
+		}
+		return printJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No updates recorded yet")
+		return nil
+	}
+	fmt.Printf("%-20s %-8s %-22s %-9s %s\n", "Started", "Result", "Version", "Duration", "Details")
+	for _, entry := range entries {
+		result, details := "ok", entry.AppImage
+		if entry.UpToDate {
+			details = "already up to date"
+		}
+		if !entry.Success {
+			result, details = "failed", entry.Error
+			if entry.Step != "" {
+				details = entry.Step + ": " + details
+			}
+		}
+		version := entry.ToVersion
+		if entry.FromVersion != "" && entry.FromVersion != entry.ToVersion {
+			version = entry.FromVersion + " -> " + entry.ToVersion
+		}
+		duration := time.Duration(entry.DurationSeconds) * time.Second
+		fmt.Printf("%-20s %-8s %-22s %-9s %s\n", entry.StartedAt.Local().Format("2006-01-02 15:04:05"), result, version, duration, details)
+	}
+	return nil
+}
+
+func runVerifyBackups(inst *installer.Installer, logger *logging.Logger) error {
+	jsonOutput := hasFlag("--json")
+	if jsonOutput {
+		logger.SetOutput(os.Stderr) // keep stdout clean for the JSON
+	}
+	if err := inst.LoadConfig("/opt/infinity-metrics/.env"); err != nil {
+		return err
+	}
+	// Logged to stderr with --json, so monitoring can tell which directory it saw
+	logger.Info("Reading backups from %s", inst.GetBackupDir())
+
+	backups, err := inst.ListBackups()
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+	statuses := inst.VerifyBackups(backups)
+
+	invalid := 0
+	for _, status := range statuses {
+		if !status.Valid {
+			invalid++
+		}
+	}
+
+	if jsonOutput {
+		if err := printJSON(statuses); err != nil {
+			return err
+		}
+	} else {
+		if len(statuses) == 0 {
+			fmt.Printf("No backups found in %s\n", inst.GetBackupDir())
+			return nil
+		}
+		for _, status := range statuses {
+			if status.Valid {
+				fmt.Printf("✅ %s\n", status.Name)
+			} else {
+				fmt.Printf("❌ %s: %s\n", status.Name, status.Error)
+			}
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d backups failed validation", invalid, len(statuses))
+	}
+	return nil
+}
+
+// runTestBackupRestore proves the backup and restore path works on this server
+// by restoring a fresh backup next to the real backups, never over the live database
+func runTestBackupRestore(inst *installer.Installer, logger *logging.Logger) error {
+	jsonOutput := hasFlag("--json")
+	if jsonOutput {
+		logger.SetOutput(os.Stderr) // keep stdout clean for the JSON
+	}
+	if err := inst.LoadConfig("/opt/infinity-metrics/.env"); err != nil {
+		return err
+	}
+
+	// Working in the backup directory tests the disk real backups are written to
+	if err := os.MkdirAll(inst.GetBackupDir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	workDir, err := os.MkdirTemp(inst.GetBackupDir(), ".backup-restore-test-")
+	if err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	report, err := inst.SelfTestBackupRestore(workDir)
+	if err != nil {
+		return fmt.Errorf("backup/restore self-test failed: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(report)
+	}
+	fmt.Println("✅ Backup created and validated")
+	fmt.Println("✅ Backup restored to a temporary copy")
+	fmt.Println("✅ Restored copy passed the integrity check")
+	for _, count := range report.RowCounts {
+		fmt.Printf("   %s: %d rows\n", count.Table, count.Rows)
+	}
+	fmt.Println("Backup and restore work on this server")
+	return nil
+}
+
+// printError shows a failed command's error to the user, with a suggested
+// next step for typed errors. The full error chain goes to the log file.
+func printError(logger *logging.Logger, err error) {
+	message, hint := errors.UserMessage(err)
+	logger.Detail("Full error: %v", err)
+	fmt.Printf("Error: %s\n", message)
+	if hint != "" {
+		fmt.Printf("Hint: %s\n", hint)
+	}
+}
+
+// logFailure is printError for failures reported through the logger
+func logFailure(logger *logging.Logger, prefix string, err error) {
+	message, hint := errors.UserMessage(err)
+	logger.Detail("Full error: %v", err)
+	logger.Error("%s: %s", prefix, message)
+	if hint != "" {
+		logger.Info("Hint: %s", hint)
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func runReload(logger *logging.Logger, startTime time.Time) {
+	if hasFlag("--print-deploy-command") {
+		if err := runPrintDeployCommand(logger); err != nil {
+			printError(logger, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Println("Reloading containers with latest configuration")
+	logger.Debug("Initializing reload environment")
+
+	reloader := updater.NewReloader(logger)
+	reloader.SetForceCaddyRedeploy(hasFlag("--force-caddy-redeploy"))
+	reloader.SetBackup(hasFlag("--backup"))
+	logger.Info("Reloading containers...")
+	err := reloader.Run()
+	var outputs map[string]string
+	if backup := reloader.BackupPath(); backup != "" {
+		outputs = map[string]string{"backup": backup}
+	}
+	recordRun(logger, "reload", startTime, err, outputs)
+	if err != nil {
+		logFailure(logger, "Reload failed", err)
+		os.Exit(1)
+	}
+
+	elapsedTime := time.Since(startTime).Round(time.Second)
+	logger.Success("Reload completed in %s", elapsedTime)
+}
+
+// runRefreshConfig fetches the latest release configuration, shows how it
+// differs from .env and, once confirmed, saves it and reloads the containers
+func runRefreshConfig(logger *logging.Logger, startTime time.Time) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+	if hasFlag("--reset-config") {
+		err := runResetConfig(logger, envFile)
+		recordRun(logger, "reset-config", startTime, err, nil)
+		return err
+	}
+
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
+	before := cfg.GetData()
+	if err := cfg.FetchFromServer(""); err != nil {
+		return fmt.Errorf("failed to fetch latest configuration: %w", err)
+	}
+
+	changes := config.ReleaseChanges(before, cfg.GetData())
+	if len(changes) == 0 {
+		logger.Success("%s already matches the latest release", envFile)
+		return nil
+	}
+	fmt.Println("The latest release changes:")
+	for _, change := range changes {
+		fmt.Printf("  %s: %s -> %s\n", change.Key, change.From, change.To)
+	}
+
+	if !hasFlag("--apply") {
+		if os.Getenv("NONINTERACTIVE") == "1" {
+			logger.Info("Nothing saved. Run 'infinity-metrics refresh-config --apply' to save and reload.")
+			return nil
+		}
+		fmt.Print("Save these changes and reload the containers? (yes/no): ")
+		confirmation, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+		if confirmation != "yes" && confirmation != "y" {
+			logger.Info("Nothing saved")
+			return nil
+		}
+	}
+
+	if err := cfg.SaveToFile(envFile); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	err := updater.NewReloader(logger).Run()
+	recordRun(logger, "refresh-config", startTime, err, deployOutputs(cfg.GetData()))
+	if err != nil {
+		return fmt.Errorf("configuration saved but reload failed: %w", err)
+	}
+	logger.Success("Latest release configuration applied")
+	return nil
+}
+
+// runResetConfig rewrites envFile from the defaults, keeping the domain, keys
+// and the paths and registry settings that point at existing data, after
+// backing it up. It recovers a .env with
+// stale or malformed keys; the containers are left as they are.
+func runResetConfig(logger *logging.Logger, envFile string) error {
+	fmt.Printf("This rewrites %s with default settings, keeping the domain, private and license keys, storage and backup paths, TLS certificate, container user and registry settings.\n", envFile)
+	if !hasFlag("--apply") {
+		if os.Getenv("NONINTERACTIVE") == "1" {
+			logger.Info("Nothing reset. Run 'infinity-metrics refresh-config --reset-config --apply' to reset.")
+			return nil
+		}
+		fmt.Print("Reset the configuration? (yes/no): ")
+		confirmation, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+		if confirmation != "yes" && confirmation != "y" {
+			logger.Info("Nothing reset")
+			return nil
+		}
+	}
+
+	backupPath, err := config.NewConfig(logger).ResetFromFile(envFile)
+	if err != nil {
+		return fmt.Errorf("failed to reset configuration: %w", err)
+	}
+	logger.Success("Configuration reset, the previous .env is saved at %s", backupPath)
+	logger.Info("Run 'infinity-metrics refresh-config --apply' to use the latest release images and reload the containers")
+	return nil
+}
+
+// runExec runs "exec [--yes] -- <command>" in the active app container and
+// returns the command's exit code. Commands that look destructive must be
+// confirmed by typing "yes", or with --yes when there is no terminal.
+func runExec(logger *logging.Logger) (int, error) {
+	var command []string
+	assumeYes := false
+	for idx, arg := range os.Args[2:] {
+		if arg == "--" {
+			command = os.Args[idx+3:]
+			break
+		}
+		if arg == "--yes" || arg == "-y" {
+			assumeYes = true
+			continue
+		}
+		command = os.Args[idx+2:]
+		break
+	}
+	if len(command) == 0 {
+		return 0, fmt.Errorf("usage: infinity-metrics exec [--yes] -- <command> [args...]")
+	}
+
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	if docker.IsDestructiveCommand(command) && !assumeYes {
+		if !interactive || os.Getenv("NONINTERACTIVE") == "1" {
+			return 0, fmt.Errorf("%q looks destructive, rerun with --yes to run it without a prompt", strings.Join(command, " "))
+		}
+		fmt.Printf("⚠️  %q looks like it deletes or overwrites data in the app container.\n", strings.Join(command, " "))
+		fmt.Print("Type yes to run it: ")
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil || strings.TrimSpace(answer) != "yes" {
+			return 0, fmt.Errorf("command not confirmed, nothing was run")
+		}
+	}
+
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	return d.ExecInApp(command, interactive && term.IsTerminal(int(os.Stdout.Fd())))
+}
+
+func runLogs(logger *logging.Logger) error {
+	service := "app"
+	if len(os.Args) >= 3 && !strings.HasPrefix(os.Args[2], "-") {
+		service = os.Args[2]
+	}
+
+	opts := docker.LogOptions{Follow: hasFlag("--follow") || hasFlag("-f")}
+	if tail, ok := flagValue("--tail"); ok {
+		if _, err := strconv.Atoi(tail); err != nil && tail != "all" {
+			return fmt.Errorf("invalid --tail value %q: must be a number or \"all\"", tail)
+		}
+		opts.Tail = tail
+	}
+	if since, ok := flagValue("--since"); ok {
+		if err := validation.ValidateLogSince(since); err != nil {
+			return err
+		}
+		opts.Since = since
+	}
+
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	var container string
+	switch service {
+	case "app":
+		name, err := d.ActiveAppContainer()
+		if err != nil {
+			return err
+		}
+		container = name
+	case "caddy":
+		container = docker.CaddyName
+	default:
+		return fmt.Errorf("unknown service %q: expected app or caddy", service)
+	}
+
+	return d.StreamLogs(container, opts)
+}
+
+func runMaintenance(logger *logging.Logger) error {
+	if len(os.Args) < 3 || (os.Args[2] != "on" && os.Args[2] != "off") {
+		return fmt.Errorf("usage: infinity-metrics maintenance on|off [--page FILE]")
+	}
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
+	data := cfg.GetData()
+	data.MaintenanceMode = os.Args[2] == "on"
+	if page, ok := flagValue("--page"); ok {
+		data.MaintenancePage = page
+	}
+	cfg.SetData(data)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if data.CaddyTemplate != "" {
+		logger.Warn("CADDYFILE_TEMPLATE is set, maintenance mode only applies if %s handles .Maintenance", data.CaddyTemplate)
+	}
+
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	if err := d.ReloadCaddy(data); err != nil {
+		return err
+	}
+	// Saved after the reload so .env never claims a mode Caddy is not serving
+	if err := cfg.SaveToFile(envFile); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	if data.MaintenanceMode {
+		logger.Success("Maintenance mode on: %s now answers with a 503 maintenance page, the app keeps running", data.Domain)
+	} else {
+		logger.Success("Maintenance mode off: %s is served by the app again", data.Domain)
+	}
+	return nil
+}
+
+func runRepairPermissions(logger *logging.Logger) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
+
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	if err := d.RepairPermissions(cfg.GetData()); err != nil {
+		return err
+	}
+	logger.Success("Permissions repaired, run 'infinity-metrics reload' if the app was failing to write")
+	return nil
+}
+
+func runRenewCert(logger *logging.Logger) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
+	domain := cfg.GetData().Domain
+	if certPath := cfg.GetData().TLSCertPath; certPath != "" {
+		return fmt.Errorf("the certificate is provided by TLS_CERT_PATH (%s) and is not managed by Caddy; replace the certificate files and run 'infinity-metrics reload'", certPath)
+	}
+
+	before, err := docker.CertificateExpiry(domain)
+	if err != nil {
+		logger.Warn("Could not read current certificate: %v", err)
+	} else {
+		logger.Info("Current certificate for %s expires %s (%s)", domain, before.Format(time.RFC1123), time.Until(before).Round(time.Hour))
+	}
+
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	if err := d.RenewCertificate(); err != nil {
+		return err
+	}
+
+	// Issuance happens in the background, give Caddy a minute to finish
+	logger.Info("Waiting for Caddy to serve the renewed certificate...")
+	var after time.Time
+	for attempt := 0; attempt < 12; attempt++ {
+		time.Sleep(5 * time.Second)
+		if after, err = docker.CertificateExpiry(domain); err != nil {
+			continue
+		}
+		// Without the old expiry any certificate would look renewed
+		if before.IsZero() {
+			logger.Info("Caddy serves a certificate that expires %s (%s). The previous certificate could not be read, so whether it was renewed cannot be compared.", after.Format(time.RFC1123), time.Until(after).Round(time.Hour))
+			return nil
+		}
+		if after.After(before) {
+			logger.Success("Certificate renewed, now expires %s (%s)", after.Format(time.RFC1123), time.Until(after).Round(time.Hour))
+			return nil
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not read certificate after reload: %w", err)
+	}
+	logger.Info("Certificate still expires %s. Caddy only renews certificates within their renewal window (the last third of their lifetime).", after.Format(time.RFC1123))
+	logger.Info("If expiry is close and renewal keeps failing, check 'infinity-metrics logs caddy' for ACME errors.")
+	return nil
+}
+
+// runConfigCommand exports the installation settings to a portable file or
+// imports them into this server's .env
+func runConfigCommand(logger *logging.Logger) error {
+	if len(os.Args) < 4 || strings.HasPrefix(os.Args[3], "--") {
+		return fmt.Errorf("usage: infinity-metrics config export <file> [--include-secrets] | config import <file>")
+	}
+	action, file := os.Args[2], os.Args[3]
+	envFile := "/opt/infinity-metrics/.env"
+	cfg := config.NewConfig(logger)
+
+	switch action {
+	case "export":
+		if _, err := os.Stat(envFile); os.IsNotExist(err) {
+			return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+		}
+		if err := cfg.LoadFromFile(envFile); err != nil {
+			return fmt.Errorf("failed to load current configuration: %w", err)
+		}
+		includeSecrets := hasFlag("--include-secrets")
+		if err := cfg.ExportToFile(file, includeSecrets); err != nil {
+			return err
+		}
+		if includeSecrets {
+			logger.Warn("%s contains the private key and license key, store it securely", file)
+		}
+		logger.Success("Configuration exported to %s", file)
+		return nil
+	case "import":
+		// Keep secrets already on this server when the export has them redacted
+		if _, err := os.Stat(envFile); err == nil {
+			if err := cfg.LoadFromFile(envFile); err != nil {
+				return fmt.Errorf("failed to load current configuration: %w", err)
+			}
+		}
+		currentDomain := cfg.GetData().Domain
+		if err := cfg.ImportFromFile(file); err != nil {
+			return err
+		}
+		confirmed, _ := flagValue("--confirm-domain")
+		interactive := term.IsTerminal(int(os.Stdin.Fd())) && os.Getenv("NONINTERACTIVE") != "1"
+		if err := config.ConfirmDomainChange(bufio.NewReader(os.Stdin), currentDomain, cfg.GetData().Domain, confirmed, interactive); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(cfg.GetData().InstallDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create install directory: %w", err)
+		}
+		if err := cfg.SaveToFile(envFile); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+		if cfg.GetData().LicenseKey == "" {
+			logger.Warn("No license key set, add it with 'infinity-metrics update-license-key'")
+		}
+		logger.Success("Configuration imported into %s", envFile)
+		logger.Info("Apply it with 'infinity-metrics install' on a new server or 'infinity-metrics reload' on a running one")
+		return nil
+	default:
+		return fmt.Errorf("unknown config action %q, expected export or import", action)
+	}
+}
+
+// runFleetUpdate runs the update over SSH on every host in --hosts and prints a summary
+func runFleetUpdate(logger *logging.Logger) error {
+	hostsFile, ok := flagValue("--hosts")
+	if !ok || hostsFile == "" {
+		return fmt.Errorf("usage: infinity-metrics fleet-update --hosts hosts.txt [--concurrency N]")
+	}
+	concurrency := 1
+	if value, ok := flagValue("--concurrency"); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid --concurrency value %q: must be a positive number", value)
+		}
+		concurrency = n
+	}
+
+	hosts, err := fleet.ParseHosts(hostsFile)
+	if err != nil {
+		return err
+	}
+	logger.Info("Updating %d host(s), %d at a time", len(hosts), concurrency)
+	results := fleet.Run(hosts, fleet.UpdateCommand, concurrency)
+
+	fmt.Println()
+	fmt.Println("Fleet update summary:")
+	for _, result := range results {
+		if result.Err == nil {
+			fmt.Printf("  ✅ %s (%s)\n", result.Host, result.Duration.Round(time.Second))
+			continue
+		}
+		fmt.Printf("  ❌ %s (%s): %v\n", result.Host, result.Duration.Round(time.Second), result.Err)
+		lines := strings.Split(strings.TrimSpace(result.Output), "\n")
+		if len(lines) > 5 {
+			lines = lines[len(lines)-5:]
+		}
+		for _, line := range lines {
+			fmt.Printf("       %s\n", line)
+		}
+	}
+	return fleet.Summarize(results)
+}
+
+// runCertStatus reports the certificate the configured domain currently serves
+func runCertStatus(logger *logging.Logger) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
+	data := cfg.GetData()
+
+	status, err := docker.CertificateStatus(data.Domain)
+	if err != nil {
+		return err
+	}
+
+	authority := status.Authority
+	if data.TLSCertPath != "" {
+		authority = fmt.Sprintf("%s, provided by TLS_CERT_PATH (%s)", authority, data.TLSCertPath)
+	}
+	fmt.Printf("Certificate for %s\n", data.Domain)
+	fmt.Printf("  Subject:     %s\n", status.Subject)
+	fmt.Printf("  Names:       %s\n", strings.Join(status.DNSNames, ", "))
+	fmt.Printf("  Issuer:      %s\n", status.Issuer)
+	fmt.Printf("  Authority:   %s\n", authority)
+	fmt.Printf("  Valid from:  %s\n", status.NotBefore.Format(time.RFC1123))
+	fmt.Printf("  Expires:     %s (%s)\n", status.NotAfter.Format(time.RFC1123), time.Until(status.NotAfter).Round(time.Hour))
+
+	switch {
+	case status.Trusted:
+		logger.Success("HTTPS is working with a trusted certificate")
+	case status.Authority == docker.AuthorityCaddyInternal:
+		logger.Info("Caddy serves a self-signed certificate from its internal CA, browsers will show a warning")
+	default:
+		logger.Warn("The certificate is not trusted: %v", status.VerifyErr)
+	}
+	if time.Until(status.NotAfter) < 0 {
+		return fmt.Errorf("certificate for %s expired %s", data.Domain, status.NotAfter.Format(time.RFC1123))
+	}
+	return nil
+}
+
+// runCheckCron reports whether the automatic update job is installed and
+// still points at an executable installer binary
+func runCheckCron(logger *logging.Logger) error {
+	status := cron.NewManager(logger).CheckCronJob()
+	if status.Source != "" {
+		fmt.Printf("Job:      %s\n", status.Source)
+		fmt.Printf("Schedule: %s\n", status.Schedule)
+		fmt.Printf("Runs:     %s update (in %s)\n", status.Binary, status.WorkDir)
+	}
+	for _, problem := range status.Problems {
+		fmt.Printf("❌ %s\n", problem)
+	}
+	if !status.OK() {
+		return fmt.Errorf("automatic updates are not scheduled correctly, reinstall the job with 'infinity-metrics update --force'")
+	}
+	logger.Success("Automatic updates are scheduled")
+	return nil
+}
+
+// runDoctor checks the installation and host, and with --fix applies the
+// remediation of each failed check that has one, re-checking after every fix.
+// Problems without a safe fix, such as DNS or disk space, are only reported.
+func runDoctor(logger *logging.Logger) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
+	data := cfg.GetData()
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	checker := requirements.NewChecker(logger)
 
-func initLogging() *logging.Logger {
-	logLevel := "info"
-	if envLevel := os.Getenv("LOG_LEVEL"); envLevel != "" {
-		logLevel = envLevel
+	runChecks := func() []docker.DoctorCheck {
+		checks := d.Doctor(data)
+		for _, result := range checker.HostChecks(data) {
+			checks = append(checks, docker.DoctorCheck{Name: result.Name, Passed: result.Passed, Detail: result.Detail})
+		}
+		return checks
 	}
 
-	verbose := false
-	if os.Getenv("VERBOSE") == "true" {
-		verbose = true
-	}
+	fmt.Println("🩺 Checking the installation...")
+	fmt.Println()
+	checks := runChecks()
 
-	quiet := false
-	if os.Getenv("QUIET") == "true" {
-		quiet = true
-	}
+	if hasFlag("--fix") {
+		lock, err := updater.AcquireLock(data.InstallDir, "doctor")
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
 
-	// Configure the main logger to log to stdout
-	logger := logging.NewLogger(logging.Config{
-		Level:   logLevel,
-		Verbose: verbose,
-		Quiet:   quiet,
-	})
+		attempted := make(map[string]bool)
+		for {
+			var next *docker.DoctorCheck
+			for idx := range checks {
+				if !checks[idx].Passed && checks[idx].Fix != nil && !attempted[checks[idx].Name] {
+					next = &checks[idx]
+					break
+				}
+			}
+			if next == nil {
+				break
+			}
+			attempted[next.Name] = true
+			logger.Info("Fixing %s: %s", next.Name, next.Detail)
+			if err := next.Fix(); err != nil {
+				logger.Error("Fix for %s failed: %v", next.Name, err)
+			}
 
-	return logger
-}
+			name := next.Name
+			checks = runChecks()
+			fixed := true
+			for _, check := range checks {
+				if check.Name == name && !check.Passed {
+					fixed = false
+				}
+			}
+			if fixed {
+				logger.Success("%s fixed", name)
+			} else {
+				logger.Warn("%s still fails after the fix", name)
+			}
+		}
+		fmt.Println()
+	}
 
-func runInstall(inst *installer.Installer, logger *logging.Logger, startTime time.Time) {
-	logger.Debug("Initializing installation environment")
+	failed, fixable := 0, 0
+	for _, check := range checks {
+		icon := "✅"
+		if !check.Passed {
+			icon = "❌"
+			failed++
+			if check.Fix != nil {
+				fixable++
+			}
+		}
+		line := fmt.Sprintf("%s %s", icon, check.Name)
+		if check.Detail != "" {
+			line += ": " + check.Detail
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
 
-	// Run the complete installation process
-	if err := inst.RunCompleteInstallation(); err != nil {
-		logger.Error("Installation failed: %v", err)
-		os.Exit(1)
+	if failed == 0 {
+		logger.Success("No problems found")
+		return nil
+	}
+	if fixable > 0 && !hasFlag("--fix") {
+		logger.Info("Run 'infinity-metrics doctor --fix' to fix %d of them automatically", fixable)
 	}
+	return fmt.Errorf("%d doctor check(s) failed", failed)
+}
 
-	// Calculate and display completion time
-	elapsedTime := time.Since(startTime).Round(time.Second)
-	logger.Success("Installation completed in %s", elapsedTime)
+// runNetworkDiagnostics reports the state of the container network and its connections
+func runNetworkDiagnostics(logger *logging.Logger) error {
+	fmt.Println("🔍 Checking container networking...")
+	fmt.Println()
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
 
-	// Display final success message and access information
-	inst.DisplayCompletionMessage()
+	failed := 0
+	for _, check := range d.DiagnoseNetwork() {
+		icon := "✅"
+		if !check.Passed {
+			icon = "❌"
+			failed++
+		}
+		line := fmt.Sprintf("%s %s", icon, check.Name)
+		if check.Detail != "" {
+			line += ": " + check.Detail
+		}
+		fmt.Println(line)
+	}
 
-	os.Stdout.Sync() // Force flush to ensure output is captured
+	fmt.Println()
+	if failed > 0 {
+		return fmt.Errorf("%d network check(s) failed", failed)
+	}
+	logger.Success("Container networking looks healthy")
+	return nil
 }
 
-func runUpdate(inst *installer.Installer, logger *logging.Logger, startTime time.Time) {
-	logger.Debug("Initializing update environment")
+// runPrintDeployCommand prints the docker run commands a reload would use
+// with the current .env, secrets masked, without running them
+func runPrintDeployCommand(logger *logging.Logger) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
 
-	updater := updater.NewUpdater(logger)
-	logger.Info("Running update...")
-	err := updater.Run(currentInstallerVersion)
-	if err != nil {
-		logger.Error("Update failed: %v", err)
-		os.Exit(1)
+	logger.SetOutput(os.Stderr) // keep stdout to the commands
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
 	}
 
-	elapsedTime := time.Since(startTime).Round(time.Second)
-	logger.Success("Update completed in %s", elapsedTime)
+	// A reload recreates the running app container, or the primary when none runs
+	appName, err := docker.NewDocker(logger, database.NewDatabase(logger)).ActiveAppContainer()
+	if err != nil {
+		appName = docker.AppNamePrimary
+	}
+	for _, command := range docker.DeployCommands(cfg.GetData(), appName) {
+		fmt.Println(command)
+	}
+	return nil
 }
 
-func runRestoreDB(inst *installer.Installer, logger *logging.Logger, startTime time.Time) {
-	logger.Info("Starting database restore...")
+func runDiffEnv(logger *logging.Logger) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
 
-	backupDir := inst.GetBackupDir()
-	mainDBPath := inst.GetMainDBPath()
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
 
-	// List available backups
-	backups, err := inst.ListBackups()
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	drifts, err := d.DiffEnv(cfg.GetData())
 	if err != nil {
-		logger.Error("Failed to list backups: %v", err)
-		os.Exit(1)
+		return err
 	}
 
-	if len(backups) == 0 {
-		logger.Error("No backups found in %s", backupDir)
-		os.Exit(1)
+	if len(drifts) == 0 {
+		logger.Success("Running containers match %s", envFile)
+		return nil
 	}
 
-	// Let user select a backup
-	selectedBackup, err := inst.PromptBackupSelection(backups)
-	if err != nil {
-		logger.Error("Backup selection failed: %v", err)
-		os.Exit(1)
+	fmt.Printf("Running containers differ from %s:\n", envFile)
+	for _, drift := range drifts {
+		if drift.Secret {
+			fmt.Printf("  %s: %s differs (value hidden)\n", drift.Container, drift.Key)
+		} else {
+			fmt.Printf("  %s: %s is %q, .env has %q\n", drift.Container, drift.Key, drift.Running, drift.Expected)
+		}
 	}
+	fmt.Println("Run 'infinity-metrics reload' to apply the current configuration.")
+	return fmt.Errorf("%d setting(s) out of date", len(drifts))
+}
 
-	// Validate the selected backup
-	if err := inst.ValidateBackup(selectedBackup); err != nil {
-		logger.Error("Backup validation failed: %v", err)
-		os.Exit(1)
+// runExplainPull prints the digest comparison behind the skip-pull decision
+// for the given image, or for the configured app and Caddy images
+func runExplainPull(logger *logging.Logger) error {
+	var images []string
+	if len(os.Args) >= 3 && !strings.HasPrefix(os.Args[2], "--") {
+		images = []string{os.Args[2]}
 	}
 
-	// Confirmation prompt
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Printf("⚠️  This will replace your current database with the selected backup.\n")
-	fmt.Printf("   Current database: %s\n", mainDBPath)
-	fmt.Printf("   Selected backup: %s\n", selectedBackup)
-	fmt.Print("Are you sure you want to continue? (yes/no): ")
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); err == nil {
+		cfg := config.NewConfig(logger)
+		if err := cfg.LoadFromFile(envFile); err != nil {
+			return fmt.Errorf("failed to load current configuration: %w", err)
+		}
+		data := cfg.GetData()
+		d.SetInsecureRegistries(data.RegistryInsecure)
+		if len(images) == 0 {
+			images = []string{data.AppImage, data.CaddyImage}
+		}
+	} else if len(images) == 0 {
+		return fmt.Errorf(".env file not found at %s. Pass an image or run installation first", envFile)
+	}
 
-	confirmation, err := reader.ReadString('\n')
-	if err != nil {
-		logger.Error("Failed to read confirmation: %v", err)
-		os.Exit(1)
+	if hasFlag("--compare-digests") {
+		printDigestComparison(d, images)
+		return nil
 	}
 
-	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
-	if confirmation != "yes" && confirmation != "y" {
-		logger.Info("Restore cancelled by user")
-		os.Exit(0)
+	for _, image := range images {
+		decision := d.ExplainPull(image)
+		fmt.Printf("%s\n", image)
+		if decision.LocalErr != nil {
+			fmt.Printf("  Local digest:  none (%v)\n", decision.LocalErr)
+		} else {
+			fmt.Printf("  Local digest:  %s\n", decision.LocalDigest)
+		}
+		switch {
+		case decision.RemoteErr != nil:
+			fmt.Printf("  Remote digest: unavailable (%v)\n", decision.RemoteErr)
+		case decision.RemoteDigest == "":
+			fmt.Printf("  Remote digest: not checked\n")
+		default:
+			fmt.Printf("  Remote digest: %s\n", decision.RemoteDigest)
+		}
+		if decision.RemoteCached {
+			fmt.Printf("  Digest cache:  hit\n")
+		} else {
+			fmt.Printf("  Digest cache:  miss (entries live for this run only)\n")
+		}
+		action := "skip pull"
+		if decision.Pull {
+			action = "pull"
+		}
+		fmt.Printf("  Decision:      %s, %s\n", action, decision.Reason)
+		if decision.Err != nil {
+			fmt.Printf("  Error:         %v\n", decision.Err)
+		}
 	}
+	return nil
+}
 
-	// Perform the restore
-	err = inst.RestoreFromBackup(selectedBackup)
-	if err != nil {
-		logger.Error("Restore failed: %v", err)
-		os.Exit(1)
+// printDigestComparison shows the local and remote digest of every image and
+// whether they match, so a stale local image stands out in one view
+func printDigestComparison(d *docker.Docker, images []string) {
+	fmt.Printf("%-48s %-14s %-14s %s\n", "Image", "Local", "Remote", "Match")
+	for _, image := range images {
+		comparison := d.CompareDigests(image)
+		local, remote := shortDigest(comparison.LocalDigest), shortDigest(comparison.RemoteDigest)
+		if comparison.LocalErr != nil {
+			local = "not present"
+		}
+		if comparison.RemoteErr != nil {
+			remote = "unavailable"
+		}
+		match := "no"
+		if comparison.Match() {
+			match = "yes"
+		}
+		fmt.Printf("%-48s %-14s %-14s %s\n", image, local, remote, match)
+		if comparison.RemoteErr != nil {
+			fmt.Printf("  remote lookup failed: %v\n", comparison.RemoteErr)
+		}
 	}
+}
 
-	elapsedTime := time.Since(startTime).Round(time.Second)
-	logger.Success("Database restored successfully in %s", elapsedTime)
-	logger.Info("Verify the installation by running: sudo docker ps | grep infinity-metrics")
+// shortDigest returns the first 12 hex characters of a digest, as docker prints image IDs
+func shortDigest(digest string) string {
+	if index := strings.LastIndex(digest, "sha256:"); index >= 0 {
+		digest = digest[index+len("sha256:"):]
+	}
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
 }
 
-func runReload(logger *logging.Logger, startTime time.Time) {
-	fmt.Println("Reloading containers with latest configuration")
-	logger.Debug("Initializing reload environment")
+func runSwitchSlot(logger *logging.Logger) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
+	data := cfg.GetData()
 
-	reloader := updater.NewReloader(logger)
-	logger.Info("Reloading containers...")
-	err := reloader.Run()
+	lock, err := updater.AcquireLock(data.InstallDir, "switch-slot")
 	if err != nil {
-		logger.Error("Reload failed: %v", err)
-		os.Exit(1)
+		return err
 	}
+	defer lock.Release()
 
-	elapsedTime := time.Since(startTime).Round(time.Second)
-	logger.Success("Reload completed in %s", elapsedTime)
+	from, to, err := docker.NewDocker(logger, database.NewDatabase(logger)).SwitchSlot(data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Caddy now routes all traffic to %s (was %s)\n", to, from)
+	fmt.Println("Run 'infinity-metrics reload' to route to both slots again")
+	return nil
+}
+
+func runWatch(logger *logging.Logger) error {
+	interval := 60 * time.Second
+	if value, ok := flagValue("--interval"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 10*time.Second {
+			return fmt.Errorf("invalid --interval value %q: must be a duration of at least 10s", value)
+		}
+		interval = parsed
+	}
+
+	watchdog := updater.NewWatchdog(logger)
+	if hasFlag("--once") {
+		return watchdog.Check()
+	}
+	return watchdog.Run(interval)
 }
 
 func runAdminPasswordChange(logger *logging.Logger) error {
@@ -242,7 +1791,9 @@ func runAdminPasswordChange(logger *logging.Logger) error {
 
 		password = strings.TrimSpace(string(passBytes))
 		if err := validation.ValidatePassword(password); err != nil {
-			fmt.Printf("Error: %v\n", err)
+			// The raw validation error would echo the password
+			message, _ := errors.UserMessage(err)
+			fmt.Printf("Error: %s\n", message)
 			continue
 		}
 
@@ -272,15 +1823,138 @@ func runAdminPasswordChange(logger *logging.Logger) error {
 	return nil
 }
 
+func runConfigureBackups(logger *logging.Logger) error {
+	envFile := "/opt/infinity-metrics/.env"
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf(".env file not found at %s. Please run installation first", envFile)
+	}
+
+	cfg := config.NewConfig(logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load current configuration: %w", err)
+	}
+	data := cfg.GetData()
+
+	db := database.NewDatabase(logger)
+	db.SetRetentionConfig(database.RetentionConfigFromDays(
+		data.BackupDailyRetentionDays, data.BackupWeeklyRetentionDays, data.BackupMonthlyRetentionDays))
+	current := db.GetRetentionConfig()
+
+	fmt.Println("Current backup retention:")
+	fmt.Printf("  Daily backups:   %d days\n", current.DailyRetentionDays)
+	fmt.Printf("  Weekly backups:  %d days\n", current.WeeklyRetentionDays)
+	fmt.Printf("  Monthly backups: %d days\n", current.MonthlyRetentionDays)
+	fmt.Printf("  Backup directory: %s\n", data.BackupPath)
+	fmt.Println("\nEnter new values, or press Enter to keep the current one.")
+
+	reader := bufio.NewReader(os.Stdin)
+	updated := current
+	var err error
+	if updated.DailyRetentionDays, err = promptRetentionDays(reader, "daily", current.DailyRetentionDays); err != nil {
+		return err
+	}
+	if updated.WeeklyRetentionDays, err = promptRetentionDays(reader, "weekly", current.WeeklyRetentionDays); err != nil {
+		return err
+	}
+	if updated.MonthlyRetentionDays, err = promptRetentionDays(reader, "monthly", current.MonthlyRetentionDays); err != nil {
+		return err
+	}
+
+	if updated == current {
+		fmt.Println("No changes made.")
+		return nil
+	}
+
+	fmt.Printf("\nNew retention: daily %d days, weekly %d days, monthly %d days\n",
+		updated.DailyRetentionDays, updated.WeeklyRetentionDays, updated.MonthlyRetentionDays)
+	fmt.Print("Save these settings? [Y/n]: ")
+	confirmation, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "" && confirmation != "y" && confirmation != "yes" {
+		fmt.Println("Retention settings not changed.")
+		return nil
+	}
+
+	data.BackupDailyRetentionDays = updated.DailyRetentionDays
+	data.BackupWeeklyRetentionDays = updated.WeeklyRetentionDays
+	data.BackupMonthlyRetentionDays = updated.MonthlyRetentionDays
+	cfg.SetData(data)
+	if err := cfg.SaveToFile(envFile); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	db.SetRetentionConfig(updated)
+
+	logger.Success("Backup retention saved to %s, it applies from the next backup", envFile)
+	return nil
+}
+
+// promptRetentionDays asks for a retention period until a valid value is
+// entered. An empty answer keeps the current value.
+func promptRetentionDays(reader *bufio.Reader, backupType string, current int) (int, error) {
+	for {
+		fmt.Printf("Keep %s backups for how many days? [%d]: ", backupType, current)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s retention: %w", backupType, err)
+		}
+		input = strings.TrimSpace(input)
+		if input == "" {
+			return current, nil
+		}
+
+		days, err := strconv.Atoi(input)
+		if err != nil {
+			fmt.Println("Error: Enter a whole number of days.")
+			continue
+		}
+		if err := validation.ValidateRetentionDays(days); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		return days, nil
+	}
+}
+
+// verifyLicenseSignature checks a signed license key offline against domain,
+// "" to skip the domain check, and reports whether the signature was
+// verified. Unsigned keys have nothing to verify.
+func verifyLicenseSignature(logger *logging.Logger, key, domain string) (bool, error) {
+	if !license.IsSigned(key) {
+		return false, nil
+	}
+	claims, err := license.Verify(key, domain, time.Now())
+	switch {
+	case err == license.ErrNoPublicKey:
+		logger.Warn("Cannot verify the license signature offline: %v", err)
+		return false, nil
+	case err != nil:
+		logger.Error("Invalid license key: %v", err)
+		return false, err
+	case claims.ExpiresAt != 0:
+		logger.Info("License signature verified, valid until %s", time.Unix(claims.ExpiresAt, 0).UTC().Format(time.DateOnly))
+	default:
+		logger.Info("License signature verified")
+	}
+	return true, nil
+}
+
 func runUpdateLicenseKey(logger *logging.Logger, startTime time.Time) error {
 	envFile := "/opt/infinity-metrics/.env"
 
 	var newLicenseKey string
+	validateOnly := hasFlag("--validate-only")
 
 	// Check if license key was provided as command line argument
-	if len(os.Args) >= 3 {
-		newLicenseKey = os.Args[2]
-	} else {
+	for _, arg := range os.Args[2:] {
+		if !strings.HasPrefix(arg, "--") {
+			newLicenseKey = arg
+			break
+		}
+	}
+	if newLicenseKey == "" {
 		// Prompt user for license key
 		reader := bufio.NewReader(os.Stdin)
 		fmt.Print("Enter new license key: ")
@@ -302,6 +1976,28 @@ func runUpdateLicenseKey(logger *logging.Logger, startTime time.Time) error {
 		return err
 	}
 
+	if validateOnly {
+		// The domain check of signed keys needs the installation's domain, when there is one
+		domain := ""
+		if _, err := os.Stat(envFile); err == nil {
+			cfg := config.NewConfig(logger)
+			if err := cfg.LoadFromFile(envFile); err != nil {
+				return fmt.Errorf("failed to load current configuration: %w", err)
+			}
+			domain = cfg.GetData().Domain
+		}
+		verified, err := verifyLicenseSignature(logger, newLicenseKey, domain)
+		if err != nil {
+			return err
+		}
+		if verified {
+			logger.Success("License key is valid, nothing was changed")
+		} else {
+			logger.Success("License key format is valid, nothing was changed (the key itself was not verified)")
+		}
+		return nil
+	}
+
 	logger.Info("Updating license key in %s", envFile)
 
 	// Check if .env file exists
@@ -315,8 +2011,14 @@ func runUpdateLicenseKey(logger *logging.Logger, startTime time.Time) error {
 		return fmt.Errorf("failed to load current configuration: %w", err)
 	}
 
-	// Update the license key
+	// Signed licenses are checked offline so a tampered or expired key is
+	// rejected before it reaches the containers
 	data := cfg.GetData()
+	if _, err := verifyLicenseSignature(logger, newLicenseKey, data.Domain); err != nil {
+		return err
+	}
+
+	// Update the license key
 	data.LicenseKey = newLicenseKey
 	cfg.SetData(data)
 
@@ -339,6 +2041,53 @@ func runUpdateLicenseKey(logger *logging.Logger, startTime time.Time) error {
 	return nil
 }
 
+// hasFlag reports whether a flag was passed after the command name
+func hasFlag(name string) bool {
+	for _, arg := range os.Args[2:] {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue returns the value of a flag given as "--name value" or "--name=value"
+func flagValue(name string) (string, bool) {
+	args := os.Args[2:]
+	for i, arg := range args {
+		if arg == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			return strings.TrimPrefix(arg, name+"="), true
+		}
+	}
+	return "", false
+}
+
+// exitUpdateAvailable is the exit code of version --check when a newer
+// installer is released, distinct from 1 for errors
+const exitUpdateAvailable = 10
+
+// runVersionCheck prints the installed and latest installer versions and
+// returns 0 when current, exitUpdateAvailable when outdated or 1 on errors
+func runVersionCheck(logger *logging.Logger) int {
+	logger.SetOutput(os.Stderr) // keep stdout to the version report
+	latest, updateAvailable, err := updater.NewUpdater(logger).CheckInstallerVersion(currentInstallerVersion)
+	fmt.Printf("Installed: %s\n", currentInstallerVersion)
+	if err != nil {
+		printError(logger, fmt.Errorf("could not determine the latest version: %w", err))
+		return 1
+	}
+	fmt.Printf("Latest:    %s\n", latest)
+	if updateAvailable {
+		fmt.Println("Update available, run 'infinity-metrics update'")
+		return exitUpdateAvailable
+	}
+	fmt.Println("Up to date")
+	return 0
+}
+
 func printVersion() {
 	fmt.Println(currentInstallerVersion)
 }
@@ -346,12 +2095,61 @@ func printVersion() {
 func printUsage() {
 	fmt.Println("Usage: infinity-metrics [command] [options]")
 	fmt.Println("\nCommands:")
-	fmt.Println("  install                     Install Infinity Metrics")
-	fmt.Println("  update                      Update an existing installation")
+	fmt.Println("  install [--resume]          Install Infinity Metrics, --resume continues a failed install")
+	fmt.Println("          [--assume-yes]      Accept the configuration summary without asking (-y)")
+	fmt.Println("          [--env-file FILE]   Install with the settings in FILE, a filled-in dump-env-template")
+	fmt.Println("          [--tui]             Show all steps with a live status on interactive terminals")
+	fmt.Println("          [--dns-wait DUR]    Wait up to DUR (e.g. 10m) for DNS to point here before deploying")
+	fmt.Println("          [--confirm-domain D] Accept changing an existing installation's domain to D unattended")
+	fmt.Println("          [--clean-orphans]   Remove containers left by a failed install after confirmation")
+	fmt.Println("          [--report FILE]     Write a JSON report of the config, steps, images and verification")
+	fmt.Println("          [--smoke-load]      After install, load test the health endpoint and report latency")
+	fmt.Println("          [--install-systemd] Start the containers on boot through a systemd service")
+	fmt.Println("          [--check]           Only run read-only pre-flight checks (--domain to check DNS)")
+	fmt.Println("  update [--skip-backup]      Update an existing installation")
+	fmt.Println("         [--only-if-healthy]  Skip the update when the containers are down or unhealthy")
+	fmt.Println("         [--force]            Back up and redeploy even when already up to date")
+	fmt.Println("  fleet-update --hosts FILE   Run update over SSH on every host in FILE, N at a time (--concurrency N)")
 	fmt.Println("  reload                      Reload containers with latest .env config without backup")
+	fmt.Println("         [--force-caddy-redeploy] Only recreate Caddy, e.g. to pick up a new CADDY_IMAGE")
+	fmt.Println("         [--print-deploy-command] Print the docker run commands, secrets masked, without running them")
+	fmt.Println("         [--backup]           Back up the database before restarting (default with BACKUP_BEFORE_RELOAD=true)")
+	fmt.Println("  switch-slot                 Route traffic only to the standby app container, if it is healthy")
+	fmt.Println("  refresh-config [--apply]    Show image changes in the latest release, then save and reload")
+	fmt.Println("         [--reset-config]     Rewrite .env from defaults, keeping domain, keys, paths and registry")
+	fmt.Println("  update-history [--limit N]  Show recent update attempts (--json for machine-readable output)")
+	fmt.Println("  maintenance on|off          Serve a 503 maintenance page instead of the app (--page FILE)")
+	fmt.Println("  repair-permissions          Reset ownership and modes of the data directories and .env")
 	fmt.Println("  restore-db                  Interactively restore database from a backup")
+	fmt.Println("  backup                      Back up the database now, keeping backups per the retention settings")
+	fmt.Println("  enable-backup-cron          Back up daily, independent of updates (--schedule \"30 2 * * *\")")
+	fmt.Println("  disable-backup-cron         Remove the scheduled backup job")
+	fmt.Println("  check-cron                  Check the automatic update job is installed and can run")
+	fmt.Println("  list-backups [--json]       List database backups")
+	fmt.Println("  verify-backups [--json]     Check the integrity of every backup, exits 1 if any fail")
+	fmt.Println("  test-backup-restore         Back up and restore to a temporary copy to prove recovery works (--json)")
+	fmt.Println("  exec [--yes] -- CMD         Run CMD in the active app container, destructive ones need confirming")
+	fmt.Println("  doctor [--fix]              Check the installation, and with --fix repair what is safe to repair")
+	fmt.Println("  network-diagnostics         Check the container network and connectivity between services")
+	fmt.Println("  diff-env                    Compare running containers against .env")
+	fmt.Println("  explain-pull [image]        Show the digests behind the skip-pull decision")
+	fmt.Println("         [--compare-digests]  Only compare local and remote digests of the images, side by side")
+	fmt.Println("  cert-status                 Show the issuer, names and expiry of the served certificate")
+	fmt.Println("  renew-cert                  Ask Caddy to renew the TLS certificate and report its expiry")
+	fmt.Println("  logs [app|caddy]            Show container logs (--tail N, --since 10m|timestamp, --follow)")
+	fmt.Println("  reconcile                   Start any missing or unhealthy containers once (used on boot)")
+	fmt.Println("  watch [--once]              Restart crashed or unhealthy containers (--interval 60s)")
+	fmt.Println("  enable-watchdog             Run 'watch --once' every five minutes from cron or a systemd timer")
+	fmt.Println("  disable-watchdog            Remove the scheduled watchdog job")
+	fmt.Println("  config export FILE          Save settings to FILE, secrets only with --include-secrets")
+	fmt.Println("  config import FILE          Validate settings from FILE and write them to .env")
+	fmt.Println("         [--confirm-domain D] Accept a domain change to D without the prompt")
+	fmt.Println("  dump-env-template           Print every .env setting with its default, to fill in for install --env-file")
+	fmt.Println("  configure-backups           View and change how long backups are kept")
 	fmt.Println("  change-admin-password       Change the admin user password")
 	fmt.Println("  update-license-key [key]    Update the license key and restart containers")
+	fmt.Println("         [--validate-only]    Only check the key, without changing .env or restarting")
 	fmt.Println("  version                     Show version information")
+	fmt.Println("          [--check]           Compare with the latest release, exits 10 if an update exists")
 	fmt.Println("  help                        Show this help message")
 }
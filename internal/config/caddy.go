@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CaddyGlobalOptionLines splits CADDY_GLOBAL_OPTIONS into directive lines.
+// .env values are single lines, so directives are separated with a literal \n.
+func (d ConfigData) CaddyGlobalOptionLines() []string {
+	var lines []string
+	for _, line := range strings.Split(d.CaddyGlobalOptions, `\n`) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// checkCaddyGlobalOptions catches unbalanced braces, which would close the
+// global options block early or swallow the site blocks that follow it
+func checkCaddyGlobalOptions(lines []string) error {
+	depth := 0
+	for _, line := range lines {
+		for _, char := range line {
+			switch char {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth < 0 {
+					return fmt.Errorf("unexpected } in %q", line)
+				}
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("%d unclosed { block(s)", depth)
+	}
+	return nil
+}
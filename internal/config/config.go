@@ -12,12 +12,15 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"golang.org/x/term"
 
 	"infinity-metrics-installer/internal/errors"
+	"infinity-metrics-installer/internal/httpclient"
 	"infinity-metrics-installer/internal/logging"
 	"infinity-metrics-installer/internal/validation"
 )
@@ -27,23 +30,116 @@ const GithubRepo = "karloscodes/infinity-metrics-installer"
 
 // ConfigData holds the configuration
 type ConfigData struct {
-	Domain       string   // Local: User-provided
-	AppImage     string   // GitHub Release/Default: e.g., "karloscodes/infinity-metrics-beta:latest"
-	CaddyImage   string   // GitHub Release/Default: e.g., "caddy:2.7-alpine"
-	InstallDir   string   // Default: e.g., "/opt/infinity-metrics"
-	BackupPath   string   // Default: SQLite backup location
-	PrivateKey   string   // Generated: secure random key for INFINITY_METRICS_PRIVATE_KEY
-	Version      string   // GitHub Release: Version of the infinity-metrics binary (optional)
-	InstallerURL string   // GitHub Release: URL to download new infinity-metrics binary
-	DNSWarnings  []string // DNS configuration warnings
-	User         string   // Database: Admin user email from users table
-	LicenseKey   string   // License key for the application
+	Domain        string   // Local: User-provided
+	AppImage      string   // GitHub Release/Default: e.g., "karloscodes/infinity-metrics-beta:latest"
+	CaddyImage    string   // GitHub Release/Default: e.g., "caddy:2.7-alpine"
+	InstallDir    string   // Default: e.g., "/opt/infinity-metrics"
+	BackupPath    string   // Default: SQLite backup location
+	PrivateKey    string   // Generated: secure random key for INFINITY_METRICS_PRIVATE_KEY
+	Version       string   // GitHub Release: Version of the infinity-metrics binary (optional)
+	InstallerURL  string   // GitHub Release: URL to download new infinity-metrics binary
+	DNSWarnings   []string // DNS configuration warnings
+	User          string   // Database: Admin user email from users table
+	LicenseKey    string   // License key for the application
+	RequireBackup bool     // Local: abort updates when the pre-update backup fails (default true)
+	ContainerUser string   // Local: optional uid:gid the app container runs as
+	CaddyTemplate string   // Local: optional path to a custom Caddyfile template
+	AppEnvFile    string   // Local: optional env file passed to the app container with --env-file
+	TLSCertPath   string   // Local: optional certificate served by Caddy instead of ACME
+	TLSKeyPath    string   // Local: private key for TLSCertPath
+	TLSInternal   bool     // Local: serve a certificate from Caddy's internal CA instead of ACME, for private domains
+	MigrationCmd  string   // Local: optional command run in the new app container before cutover
+	AppCPULimit   string   // Local: optional --cpus limit for the app container, e.g. "1.5"
+	CaddyCPULimit string   // Local: optional --cpus limit for the Caddy container
+
+	// Local: optional address family Caddy publishes ports 80 and 443 on,
+	// "ipv4", "ipv6" or "dual"; empty leaves the choice to Docker
+	ListenStack string
+
+	// Local: relabel bind mounts for SELinux, true, false or auto (the
+	// default) to relabel when SELinux is enforcing
+	SELinuxRelabel string
+
+	// Local: optional comma-separated registry hosts reached over plain HTTP
+	RegistryInsecure string
+
+	// Local: optional registry account used to log in when anonymous pulls
+	// hit a rate limit, such as Docker Hub's per-IP limit
+	RegistryUsername string
+	RegistryPassword string
+
+	// Local: optional release source for forks and mirrors, an owner/repo on
+	// GitHub or the full URL of an endpoint serving the latest release JSON
+	ReleaseSourceRepo  string
+	ReleaseAPIEndpoint string
+
+	// Local: optional cap on the installer binary download, e.g. "5MB/s"
+	DownloadRateLimit string
+
+	// Local: optional raw Caddy global option directives injected into the
+	// global options block, separated by a literal \n in .env
+	CaddyGlobalOptions string
+
+	// Local: optional absolute host directory mounted as the app's storage,
+	// holding the database, instead of the storage directory of InstallDir
+	DBStoragePath string
+
+	// Local: optional shell commands run after an install and around an
+	// update; a failing hook only warns unless HooksFatal is set
+	PostInstallHook string
+	PreUpdateHook   string
+	PostUpdateHook  string
+	HooksFatal      bool
+
+	// Local: back up the database before every reload, as `reload --backup` does
+	BackupBeforeReload bool
+
+	// Local: deploy images side-loaded with `docker load` without contacting a
+	// registry or the release API
+	OfflineMode bool
+
+	// Local: app image versions kept locally for rollback, 0 keeps the built-in default
+	KeepImageVersions int
+
+	// Local: serve a 503 maintenance page instead of proxying to the app,
+	// optionally from an HTML file instead of the built-in page
+	MaintenanceMode bool
+	MaintenancePage string
+
+	// Local: backup retention overrides in days, 0 keeps the built-in default
+	BackupDailyRetentionDays   int
+	BackupWeeklyRetentionDays  int
+	BackupMonthlyRetentionDays int
+
+	// Local: app health endpoint probed inside the container, "http" (the
+	// default) or "https" for images serving TLS on the app port, and the
+	// path, "/_health" by default
+	AppHealthScheme string
+	AppHealthPath   string
+
+	// Local: how long a stopping container gets before Docker kills it, a
+	// duration such as "1m" or a number of seconds; empty keeps 30s
+	StopTimeout string
+
+	// Local: upper bound for one app health probe, a duration or a number of
+	// seconds, and how many probes are made before a container is declared
+	// unhealthy; empty and 0 keep 5s and 5 tries
+	HealthCheckTimeout string
+	HealthCheckTries   int
 }
 
+// InstallerVersionEnvVar carries the running installer's version, set by main at startup
+const InstallerVersionEnvVar = "INFINITY_METRICS_VERSION"
+
+// MaxInstallerMinorLag is how many minor releases the installer may trail the
+// installer version a release expects before a compatibility warning is shown
+const MaxInstallerMinorLag = 2
+
 // Config manages configuration
 type Config struct {
-	logger *logging.Logger
-	data   ConfigData
+	logger    *logging.Logger
+	data      ConfigData
+	assumeYes bool
 }
 
 // NewConfig creates a Config with defaults
@@ -51,32 +147,34 @@ func NewConfig(logger *logging.Logger) *Config {
 	return &Config{
 		logger: logger,
 		data: ConfigData{
-			Domain:       "", // Required from user
-			AppImage:     "karloscodes/infinity-metrics-beta:latest",
-			CaddyImage:   "caddy:2.7-alpine",
-			InstallDir:   "/opt/infinity-metrics",
-			BackupPath:   "/opt/infinity-metrics/storage/backups",
-			PrivateKey:   "",
-			Version:      "latest",
-			InstallerURL: fmt.Sprintf("https://github.com/%s/releases/latest", GithubRepo),
+			Domain:        "", // Required from user
+			AppImage:      "karloscodes/infinity-metrics-beta:latest",
+			CaddyImage:    "caddy:2.7-alpine",
+			InstallDir:    "/opt/infinity-metrics",
+			BackupPath:    "/opt/infinity-metrics/storage/backups",
+			PrivateKey:    "",
+			Version:       "latest",
+			InstallerURL:  fmt.Sprintf("https://github.com/%s/releases/latest", GithubRepo),
+			RequireBackup: true,
 		},
 	}
 }
 
+// PublicIPServices echo the caller's public IP, tried in order by the DNS
+// check to find the server's address
+var PublicIPServices = []string{
+	"https://api.ipify.org",
+	"https://ifconfig.me/ip",
+	"https://icanhazip.com",
+}
+
 // Helper function to get the current server's primary public IP address
 func getCurrentServerIP() (string, error) {
-	// Try to get IPs from multiple external services for better reliability
-	externalServices := []string{
-		"https://api.ipify.org",
-		"https://ifconfig.me/ip",
-		"https://icanhazip.com",
-	}
-
 	var publicIPs []string
 
-	// Try external services first
-	for _, service := range externalServices {
-		resp, err := http.Get(service)
+	// Try external services first, several for better reliability
+	for _, service := range PublicIPServices {
+		resp, err := httpclient.New().Get(service)
 		if err == nil {
 			defer resp.Body.Close()
 			ip, err := io.ReadAll(resp.Body)
@@ -171,8 +269,40 @@ func checkDomainIPMatch(domain string, serverIPs string) (bool, string) {
 	return false, strings.Join(domainIPStrings, ", ")
 }
 
+// SetAssumeYes makes CollectFromUser accept the configuration summary without asking
+func (c *Config) SetAssumeYes(assumeYes bool) {
+	c.assumeYes = assumeYes
+}
+
+// logSummary records the configuration an unattended install is about to use
+func (c *Config) logSummary() {
+	c.logger.Info("Configuration summary:")
+	c.logger.Info("  Domain: %s", c.data.Domain)
+	c.logger.Info("  Installation directory: %s", c.data.InstallDir)
+	c.logger.Info("  Backup path: %s", c.data.BackupPath)
+	c.logger.Info("  App image: %s", c.data.AppImage)
+	c.logger.Info("  Caddy image: %s", c.data.CaddyImage)
+}
+
 // CollectFromUser gets required user input upfront
 func (c *Config) CollectFromUser(reader *bufio.Reader) error {
+	// An air-gapped install has no .env yet to read OFFLINE_MODE from
+	if offline := os.Getenv("OFFLINE_MODE"); offline != "" {
+		mode, err := strconv.ParseBool(offline)
+		if err != nil {
+			return errors.NewConfigError("offline_mode", offline, "must be true or false")
+		}
+		c.data.OfflineMode = mode
+	}
+	// Neither can a private-network install read TLS_INTERNAL from .env
+	if internal := os.Getenv("TLS_INTERNAL"); internal != "" {
+		mode, err := strconv.ParseBool(internal)
+		if err != nil {
+			return errors.NewConfigError("tls_internal", internal, "must be true or false")
+		}
+		c.data.TLSInternal = mode
+	}
+
 	// Check if we're in non-interactive mode
 	if os.Getenv("NONINTERACTIVE") == "1" {
 		return c.collectFromEnvironment()
@@ -220,6 +350,11 @@ func (c *Config) CollectFromUser(reader *bufio.Reader) error {
 		fmt.Printf("Installation Directory: %s\n", c.data.InstallDir)
 		fmt.Printf("Backup Path: %s\n", c.data.BackupPath)
 
+		if c.assumeYes {
+			c.logSummary()
+			break
+		}
+
 		fmt.Print("\nProceed with this configuration? [Y/n]: ")
 		confirmStr, err := reader.ReadString('\n')
 		if err != nil {
@@ -252,15 +387,16 @@ func (c *Config) collectFromEnvironment() error {
 	}
 	c.data.Domain = domain
 
-	c.logger.Info("Configuration loaded from environment variables:")
-	c.logger.Info("  Domain: %s", c.data.Domain)
-
 	// Set default values for other fields
 	c.data.InstallDir = "/opt/infinity-metrics"
 	c.data.BackupPath = filepath.Join(c.data.InstallDir, "backups")
 	c.data.AppImage = "karloscodes/infinity-metrics-beta:latest"
 	c.data.CaddyImage = "caddy:2.7-alpine"
+	c.data.ReleaseSourceRepo = os.Getenv("RELEASE_SOURCE_REPO")
+	c.data.ReleaseAPIEndpoint = os.Getenv("RELEASE_API_URL")
 
+	c.logger.Info("Configuration loaded from environment variables")
+	c.logSummary()
 	return nil
 }
 
@@ -276,13 +412,42 @@ func formatIPs(ips []net.IP) string {
 // LoadFromFile loads local config from .env
 func (c *Config) LoadFromFile(filename string) error {
 	c.logger.Info("Loading from %s", filename)
-	file, err := os.Open(filename)
+	content, err := os.ReadFile(filename)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	// Deprecated keys are renamed in memory only, read-only commands load
+	// .env too; MigrateFile rewrites them
+	lines, migrations := migrateEnvKeys(strings.Split(string(content), "\n"))
+	c.logMigrations(filename, migrations)
+
+	if err := c.loadEnv(strings.NewReader(strings.Join(lines, "\n"))); err != nil {
+		return err
+	}
+
+	// If PrivateKey is missing, generate one and append to file
+	if c.data.PrivateKey == "" {
+		pk, err := generatePrivateKey()
+		if err != nil {
+			return err
+		}
+		c.data.PrivateKey = pk
+		// Append to file
+		f, ferr := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
+		if ferr == nil {
+			fmt.Fprintf(f, "INFINITY_METRICS_PRIVATE_KEY=%s\n", pk)
+			f.Close()
+			c.logger.Info("Added missing INFINITY_METRICS_PRIVATE_KEY to %s", filename)
+		}
+	}
+	c.logger.Success("Configuration loaded from %s", filename)
+	return nil
+}
+
+// loadEnv applies the KEY=value lines read from r on top of the current data
+func (c *Config) loadEnv(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
@@ -314,28 +479,124 @@ func (c *Config) LoadFromFile(filename string) error {
 			c.data.User = value
 		case "INFINITY_METRICS_LICENSE_KEY":
 			c.data.LicenseKey = value
+		case "REQUIRE_BACKUP":
+			requireBackup, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.NewConfigError("require_backup", value, "must be true or false")
+			}
+			c.data.RequireBackup = requireBackup
+		case "CONTAINER_USER":
+			c.data.ContainerUser = value
+		case "CADDYFILE_TEMPLATE":
+			c.data.CaddyTemplate = value
+		case "APP_ENV_FILE":
+			c.data.AppEnvFile = value
+		case "TLS_CERT_PATH":
+			c.data.TLSCertPath = value
+		case "TLS_KEY_PATH":
+			c.data.TLSKeyPath = value
+		case "MIGRATION_COMMAND":
+			c.data.MigrationCmd = value
+		case "APP_CPU_LIMIT":
+			c.data.AppCPULimit = value
+		case "CADDY_CPU_LIMIT":
+			c.data.CaddyCPULimit = value
+		case "LISTEN_STACK":
+			c.data.ListenStack = value
+		case "SELINUX_RELABEL":
+			c.data.SELinuxRelabel = value
+		case "APP_HEALTH_SCHEME":
+			c.data.AppHealthScheme = value
+		case "APP_HEALTH_PATH":
+			c.data.AppHealthPath = value
+		case "STOP_TIMEOUT":
+			c.data.StopTimeout = value
+		case "HEALTH_CHECK_TIMEOUT":
+			c.data.HealthCheckTimeout = value
+		case "HEALTH_CHECK_TRIES":
+			tries, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.NewConfigError("health_check_tries", value, "must be a whole number")
+			}
+			c.data.HealthCheckTries = tries
+		case "REGISTRY_INSECURE":
+			c.data.RegistryInsecure = value
+		case "REGISTRY_USERNAME":
+			c.data.RegistryUsername = value
+		case "REGISTRY_PASSWORD":
+			c.data.RegistryPassword = value
+		case "RELEASE_SOURCE_REPO":
+			c.data.ReleaseSourceRepo = value
+		case "RELEASE_API_URL":
+			c.data.ReleaseAPIEndpoint = value
+		case "CADDY_GLOBAL_OPTIONS":
+			c.data.CaddyGlobalOptions = value
+		case "DB_STORAGE_PATH":
+			c.data.DBStoragePath = value
+		case "DOWNLOAD_RATE_LIMIT":
+			c.data.DownloadRateLimit = value
+		case "POST_INSTALL_HOOK":
+			c.data.PostInstallHook = value
+		case "PRE_UPDATE_HOOK":
+			c.data.PreUpdateHook = value
+		case "POST_UPDATE_HOOK":
+			c.data.PostUpdateHook = value
+		case "HOOKS_FATAL":
+			fatal, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.NewConfigError("hooks_fatal", value, "must be true or false")
+			}
+			c.data.HooksFatal = fatal
+		case "BACKUP_BEFORE_RELOAD":
+			backup, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.NewConfigError("backup_before_reload", value, "must be true or false")
+			}
+			c.data.BackupBeforeReload = backup
+		case "OFFLINE_MODE":
+			offline, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.NewConfigError("offline_mode", value, "must be true or false")
+			}
+			c.data.OfflineMode = offline
+		case "TLS_INTERNAL":
+			internal, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.NewConfigError("tls_internal", value, "must be true or false")
+			}
+			c.data.TLSInternal = internal
+		case "MAINTENANCE_MODE":
+			maintenance, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.NewConfigError("maintenance_mode", value, "must be true or false")
+			}
+			c.data.MaintenanceMode = maintenance
+		case "MAINTENANCE_PAGE":
+			c.data.MaintenancePage = value
+		case "KEEP_IMAGE_VERSIONS":
+			versions, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.NewConfigError("keep_image_versions", value, "must be a whole number")
+			}
+			c.data.KeepImageVersions = versions
+		case "BACKUP_DAILY_RETENTION_DAYS", "BACKUP_WEEKLY_RETENTION_DAYS", "BACKUP_MONTHLY_RETENTION_DAYS":
+			days, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.NewConfigError(strings.ToLower(key), value, "must be a whole number of days")
+			}
+			switch key {
+			case "BACKUP_DAILY_RETENTION_DAYS":
+				c.data.BackupDailyRetentionDays = days
+			case "BACKUP_WEEKLY_RETENTION_DAYS":
+				c.data.BackupWeeklyRetentionDays = days
+			default:
+				c.data.BackupMonthlyRetentionDays = days
+			}
 		}
 	}
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
-
-	// If PrivateKey is missing, generate one and append to file
-	if c.data.PrivateKey == "" {
-		pk, err := generatePrivateKey()
-		if err != nil {
-			return err
-		}
-		c.data.PrivateKey = pk
-		// Append to file
-		f, ferr := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
-		if ferr == nil {
-			fmt.Fprintf(f, "INFINITY_METRICS_PRIVATE_KEY=%s\n", pk)
-			f.Close()
-			c.logger.Info("Added missing INFINITY_METRICS_PRIVATE_KEY to %s", filename)
-		}
-	}
-	c.logger.Success("Configuration loaded from %s", filename)
 	return nil
 }
 
@@ -359,6 +620,15 @@ func (c *Config) SaveToFile(filename string) error {
 	}
 	defer file.Close()
 
+	c.writeEnv(file, false)
+
+	c.logger.Info("Configuration saved to %s", filename)
+	return nil
+}
+
+// writeEnv writes the configuration as KEY=value lines. With redact set the
+// private key, license key and registry password are left out.
+func (c *Config) writeEnv(file io.Writer, redact bool) {
 	fmt.Fprintf(file, "INFINITY_METRICS_DOMAIN=%s\n", c.data.Domain)
 	fmt.Fprintf(file, "APP_IMAGE=%s\n", c.data.AppImage)
 	fmt.Fprintf(file, "CADDY_IMAGE=%s\n", c.data.CaddyImage)
@@ -366,16 +636,126 @@ func (c *Config) SaveToFile(filename string) error {
 	fmt.Fprintf(file, "BACKUP_PATH=%s\n", c.data.BackupPath)
 	fmt.Fprintf(file, "VERSION=%s\n", c.data.Version)
 	fmt.Fprintf(file, "INSTALLER_URL=%s\n", c.data.InstallerURL)
-	fmt.Fprintf(file, "INFINITY_METRICS_PRIVATE_KEY=%s\n", c.data.PrivateKey)
+	if !redact {
+		fmt.Fprintf(file, "INFINITY_METRICS_PRIVATE_KEY=%s\n", c.data.PrivateKey)
+	}
 	if c.data.User != "" {
 		fmt.Fprintf(file, "INFINITY_METRICS_USER=%s\n", c.data.User)
 	}
-	if c.data.LicenseKey != "" {
+	if c.data.LicenseKey != "" && !redact {
 		fmt.Fprintf(file, "INFINITY_METRICS_LICENSE_KEY=%s\n", c.data.LicenseKey)
 	}
-
-	c.logger.Info("Configuration saved to %s", filename)
-	return nil
+	if !c.data.RequireBackup {
+		fmt.Fprintf(file, "REQUIRE_BACKUP=false\n")
+	}
+	if c.data.ContainerUser != "" {
+		fmt.Fprintf(file, "CONTAINER_USER=%s\n", c.data.ContainerUser)
+	}
+	if c.data.CaddyTemplate != "" {
+		fmt.Fprintf(file, "CADDYFILE_TEMPLATE=%s\n", c.data.CaddyTemplate)
+	}
+	if c.data.AppEnvFile != "" {
+		fmt.Fprintf(file, "APP_ENV_FILE=%s\n", c.data.AppEnvFile)
+	}
+	if c.data.TLSCertPath != "" {
+		fmt.Fprintf(file, "TLS_CERT_PATH=%s\n", c.data.TLSCertPath)
+	}
+	if c.data.TLSKeyPath != "" {
+		fmt.Fprintf(file, "TLS_KEY_PATH=%s\n", c.data.TLSKeyPath)
+	}
+	if c.data.TLSInternal {
+		fmt.Fprintf(file, "TLS_INTERNAL=true\n")
+	}
+	if c.data.MigrationCmd != "" {
+		fmt.Fprintf(file, "MIGRATION_COMMAND=%s\n", c.data.MigrationCmd)
+	}
+	if c.data.AppCPULimit != "" {
+		fmt.Fprintf(file, "APP_CPU_LIMIT=%s\n", c.data.AppCPULimit)
+	}
+	if c.data.CaddyCPULimit != "" {
+		fmt.Fprintf(file, "CADDY_CPU_LIMIT=%s\n", c.data.CaddyCPULimit)
+	}
+	if c.data.ListenStack != "" {
+		fmt.Fprintf(file, "LISTEN_STACK=%s\n", c.data.ListenStack)
+	}
+	if c.data.SELinuxRelabel != "" {
+		fmt.Fprintf(file, "SELINUX_RELABEL=%s\n", c.data.SELinuxRelabel)
+	}
+	if c.data.RegistryInsecure != "" {
+		fmt.Fprintf(file, "REGISTRY_INSECURE=%s\n", c.data.RegistryInsecure)
+	}
+	if c.data.RegistryUsername != "" {
+		fmt.Fprintf(file, "REGISTRY_USERNAME=%s\n", c.data.RegistryUsername)
+	}
+	if c.data.RegistryPassword != "" && !redact {
+		fmt.Fprintf(file, "REGISTRY_PASSWORD=%s\n", c.data.RegistryPassword)
+	}
+	if c.data.ReleaseSourceRepo != "" {
+		fmt.Fprintf(file, "RELEASE_SOURCE_REPO=%s\n", c.data.ReleaseSourceRepo)
+	}
+	if c.data.ReleaseAPIEndpoint != "" {
+		fmt.Fprintf(file, "RELEASE_API_URL=%s\n", c.data.ReleaseAPIEndpoint)
+	}
+	if c.data.CaddyGlobalOptions != "" {
+		fmt.Fprintf(file, "CADDY_GLOBAL_OPTIONS=%s\n", c.data.CaddyGlobalOptions)
+	}
+	if c.data.DBStoragePath != "" {
+		fmt.Fprintf(file, "DB_STORAGE_PATH=%s\n", c.data.DBStoragePath)
+	}
+	if c.data.DownloadRateLimit != "" {
+		fmt.Fprintf(file, "DOWNLOAD_RATE_LIMIT=%s\n", c.data.DownloadRateLimit)
+	}
+	if c.data.PostInstallHook != "" {
+		fmt.Fprintf(file, "POST_INSTALL_HOOK=%s\n", c.data.PostInstallHook)
+	}
+	if c.data.PreUpdateHook != "" {
+		fmt.Fprintf(file, "PRE_UPDATE_HOOK=%s\n", c.data.PreUpdateHook)
+	}
+	if c.data.PostUpdateHook != "" {
+		fmt.Fprintf(file, "POST_UPDATE_HOOK=%s\n", c.data.PostUpdateHook)
+	}
+	if c.data.HooksFatal {
+		fmt.Fprintf(file, "HOOKS_FATAL=true\n")
+	}
+	if c.data.BackupBeforeReload {
+		fmt.Fprintf(file, "BACKUP_BEFORE_RELOAD=true\n")
+	}
+	if c.data.OfflineMode {
+		fmt.Fprintf(file, "OFFLINE_MODE=true\n")
+	}
+	if c.data.KeepImageVersions != 0 {
+		fmt.Fprintf(file, "KEEP_IMAGE_VERSIONS=%d\n", c.data.KeepImageVersions)
+	}
+	if c.data.MaintenanceMode {
+		fmt.Fprintf(file, "MAINTENANCE_MODE=true\n")
+	}
+	if c.data.MaintenancePage != "" {
+		fmt.Fprintf(file, "MAINTENANCE_PAGE=%s\n", c.data.MaintenancePage)
+	}
+	if c.data.BackupDailyRetentionDays != 0 {
+		fmt.Fprintf(file, "BACKUP_DAILY_RETENTION_DAYS=%d\n", c.data.BackupDailyRetentionDays)
+	}
+	if c.data.BackupWeeklyRetentionDays != 0 {
+		fmt.Fprintf(file, "BACKUP_WEEKLY_RETENTION_DAYS=%d\n", c.data.BackupWeeklyRetentionDays)
+	}
+	if c.data.BackupMonthlyRetentionDays != 0 {
+		fmt.Fprintf(file, "BACKUP_MONTHLY_RETENTION_DAYS=%d\n", c.data.BackupMonthlyRetentionDays)
+	}
+	if c.data.AppHealthScheme != "" {
+		fmt.Fprintf(file, "APP_HEALTH_SCHEME=%s\n", c.data.AppHealthScheme)
+	}
+	if c.data.AppHealthPath != "" {
+		fmt.Fprintf(file, "APP_HEALTH_PATH=%s\n", c.data.AppHealthPath)
+	}
+	if c.data.StopTimeout != "" {
+		fmt.Fprintf(file, "STOP_TIMEOUT=%s\n", c.data.StopTimeout)
+	}
+	if c.data.HealthCheckTimeout != "" {
+		fmt.Fprintf(file, "HEALTH_CHECK_TIMEOUT=%s\n", c.data.HealthCheckTimeout)
+	}
+	if c.data.HealthCheckTries != 0 {
+		fmt.Fprintf(file, "HEALTH_CHECK_TRIES=%d\n", c.data.HealthCheckTries)
+	}
 }
 
 // GetData returns the config data
@@ -408,8 +788,12 @@ func (c *Config) GetDockerImages() DockerImages {
 	}
 }
 
-// SetInstallDir sets the InstallDir field in ConfigData
+// SetInstallDir sets the InstallDir field in ConfigData. A backup path still
+// at its default under the old directory moves along with it.
 func (c *Config) SetInstallDir(dir string) {
+	if c.data.BackupPath == filepath.Join(c.data.InstallDir, "storage", "backups") {
+		c.data.BackupPath = filepath.Join(dir, "storage", "backups")
+	}
 	c.data.InstallDir = dir
 }
 
@@ -420,7 +804,7 @@ func (c *Config) SetInstallerURL(url string) {
 
 // GetMainDBPath returns the main database path
 func (c *Config) GetMainDBPath() string {
-	return filepath.Join(c.data.InstallDir, "storage", "infinity-metrics-production.db")
+	return c.data.MainDBPath()
 }
 
 // Validate checks required fields
@@ -434,11 +818,17 @@ func (c *Config) Validate() error {
 	if c.data.AppImage == "" {
 		return errors.NewConfigError("app_image", "", "app image cannot be empty")
 	}
+	if _, err := name.ParseReference(c.data.AppImage); err != nil {
+		return errors.NewConfigError("app_image", c.data.AppImage, fmt.Sprintf("invalid image reference, check APP_IMAGE in .env: %v", err))
+	}
 
 	// Validate caddy image
 	if c.data.CaddyImage == "" {
 		return errors.NewConfigError("caddy_image", "", "caddy image cannot be empty")
 	}
+	if _, err := name.ParseReference(c.data.CaddyImage); err != nil {
+		return errors.NewConfigError("caddy_image", c.data.CaddyImage, fmt.Sprintf("invalid image reference, check CADDY_IMAGE in .env: %v", err))
+	}
 
 	// Validate install directory path
 	if err := validation.ValidateFilePath(c.data.InstallDir); err != nil {
@@ -472,6 +862,177 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate container user if provided
+	if c.data.ContainerUser != "" {
+		if err := validation.ValidateContainerUser(c.data.ContainerUser); err != nil {
+			return errors.NewConfigError("container_user", c.data.ContainerUser, err.Error())
+		}
+	}
+
+	// Validate custom Caddyfile template if provided
+	if c.data.CaddyTemplate != "" {
+		if err := validation.ValidateFilePath(c.data.CaddyTemplate); err != nil {
+			return errors.NewConfigError("caddyfile_template", c.data.CaddyTemplate, err.Error())
+		}
+		if _, err := os.Stat(c.data.CaddyTemplate); err != nil {
+			return errors.NewConfigError("caddyfile_template", c.data.CaddyTemplate, "template file is not readable")
+		}
+	}
+
+	// Validate app env file if provided
+	if c.data.AppEnvFile != "" {
+		if err := validation.ValidateFilePath(c.data.AppEnvFile); err != nil {
+			return errors.NewConfigError("app_env_file", c.data.AppEnvFile, err.Error())
+		}
+		f, err := os.Open(c.data.AppEnvFile)
+		if err != nil {
+			return errors.NewConfigError("app_env_file", c.data.AppEnvFile, "env file is not readable")
+		}
+		f.Close()
+	}
+
+	// Validate bring-your-own certificate if provided
+	if c.data.TLSCertPath != "" || c.data.TLSKeyPath != "" {
+		if c.data.TLSCertPath == "" || c.data.TLSKeyPath == "" {
+			return errors.NewConfigError("tls_cert_path", c.data.TLSCertPath, "TLS_CERT_PATH and TLS_KEY_PATH must be set together")
+		}
+		if err := validation.ValidateCertificatePair(c.data.TLSCertPath, c.data.TLSKeyPath); err != nil {
+			return errors.NewConfigError("tls_cert_path", c.data.TLSCertPath, err.Error())
+		}
+		if c.data.TLSInternal {
+			return errors.NewConfigError("tls_internal", "true", "TLS_INTERNAL cannot be combined with TLS_CERT_PATH")
+		}
+	}
+
+	// Validate CPU limits if provided
+	if c.data.AppCPULimit != "" {
+		if err := validation.ValidateCPULimit(c.data.AppCPULimit); err != nil {
+			return errors.NewConfigError("app_cpu_limit", c.data.AppCPULimit, err.Error())
+		}
+	}
+	if c.data.CaddyCPULimit != "" {
+		if err := validation.ValidateCPULimit(c.data.CaddyCPULimit); err != nil {
+			return errors.NewConfigError("caddy_cpu_limit", c.data.CaddyCPULimit, err.Error())
+		}
+	}
+
+	// Validate the listen address family if provided
+	if c.data.ListenStack != "" {
+		if err := validation.ValidateListenStack(c.data.ListenStack); err != nil {
+			return errors.NewConfigError("listen_stack", c.data.ListenStack, err.Error())
+		}
+	}
+
+	// Validate SELinux relabeling if provided
+	if c.data.SELinuxRelabel != "" {
+		if err := validation.ValidateSELinuxRelabel(c.data.SELinuxRelabel); err != nil {
+			return errors.NewConfigError("selinux_relabel", c.data.SELinuxRelabel, err.Error())
+		}
+	}
+
+	// Validate the app health check scheme if provided
+	if c.data.AppHealthScheme != "" {
+		if err := validation.ValidateAppHealthScheme(c.data.AppHealthScheme); err != nil {
+			return errors.NewConfigError("app_health_scheme", c.data.AppHealthScheme, err.Error())
+		}
+	}
+
+	// Validate the container stop timeout if provided
+	if c.data.StopTimeout != "" {
+		if _, err := validation.ParseTimeout(c.data.StopTimeout); err != nil {
+			return errors.NewConfigError("stop_timeout", c.data.StopTimeout, err.Error())
+		}
+	}
+
+	// Validate the health check timeout and tries if provided
+	if c.data.HealthCheckTimeout != "" {
+		if _, err := validation.ParseTimeout(c.data.HealthCheckTimeout); err != nil {
+			return errors.NewConfigError("health_check_timeout", c.data.HealthCheckTimeout, err.Error())
+		}
+	}
+	if c.data.HealthCheckTries < 0 {
+		return errors.NewConfigError("health_check_tries", strconv.Itoa(c.data.HealthCheckTries), "must be a positive number of tries")
+	}
+
+	// Validate plain-HTTP registry hosts if provided
+	if c.data.RegistryInsecure != "" {
+		for _, host := range strings.Split(c.data.RegistryInsecure, ",") {
+			if _, err := name.NewRegistry(strings.TrimSpace(host), name.StrictValidation); err != nil {
+				return errors.NewConfigError("registry_insecure", c.data.RegistryInsecure, fmt.Sprintf("invalid registry host %q, expected host or host:port", strings.TrimSpace(host)))
+			}
+		}
+	}
+
+	// Validate release source overrides if provided
+	if c.data.ReleaseSourceRepo != "" {
+		if err := validation.ValidateRepoSlug(c.data.ReleaseSourceRepo); err != nil {
+			return errors.NewConfigError("release_source_repo", c.data.ReleaseSourceRepo, err.Error())
+		}
+	}
+	if c.data.ReleaseAPIEndpoint != "" {
+		if err := validation.ValidateURL(c.data.ReleaseAPIEndpoint); err != nil {
+			return errors.NewConfigError("release_api_url", c.data.ReleaseAPIEndpoint, err.Error())
+		}
+	}
+
+	// Validate download rate limit if provided
+	if c.data.DownloadRateLimit != "" {
+		if _, err := httpclient.ParseRate(c.data.DownloadRateLimit); err != nil {
+			return errors.NewConfigError("download_rate_limit", c.data.DownloadRateLimit, err.Error())
+		}
+	}
+
+	// Validate custom Caddy global options if provided
+	if c.data.CaddyGlobalOptions != "" {
+		if err := checkCaddyGlobalOptions(c.data.CaddyGlobalOptionLines()); err != nil {
+			return errors.NewConfigError("caddy_global_options", c.data.CaddyGlobalOptions, err.Error())
+		}
+	}
+
+	// Validate relocated database storage if provided
+	if c.data.DBStoragePath != "" {
+		if err := validation.ValidateFilePath(c.data.DBStoragePath); err != nil {
+			return errors.NewConfigError("db_storage_path", c.data.DBStoragePath, err.Error())
+		}
+		if !filepath.IsAbs(c.data.DBStoragePath) {
+			return errors.NewConfigError("db_storage_path", c.data.DBStoragePath, "must be an absolute path")
+		}
+	}
+
+	// Validate image retention if provided
+	if c.data.KeepImageVersions != 0 {
+		if err := validation.ValidateKeepImageVersions(c.data.KeepImageVersions); err != nil {
+			return errors.NewConfigError("keep_image_versions", strconv.Itoa(c.data.KeepImageVersions), err.Error())
+		}
+	}
+
+	// Validate custom maintenance page if provided
+	if c.data.MaintenancePage != "" {
+		if err := validation.ValidateFilePath(c.data.MaintenancePage); err != nil {
+			return errors.NewConfigError("maintenance_page", c.data.MaintenancePage, err.Error())
+		}
+		if _, err := os.Stat(c.data.MaintenancePage); err != nil {
+			return errors.NewConfigError("maintenance_page", c.data.MaintenancePage, "maintenance page is not readable")
+		}
+	}
+
+	// Validate backup retention overrides if provided
+	for _, retention := range []struct {
+		field string
+		days  int
+	}{
+		{"backup_daily_retention_days", c.data.BackupDailyRetentionDays},
+		{"backup_weekly_retention_days", c.data.BackupWeeklyRetentionDays},
+		{"backup_monthly_retention_days", c.data.BackupMonthlyRetentionDays},
+	} {
+		if retention.days == 0 {
+			continue
+		}
+		if err := validation.ValidateRetentionDays(retention.days); err != nil {
+			return errors.NewConfigError(retention.field, strconv.Itoa(retention.days), err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -483,12 +1044,26 @@ func (c *Config) CheckDNSAndStoreWarnings(domain string) {
 		c.data.DNSWarnings = []string{}
 		return
 	}
+	// The internal CA needs neither a public domain nor DNS pointing here
+	if c.data.TLSInternal {
+		fmt.Printf("🔒 Skipping DNS checks, TLS_INTERNAL is set: %s\n", domain)
+		c.data.DNSWarnings = []string{}
+		return
+	}
 
 	fmt.Printf("🔍 Checking DNS configuration for %s...\n", domain)
 
 	// Clear any existing warnings
 	c.data.DNSWarnings = []string{}
 
+	// Let's Encrypt cannot issue certificates for private or reserved domains
+	if err := validation.ValidatePublicDomain(domain); err != nil {
+		c.data.DNSWarnings = append(c.data.DNSWarnings, fmt.Sprintf("%s cannot get a Let's Encrypt certificate: %v", domain, err))
+		c.data.DNSWarnings = append(c.data.DNSWarnings, "Suggestion: Use a public domain you own, or for private networks install with TLS_INTERNAL=true so Caddy issues a certificate from its internal CA")
+		c.displayDNSWarnings()
+		return
+	}
+
 	ips, err := net.LookupIP(domain)
 	if err != nil {
 		warning := fmt.Sprintf("DNS lookup failed for %s: %v", domain, err)
@@ -595,12 +1170,21 @@ func (c *Config) readPassword(reader *bufio.Reader, prompt string) (string, erro
 	return strings.TrimSpace(string(passwordBytes)), nil
 }
 
-// FetchFromServer fetches config from the latest GitHub release
+// FetchFromServer fetches config from the latest release of the release
+// source. Nothing is fetched in offline mode, where a fresh install takes the
+// running installer's version instead of the release's.
 func (c *Config) FetchFromServer(_ string) error {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", GithubRepo)
-	c.logger.Info("Fetching latest release from GitHub: %s", url)
+	if c.data.OfflineMode {
+		c.logger.Info("Offline mode: keeping the local configuration instead of fetching the latest release")
+		if validation.ValidateVersion(c.data.Version) != nil && os.Getenv(InstallerVersionEnvVar) != "" {
+			c.data.Version = os.Getenv(InstallerVersionEnvVar)
+		}
+		return nil
+	}
+	url := c.data.ReleaseAPIURL()
+	c.logger.Info("Fetching latest release: %s", url)
 
-	resp, err := http.Get(url)
+	resp, err := httpclient.New().Get(url)
 	if err != nil || resp.StatusCode != http.StatusOK {
 		c.logger.Warn("Failed to fetch latest release: %v", err)
 		if resp != nil {
@@ -680,15 +1264,21 @@ func (c *Config) FetchFromServer(_ string) error {
 // fetchConfigJSON fetches and applies config.json from a URL
 func (c *Config) fetchConfigJSON(url string) error {
 	c.logger.Info("Fetching config.json from %s", url)
-	resp, err := http.Get(url)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to fetch config.json: %v, status: %s", err, resp.Status)
+	resp, err := httpclient.New().Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch config.json: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch config.json, status: %s", resp.Status)
+	}
 
+	// Images are taken verbatim from the release; the Caddy image is versioned
+	// independently of the app and must never be derived from the release tag
 	var serverData struct {
-		AppImage   string `json:"app_image"`
-		CaddyImage string `json:"caddy_image"`
+		AppImage         string `json:"app_image"`
+		CaddyImage       string `json:"caddy_image"`
+		InstallerVersion string `json:"installer_version"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&serverData); err != nil {
 		return fmt.Errorf("failed to decode config.json: %w", err)
@@ -702,9 +1292,56 @@ func (c *Config) fetchConfigJSON(url string) error {
 	}
 
 	c.logger.Success("Applied config.json from release")
+	c.warnIfInstallerOutdated(os.Getenv(InstallerVersionEnvVar), serverData.InstallerVersion)
 	return nil
 }
 
+// warnIfInstallerOutdated warns when the running installer is far behind the
+// installer version the release expects, since an old binary may not deploy a
+// newer release correctly
+func (c *Config) warnIfInstallerOutdated(current, expected string) {
+	if !InstallerFarBehind(current, expected) {
+		return
+	}
+	c.logger.Warn("This installer (v%s) is far behind the installer expected by the latest release (v%s) and may not deploy it correctly",
+		strings.TrimPrefix(current, "v"), strings.TrimPrefix(expected, "v"))
+	c.logger.Warn("Run 'infinity-metrics update' to refresh the installer binary first")
+}
+
+// InstallerFarBehind reports whether current is a major release behind expected,
+// or more than MaxInstallerMinorLag minor releases behind. Versions that do not
+// parse, such as development builds, are never reported.
+func InstallerFarBehind(current, expected string) bool {
+	curMajor, curMinor, ok := parseMajorMinor(current)
+	if !ok {
+		return false
+	}
+	expMajor, expMinor, ok := parseMajorMinor(expected)
+	if !ok {
+		return false
+	}
+	if curMajor != expMajor {
+		return curMajor < expMajor
+	}
+	return expMinor-curMinor > MaxInstallerMinorLag
+}
+
+func parseMajorMinor(version string) (int, int, bool) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
 // isLocalhostDomain checks if the domain is localhost or a localhost variant
 func isLocalhostDomain(domain string) bool {
 	// Check for common localhost variants
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -34,6 +39,41 @@ func TestValidate_AllFieldsPresent(t *testing.T) {
 	}
 }
 
+func TestValidate_AppEnvFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(envFile, []byte("FEATURE_FLAG=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"existing file", envFile, false},
+		{"missing file", filepath.Join(t.TempDir(), "missing.env"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig(testLogger(t))
+			c.data.Domain = "example.com"
+			c.data.AppImage = "appimg"
+			c.data.CaddyImage = "caddyimg"
+			c.data.InstallDir = "/test/dir"
+			c.data.BackupPath = "/backup"
+			c.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+			c.data.Version = "v1.0.0"
+			c.data.InstallerURL = "https://example.com/installer"
+			c.data.AppEnvFile = tt.path
+
+			err := c.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidate_MissingFields(t *testing.T) {
 	fields := []struct {
 		name    string
@@ -67,6 +107,43 @@ func TestValidate_MissingFields(t *testing.T) {
 	}
 }
 
+func TestValidate_InvalidImageReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantKey string
+	}{
+		{"AppImageUppercase", func(c *Config) { c.data.AppImage = "Karloscodes/Infinity-Metrics:latest" }, "APP_IMAGE"},
+		{"AppImageEmptyTag", func(c *Config) { c.data.AppImage = "karloscodes/infinity-metrics:" }, "APP_IMAGE"},
+		{"CaddyImageWhitespace", func(c *Config) { c.data.CaddyImage = "caddy 2.7" }, "CADDY_IMAGE"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewConfig(testLogger(t))
+			c.data.Domain = "example.com"
+			c.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+			c.data.Version = "v1.0.0"
+			tc.modify(c)
+			err := c.Validate()
+			if err == nil {
+				t.Fatalf("Validate() accepted invalid image reference")
+			}
+			if !strings.Contains(err.Error(), "invalid image reference") || !strings.Contains(err.Error(), tc.wantKey) {
+				t.Errorf("Validate() error should name %s, got %v", tc.wantKey, err)
+			}
+		})
+	}
+
+	c := NewConfig(testLogger(t))
+	c.data.Domain = "example.com"
+	c.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+	c.data.Version = "v1.0.0"
+	c.data.AppImage = "ghcr.io/karloscodes/infinity-metrics@sha256:" + strings.Repeat("a", 64)
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate() rejected digest reference: %v", err)
+	}
+}
+
 func TestSettersAndGetters(t *testing.T) {
 	c := NewConfig(testLogger(t))
 	c.SetInstallDir("/foo/bar")
@@ -83,6 +160,30 @@ func TestSettersAndGetters(t *testing.T) {
 	}
 }
 
+func TestDBStoragePath(t *testing.T) {
+	c := NewConfig(testLogger(t))
+	c.SetInstallDir("/foo/bar")
+	data := c.GetData()
+	data.Domain = "example.com"
+	data.PrivateKey = "abcdefghijklmnopqrstuvwxyz123456"
+	data.Version = "v1.0.0"
+	data.DBStoragePath = "/mnt/fast/infinity-metrics"
+	c.SetData(data)
+
+	if got, want := c.GetMainDBPath(), "/mnt/fast/infinity-metrics/infinity-metrics-production.db"; got != want {
+		t.Errorf("GetMainDBPath() = %q, want %q", got, want)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate() with an absolute DB_STORAGE_PATH: %v", err)
+	}
+
+	data.DBStoragePath = "relative/storage"
+	c.SetData(data)
+	if err := c.Validate(); err == nil {
+		t.Error("Validate() must reject a relative DB_STORAGE_PATH")
+	}
+}
+
 func TestGeneratePrivateKey_Uniqueness(t *testing.T) {
 	keys := make(map[string]bool)
 	for i := 0; i < 100; i++ {
@@ -225,6 +326,77 @@ INFINITY_METRICS_PRIVATE_KEY=testprivatekey123
 	})
 }
 
+func TestRequireBackupRoundTrip(t *testing.T) {
+	c := NewConfig(testLogger(t))
+	if !c.data.RequireBackup {
+		t.Fatal("RequireBackup should default to true")
+	}
+
+	tmpFile := t.TempDir() + "/test.env"
+	content := "INFINITY_METRICS_DOMAIN=test.example.com\nREQUIRE_BACKUP=false\nINFINITY_METRICS_PRIVATE_KEY=testprivatekey123\n"
+	if err := os.WriteFile(tmpFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadFromFile(tmpFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if c.data.RequireBackup {
+		t.Error("RequireBackup should be false after loading REQUIRE_BACKUP=false")
+	}
+
+	if err := c.SaveToFile(tmpFile); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	saved, _ := os.ReadFile(tmpFile)
+	if !strings.Contains(string(saved), "REQUIRE_BACKUP=false") {
+		t.Error("SaveToFile() should persist REQUIRE_BACKUP=false")
+	}
+
+	if err := os.WriteFile(tmpFile, []byte("REQUIRE_BACKUP=maybe\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewConfig(testLogger(t)).LoadFromFile(tmpFile); err == nil {
+		t.Error("LoadFromFile() should reject a non-boolean REQUIRE_BACKUP")
+	}
+}
+
+func TestBackupRetentionRoundTrip(t *testing.T) {
+	tmpFile := t.TempDir() + "/test.env"
+	content := "INFINITY_METRICS_DOMAIN=test.example.com\nBACKUP_DAILY_RETENTION_DAYS=30\nBACKUP_MONTHLY_RETENTION_DAYS=365\n"
+	if err := os.WriteFile(tmpFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfig(testLogger(t))
+	if err := c.LoadFromFile(tmpFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if c.data.BackupDailyRetentionDays != 30 || c.data.BackupWeeklyRetentionDays != 0 || c.data.BackupMonthlyRetentionDays != 365 {
+		t.Errorf("retention = %d/%d/%d, want 30/0/365",
+			c.data.BackupDailyRetentionDays, c.data.BackupWeeklyRetentionDays, c.data.BackupMonthlyRetentionDays)
+	}
+
+	if err := c.SaveToFile(tmpFile); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	saved, _ := os.ReadFile(tmpFile)
+	for _, want := range []string{"BACKUP_DAILY_RETENTION_DAYS=30", "BACKUP_MONTHLY_RETENTION_DAYS=365"} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("SaveToFile() should persist %s", want)
+		}
+	}
+	if strings.Contains(string(saved), "BACKUP_WEEKLY_RETENTION_DAYS") {
+		t.Error("SaveToFile() should not write an unset retention value")
+	}
+
+	if err := os.WriteFile(tmpFile, []byte("BACKUP_WEEKLY_RETENTION_DAYS=two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewConfig(testLogger(t)).LoadFromFile(tmpFile); err == nil {
+		t.Error("LoadFromFile() should reject a non-numeric retention value")
+	}
+}
+
 func TestSaveToFile(t *testing.T) {
 	c := NewConfig(testLogger(t))
 	c.data.Domain = "save.example.com"
@@ -306,7 +478,7 @@ func TestCheckDNSAndStoreWarnings(t *testing.T) {
 	c := NewConfig(testLogger(t))
 
 	// Test with invalid domain (should generate warnings)
-	c.CheckDNSAndStoreWarnings("invalid-domain-that-does-not-exist.nonexistent")
+	c.CheckDNSAndStoreWarnings("invalid-domain-that-does-not-exist-7f3a9c.com")
 
 	if !c.HasDNSWarnings() {
 		t.Error("CheckDNSAndStoreWarnings() should generate warnings for invalid domain")
@@ -330,6 +502,28 @@ func TestCheckDNSAndStoreWarnings(t *testing.T) {
 	}
 }
 
+func TestCheckDNSAndStoreWarningsNonPublicDomain(t *testing.T) {
+	c := NewConfig(testLogger(t))
+	c.CheckDNSAndStoreWarnings("analytics.local")
+
+	warnings := c.GetDNSWarnings()
+	if len(warnings) == 0 {
+		t.Fatal("CheckDNSAndStoreWarnings() should warn about a non-public domain")
+	}
+	if !strings.Contains(warnings[0], "Let's Encrypt") {
+		t.Errorf("Expected Let's Encrypt warning, got %q", warnings[0])
+	}
+	if !strings.Contains(strings.Join(warnings, "\n"), "TLS_INTERNAL=true") {
+		t.Error("Expected suggestion to use internal TLS mode")
+	}
+
+	c.data.TLSInternal = true
+	c.CheckDNSAndStoreWarnings("analytics.local")
+	if c.HasDNSWarnings() {
+		t.Errorf("CheckDNSAndStoreWarnings() should skip the checks with TLS_INTERNAL, got %v", c.GetDNSWarnings())
+	}
+}
+
 func TestCheckDNSAndStoreWarningsSkipsLocalhost(t *testing.T) {
 	c := NewConfig(testLogger(t))
 
@@ -424,6 +618,93 @@ func TestFetchFromServer(t *testing.T) {
 	}
 }
 
+func TestFetchConfigJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantApp   string
+		wantCaddy string
+	}{
+		{"both images", `{"app_image":"karloscodes/infinity-metrics-beta:1.4.2","caddy_image":"caddy:2.8-alpine"}`, "karloscodes/infinity-metrics-beta:1.4.2", "caddy:2.8-alpine"},
+		{"caddy image omitted", `{"app_image":"karloscodes/infinity-metrics-beta:1.4.2"}`, "karloscodes/infinity-metrics-beta:1.4.2", "caddy:2.7-alpine"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			c := NewConfig(testLogger(t))
+			if err := c.fetchConfigJSON(server.URL); err != nil {
+				t.Fatalf("fetchConfigJSON() error = %v", err)
+			}
+			if c.data.AppImage != tt.wantApp {
+				t.Errorf("AppImage = %q, want %q", c.data.AppImage, tt.wantApp)
+			}
+			if c.data.CaddyImage != tt.wantCaddy {
+				t.Errorf("CaddyImage = %q, want %q", c.data.CaddyImage, tt.wantCaddy)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := server.URL
+		server.Close()
+
+		c := NewConfig(testLogger(t))
+		if err := c.fetchConfigJSON(url); err == nil {
+			t.Error("fetchConfigJSON() should fail when the server is unreachable")
+		}
+		if c.data.CaddyImage != "caddy:2.7-alpine" {
+			t.Errorf("CaddyImage = %q, want default to be kept", c.data.CaddyImage)
+		}
+	})
+}
+
+func TestInstallerFarBehind(t *testing.T) {
+	tests := []struct {
+		current  string
+		expected string
+		want     bool
+	}{
+		{"1.4.0", "1.4.2", false},
+		{"1.2.0", "1.4.0", false},
+		{"v1.1.9", "v1.4.0", true},
+		{"1.9.0", "2.0.0", true},
+		{"2.0.0", "1.9.0", false},
+		{"1.6.0", "1.4.0", false},
+		{"dev", "1.4.0", false},
+		{"1.4.0", "", false},
+	}
+	for _, tt := range tests {
+		if got := InstallerFarBehind(tt.current, tt.expected); got != tt.want {
+			t.Errorf("InstallerFarBehind(%q, %q) = %v, want %v", tt.current, tt.expected, got, tt.want)
+		}
+	}
+}
+
+func TestFetchConfigJSON_WarnsOnOutdatedInstaller(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"app_image":"karloscodes/infinity-metrics-beta:2.0.0","installer_version":"2.0.0"}`))
+	}))
+	defer server.Close()
+	t.Setenv(InstallerVersionEnvVar, "1.3.0")
+
+	var logs bytes.Buffer
+	logger := testLogger(t)
+	logger.SetOutput(&logs)
+	c := NewConfig(logger)
+	if err := c.fetchConfigJSON(server.URL); err != nil {
+		t.Fatalf("fetchConfigJSON() error = %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "is far behind the installer expected by the latest release") {
+		t.Errorf("expected outdated installer warning in logs, got:\n%s", logs.String())
+	}
+}
+
 func TestConfigurationValidation(t *testing.T) {
 	t.Run("ValidateCompleteConfiguration", func(t *testing.T) {
 		c := NewConfig(testLogger(t))
@@ -521,3 +802,502 @@ func TestEnvironmentConfigCollection(t *testing.T) {
 		}
 	})
 }
+
+func TestValidate_RegistryInsecure(t *testing.T) {
+	tests := []struct {
+		name    string
+		hosts   string
+		wantErr bool
+	}{
+		{"host with port", "registry.internal:5000", false},
+		{"several hosts", "registry.internal:5000, 10.0.0.5", false},
+		{"url instead of host", "http://registry.internal", true},
+		{"empty entry", "registry.internal,", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig(testLogger(t))
+			c.data.Domain = "example.com"
+			c.data.InstallDir = "/test/dir"
+			c.data.BackupPath = "/backup"
+			c.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+			c.data.Version = "v1.0.0"
+			c.data.RegistryInsecure = tt.hosts
+
+			err := c.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir := t.TempDir()
+	source := NewConfig(testLogger(t))
+	source.data.Domain = "analytics.example.com"
+	source.data.InstallDir = "/test/dir"
+	source.data.BackupPath = "/backup"
+	source.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+	source.data.LicenseKey = "IM-LICENSE-KEY"
+	source.data.Version = "v1.0.0"
+	source.data.BackupDailyRetentionDays = 14
+
+	redacted := filepath.Join(dir, "redacted.env")
+	if err := source.ExportToFile(redacted, false); err != nil {
+		t.Fatalf("ExportToFile error: %v", err)
+	}
+	content, err := os.ReadFile(redacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), source.data.PrivateKey) || strings.Contains(string(content), source.data.LicenseKey) {
+		t.Errorf("redacted export contains secrets:\n%s", content)
+	}
+	if info, err := os.Stat(redacted); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("export should be private, got %v (%v)", info.Mode().Perm(), err)
+	}
+
+	// Redacted secrets keep the values already on the target
+	target := NewConfig(testLogger(t))
+	target.data.PrivateKey = "existing-private-key-on-the-new-server-0123456789"
+	target.data.LicenseKey = "EXISTING-LICENSE"
+	if err := target.ImportFromFile(redacted); err != nil {
+		t.Fatalf("ImportFromFile error: %v", err)
+	}
+	if target.data.Domain != "analytics.example.com" || target.data.BackupDailyRetentionDays != 14 {
+		t.Errorf("imported data = %+v, want settings from the export", target.data)
+	}
+	if target.data.PrivateKey != "existing-private-key-on-the-new-server-0123456789" || target.data.LicenseKey != "EXISTING-LICENSE" {
+		t.Error("redacted import should keep the existing secrets")
+	}
+
+	withSecrets := filepath.Join(dir, "full.env")
+	if err := source.ExportToFile(withSecrets, true); err != nil {
+		t.Fatalf("ExportToFile error: %v", err)
+	}
+	fresh := NewConfig(testLogger(t))
+	if err := fresh.ImportFromFile(withSecrets); err != nil {
+		t.Fatalf("ImportFromFile error: %v", err)
+	}
+	if fresh.data.PrivateKey != source.data.PrivateKey || fresh.data.LicenseKey != source.data.LicenseKey {
+		t.Error("import with secrets should restore the private key and license key")
+	}
+
+	// An invalid import leaves the current configuration untouched
+	invalid := filepath.Join(dir, "invalid.env")
+	if err := os.WriteFile(invalid, []byte("INFINITY_METRICS_DOMAIN=not a domain\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := fresh.ImportFromFile(invalid); err == nil {
+		t.Error("expected invalid import to fail")
+	}
+	if fresh.data.Domain != "analytics.example.com" {
+		t.Errorf("domain = %q after a failed import, want it unchanged", fresh.data.Domain)
+	}
+}
+
+func TestLoadFromFile_MigratesDeprecatedKeys(t *testing.T) {
+	original := deprecatedKeys
+	deprecatedKeys = map[string]string{
+		"OLD_DOMAIN":      "INFINITY_METRICS_DOMAIN",
+		"OLD_LICENSE_KEY": "INFINITY_METRICS_LICENSE_KEY",
+		"OLD_PRIVATE_KEY": "INFINITY_METRICS_PRIVATE_KEY",
+	}
+	defer func() { deprecatedKeys = original }()
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	content := "# hand-written\nOLD_DOMAIN=old.example.com\nOLD_LICENSE_KEY=IM-KEY\nOLD_PRIVATE_KEY=stale\nINFINITY_METRICS_PRIVATE_KEY=this-is-a-very-long-private-key-that-meets-minimum-requirements\nCUSTOM_SETTING=kept\n"
+	if err := os.WriteFile(envFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfig(testLogger(t))
+	if err := c.LoadFromFile(envFile); err != nil {
+		t.Fatalf("LoadFromFile error: %v", err)
+	}
+	if c.data.Domain != "old.example.com" || c.data.LicenseKey != "IM-KEY" {
+		t.Errorf("deprecated keys not applied: domain %q, license %q", c.data.Domain, c.data.LicenseKey)
+	}
+	if c.data.PrivateKey != "this-is-a-very-long-private-key-that-meets-minimum-requirements" {
+		t.Errorf("current key should win over the deprecated one, got %q", c.data.PrivateKey)
+	}
+	if unchanged, _ := os.ReadFile(envFile); string(unchanged) != content {
+		t.Errorf("LoadFromFile rewrote .env to %q, read-only commands must leave it alone", unchanged)
+	}
+
+	if err := c.MigrateFile(envFile); err != nil {
+		t.Fatalf("MigrateFile error: %v", err)
+	}
+	rewritten, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# hand-written\nINFINITY_METRICS_DOMAIN=old.example.com\nINFINITY_METRICS_LICENSE_KEY=IM-KEY\nINFINITY_METRICS_PRIVATE_KEY=this-is-a-very-long-private-key-that-meets-minimum-requirements\nCUSTOM_SETTING=kept\n"
+	if string(rewritten) != want {
+		t.Errorf("rewritten .env = %q, want %q", rewritten, want)
+	}
+	if info, err := os.Stat(envFile); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("rewrite should keep permissions, got %v (%v)", info.Mode().Perm(), err)
+	}
+	if _, err := os.Stat(envFile + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary file should be renamed over .env")
+	}
+}
+
+func TestCollectFromEnvironment_LogsSummary(t *testing.T) {
+	t.Setenv("DOMAIN", "env.example.com")
+	logger := testLogger(t)
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+
+	c := NewConfig(logger)
+	if err := c.collectFromEnvironment(); err != nil {
+		t.Fatalf("collectFromEnvironment() error = %v", err)
+	}
+	for _, want := range []string{"Domain: env.example.com", "Installation directory: /opt/infinity-metrics", "App image: ", "Caddy image: ", "Backup path: "} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log should contain %q, got:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestCollectFromUser_AssumeYes(t *testing.T) {
+	t.Setenv("NONINTERACTIVE", "")
+	logger := testLogger(t)
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+
+	c := NewConfig(logger)
+	c.SetAssumeYes(true)
+	// Only the domain is provided, there is no answer for the confirmation prompt
+	if err := c.CollectFromUser(bufio.NewReader(strings.NewReader("localhost\n"))); err != nil {
+		t.Fatalf("CollectFromUser() error = %v", err)
+	}
+	if c.data.Domain != "localhost" {
+		t.Errorf("Domain = %q, want localhost", c.data.Domain)
+	}
+	if !strings.Contains(logs.String(), "Domain: localhost") {
+		t.Errorf("summary should be logged, got:\n%s", logs.String())
+	}
+}
+
+func TestReleaseChanges(t *testing.T) {
+	before := ConfigData{AppImage: "app:1.0", CaddyImage: "caddy:2.7", Version: "1.0.0", Domain: "a.example.com"}
+	after := before
+	if changes := ReleaseChanges(before, after); len(changes) != 0 {
+		t.Errorf("identical configs should have no changes, got %+v", changes)
+	}
+
+	after.AppImage = "app:1.1"
+	after.Version = "1.1.0"
+	after.Domain = "b.example.com" // not managed by releases
+	changes := ReleaseChanges(before, after)
+	if len(changes) != 2 {
+		t.Fatalf("changes = %+v, want APP_IMAGE and VERSION", changes)
+	}
+	if changes[0] != (Change{Key: "APP_IMAGE", From: "app:1.0", To: "app:1.1"}) {
+		t.Errorf("changes[0] = %+v", changes[0])
+	}
+	if changes[1].Key != "VERSION" {
+		t.Errorf("changes[1] = %+v, want VERSION", changes[1])
+	}
+}
+
+func TestMaintenanceModeRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	page := dir + "/maintenance.html"
+	if err := os.WriteFile(page, []byte("<h1>Back soon</h1>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpFile := dir + "/test.env"
+	content := "INFINITY_METRICS_DOMAIN=test.example.com\nMAINTENANCE_MODE=true\nMAINTENANCE_PAGE=" + page + "\nINFINITY_METRICS_PRIVATE_KEY=testprivatekey123\n"
+	if err := os.WriteFile(tmpFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfig(testLogger(t))
+	if err := c.LoadFromFile(tmpFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if !c.data.MaintenanceMode || c.data.MaintenancePage != page {
+		t.Errorf("maintenance settings not loaded: %v %q", c.data.MaintenanceMode, c.data.MaintenancePage)
+	}
+
+	c.data.MaintenanceMode = false
+	if err := c.SaveToFile(tmpFile); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	saved, _ := os.ReadFile(tmpFile)
+	if strings.Contains(string(saved), "MAINTENANCE_MODE") {
+		t.Error("SaveToFile() should omit MAINTENANCE_MODE when maintenance is off")
+	}
+	if !strings.Contains(string(saved), "MAINTENANCE_PAGE="+page) {
+		t.Error("SaveToFile() should keep the custom maintenance page")
+	}
+
+	c.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+	c.data.Version = "v1.0.0"
+	c.data.MaintenancePage = dir + "/missing.html"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "maintenance_page") {
+		t.Errorf("Validate() should reject a missing maintenance page, got %v", err)
+	}
+}
+
+func TestRuntimeSettingsRoundTrip(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.env")
+	content := "INFINITY_METRICS_DOMAIN=test.example.com\nINFINITY_METRICS_PRIVATE_KEY=testprivatekey123\n" +
+		"APP_HEALTH_SCHEME=https\nAPP_HEALTH_PATH=/ready\nSTOP_TIMEOUT=90\nHEALTH_CHECK_TIMEOUT=10s\nHEALTH_CHECK_TRIES=20\n"
+	if err := os.WriteFile(tmpFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfig(testLogger(t))
+	if err := c.LoadFromFile(tmpFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	d := c.GetData()
+	if d.AppHealthScheme != "https" || d.AppHealthPath != "/ready" || d.StopTimeout != "90" ||
+		d.HealthCheckTimeout != "10s" || d.HealthCheckTries != 20 {
+		t.Errorf("runtime settings not loaded: %+v", d)
+	}
+	if err := c.SaveToFile(tmpFile); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	saved, _ := os.ReadFile(tmpFile)
+	for _, line := range []string{"APP_HEALTH_SCHEME=https", "APP_HEALTH_PATH=/ready", "STOP_TIMEOUT=90", "HEALTH_CHECK_TIMEOUT=10s", "HEALTH_CHECK_TRIES=20"} {
+		if !strings.Contains(string(saved), line+"\n") {
+			t.Errorf("SaveToFile() should keep %s", line)
+		}
+	}
+
+	c.data.Version = "v1.0.0"
+	c.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+	for field, mutate := range map[string]func(*ConfigData){
+		"app_health_scheme":    func(d *ConfigData) { d.AppHealthScheme = "unix" },
+		"stop_timeout":         func(d *ConfigData) { d.StopTimeout = "forever" },
+		"health_check_timeout": func(d *ConfigData) { d.HealthCheckTimeout = "0" },
+		"health_check_tries":   func(d *ConfigData) { d.HealthCheckTries = -1 },
+	} {
+		invalid := NewConfig(testLogger(t))
+		invalid.data = c.data
+		mutate(&invalid.data)
+		if err := invalid.Validate(); err == nil || !strings.Contains(err.Error(), field) {
+			t.Errorf("Validate() should reject an invalid %s, got %v", field, err)
+		}
+	}
+}
+
+func TestCaddyGlobalOptions(t *testing.T) {
+	tmpFile := t.TempDir() + "/test.env"
+	options := `debug\nservers {\n  protocols h1 h2\n}`
+	content := "INFINITY_METRICS_DOMAIN=test.example.com\nCADDY_GLOBAL_OPTIONS=" + options + "\n"
+	if err := os.WriteFile(tmpFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfig(testLogger(t))
+	if err := c.LoadFromFile(tmpFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	want := []string{"debug", "servers {", "protocols h1 h2", "}"}
+	if got := c.data.CaddyGlobalOptionLines(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("CaddyGlobalOptionLines() = %q, want %q", got, want)
+	}
+
+	if err := c.SaveToFile(tmpFile); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	saved, _ := os.ReadFile(tmpFile)
+	if !strings.Contains(string(saved), "CADDY_GLOBAL_OPTIONS="+options+"\n") {
+		t.Errorf("SaveToFile() should keep the options on one line, got:\n%s", saved)
+	}
+
+	c.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+	c.data.Version = "v1.0.0"
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, invalid := range []string{`servers {\n  protocols h1`, `debug\n}\nexample.com {`} {
+		c.data.CaddyGlobalOptions = invalid
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "caddy_global_options") {
+			t.Errorf("Validate() should reject %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestReleaseSource(t *testing.T) {
+	data := NewConfig(testLogger(t)).GetData()
+	if got := data.ReleaseAPIURL(); got != "https://api.github.com/repos/"+GithubRepo+"/releases/latest" {
+		t.Errorf("default ReleaseAPIURL() = %q", got)
+	}
+
+	data.ReleaseSourceRepo = "acme/metrics-installer"
+	if got := data.ReleaseAPIURL(); got != "https://api.github.com/repos/acme/metrics-installer/releases/latest" {
+		t.Errorf("fork ReleaseAPIURL() = %q", got)
+	}
+	if got := data.ReleaseDownloadURL("1.2.3", "infinity-metrics-installer-v1.2.3-amd64"); got != "https://github.com/acme/metrics-installer/releases/download/v1.2.3/infinity-metrics-installer-v1.2.3-amd64" {
+		t.Errorf("fork ReleaseDownloadURL() = %q", got)
+	}
+
+	data.ReleaseAPIEndpoint = "https://mirror.internal/releases/latest.json"
+	if got := data.ReleaseAPIURL(); got != data.ReleaseAPIEndpoint {
+		t.Errorf("mirror ReleaseAPIURL() = %q, want %q", got, data.ReleaseAPIEndpoint)
+	}
+}
+
+func TestFetchFromServer_UsesReleaseAPIURL(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.Write([]byte(`{"tag_name": "v9.9.9", "assets": []}`))
+	}))
+	defer server.Close()
+
+	c := NewConfig(testLogger(t))
+	c.data.ReleaseAPIEndpoint = server.URL + "/mirror/latest"
+	if err := c.FetchFromServer(""); err != nil {
+		t.Fatalf("FetchFromServer() error = %v", err)
+	}
+	if requested != "/mirror/latest" {
+		t.Errorf("FetchFromServer() requested %q, want the mirror endpoint", requested)
+	}
+	if c.data.Version != "9.9.9" {
+		t.Errorf("Version = %q, want the mirror's release", c.data.Version)
+	}
+}
+
+func TestValidate_ReleaseSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		repo     string
+		endpoint string
+		wantErr  string
+	}{
+		{"fork", "acme/metrics-installer", "", ""},
+		{"mirror", "", "https://mirror.internal/releases/latest.json", ""},
+		{"repo url", "https://github.com/acme/metrics-installer", "", "release_source_repo"},
+		{"endpoint without scheme", "", "mirror.internal/latest.json", "release_api_url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig(testLogger(t))
+			c.data.Domain = "example.com"
+			c.data.PrivateKey = "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+			c.data.Version = "v1.0.0"
+			c.data.ReleaseSourceRepo = tt.repo
+			c.data.ReleaseAPIEndpoint = tt.endpoint
+
+			err := c.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to mention %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfirmDomainChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldDomain   string
+		input       string
+		confirmed   string
+		interactive bool
+		wantErr     string
+	}{
+		{"FirstInstall", "", "", "", false, ""},
+		{"SameDomain", "Analytics.example.com", "", "", false, ""},
+		{"Retyped", "old.example.com", "analytics.example.com\n", "", true, ""},
+		{"Mistyped", "old.example.com", "analytics.exmple.com\n", "", true, "not confirmed"},
+		{"Flag", "old.example.com", "", "analytics.example.com", false, ""},
+		{"FlagMismatch", "old.example.com", "", "old.example.com", false, "does not match"},
+		{"UnattendedWithoutFlag", "old.example.com", "", "", false, "requires --confirm-domain analytics.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.input))
+			err := ConfirmDomainChange(reader, tt.oldDomain, "analytics.example.com", tt.confirmed, tt.interactive)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ConfirmDomainChange() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ConfirmDomainChange() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResetFromFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	privateKey := "this-is-a-very-long-private-key-that-meets-minimum-requirements"
+	mangled := "INFINITY_METRICS_DOMAIN=analytics.example.com\n" +
+		"APP_IMAGE=::not an image::\n" +
+		"REQUIRE_BACKUP=maybe\n" +
+		"INFINITY_METRICS_PRIVATE_KEY=" + privateKey + "\n" +
+		"INFINITY_METRICS_LICENSE_KEY=IM-LICENSE\n" +
+		"DB_STORAGE_PATH=/srv/infinity-metrics\n" +
+		"CONTAINER_USER=1000:1000\n" +
+		"REGISTRY_USERNAME=deploy\n" +
+		"TLS_CERT_PATH=/missing/cert.pem\n" +
+		"TLS_KEY_PATH=/missing/key.pem\n" +
+		"garbage line\n"
+	if err := os.WriteFile(envFile, []byte(mangled), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewConfig(testLogger(t)).LoadFromFile(envFile); err == nil {
+		t.Fatal("expected the mangled .env to fail loading")
+	}
+
+	c := NewConfig(testLogger(t))
+	backupPath, err := c.ResetFromFile(envFile)
+	if err != nil {
+		t.Fatalf("ResetFromFile() error = %v", err)
+	}
+	if backup, err := os.ReadFile(backupPath); err != nil || string(backup) != mangled {
+		t.Errorf("backup %s = %q, %v; want the original .env", backupPath, backup, err)
+	}
+	if info, err := os.Stat(backupPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("backup %s mode = %v, %v; want 0600, it holds the private key", backupPath, info.Mode().Perm(), err)
+	}
+
+	loaded := NewConfig(testLogger(t))
+	if err := loaded.LoadFromFile(envFile); err != nil {
+		t.Fatalf("reset .env does not load: %v", err)
+	}
+	data, defaults := loaded.GetData(), NewConfig(testLogger(t)).GetData()
+	if data.Domain != "analytics.example.com" || data.PrivateKey != privateKey || data.LicenseKey != "IM-LICENSE" {
+		t.Errorf("kept values = %q, %q, %q", data.Domain, data.PrivateKey, data.LicenseKey)
+	}
+	if data.DBStoragePath != "/srv/infinity-metrics" || data.ContainerUser != "1000:1000" || data.RegistryUsername != "deploy" {
+		t.Errorf("DB_STORAGE_PATH = %q, CONTAINER_USER = %q, REGISTRY_USERNAME = %q; want them kept", data.DBStoragePath, data.ContainerUser, data.RegistryUsername)
+	}
+	if data.TLSCertPath != "" || data.TLSKeyPath != "" {
+		t.Errorf("TLS_CERT_PATH = %q, TLS_KEY_PATH = %q; want the missing certificate dropped", data.TLSCertPath, data.TLSKeyPath)
+	}
+	if data.AppImage != defaults.AppImage || data.RequireBackup != defaults.RequireBackup {
+		t.Errorf("APP_IMAGE = %q, REQUIRE_BACKUP = %v; want defaults", data.AppImage, data.RequireBackup)
+	}
+	if c.GetData().Domain != data.Domain || c.GetData().AppImage != data.AppImage {
+		t.Error("ResetFromFile did not update the config")
+	}
+}
+
+func TestResetFromFileRequiresDomain(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("APP_IMAGE=app:1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewConfig(testLogger(t)).ResetFromFile(envFile); err == nil {
+		t.Fatal("expected an error without INFINITY_METRICS_DOMAIN")
+	}
+	if content, _ := os.ReadFile(envFile); string(content) != "APP_IMAGE=app:1\n" {
+		t.Errorf(".env was changed: %q", content)
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Overridden in tests
+var (
+	lookupIP        = net.LookupIP
+	serverIPs       = getCurrentServerIP
+	dnsPollInterval = 15 * time.Second
+)
+
+// WaitForDNS polls until the configured domain resolves to this server or the
+// timeout elapses, so the first certificate request runs after propagation
+// instead of failing and backing off inside Caddy
+func (c *Config) WaitForDNS(timeout time.Duration) error {
+	domain := c.data.Domain
+	server, err := serverIPs()
+	if err != nil {
+		return fmt.Errorf("could not determine server IP addresses: %w", err)
+	}
+
+	c.logger.Info("Waiting up to %s for %s to resolve to %s", timeout, domain, server)
+	deadline := time.Now().Add(timeout)
+	for {
+		ips, err := lookupIP(domain)
+		if matched := matchServerIP(ips, server); matched != "" {
+			c.logger.Success("%s resolves to this server (%s)", domain, matched)
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if err != nil {
+				return fmt.Errorf("%s did not resolve within %s: %w", domain, timeout, err)
+			}
+			return fmt.Errorf("%s still resolves to %s instead of %s after %s", domain, formatIPs(ips), server, timeout)
+		}
+		if err != nil {
+			c.logger.Info("%s does not resolve yet, retrying (%s left)", domain, remaining.Round(time.Second))
+		} else {
+			c.logger.Info("%s resolves to %s, waiting for %s (%s left)", domain, formatIPs(ips), server, remaining.Round(time.Second))
+		}
+		time.Sleep(min(dnsPollInterval, remaining))
+	}
+}
+
+// matchServerIP returns the first of ips that is one of the comma-separated
+// server IPs, or "" if none is
+func matchServerIP(ips []net.IP, server string) string {
+	for _, ip := range ips {
+		for _, serverIP := range strings.Split(server, ",") {
+			if ip.String() == strings.TrimSpace(serverIP) {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
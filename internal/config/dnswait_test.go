@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"infinity-metrics-installer/internal/logging"
+)
+
+func TestWaitForDNS(t *testing.T) {
+	originalLookup, originalServer, originalInterval := lookupIP, serverIPs, dnsPollInterval
+	defer func() { lookupIP, serverIPs, dnsPollInterval = originalLookup, originalServer, originalInterval }()
+	dnsPollInterval = time.Millisecond
+	serverIPs = func() (string, error) { return "203.0.113.7", nil }
+
+	cfg := NewConfig(logging.NewLogger(logging.Config{Level: "error"}))
+	data := cfg.GetData()
+	data.Domain = "analytics.example.com"
+	cfg.SetData(data)
+
+	t.Run("ResolvesAfterPropagation", func(t *testing.T) {
+		lookups := 0
+		lookupIP = func(string) ([]net.IP, error) {
+			lookups++
+			switch lookups {
+			case 1:
+				return nil, fmt.Errorf("no such host")
+			case 2:
+				return []net.IP{net.ParseIP("198.51.100.1")}, nil
+			default:
+				return []net.IP{net.ParseIP("203.0.113.7")}, nil
+			}
+		}
+		if err := cfg.WaitForDNS(time.Minute); err != nil {
+			t.Fatalf("expected DNS to resolve, got %v", err)
+		}
+		if lookups != 3 {
+			t.Errorf("lookups = %d, want 3", lookups)
+		}
+	})
+
+	t.Run("TimesOut", func(t *testing.T) {
+		lookupIP = func(string) ([]net.IP, error) {
+			return []net.IP{net.ParseIP("198.51.100.1")}, nil
+		}
+		err := cfg.WaitForDNS(20 * time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "198.51.100.1") {
+			t.Fatalf("expected timeout naming the current IP, got %v", err)
+		}
+	})
+}
+
+func TestMatchServerIP(t *testing.T) {
+	ips := []net.IP{net.ParseIP("198.51.100.1"), net.ParseIP("203.0.113.7")}
+	if got := matchServerIP(ips, "10.0.0.2, 203.0.113.7"); got != "203.0.113.7" {
+		t.Errorf("matchServerIP = %q, want 203.0.113.7", got)
+	}
+	if got := matchServerIP(ips, "10.0.0.2"); got != "" {
+		t.Errorf("matchServerIP = %q, want no match", got)
+	}
+}
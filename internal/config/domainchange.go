@@ -0,0 +1,40 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// ConfirmDomainChange guards a change of the installation's domain, which
+// requests new certificates and can lock users out if mistyped. It shows both
+// domains and requires the new one to be typed again, or passed as confirmed
+// (--confirm-domain) when no one can answer a prompt.
+func ConfirmDomainChange(reader *bufio.Reader, oldDomain, newDomain, confirmed string, interactive bool) error {
+	if oldDomain == "" || strings.EqualFold(oldDomain, newDomain) {
+		return nil
+	}
+
+	fmt.Printf("\n⚠️  This changes the domain from %s to %s\n", oldDomain, newDomain)
+	fmt.Println("   New certificates will be requested and the dashboard will only be reachable at the new domain.")
+
+	if confirmed != "" {
+		if !strings.EqualFold(strings.TrimSpace(confirmed), newDomain) {
+			return fmt.Errorf("--confirm-domain %s does not match the new domain %s", confirmed, newDomain)
+		}
+		return nil
+	}
+	if !interactive {
+		return fmt.Errorf("changing the domain from %s to %s requires --confirm-domain %s", oldDomain, newDomain, newDomain)
+	}
+
+	fmt.Printf("Type the new domain (%s) to confirm: ", newDomain)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(answer), newDomain) {
+		return fmt.Errorf("domain change not confirmed, %s is unchanged", oldDomain)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ExportToFile writes the configuration to a portable file in .env format.
+// Unless includeSecrets is set the private key, license key and registry
+// password are left out, and an import keeps the values already on the
+// target server.
+func (c *Config) ExportToFile(filename string, includeSecrets bool) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	fmt.Fprintf(file, "# Infinity Metrics configuration exported %s\n", time.Now().UTC().Format(time.RFC3339))
+	if os.Getenv(InstallerVersionEnvVar) != "" {
+		fmt.Fprintf(file, "# Installer version: %s\n", os.Getenv(InstallerVersionEnvVar))
+	}
+	if includeSecrets {
+		fmt.Fprintf(file, "# Contains secrets: keep this file private\n")
+	} else {
+		fmt.Fprintf(file, "# Secrets redacted: the private key, license key and registry password are not included\n")
+	}
+	c.writeEnv(file, !includeSecrets)
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	c.logger.Info("Configuration exported to %s", filename)
+	return nil
+}
+
+// RedactedSettings returns the .env settings as a map, with the private key,
+// license key and registry password masked, for reports that may be shared
+func (c *Config) RedactedSettings() map[string]string {
+	var buf bytes.Buffer
+	c.writeEnv(&buf, true)
+
+	settings := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			settings[key] = value
+		}
+	}
+	if c.data.PrivateKey != "" {
+		settings["INFINITY_METRICS_PRIVATE_KEY"] = redactedValue
+	}
+	if c.data.LicenseKey != "" {
+		settings["INFINITY_METRICS_LICENSE_KEY"] = redactedValue
+	}
+	if c.data.RegistryPassword != "" {
+		settings["REGISTRY_PASSWORD"] = redactedValue
+	}
+	return settings
+}
+
+// redactedValue replaces secrets in RedactedSettings
+const redactedValue = "********"
+
+// ImportFromFile applies an exported configuration on top of the current one
+// and validates the result. Settings missing from the file, such as redacted
+// secrets, keep their current values.
+func (c *Config) ImportFromFile(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	lines, migrations := migrateEnvKeys(strings.Split(string(content), "\n"))
+	c.logMigrations(filename, migrations)
+
+	imported := *c
+	if err := imported.loadEnv(strings.NewReader(strings.Join(lines, "\n"))); err != nil {
+		return err
+	}
+	if imported.data.PrivateKey == "" {
+		pk, err := generatePrivateKey()
+		if err != nil {
+			return err
+		}
+		imported.data.PrivateKey = pk
+		c.logger.Info("Generated new INFINITY_METRICS_PRIVATE_KEY, the export did not include one")
+	}
+	if err := imported.Validate(); err != nil {
+		return fmt.Errorf("imported configuration is invalid: %w", err)
+	}
+
+	c.data = imported.data
+	c.logger.Success("Configuration imported from %s", filename)
+	return nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"infinity-metrics-installer/internal/utils"
+)
+
+// deprecatedKeys maps .env keys that are no longer read to their current
+// name. No key has been renamed yet; add the old name here when one is, so
+// existing .env files keep working.
+var deprecatedKeys = map[string]string{}
+
+// KeyMigration records a deprecated key found in a .env file
+type KeyMigration struct {
+	From    string
+	To      string
+	Dropped bool // the current key was also set, so the deprecated line was removed
+}
+
+// migrateEnvKeys renames deprecated keys in .env lines, keeping comments,
+// ordering and unknown keys. When both names are present the current one wins.
+func migrateEnvKeys(lines []string) ([]string, []KeyMigration) {
+	present := make(map[string]bool)
+	for _, line := range lines {
+		if key, _, ok := envLineKey(line); ok {
+			present[key] = true
+		}
+	}
+
+	var migrations []KeyMigration
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		key, value, ok := envLineKey(line)
+		current, deprecated := deprecatedKeys[key]
+		if !ok || !deprecated {
+			out = append(out, line)
+			continue
+		}
+		if present[current] {
+			migrations = append(migrations, KeyMigration{From: key, To: current, Dropped: true})
+			continue
+		}
+		present[current] = true
+		migrations = append(migrations, KeyMigration{From: key, To: current})
+		out = append(out, current+"="+value)
+	}
+	return out, migrations
+}
+
+// envLineKey returns the key and value of a KEY=value line
+func envLineKey(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+// logMigrations reports each migrated key
+func (c *Config) logMigrations(filename string, migrations []KeyMigration) {
+	for _, m := range migrations {
+		if m.Dropped {
+			c.logger.Warn("%s: ignoring deprecated %s, %s is already set", filename, m.From, m.To)
+		} else {
+			c.logger.Warn("%s: deprecated %s read as %s", filename, m.From, m.To)
+		}
+	}
+}
+
+// MigrateFile rewrites the deprecated keys of filename under their current
+// names, atomically and keeping its permissions. LoadFromFile only renames
+// them in memory, so commands that change the installation call this first.
+func (c *Config) MigrateFile(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	lines, migrations := migrateEnvKeys(strings.Split(string(content), "\n"))
+	if len(migrations) == 0 {
+		return nil
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	if err := utils.SafeFileWrite(c.logger, filename, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to rewrite migrated file: %w", err)
+	}
+	c.logger.Info("Migrated %d deprecated key(s) in %s", len(migrations), filename)
+	return nil
+}
@@ -0,0 +1,28 @@
+package config
+
+// Change is a release-managed setting whose value differs between two configurations
+type Change struct {
+	Key  string
+	From string
+	To   string
+}
+
+// ReleaseChanges lists the settings FetchFromServer manages that differ
+// between before and after
+func ReleaseChanges(before, after ConfigData) []Change {
+	var changes []Change
+	for _, setting := range []struct {
+		key      string
+		from, to string
+	}{
+		{"APP_IMAGE", before.AppImage, after.AppImage},
+		{"CADDY_IMAGE", before.CaddyImage, after.CaddyImage},
+		{"VERSION", before.Version, after.Version},
+		{"INSTALLER_URL", before.InstallerURL, after.InstallerURL},
+	} {
+		if setting.from != setting.to {
+			changes = append(changes, Change{Key: setting.key, From: setting.from, To: setting.to})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,28 @@
+package config
+
+import "fmt"
+
+// ReleaseRepo returns the GitHub owner/repo releases are fetched from,
+// RELEASE_SOURCE_REPO for forks or GithubRepo by default
+func (d ConfigData) ReleaseRepo() string {
+	if d.ReleaseSourceRepo != "" {
+		return d.ReleaseSourceRepo
+	}
+	return GithubRepo
+}
+
+// ReleaseAPIURL returns the endpoint describing the latest release.
+// RELEASE_API_URL points it at a mirror serving the GitHub release JSON, whose
+// asset download URLs are then used as is.
+func (d ConfigData) ReleaseAPIURL() string {
+	if d.ReleaseAPIEndpoint != "" {
+		return d.ReleaseAPIEndpoint
+	}
+	return fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", d.ReleaseRepo())
+}
+
+// ReleaseDownloadURL returns the GitHub download URL of a release asset, used
+// when the release listing does not provide one
+func (d ConfigData) ReleaseDownloadURL(version, asset string) string {
+	return fmt.Sprintf("https://github.com/%s/releases/download/v%s/%s", d.ReleaseRepo(), version, asset)
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"infinity-metrics-installer/internal/errors"
+	"infinity-metrics-installer/internal/utils"
+	"infinity-metrics-installer/internal/validation"
+)
+
+// resetKeptKeys are the .env keys a reset carries over: the values that
+// cannot be recreated from defaults, and those pointing at the existing data,
+// certificates and registry, which the next apply would otherwise replace
+// with an empty default storage directory or ACME
+var resetKeptKeys = []string{
+	"INFINITY_METRICS_DOMAIN",
+	"INFINITY_METRICS_PRIVATE_KEY",
+	"INFINITY_METRICS_LICENSE_KEY",
+	"INSTALL_DIR",
+	"BACKUP_PATH",
+	"DB_STORAGE_PATH",
+	"CONTAINER_USER",
+	"TLS_CERT_PATH",
+	"TLS_KEY_PATH",
+	"TLS_INTERNAL",
+	"REGISTRY_INSECURE",
+	"REGISTRY_USERNAME",
+	"REGISTRY_PASSWORD",
+}
+
+// ResetFromFile replaces the configuration with the defaults plus the
+// resetKeptKeys found in filename, then backs up filename, readable by root
+// only, and writes the clean configuration to it. The other lines are only
+// scanned for those keys, so a file that LoadFromFile rejects can still be
+// reset. It returns the backup path.
+func (c *Config) ResetFromFile(filename string) (string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	lines, _ := migrateEnvKeys(strings.Split(string(content), "\n"))
+
+	kept := make(map[string]string)
+	for _, line := range lines {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		for _, keep := range resetKeptKeys {
+			if key == keep && value != "" {
+				kept[key] = value
+			}
+		}
+	}
+	if kept["INFINITY_METRICS_DOMAIN"] == "" {
+		return "", fmt.Errorf("no INFINITY_METRICS_DOMAIN in %s, set it before resetting the configuration", filename)
+	}
+	if kept["INFINITY_METRICS_PRIVATE_KEY"] == "" {
+		c.logger.Warn("No INFINITY_METRICS_PRIVATE_KEY in %s, a new one will be generated", filename)
+	}
+
+	reset := NewConfig(c.logger)
+	for _, key := range resetKeptKeys {
+		if value, ok := kept[key]; ok {
+			if err := reset.loadEnv(strings.NewReader(key + "=" + value)); err != nil {
+				return "", err
+			}
+			c.logger.Info("Keeping %s", key)
+		}
+	}
+	if reset.data.ContainerUser != "" {
+		if err := validation.ValidateContainerUser(reset.data.ContainerUser); err != nil {
+			c.logger.Warn("Dropping invalid CONTAINER_USER %q: %v", reset.data.ContainerUser, err)
+			reset.data.ContainerUser = ""
+		}
+	}
+	if reset.data.TLSCertPath != "" || reset.data.TLSKeyPath != "" {
+		if err := validation.ValidateCertificatePair(reset.data.TLSCertPath, reset.data.TLSKeyPath); err != nil {
+			c.logger.Warn("Dropping TLS_CERT_PATH and TLS_KEY_PATH, Caddy will request a certificate from ACME: %v", err)
+			reset.data.TLSCertPath, reset.data.TLSKeyPath = "", ""
+		}
+	}
+	if reset.data.PrivateKey == "" {
+		pk, err := generatePrivateKey()
+		if err != nil {
+			return "", err
+		}
+		reset.data.PrivateKey = pk
+	}
+	if err := validation.ValidateDomain(reset.data.Domain); err != nil {
+		return "", errors.NewConfigError("domain", reset.data.Domain, err.Error())
+	}
+
+	backupPath, err := utils.BackupFile(c.logger, filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", filename, err)
+	}
+	if err := reset.SaveToFile(filename); err != nil {
+		return backupPath, err
+	}
+	c.data = reset.data
+	return backupPath, nil
+}
@@ -0,0 +1,21 @@
+package config
+
+import "path/filepath"
+
+// MainDBFileName is the app's SQLite database inside the storage directory
+const MainDBFileName = "infinity-metrics-production.db"
+
+// StorageDir returns the host directory mounted at /app/storage in the app
+// container: DB_STORAGE_PATH when set, so the database can live on separate
+// storage, or the storage directory of the install dir by default
+func (d ConfigData) StorageDir() string {
+	if d.DBStoragePath != "" {
+		return d.DBStoragePath
+	}
+	return filepath.Join(d.InstallDir, "storage")
+}
+
+// MainDBPath returns the host path of the app's main database
+func (d ConfigData) MainDBPath() string {
+	return filepath.Join(d.StorageDir(), MainDBFileName)
+}
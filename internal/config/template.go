@@ -0,0 +1,141 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"infinity-metrics-installer/internal/logging"
+)
+
+// EnvVar documents one .env setting for the template
+type EnvVar struct {
+	Key         string
+	Default     string // used when NewConfig does not write the key
+	Description string
+	Required    bool
+}
+
+// EnvVars lists every setting loadEnv reads, in the order writeEnv writes them
+var EnvVars = []EnvVar{
+	{Key: "INFINITY_METRICS_DOMAIN", Description: "Domain the dashboard is served on, its A/AAAA records must point to this server", Required: true},
+	{Key: "APP_IMAGE", Description: "App image, replaced by the image of the latest release on install and update"},
+	{Key: "CADDY_IMAGE", Description: "Caddy image, replaced by the image of the latest release on install and update"},
+	{Key: "INSTALL_DIR", Description: "Directory holding .env, the Caddyfile and the app's storage"},
+	{Key: "BACKUP_PATH", Description: "Directory the SQLite backups are written to"},
+	{Key: "VERSION", Description: "Installer version, set from the latest release"},
+	{Key: "INSTALLER_URL", Description: "Release page the installer binary is downloaded from"},
+	{Key: "INFINITY_METRICS_PRIVATE_KEY", Description: "Key signing sessions, generated on install when empty; keep it when moving servers"},
+	{Key: "INFINITY_METRICS_USER", Description: "Admin user email, filled in from the database"},
+	{Key: "INFINITY_METRICS_LICENSE_KEY", Description: "License key for the application"},
+	{Key: "REQUIRE_BACKUP", Default: "true", Description: "Abort an update when the pre-update backup fails"},
+	{Key: "CONTAINER_USER", Description: "uid:gid the app container runs as"},
+	{Key: "CADDYFILE_TEMPLATE", Description: "Path to a custom Caddyfile template"},
+	{Key: "APP_ENV_FILE", Description: "Env file passed to the app container with --env-file"},
+	{Key: "TLS_CERT_PATH", Description: "Certificate Caddy serves instead of one from ACME, set with TLS_KEY_PATH"},
+	{Key: "TLS_KEY_PATH", Description: "Private key for TLS_CERT_PATH"},
+	{Key: "TLS_INTERNAL", Default: "false", Description: "Serve a certificate from Caddy's internal CA instead of Let's Encrypt, for domains only reachable on a private network"},
+	{Key: "MIGRATION_COMMAND", Description: "Command run in the new app container before cutover on update"},
+	{Key: "APP_CPU_LIMIT", Description: "--cpus limit for the app container, e.g. 1.5"},
+	{Key: "CADDY_CPU_LIMIT", Description: "--cpus limit for the Caddy container"},
+	{Key: "LISTEN_STACK", Description: "Address family Caddy publishes ports 80 and 443 on: ipv4, ipv6 or dual; empty leaves it to Docker"},
+	{Key: "SELINUX_RELABEL", Default: "auto", Description: "Relabel bind mounts for SELinux: true, false or auto to relabel when SELinux is enforcing"},
+	{Key: "REGISTRY_INSECURE", Description: "Comma-separated registry hosts reached over plain HTTP"},
+	{Key: "REGISTRY_USERNAME", Description: "Registry account used to log in when anonymous pulls hit a rate limit"},
+	{Key: "REGISTRY_PASSWORD", Description: "Password or token for REGISTRY_USERNAME"},
+	{Key: "RELEASE_SOURCE_REPO", Description: "owner/repo on GitHub releases are read from, for forks and mirrors"},
+	{Key: "RELEASE_API_URL", Description: "Full URL of an endpoint serving the latest release JSON, for mirrors"},
+	{Key: "CADDY_GLOBAL_OPTIONS", Description: "Raw Caddy global option directives, separated by a literal \\n"},
+	{Key: "DB_STORAGE_PATH", Description: "Absolute host directory mounted as the app's storage instead of INSTALL_DIR/storage"},
+	{Key: "DOWNLOAD_RATE_LIMIT", Description: "Cap on the installer binary download, e.g. 5MB/s"},
+	{Key: "POST_INSTALL_HOOK", Description: "Shell command run after an install"},
+	{Key: "PRE_UPDATE_HOOK", Description: "Shell command run before an update"},
+	{Key: "POST_UPDATE_HOOK", Description: "Shell command run after an update"},
+	{Key: "HOOKS_FATAL", Default: "false", Description: "Abort when a hook fails instead of warning"},
+	{Key: "BACKUP_BEFORE_RELOAD", Default: "false", Description: "Back up the database before every reload"},
+	{Key: "OFFLINE_MODE", Default: "false", Description: "Deploy images side-loaded with docker load without contacting a registry or the release API"},
+	{Key: "KEEP_IMAGE_VERSIONS", Default: "0", Description: "App image versions kept locally for rollback, 0 keeps the built-in default of 2"},
+	{Key: "MAINTENANCE_MODE", Default: "false", Description: "Serve a 503 maintenance page instead of proxying to the app"},
+	{Key: "MAINTENANCE_PAGE", Description: "HTML file served in maintenance mode instead of the built-in page"},
+	{Key: "BACKUP_DAILY_RETENTION_DAYS", Default: "0", Description: "Days daily backups are kept, 0 keeps the built-in default of 7"},
+	{Key: "BACKUP_WEEKLY_RETENTION_DAYS", Default: "0", Description: "Days weekly backups are kept, 0 keeps the built-in default of 14"},
+	{Key: "BACKUP_MONTHLY_RETENTION_DAYS", Default: "0", Description: "Days monthly backups are kept, 0 keeps the built-in default of 90"},
+	{Key: "APP_HEALTH_SCHEME", Default: "http", Description: "Scheme of the app health endpoint probed inside the container: http or https"},
+	{Key: "APP_HEALTH_PATH", Default: "/_health", Description: "Path of the app health endpoint probed inside the container"},
+	{Key: "STOP_TIMEOUT", Default: "30s", Description: "Time a stopping container gets to shut down before Docker kills it, e.g. 1m or 90"},
+	{Key: "HEALTH_CHECK_TIMEOUT", Default: "5s", Description: "Upper bound for one app health probe, e.g. 10s or 10"},
+	{Key: "HEALTH_CHECK_TRIES", Default: "5", Description: "Health probes made before a new container is declared unhealthy and rolled back"},
+}
+
+// ProcessEnvVars lists the settings read from the environment of each run
+// rather than from .env. TestProcessEnvVarsCoverSource keeps it in sync with
+// the variables the code reads.
+var ProcessEnvVars = []EnvVar{
+	{Key: "NONINTERACTIVE", Description: "Set to 1 to install without prompts, with the domain from DOMAIN"},
+	{Key: "DOMAIN", Description: "Domain of a non-interactive install"},
+	{Key: "OFFLINE_MODE", Default: "false", Description: "Install from side-loaded images; read before .env exists, which later runs use"},
+	{Key: "TLS_INTERNAL", Default: "false", Description: "Install with a certificate from Caddy's internal CA; read before .env exists, which later runs use"},
+	{Key: "MIN_MEMORY_MB", Default: "896", Description: "Memory an install needs in MiB, 0 skips the check"},
+	{Key: "SKIP_PORT_CHECKING", Description: "Set to 1 to skip checking that ports 80 and 443 are free"},
+	{Key: "CONNECTIVITY_TIMEOUT", Default: "10s", Description: "Timeout of each connectivity check before an install"},
+	{Key: "SKIP_CONNECTIVITY_CHECK", Description: "Set to 1 to skip the connectivity checks, when only Docker reaches the internet through its own proxy"},
+	{Key: "SKIP_SQLITE_INSTALL", Description: "Set to 1 to skip installing the sqlite3 package"},
+	{Key: "HTTP_TIMEOUT", Default: "60s", Description: "Timeout of requests to the release API, registries and downloads"},
+	{Key: "FORCE_IPV4", Description: "Set to 1 to make outbound requests over IPv4 only"},
+	{Key: "PULL_MAX_RETRIES", Default: "3", Description: "Attempts per image pull"},
+	{Key: "PULL_BACKOFF_MAX", Default: "30s", Description: "Longest wait between image pull attempts"},
+	{Key: "LOG_LEVEL", Default: "info", Description: "Log level: debug, info, warn or error"},
+	{Key: "LOG_DIR", Default: "/opt/infinity-metrics/logs", Description: "Directory the installer log file is written to"},
+	{Key: "LOG_TIME_FORMAT", Default: "15:04:05", Description: "Timestamp layout of log lines, a Go layout or RFC3339, RFC3339Nano or DateTime"},
+	{Key: "LOG_TIMEZONE", Description: "IANA timezone of log timestamps, e.g. UTC; empty uses local time"},
+	{Key: "VERBOSE", Default: "false", Description: "Set to true for verbose output"},
+	{Key: "QUIET", Default: "false", Description: "Set to true to print only warnings and errors"},
+	{Key: "RUN_RESULT_FILE", Description: "Set to 0 to not write last-run.json after each command"},
+	{Key: "TELEMETRY_ENABLED", Description: "Set to 1 to send anonymized install and update reports to TELEMETRY_ENDPOINT"},
+	{Key: "TELEMETRY_ENDPOINT", Description: "URL telemetry reports are posted to"},
+	{Key: "CRASH_REPORTS_ENABLED", Description: "Set to 1 to send redacted crash reports to CRASH_REPORT_ENDPOINT"},
+	{Key: "CRASH_REPORT_ENDPOINT", Description: "URL crash reports are posted to"},
+}
+
+// envOverrides are the .env settings the environment overrides for one run
+var envOverrides = []string{"STOP_TIMEOUT", "HEALTH_CHECK_TIMEOUT", "HEALTH_CHECK_TRIES", "APP_HEALTH_SCHEME", "APP_HEALTH_PATH"}
+
+// WriteEnvTemplate writes a commented .env with every setting and its
+// default, to fill in for non-interactive installs. Settings other than the
+// required domain are commented out, so uncommenting one overrides its default.
+// The process-only variables follow, for reference.
+func WriteEnvTemplate(w io.Writer) {
+	defaults := make(map[string]string)
+	var buf bytes.Buffer
+	NewConfig(logging.NewLogger(logging.Config{Level: "error", Quiet: true})).writeEnv(&buf, false)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			defaults[key] = value
+		}
+	}
+
+	fmt.Fprintf(w, "# Infinity Metrics configuration template\n")
+	fmt.Fprintf(w, "# Fill in and install with 'infinity-metrics install --env-file FILE',\n")
+	fmt.Fprintf(w, "# or apply to an existing installation with 'infinity-metrics config import FILE'\n")
+	for _, v := range EnvVars {
+		value, ok := defaults[v.Key]
+		if !ok {
+			value = v.Default
+		}
+		fmt.Fprintf(w, "\n# %s\n", v.Description)
+		if v.Required {
+			fmt.Fprintf(w, "# Required\n%s=%s\n", v.Key, value)
+			continue
+		}
+		fmt.Fprintf(w, "# %s=%s\n", v.Key, value)
+	}
+
+	fmt.Fprintf(w, "\n# Environment variables\n")
+	fmt.Fprintf(w, "# Read from the environment of each run, not from this file, e.g.\n")
+	fmt.Fprintf(w, "# 'MIN_MEMORY_MB=768 infinity-metrics install'. The environment also\n")
+	fmt.Fprintf(w, "# overrides %s from this file for one run.\n", strings.Join(envOverrides, ", "))
+	for _, v := range ProcessEnvVars {
+		fmt.Fprintf(w, "\n# %s\n# export %s=%s\n", v.Description, v.Key, v.Default)
+	}
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// writtenKeys returns the keys writeEnv writes for data
+func writtenKeys(data ConfigData) map[string]bool {
+	c := &Config{data: data}
+	var buf bytes.Buffer
+	c.writeEnv(&buf, false)
+	keys := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if key, _, ok := strings.Cut(line, "="); ok {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func TestEnvVarsCoverWrittenKeys(t *testing.T) {
+	// Every field set, then every bool cleared, so keys written only for a
+	// zero value such as REQUIRE_BACKUP=false show up too
+	var set, cleared ConfigData
+	v := reflect.ValueOf(&set).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch field := v.Field(i); field.Kind() {
+		case reflect.String:
+			field.SetString("x")
+		case reflect.Int:
+			field.SetInt(1)
+		case reflect.Bool:
+			field.SetBool(true)
+		}
+	}
+	cleared = set
+	cv := reflect.ValueOf(&cleared).Elem()
+	for i := 0; i < cv.NumField(); i++ {
+		if cv.Field(i).Kind() == reflect.Bool {
+			cv.Field(i).SetBool(false)
+		}
+	}
+
+	written := writtenKeys(set)
+	for key := range writtenKeys(cleared) {
+		written[key] = true
+	}
+	documented := make(map[string]bool)
+	for _, envVar := range EnvVars {
+		if documented[envVar.Key] {
+			t.Errorf("%s is listed twice in EnvVars", envVar.Key)
+		}
+		documented[envVar.Key] = true
+		if envVar.Description == "" {
+			t.Errorf("%s has no description", envVar.Key)
+		}
+		if !written[envVar.Key] {
+			t.Errorf("%s is in EnvVars but never written to .env", envVar.Key)
+		}
+	}
+	for key := range written {
+		if !documented[key] {
+			t.Errorf("%s is written to .env but missing from EnvVars", key)
+		}
+	}
+}
+
+// internalEnvVars are read by the code but not meant to be set by users:
+// development and CI switches, and values the installer sets for itself
+var internalEnvVars = map[string]bool{
+	"ENV":                      true,
+	"HOME":                     true,
+	"DEBUG":                    true,
+	"GITHUB_ACTIONS":           true,
+	"GITHUB_RUN_NUMBER":        true,
+	"SSH_KEY_PATH":             true,
+	"KEEP_VM":                  true,
+	"INFINITY_METRICS_TRIGGER": true,
+	"INFINITY_METRICS_VERSION": true,
+}
+
+func TestProcessEnvVarsCoverSource(t *testing.T) {
+	getenv := regexp.MustCompile(`os\.(?:Getenv|LookupEnv)\("([A-Z0-9_]+)"\)`)
+	constant := regexp.MustCompile(`[A-Za-z]*EnvVar\s*=\s*"([A-Z0-9_]+)"`)
+
+	read := make(map[string]string)
+	for _, root := range []string{"../../cmd", "../../internal"} {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			for _, re := range []*regexp.Regexp{getenv, constant} {
+				for _, match := range re.FindAllStringSubmatch(string(content), -1) {
+					read[match[1]] = path
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	documented := make(map[string]bool)
+	for _, envVar := range EnvVars {
+		documented[envVar.Key] = true
+	}
+	for _, envVar := range ProcessEnvVars {
+		if envVar.Description == "" {
+			t.Errorf("%s has no description", envVar.Key)
+		}
+		if _, ok := read[envVar.Key]; !ok {
+			t.Errorf("%s is in ProcessEnvVars but never read", envVar.Key)
+		}
+		documented[envVar.Key] = true
+	}
+	for _, key := range envOverrides {
+		if !documented[key] {
+			t.Errorf("%s overrides a .env setting missing from EnvVars", key)
+		}
+	}
+	for key, path := range read {
+		if !documented[key] && !internalEnvVars[key] {
+			t.Errorf("%s is read in %s but missing from EnvVars and ProcessEnvVars", key, path)
+		}
+	}
+}
+
+func TestWriteEnvTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	WriteEnvTemplate(&buf)
+	template := buf.String()
+
+	for _, want := range []string{
+		"\nINFINITY_METRICS_DOMAIN=\n",
+		"\n# APP_IMAGE=karloscodes/infinity-metrics-beta:latest\n",
+		"\n# REQUIRE_BACKUP=true\n",
+		"\n# SELINUX_RELABEL=auto\n",
+		"\n# BACKUP_MONTHLY_RETENTION_DAYS=0\n",
+		"\n# export MIN_MEMORY_MB=896\n",
+	} {
+		if !strings.Contains(template, want) {
+			t.Errorf("template missing %q", want)
+		}
+	}
+
+	// Filling in the domain gives a file that loads to the defaults
+	envFile := filepath.Join(t.TempDir(), ".env")
+	filled := strings.Replace(template, "\nINFINITY_METRICS_DOMAIN=\n", "\nINFINITY_METRICS_DOMAIN=analytics.example.com\n", 1)
+	if err := os.WriteFile(envFile, []byte(filled), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfig(testLogger(t))
+	if err := c.LoadFromFile(envFile); err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	got := c.GetData()
+	if got.PrivateKey == "" {
+		t.Error("expected a private key to be generated")
+	}
+	want := NewConfig(testLogger(t)).GetData()
+	want.Domain = "analytics.example.com"
+	want.PrivateKey, want.DNSWarnings = got.PrivateKey, got.DNSWarnings
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+}
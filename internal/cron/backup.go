@@ -0,0 +1,118 @@
+package cron
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultBackupCronFile is the path to the backup cron job file
+	DefaultBackupCronFile = "/etc/cron.d/infinity-metrics-backup"
+	// DefaultBackupSchedule runs the backup daily at 2:30 AM, ahead of the update
+	DefaultBackupSchedule = "30 2 * * *"
+	// BackupTimerUnit is the systemd timer that replaces the backup cron job
+	BackupTimerUnit = backupUnit + ".timer"
+	// BackupServiceUnit is the name of the systemd service started by BackupTimerUnit
+	BackupServiceUnit = backupUnit + ".service"
+
+	// backupUnit is the name shared by the backup service and timer units
+	backupUnit = "infinity-metrics-backup"
+)
+
+// SetupBackupCronJob schedules `infinity-metrics backup` independently of
+// updates, so installations pinned to a version still get regular backups.
+// An empty schedule uses DefaultBackupSchedule.
+func (m *Manager) SetupBackupCronJob(schedule string) error {
+	if schedule == "" {
+		schedule = DefaultBackupSchedule
+	}
+	if len(strings.Fields(schedule)) != 5 {
+		return fmt.Errorf("invalid backup schedule %q: expected five cron fields, e.g. %q", schedule, DefaultBackupSchedule)
+	}
+
+	if err := os.MkdirAll(filepath.Join(m.installDir, "logs"), 0755); err != nil {
+		m.logger.Warn("Failed to create logs directory: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Dir(m.backupCronFile)); err != nil {
+		if _, err := os.Stat(systemdBootedDir); err == nil {
+			m.logger.Info("%s not found, using a systemd timer instead of cron", filepath.Dir(m.backupCronFile))
+			return m.setupBackupTimer(schedule)
+		}
+		return fmt.Errorf("cannot schedule backups: %s does not exist and systemd is not running. "+
+			"Install cron and re-run the command, or schedule '%s backup' yourself", filepath.Dir(m.backupCronFile), m.binaryPath)
+	}
+
+	cronContent := "# Infinity Metrics scheduled backups\n"
+	cronContent += "SHELL=/bin/bash\n"
+	cronContent += "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n"
+	cronContent += fmt.Sprintf("INSTALL_DIR=%s\n", m.installDir)
+	cronContent += fmt.Sprintf("%s=%s\n", TriggerEnvVar, TriggerCron)
+	cronContent += fmt.Sprintf("%s root cd %s && %s backup > %s/logs/backup.log 2>&1\n",
+		schedule,
+		m.installDir,
+		m.binaryPath,
+		m.installDir)
+
+	if err := os.WriteFile(m.backupCronFile, []byte(cronContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write cron file %s: %w", m.backupCronFile, err)
+	}
+	m.logger.Success("Backup cron job scheduled (%s)", schedule)
+	return nil
+}
+
+// setupBackupTimer installs a service and timer running the backup on
+// schedule, which must be a daily "minute hour * * *" cron schedule
+func (m *Manager) setupBackupTimer(schedule string) error {
+	calendar, err := dailyCalendar(schedule)
+	if err != nil {
+		return err
+	}
+
+	execStart := fmt.Sprintf("/bin/sh -c '%s backup > %s/logs/backup.log 2>&1'", m.binaryPath, m.installDir)
+	if err := m.installTimer(backupUnit, "Infinity Metrics scheduled backups", execStart, calendar); err != nil {
+		return err
+	}
+	m.logger.Success("Backup timer scheduled (%s)", calendar)
+	return nil
+}
+
+// dailyCalendar converts a "minute hour * * *" cron schedule into a systemd
+// OnCalendar expression. Other schedules have no simple equivalent.
+func dailyCalendar(schedule string) (string, error) {
+	fields := strings.Fields(schedule)
+	minute, minuteErr := strconv.Atoi(fields[0])
+	hour, hourErr := strconv.Atoi(fields[1])
+	if minuteErr != nil || hourErr != nil || minute < 0 || minute > 59 || hour < 0 || hour > 23 ||
+		fields[2] != "*" || fields[3] != "*" || fields[4] != "*" {
+		return "", fmt.Errorf("backup schedule %q cannot be converted to a systemd timer: only daily \"minute hour * * *\" schedules are supported without cron", schedule)
+	}
+	return fmt.Sprintf("*-*-* %02d:%02d:00", hour, minute), nil
+}
+
+// RemoveBackupCronJob removes the backup cron job or systemd timer, whichever
+// SetupBackupCronJob installed. Nothing scheduled is not an error.
+func (m *Manager) RemoveBackupCronJob() error {
+	removed := false
+	if err := os.Remove(m.backupCronFile); err == nil {
+		removed = true
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cron file %s: %w", m.backupCronFile, err)
+	}
+
+	timerRemoved, err := m.removeTimer(backupUnit)
+	if err != nil {
+		return err
+	}
+	removed = removed || timerRemoved
+
+	if removed {
+		m.logger.Success("Scheduled backups disabled")
+	} else {
+		m.logger.Info("No scheduled backup job found")
+	}
+	return nil
+}
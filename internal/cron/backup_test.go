@@ -0,0 +1,82 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupBackupCronJob(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.backupCronFile = filepath.Join(dir, "infinity-metrics-backup")
+	mgr.installDir = dir
+
+	if err := mgr.SetupBackupCronJob("0 1 * * 0"); err != nil {
+		t.Fatalf("SetupBackupCronJob() error = %v", err)
+	}
+	content, err := os.ReadFile(mgr.backupCronFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "0 1 * * 0 root cd "+dir+" && "+DefaultBinaryPath+" backup ") {
+		t.Errorf("cron job should run the backup on the given schedule, got:\n%s", content)
+	}
+
+	if err := mgr.SetupBackupCronJob("daily"); err == nil {
+		t.Error("SetupBackupCronJob() should reject a schedule without five fields")
+	}
+
+	if err := mgr.RemoveBackupCronJob(); err != nil {
+		t.Fatalf("RemoveBackupCronJob() error = %v", err)
+	}
+	if _, err := os.Stat(mgr.backupCronFile); !os.IsNotExist(err) {
+		t.Error("RemoveBackupCronJob() should delete the cron file")
+	}
+	if err := mgr.RemoveBackupCronJob(); err != nil {
+		t.Errorf("RemoveBackupCronJob() without a job error = %v", err)
+	}
+}
+
+func TestSetupBackupCronJob_SystemdTimer(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.backupCronFile = filepath.Join(dir, "cron.d", "infinity-metrics-backup")
+	mgr.installDir = dir
+	mgr.systemdDir = dir
+
+	originalBooted, originalRun := systemdBootedDir, runSystemctl
+	defer func() { systemdBootedDir, runSystemctl = originalBooted, originalRun }()
+	systemdBootedDir = dir
+	var calls []string
+	runSystemctl = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+
+	if err := mgr.SetupBackupCronJob("*/30 * * * *"); err == nil || !strings.Contains(err.Error(), "systemd timer") {
+		t.Errorf("SetupBackupCronJob() error = %v, want a non-daily schedule rejected without cron", err)
+	}
+	if err := mgr.SetupBackupCronJob(""); err != nil {
+		t.Fatalf("SetupBackupCronJob() error = %v", err)
+	}
+	timer, err := os.ReadFile(filepath.Join(dir, BackupTimerUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(timer), "OnCalendar=*-*-* 02:30:00\n") {
+		t.Errorf("timer should run at the default 02:30, got:\n%s", timer)
+	}
+
+	if err := mgr.RemoveBackupCronJob(); err != nil {
+		t.Fatalf("RemoveBackupCronJob() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, BackupTimerUnit)); !os.IsNotExist(err) {
+		t.Error("RemoveBackupCronJob() should delete the timer unit")
+	}
+	want := []string{"daemon-reload", "enable --now " + BackupTimerUnit, "disable --now " + BackupTimerUnit, "daemon-reload"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("systemctl calls = %v, want %v", calls, want)
+	}
+}
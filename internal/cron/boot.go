@@ -0,0 +1,46 @@
+package cron
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BootServiceUnit is the systemd service that reconciles the containers on boot
+const BootServiceUnit = "infinity-metrics.service"
+
+// SetupBootService installs and enables a systemd service that runs
+// `infinity-metrics reconcile` once Docker and the network are up, so the stack
+// starts in order after a reboot instead of relying on restart policies alone
+func (m *Manager) SetupBootService() error {
+	if _, err := os.Stat(systemdBootedDir); err != nil {
+		return fmt.Errorf("systemd is not running on this host, containers will rely on Docker's restart policy")
+	}
+
+	unit := "[Unit]\n"
+	unit += "Description=Infinity Metrics\n"
+	unit += "Requires=docker.service\n"
+	unit += "After=docker.service network-online.target\n"
+	unit += "Wants=network-online.target\n\n"
+	unit += "[Service]\n"
+	unit += "Type=oneshot\n"
+	unit += "RemainAfterExit=yes\n"
+	unit += fmt.Sprintf("WorkingDirectory=%s\n", m.installDir)
+	unit += fmt.Sprintf("ExecStart=%s reconcile\n\n", m.binaryPath)
+	unit += "[Install]\n"
+	unit += "WantedBy=multi-user.target\n"
+
+	path := filepath.Join(m.systemdDir, BootServiceUnit)
+	if err := os.WriteFile(path, []byte(unit), 0o644); err != nil {
+		return fmt.Errorf("failed to write systemd unit %s: %w", path, err)
+	}
+	if err := runSystemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if err := runSystemctl("enable", BootServiceUnit); err != nil {
+		return err
+	}
+
+	m.logger.Success("Systemd service %s enabled, containers will be reconciled on boot", BootServiceUnit)
+	return nil
+}
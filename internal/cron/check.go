@@ -0,0 +1,132 @@
+package cron
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// JobStatus describes the scheduled update job found on the host
+type JobStatus struct {
+	Source   string // cron file or systemd timer holding the job, "" when none was found
+	Schedule string // cron schedule or systemd OnCalendar expression
+	Binary   string // installer binary the job runs
+	WorkDir  string // directory the job runs in
+	Problems []string
+}
+
+// OK reports whether the job exists and will be able to run
+func (s JobStatus) OK() bool {
+	return s.Source != "" && len(s.Problems) == 0
+}
+
+// CheckCronJob finds the update job SetupCronJob installed, in the cron file
+// or the systemd timer used without cron, and checks that the binary it runs
+// is still executable, its working directory exists and no other job also
+// schedules updates
+func (m *Manager) CheckCronJob() JobStatus {
+	var status JobStatus
+	content, err := os.ReadFile(m.cronFile)
+	switch {
+	case err == nil:
+		status = parseCronJob(m.cronFile, string(content))
+	case !os.IsNotExist(err):
+		return JobStatus{Problems: []string{fmt.Sprintf("cannot read %s: %v", m.cronFile, err)}}
+	default:
+		status = m.checkTimer()
+		if status.Source == "" {
+			status.Problems = append(status.Problems, fmt.Sprintf("no update job found in %s or %s", m.cronFile, filepath.Join(m.systemdDir, TimerUnit)))
+			return status
+		}
+	}
+
+	for _, duplicate := range m.duplicateUpdateJobs(status.Source) {
+		status.Problems = append(status.Problems, fmt.Sprintf("%s also schedules updates, which may then run twice", duplicate))
+	}
+	if status.Binary != "" {
+		info, err := os.Stat(status.Binary)
+		switch {
+		case err != nil:
+			status.Problems = append(status.Problems, fmt.Sprintf("the job runs %s, which does not exist", status.Binary))
+		case !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0:
+			status.Problems = append(status.Problems, fmt.Sprintf("the job runs %s, which is not executable", status.Binary))
+		}
+	}
+	if status.WorkDir != "" {
+		if info, err := os.Stat(status.WorkDir); err != nil || !info.IsDir() {
+			status.Problems = append(status.Problems, fmt.Sprintf("the job runs in %s, which does not exist", status.WorkDir))
+		}
+	}
+	return status
+}
+
+// parseCronJob reads the update line of a cron.d file in the format
+// SetupCronJob writes: schedule, user, then "cd DIR && BINARY update ..."
+func parseCronJob(path, content string) JobStatus {
+	status := JobStatus{Source: path}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 7 || strings.HasPrefix(fields[0], "#") || strings.Contains(fields[0], "=") {
+			continue
+		}
+		command := fields[6:]
+		for idx, field := range command {
+			if field == "update" && idx > 0 {
+				status.Schedule = strings.Join(fields[:5], " ")
+				status.Binary = command[idx-1]
+			}
+			if field == "cd" && idx+1 < len(command) {
+				status.WorkDir = command[idx+1]
+			}
+		}
+		if status.Binary != "" {
+			return status
+		}
+		status.WorkDir = ""
+	}
+	status.Problems = append(status.Problems, fmt.Sprintf("%s has no update command", path))
+	return status
+}
+
+// checkTimer reads the systemd timer and service setupSystemdTimer writes and
+// checks the timer is active. Source stays empty without a timer unit.
+func (m *Manager) checkTimer() JobStatus {
+	timerPath := filepath.Join(m.systemdDir, TimerUnit)
+	timer, err := os.ReadFile(timerPath)
+	if err != nil {
+		return JobStatus{}
+	}
+	status := JobStatus{Source: timerPath}
+	for _, line := range strings.Split(string(timer), "\n") {
+		if calendar, ok := strings.CutPrefix(strings.TrimSpace(line), "OnCalendar="); ok {
+			status.Schedule = calendar
+		}
+	}
+
+	servicePath := filepath.Join(m.systemdDir, ServiceUnit)
+	service, err := os.ReadFile(servicePath)
+	if err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("the timer's service %s is missing", servicePath))
+	}
+	for _, line := range strings.Split(string(service), "\n") {
+		line = strings.TrimSpace(line)
+		if dir, ok := strings.CutPrefix(line, "WorkingDirectory="); ok {
+			status.WorkDir = dir
+		}
+		if execStart, ok := strings.CutPrefix(line, "ExecStart="); ok {
+			// ExecStart=/bin/sh -c 'BINARY update ...'
+			fields := strings.Fields(strings.Trim(strings.TrimPrefix(execStart, "/bin/sh -c "), "'"))
+			for idx, field := range fields {
+				if field == "update" && idx > 0 {
+					status.Binary = fields[idx-1]
+				}
+			}
+		}
+	}
+
+	if err := runSystemctl("is-active", "--quiet", TimerUnit); err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("%s is not active, start it with 'systemctl enable --now %s'", TimerUnit, TimerUnit))
+	}
+	return status
+}
@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckCronJob(t *testing.T) {
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.cronFile = filepath.Join(dir, "infinity-metrics-update")
+	mgr.systemdDir = dir
+	mgr.installDir = dir
+	mgr.binaryPath = filepath.Join(dir, "infinity-metrics")
+	stubCrontab(t, "")
+
+	if status := mgr.CheckCronJob(); status.OK() || status.Source != "" {
+		t.Errorf("status without a job = %+v, want it reported missing", status)
+	}
+
+	if err := mgr.SetupCronJob(); err != nil {
+		t.Fatalf("SetupCronJob() error = %v", err)
+	}
+	status := mgr.CheckCronJob()
+	if status.OK() || len(status.Problems) != 1 || !strings.Contains(status.Problems[0], "does not exist") {
+		t.Errorf("status with a missing binary = %+v, want one stale path problem", status)
+	}
+
+	if err := os.WriteFile(mgr.binaryPath, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	status = mgr.CheckCronJob()
+	if !status.OK() {
+		t.Errorf("status = %+v, want OK", status)
+	}
+	if status.Schedule != DefaultCronSchedule || status.Binary != mgr.binaryPath || status.WorkDir != dir {
+		t.Errorf("status = %+v, want the schedule, binary and dir SetupCronJob wrote", status)
+	}
+}
+
+func TestCheckCronJob_SystemdTimer(t *testing.T) {
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.cronFile = filepath.Join(dir, "missing", "infinity-metrics-update")
+	mgr.systemdDir = dir
+	mgr.installDir = dir
+	mgr.binaryPath = filepath.Join(dir, "infinity-metrics")
+	if err := os.WriteFile(mgr.binaryPath, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	originalBooted, originalRun := systemdBootedDir, runSystemctl
+	defer func() { systemdBootedDir, runSystemctl = originalBooted, originalRun }()
+	systemdBootedDir = dir
+	stubCrontab(t, "")
+	active := true
+	runSystemctl = func(args ...string) error {
+		if args[0] == "is-active" && !active {
+			return fmt.Errorf("systemctl is-active: exit status 3")
+		}
+		return nil
+	}
+
+	if err := mgr.SetupCronJob(); err != nil {
+		t.Fatalf("SetupCronJob() error = %v", err)
+	}
+	status := mgr.CheckCronJob()
+	if !status.OK() || status.Schedule != DefaultTimerSchedule || status.Binary != mgr.binaryPath {
+		t.Errorf("status = %+v, want the OK timer job", status)
+	}
+
+	active = false
+	if status := mgr.CheckCronJob(); status.OK() || !strings.Contains(strings.Join(status.Problems, " "), "is not active") {
+		t.Errorf("status with an inactive timer = %+v", status)
+	}
+}
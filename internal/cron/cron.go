@@ -3,6 +3,7 @@ package cron
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 
 	"infinity-metrics-installer/internal/logging"
@@ -17,25 +18,63 @@ const (
 	DefaultBinaryPath = "/usr/local/bin/infinity-metrics"
 	// DefaultCronSchedule is the default schedule for the cron job (3:00 AM daily)
 	DefaultCronSchedule = "0 3 * * *"
+	// TriggerEnvVar is set in the cron file so commands can tell they were started by cron
+	TriggerEnvVar = "INFINITY_METRICS_TRIGGER"
+	// TriggerCron is the TriggerEnvVar value for cron-launched commands
+	TriggerCron = "cron"
+	// DefaultSystemdDir is where the timer units are installed when cron is unavailable
+	DefaultSystemdDir = "/etc/systemd/system"
+	// DefaultTimerSchedule is DefaultCronSchedule as a systemd OnCalendar expression
+	DefaultTimerSchedule = "*-*-* 03:00:00"
+	// TimerUnit is the name of the systemd timer that replaces the cron job
+	TimerUnit = updateUnit + ".timer"
+	// ServiceUnit is the name of the systemd service started by TimerUnit
+	ServiceUnit = updateUnit + ".service"
+
+	// updateUnit is the name shared by the update service and timer units
+	updateUnit = "infinity-metrics-update"
 )
 
+// systemdBootedDir exists only when systemd is the running init system
+var systemdBootedDir = "/run/systemd/system"
+
+// runSystemctl is replaced in tests
+var runSystemctl = func(args ...string) error {
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", args[0], err, output)
+	}
+	return nil
+}
+
+// StartedByCron reports whether the current process was launched by the cron job
+func StartedByCron() bool {
+	return os.Getenv(TriggerEnvVar) == TriggerCron
+}
+
 // Manager handles cron job operations
 type Manager struct {
-	logger     *logging.Logger
-	cronFile   string
-	installDir string
-	binaryPath string
-	schedule   string
+	logger           *logging.Logger
+	cronFile         string
+	backupCronFile   string
+	watchdogCronFile string
+	installDir       string
+	binaryPath       string
+	schedule         string
+	systemdDir       string
 }
 
 // NewManager creates a new cron manager with default settings
 func NewManager(logger *logging.Logger) *Manager {
 	return &Manager{
-		logger:     logger,
-		cronFile:   DefaultCronFile,
-		installDir: DefaultInstallDir,
-		binaryPath: DefaultBinaryPath,
-		schedule:   DefaultCronSchedule,
+		logger:           logger,
+		cronFile:         DefaultCronFile,
+		backupCronFile:   DefaultBackupCronFile,
+		watchdogCronFile: DefaultWatchdogCronFile,
+		installDir:       DefaultInstallDir,
+		binaryPath:       DefaultBinaryPath,
+		schedule:         DefaultCronSchedule,
+		systemdDir:       DefaultSystemdDir,
 	}
 }
 
@@ -46,12 +85,24 @@ func (m *Manager) SetupCronJob() error {
 		return nil
 	}
 
+	// Minimal images and systemd-only hosts have no cron daemon reading /etc/cron.d
+	if _, err := os.Stat(filepath.Dir(m.cronFile)); err != nil {
+		if _, err := os.Stat(systemdBootedDir); err == nil {
+			m.logger.Info("%s not found, using a systemd timer instead of cron", filepath.Dir(m.cronFile))
+			return m.setupSystemdTimer()
+		}
+		return fmt.Errorf("cannot schedule automatic updates: %s does not exist and systemd is not running. "+
+			"Install cron (e.g. 'apt install cron' or 'dnf install cronie') and re-run the command, "+
+			"or schedule '%s update' daily yourself", filepath.Dir(m.cronFile), m.binaryPath)
+	}
+
 	// Create a more robust cron job with better environment setup
 	cronContent := "# Infinity Metrics automated updates\n"
 	cronContent += "SHELL=/bin/bash\n"
 	cronContent += "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n"
 	cronContent += fmt.Sprintf("INSTALL_DIR=%s\n", m.installDir)
-	cronContent += fmt.Sprintf("%s root cd %s && %s update > %s/logs/updater.log 2>&1\n",
+	cronContent += fmt.Sprintf("%s=%s\n", TriggerEnvVar, TriggerCron)
+	cronContent += fmt.Sprintf("%s root cd %s && %s update --only-if-healthy > %s/logs/updater.log 2>&1\n",
 		m.schedule,
 		m.installDir,
 		m.binaryPath,
@@ -69,8 +120,86 @@ func (m *Manager) SetupCronJob() error {
 		m.logger.Error("Cron setup failed: %v", err)
 		return fmt.Errorf("failed to write cron file %s: %w", m.cronFile, err)
 	}
+	if err := m.removeDuplicateUpdateJobs(m.cronFile); err != nil {
+		m.logger.Warn("Automatic updates may run twice: %v", err)
+	}
 
 	m.logger.Success("Cron job setup complete")
 	m.logger.InfoWithTime("Automatic updates scheduled for 3:00 AM daily")
 	return nil
 }
+
+// installTimer writes name.service running execStart from the install
+// directory and name.timer starting it on onCalendar, then enables the timer.
+// The service gets the same environment as the cron jobs.
+func (m *Manager) installTimer(name, description, execStart, onCalendar string) error {
+	service := "[Unit]\n"
+	service += fmt.Sprintf("Description=%s\n", description)
+	service += "After=docker.service\n\n"
+	service += "[Service]\n"
+	service += "Type=oneshot\n"
+	service += fmt.Sprintf("WorkingDirectory=%s\n", m.installDir)
+	service += fmt.Sprintf("Environment=INSTALL_DIR=%s\n", m.installDir)
+	service += fmt.Sprintf("Environment=%s=%s\n", TriggerEnvVar, TriggerCron)
+	service += fmt.Sprintf("ExecStart=%s\n", execStart)
+
+	timer := "[Unit]\n"
+	timer += fmt.Sprintf("Description=Run %s\n\n", description)
+	timer += "[Timer]\n"
+	timer += fmt.Sprintf("OnCalendar=%s\n", onCalendar)
+	timer += "Persistent=true\n\n"
+	timer += "[Install]\n"
+	timer += "WantedBy=timers.target\n"
+
+	for unit, content := range map[string]string{name + ".service": service, name + ".timer": timer} {
+		path := filepath.Join(m.systemdDir, unit)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("failed to write systemd unit %s: %w", path, err)
+		}
+	}
+	if err := runSystemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return runSystemctl("enable", "--now", name+".timer")
+}
+
+// removeTimer disables and deletes the units installTimer wrote for name and
+// reports whether they existed
+func (m *Manager) removeTimer(name string) (bool, error) {
+	timer := name + ".timer"
+	if _, err := os.Stat(filepath.Join(m.systemdDir, timer)); err != nil {
+		return false, nil
+	}
+	if err := runSystemctl("disable", "--now", timer); err != nil {
+		return false, err
+	}
+	for _, unit := range []string{timer, name + ".service"} {
+		if err := os.Remove(filepath.Join(m.systemdDir, unit)); err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to remove systemd unit %s: %w", unit, err)
+		}
+	}
+	if err := runSystemctl("daemon-reload"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// setupSystemdTimer installs a service and timer that run the update on the
+// same schedule as the cron job
+func (m *Manager) setupSystemdTimer() error {
+	if err := os.MkdirAll(filepath.Join(m.installDir, "logs"), 0755); err != nil {
+		m.logger.Warn("Failed to create logs directory: %v", err)
+	}
+
+	execStart := fmt.Sprintf("/bin/sh -c '%s update --only-if-healthy > %s/logs/updater.log 2>&1'", m.binaryPath, m.installDir)
+	if err := m.installTimer(updateUnit, "Infinity Metrics automated updates", execStart, DefaultTimerSchedule); err != nil {
+		return err
+	}
+	if err := m.removeDuplicateUpdateJobs(filepath.Join(m.systemdDir, TimerUnit)); err != nil {
+		m.logger.Warn("Automatic updates may run twice: %v", err)
+	}
+
+	m.logger.Success("Systemd timer setup complete")
+	m.logger.InfoWithTime("Automatic updates scheduled for 3:00 AM daily")
+	return nil
+}
@@ -1,6 +1,9 @@
 package cron
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"infinity-metrics-installer/internal/logging"
 )
@@ -28,3 +31,123 @@ func TestNewManager_Defaults(t *testing.T) {
 		t.Errorf("schedule = %q, want %q", mgr.schedule, DefaultCronSchedule)
 	}
 }
+
+func TestSetupCronJob_MarksCronTrigger(t *testing.T) {
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.cronFile = filepath.Join(dir, "infinity-metrics-update")
+	mgr.systemdDir = dir
+	mgr.installDir = dir
+	stubCrontab(t, "")
+
+	if err := mgr.SetupCronJob(); err != nil {
+		t.Fatalf("SetupCronJob() error = %v", err)
+	}
+	content, err := os.ReadFile(mgr.cronFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), TriggerEnvVar+"="+TriggerCron+"\n") {
+		t.Errorf("cron file should set %s=%s, got:\n%s", TriggerEnvVar, TriggerCron, content)
+	}
+	if !strings.Contains(string(content), " update --only-if-healthy ") {
+		t.Errorf("cron job should skip updates of an unhealthy installation, got:\n%s", content)
+	}
+}
+
+func TestSetupCronJob_FallsBackToSystemdTimer(t *testing.T) {
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.cronFile = filepath.Join(dir, "cron.d", "infinity-metrics-update")
+	mgr.installDir = dir
+	mgr.systemdDir = dir
+
+	originalBooted, originalRun := systemdBootedDir, runSystemctl
+	defer func() { systemdBootedDir, runSystemctl = originalBooted, originalRun }()
+	systemdBootedDir = dir
+	stubCrontab(t, "")
+	var calls []string
+	runSystemctl = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+
+	if err := mgr.SetupCronJob(); err != nil {
+		t.Fatalf("SetupCronJob() error = %v", err)
+	}
+	if _, err := os.Stat(mgr.cronFile); !os.IsNotExist(err) {
+		t.Error("cron file should not be written without a cron directory")
+	}
+	service, err := os.ReadFile(filepath.Join(dir, ServiceUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(service), "Environment="+TriggerEnvVar+"="+TriggerCron+"\n") {
+		t.Errorf("service should set %s=%s, got:\n%s", TriggerEnvVar, TriggerCron, service)
+	}
+	timer, err := os.ReadFile(filepath.Join(dir, TimerUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(timer), "OnCalendar="+DefaultTimerSchedule+"\n") {
+		t.Errorf("timer should run on %s, got:\n%s", DefaultTimerSchedule, timer)
+	}
+	if want := []string{"daemon-reload", "enable --now " + TimerUnit}; strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("systemctl calls = %v, want %v", calls, want)
+	}
+}
+
+func TestSetupCronJob_FailsWithoutCronOrSystemd(t *testing.T) {
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.cronFile = filepath.Join(dir, "cron.d", "infinity-metrics-update")
+	mgr.installDir = dir
+
+	originalBooted := systemdBootedDir
+	defer func() { systemdBootedDir = originalBooted }()
+	systemdBootedDir = filepath.Join(dir, "no-systemd")
+
+	err := mgr.SetupCronJob()
+	if err == nil || !strings.Contains(err.Error(), "Install cron") {
+		t.Errorf("SetupCronJob() error = %v, want instructions to install cron", err)
+	}
+}
+
+func TestSetupBootService(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.systemdDir = dir
+
+	originalBooted, originalRun := systemdBootedDir, runSystemctl
+	defer func() { systemdBootedDir, runSystemctl = originalBooted, originalRun }()
+	var calls []string
+	runSystemctl = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+
+	systemdBootedDir = filepath.Join(dir, "no-systemd")
+	if err := mgr.SetupBootService(); err == nil {
+		t.Error("SetupBootService() should fail when systemd is not running")
+	}
+
+	systemdBootedDir = dir
+	if err := mgr.SetupBootService(); err != nil {
+		t.Fatalf("SetupBootService() error = %v", err)
+	}
+	unit, err := os.ReadFile(filepath.Join(dir, BootServiceUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"After=docker.service", "ExecStart=" + DefaultBinaryPath + " reconcile\n", "WantedBy=multi-user.target"} {
+		if !strings.Contains(string(unit), want) {
+			t.Errorf("unit should contain %q, got:\n%s", want, unit)
+		}
+	}
+	if want := []string{"daemon-reload", "enable " + BootServiceUnit}; strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("systemctl calls = %v, want %v", calls, want)
+	}
+}
@@ -0,0 +1,157 @@
+package cron
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runCrontab runs crontab with args and stdin, replaced in tests
+var runCrontab = func(stdin string, args ...string) (string, error) {
+	cmd := exec.Command("crontab", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("crontab %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// isUpdateJobLine reports whether a crontab line runs an Infinity Metrics
+// update, as written by SetupCronJob, by older installers or by hand
+func isUpdateJobLine(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.Contains(fields[0], "=") {
+		return false
+	}
+	if strings.Contains(line, "infinity-metrics-updater") {
+		return true
+	}
+	for idx, field := range fields {
+		if field == "update" && idx > 0 && filepath.Base(fields[idx-1]) == "infinity-metrics" {
+			return true
+		}
+	}
+	return false
+}
+
+// filterUpdateJobs returns content without its update job lines and how many
+// were removed
+func filterUpdateJobs(content string) (string, int) {
+	var kept []string
+	removed := 0
+	for _, line := range strings.Split(content, "\n") {
+		if isUpdateJobLine(line) {
+			removed++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n"), removed
+}
+
+// hasCronJobs reports whether content still schedules any command
+func hasCronJobs(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && !strings.HasPrefix(fields[0], "#") && !strings.Contains(fields[0], "=") {
+			return true
+		}
+	}
+	return false
+}
+
+// rootCrontab returns root's crontab, empty when there is none or crontab is
+// not installed
+func rootCrontab() string {
+	content, err := runCrontab("", "-l")
+	if err != nil {
+		return ""
+	}
+	return content
+}
+
+// duplicateUpdateJobs lists the update jobs scheduled besides the one in
+// keep, the cron file or the systemd timer: lines in root's crontab, other
+// files in the cron.d directory, and the other of the cron file and timer
+func (m *Manager) duplicateUpdateJobs(keep string) []string {
+	var duplicates []string
+	if _, removed := filterUpdateJobs(rootCrontab()); removed > 0 {
+		duplicates = append(duplicates, "root's crontab")
+	}
+
+	cronDir := filepath.Dir(m.cronFile)
+	entries, _ := os.ReadDir(cronDir)
+	for _, entry := range entries {
+		path := filepath.Join(cronDir, entry.Name())
+		if entry.IsDir() || path == m.cronFile || path == m.backupCronFile || path == m.watchdogCronFile {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if _, removed := filterUpdateJobs(string(content)); removed > 0 {
+			duplicates = append(duplicates, path)
+		}
+	}
+
+	timerPath := filepath.Join(m.systemdDir, TimerUnit)
+	if keep != m.cronFile {
+		if _, err := os.Stat(m.cronFile); err == nil {
+			duplicates = append(duplicates, m.cronFile)
+		}
+	}
+	if keep != timerPath {
+		if _, err := os.Stat(timerPath); err == nil {
+			duplicates = append(duplicates, timerPath)
+		}
+	}
+	return duplicates
+}
+
+// removeDuplicateUpdateJobs removes every update job besides the one in keep,
+// so updates are not started twice by leftovers of earlier installs. Other
+// jobs in the same crontab or cron.d file are left in place.
+func (m *Manager) removeDuplicateUpdateJobs(keep string) error {
+	for _, source := range m.duplicateUpdateJobs(keep) {
+		switch source {
+		case "root's crontab":
+			filtered, _ := filterUpdateJobs(rootCrontab())
+			if _, err := runCrontab(strings.TrimRight(filtered, "\n")+"\n", "-"); err != nil {
+				return fmt.Errorf("failed to remove the update job from root's crontab: %w", err)
+			}
+		case filepath.Join(m.systemdDir, TimerUnit):
+			if err := runSystemctl("disable", "--now", TimerUnit); err != nil {
+				m.logger.Warn("Failed to stop %s: %v", TimerUnit, err)
+			}
+			for _, unit := range []string{TimerUnit, ServiceUnit} {
+				if err := os.Remove(filepath.Join(m.systemdDir, unit)); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to remove %s: %w", unit, err)
+				}
+			}
+		case m.cronFile:
+			if err := os.Remove(m.cronFile); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", m.cronFile, err)
+			}
+		default:
+			content, err := os.ReadFile(source)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", source, err)
+			}
+			filtered, _ := filterUpdateJobs(string(content))
+			if !hasCronJobs(filtered) {
+				err = os.Remove(source)
+			} else {
+				err = os.WriteFile(source, []byte(filtered), 0o644)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to remove the update job from %s: %w", source, err)
+			}
+		}
+		m.logger.Info("Removed duplicate update job from %s", source)
+	}
+	return nil
+}
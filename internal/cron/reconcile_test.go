@@ -0,0 +1,128 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubCrontab replaces root's crontab with content for the test and returns
+// a pointer to the crontab as last installed
+func stubCrontab(t *testing.T, content string) *string {
+	original := runCrontab
+	t.Cleanup(func() { runCrontab = original })
+	runCrontab = func(stdin string, args ...string) (string, error) {
+		if len(args) > 0 && args[0] == "-" {
+			content = stdin
+		}
+		return content, nil
+	}
+	return &content
+}
+
+func TestIsUpdateJobLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"0 3 * * * root cd /opt/infinity-metrics && /usr/local/bin/infinity-metrics update --only-if-healthy > /dev/null", true},
+		{"0 4 * * * /usr/local/bin/infinity-metrics update", true},
+		{"30 2 * * * /opt/infinity-metrics/infinity-metrics-updater >> /var/log/im.log", true},
+		{"0 2 * * * root /usr/local/bin/infinity-metrics backup", false},
+		{"# 0 4 * * * /usr/local/bin/infinity-metrics update", false},
+		{"INSTALL_DIR=/opt/infinity-metrics", false},
+		{"0 5 * * * /usr/bin/apt-get update", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isUpdateJobLine(tt.line); got != tt.want {
+			t.Errorf("isUpdateJobLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSetupCronJob_RemovesDuplicateJobs(t *testing.T) {
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	cronDir := filepath.Join(dir, "cron.d")
+	if err := os.MkdirAll(cronDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := NewManager(testLogger(t))
+	mgr.cronFile = filepath.Join(cronDir, "infinity-metrics-update")
+	mgr.backupCronFile = filepath.Join(cronDir, "infinity-metrics-backup")
+	mgr.systemdDir = dir
+	mgr.installDir = dir
+	mgr.binaryPath = filepath.Join(dir, "infinity-metrics")
+	if err := os.WriteFile(mgr.binaryPath, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	originalRun := runSystemctl
+	defer func() { runSystemctl = originalRun }()
+	var calls []string
+	runSystemctl = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+	crontab := stubCrontab(t, "")
+	if err := mgr.SetupCronJob(); err != nil {
+		t.Fatalf("SetupCronJob() error = %v", err)
+	}
+	if status := mgr.CheckCronJob(); !status.OK() {
+		t.Fatalf("status = %+v, want OK with a single job", status)
+	}
+
+	// Leftovers of an older installer and of a systemd-only setup
+	*crontab = "MAILTO=root\n0 4 * * * /usr/local/bin/infinity-metrics update\n0 1 * * * /usr/bin/certbot renew\n"
+	oldFile := filepath.Join(cronDir, "infinity-metrics-updater")
+	if err := os.WriteFile(oldFile, []byte("SHELL=/bin/sh\n30 2 * * * root /opt/infinity-metrics/infinity-metrics-updater\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mixedFile := filepath.Join(cronDir, "maintenance")
+	if err := os.WriteFile(mixedFile, []byte("0 4 * * * root infinity-metrics update\n0 5 * * * root /usr/bin/apt-get update\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backup := "0 2 * * * root /usr/local/bin/infinity-metrics backup\n"
+	if err := os.WriteFile(mgr.backupCronFile, []byte(backup), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, unit := range []string{TimerUnit, ServiceUnit} {
+		if err := os.WriteFile(filepath.Join(dir, unit), []byte("[Unit]\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status := mgr.CheckCronJob()
+	if status.OK() || len(status.Problems) != 4 {
+		t.Errorf("status = %+v, want the crontab, two cron.d files and the timer reported", status)
+	}
+
+	if err := mgr.SetupCronJob(); err != nil {
+		t.Fatalf("SetupCronJob() error = %v", err)
+	}
+	if status := mgr.CheckCronJob(); !status.OK() {
+		t.Errorf("status after setup = %+v, want OK", status)
+	}
+	if *crontab != "MAILTO=root\n0 1 * * * /usr/bin/certbot renew\n" {
+		t.Errorf("crontab = %q, want only the update job removed", *crontab)
+	}
+	if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
+		t.Errorf("%s should be removed, it only held the update job", oldFile)
+	}
+	if content, _ := os.ReadFile(mixedFile); string(content) != "0 5 * * * root /usr/bin/apt-get update\n" {
+		t.Errorf("%s = %q, want its other job kept", mixedFile, content)
+	}
+	if content, _ := os.ReadFile(mgr.backupCronFile); string(content) != backup {
+		t.Errorf("backup cron file = %q, want it untouched", content)
+	}
+	for _, unit := range []string{TimerUnit, ServiceUnit} {
+		if _, err := os.Stat(filepath.Join(dir, unit)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", unit)
+		}
+	}
+	if len(calls) != 1 || calls[0] != "disable --now "+TimerUnit {
+		t.Errorf("systemctl calls = %v, want the timer disabled", calls)
+	}
+}
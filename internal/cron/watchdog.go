@@ -0,0 +1,92 @@
+package cron
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DefaultWatchdogCronFile is the path to the watchdog cron job file
+	DefaultWatchdogCronFile = "/etc/cron.d/infinity-metrics-watchdog"
+	// DefaultWatchdogSchedule runs the watchdog every five minutes
+	DefaultWatchdogSchedule = "*/5 * * * *"
+	// DefaultWatchdogTimerSchedule is DefaultWatchdogSchedule as a systemd OnCalendar expression
+	DefaultWatchdogTimerSchedule = "*:0/5"
+	// WatchdogTimerUnit is the systemd timer that replaces the watchdog cron job
+	WatchdogTimerUnit = watchdogUnit + ".timer"
+	// WatchdogServiceUnit is the name of the systemd service started by WatchdogTimerUnit
+	WatchdogServiceUnit = watchdogUnit + ".service"
+
+	// watchdogUnit is the name shared by the watchdog service and timer units
+	watchdogUnit = "infinity-metrics-watchdog"
+)
+
+// SetupWatchdogJob schedules `infinity-metrics watch --once` every five
+// minutes, so crashed or unhealthy containers are restarted between updates.
+// The restart limit is kept in the install directory, so it holds across runs.
+func (m *Manager) SetupWatchdogJob() error {
+	if err := os.MkdirAll(filepath.Join(m.installDir, "logs"), 0755); err != nil {
+		m.logger.Warn("Failed to create logs directory: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Dir(m.watchdogCronFile)); err != nil {
+		if _, err := os.Stat(systemdBootedDir); err == nil {
+			m.logger.Info("%s not found, using a systemd timer instead of cron", filepath.Dir(m.watchdogCronFile))
+			return m.setupWatchdogTimer()
+		}
+		return fmt.Errorf("cannot schedule the watchdog: %s does not exist and systemd is not running. "+
+			"Install cron and re-run the command, or run '%s watch' as a service yourself", filepath.Dir(m.watchdogCronFile), m.binaryPath)
+	}
+
+	cronContent := "# Infinity Metrics container watchdog\n"
+	cronContent += "SHELL=/bin/bash\n"
+	cronContent += "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n"
+	cronContent += fmt.Sprintf("INSTALL_DIR=%s\n", m.installDir)
+	cronContent += fmt.Sprintf("%s=%s\n", TriggerEnvVar, TriggerCron)
+	cronContent += fmt.Sprintf("%s root cd %s && %s watch --once > /dev/null 2>&1\n",
+		DefaultWatchdogSchedule,
+		m.installDir,
+		m.binaryPath)
+
+	if err := os.WriteFile(m.watchdogCronFile, []byte(cronContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write cron file %s: %w", m.watchdogCronFile, err)
+	}
+	m.logger.Success("Watchdog scheduled every five minutes, restarts are logged to %s", filepath.Join(m.installDir, "logs", "infinity-metrics-watchdog.log"))
+	return nil
+}
+
+// setupWatchdogTimer installs a service and timer running the watchdog on
+// the same schedule as the cron job
+func (m *Manager) setupWatchdogTimer() error {
+	execStart := fmt.Sprintf("%s watch --once", m.binaryPath)
+	if err := m.installTimer(watchdogUnit, "Infinity Metrics container watchdog", execStart, DefaultWatchdogTimerSchedule); err != nil {
+		return err
+	}
+	m.logger.Success("Watchdog timer scheduled every five minutes")
+	return nil
+}
+
+// RemoveWatchdogJob removes the watchdog cron job or systemd timer, whichever
+// SetupWatchdogJob installed. Nothing scheduled is not an error.
+func (m *Manager) RemoveWatchdogJob() error {
+	removed := false
+	if err := os.Remove(m.watchdogCronFile); err == nil {
+		removed = true
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cron file %s: %w", m.watchdogCronFile, err)
+	}
+
+	timerRemoved, err := m.removeTimer(watchdogUnit)
+	if err != nil {
+		return err
+	}
+	removed = removed || timerRemoved
+
+	if removed {
+		m.logger.Success("Watchdog disabled")
+	} else {
+		m.logger.Info("No scheduled watchdog job found")
+	}
+	return nil
+}
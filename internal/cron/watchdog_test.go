@@ -0,0 +1,87 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupWatchdogJob(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.watchdogCronFile = filepath.Join(dir, "infinity-metrics-watchdog")
+	mgr.installDir = dir
+
+	if err := mgr.SetupWatchdogJob(); err != nil {
+		t.Fatalf("SetupWatchdogJob() error = %v", err)
+	}
+	content, err := os.ReadFile(mgr.watchdogCronFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), DefaultWatchdogSchedule+" root cd "+dir+" && "+DefaultBinaryPath+" watch --once ") {
+		t.Errorf("cron job should run a single watchdog pass every five minutes, got:\n%s", content)
+	}
+	if isUpdateJobLine(strings.TrimSpace(string(content))) {
+		t.Error("the watchdog job should not be mistaken for an update job")
+	}
+
+	if err := mgr.RemoveWatchdogJob(); err != nil {
+		t.Fatalf("RemoveWatchdogJob() error = %v", err)
+	}
+	if _, err := os.Stat(mgr.watchdogCronFile); !os.IsNotExist(err) {
+		t.Error("RemoveWatchdogJob() should delete the cron file")
+	}
+	if err := mgr.RemoveWatchdogJob(); err != nil {
+		t.Errorf("RemoveWatchdogJob() without a job error = %v", err)
+	}
+}
+
+func TestSetupWatchdogJob_SystemdTimer(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.watchdogCronFile = filepath.Join(dir, "cron.d", "infinity-metrics-watchdog")
+	mgr.installDir = dir
+	mgr.systemdDir = dir
+
+	originalBooted, originalRun := systemdBootedDir, runSystemctl
+	defer func() { systemdBootedDir, runSystemctl = originalBooted, originalRun }()
+	systemdBootedDir = dir
+	var calls []string
+	runSystemctl = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+
+	if err := mgr.SetupWatchdogJob(); err != nil {
+		t.Fatalf("SetupWatchdogJob() error = %v", err)
+	}
+	timer, err := os.ReadFile(filepath.Join(dir, WatchdogTimerUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(timer), "OnCalendar="+DefaultWatchdogTimerSchedule+"\n") {
+		t.Errorf("timer should run every five minutes, got:\n%s", timer)
+	}
+	service, err := os.ReadFile(filepath.Join(dir, WatchdogServiceUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(service), "ExecStart="+DefaultBinaryPath+" watch --once\n") {
+		t.Errorf("service should run a single watchdog pass, got:\n%s", service)
+	}
+
+	if err := mgr.RemoveWatchdogJob(); err != nil {
+		t.Fatalf("RemoveWatchdogJob() error = %v", err)
+	}
+	for _, unit := range []string{WatchdogTimerUnit, WatchdogServiceUnit} {
+		if _, err := os.Stat(filepath.Join(dir, unit)); !os.IsNotExist(err) {
+			t.Errorf("RemoveWatchdogJob() should delete %s", unit)
+		}
+	}
+	want := []string{"daemon-reload", "enable --now " + WatchdogTimerUnit, "disable --now " + WatchdogTimerUnit, "daemon-reload"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("systemctl calls = %v, want %v", calls, want)
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -34,12 +35,21 @@ const (
 	Monthly BackupType = "monthly"
 )
 
-// BackupFile represents a database backup file
+// BackupFile represents a database backup file. The JSON field names are
+// emitted by list-backups --json and must stay stable for scripts.
 type BackupFile struct {
-	Name       string
-	Path       string
-	BackupType BackupType
-	CreatedAt  time.Time
+	Name       string     `json:"name"`
+	Path       string     `json:"path"`
+	BackupType BackupType `json:"type"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Size       int64      `json:"size_bytes"`
+}
+
+// BackupStatus is a backup with the result of its integrity check
+type BackupStatus struct {
+	BackupFile
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
 }
 
 // RetentionConfig defines the retention period for each backup type
@@ -58,6 +68,22 @@ func DefaultRetentionConfig() RetentionConfig {
 	}
 }
 
+// RetentionConfigFromDays builds a retention config from per-type overrides,
+// keeping the default for any value that is 0
+func RetentionConfigFromDays(daily, weekly, monthly int) RetentionConfig {
+	config := DefaultRetentionConfig()
+	if daily > 0 {
+		config.DailyRetentionDays = daily
+	}
+	if weekly > 0 {
+		config.WeeklyRetentionDays = weekly
+	}
+	if monthly > 0 {
+		config.MonthlyRetentionDays = monthly
+	}
+	return config
+}
+
 // Database manages database operations
 type Database struct {
 	logger    *logging.Logger
@@ -85,6 +111,11 @@ func (d *Database) EnsureSQLiteInstalled() error {
 		return nil
 	}
 
+	// Operator provisions dependencies themselves, never fall back to apt-get
+	if os.Getenv("SKIP_SQLITE_INSTALL") == "1" {
+		return fmt.Errorf("sqlite3 not found on PATH and SKIP_SQLITE_INSTALL=1 is set: install sqlite3 manually or unset SKIP_SQLITE_INSTALL")
+	}
+
 	// SQLite is not installed, install it using apt-get (assuming Debian/Ubuntu)
 	d.logger.Info("Installing SQLite...")
 	if os.Geteuid() != 0 {
@@ -236,9 +267,7 @@ func (d *Database) ListBackups(backupDir string) ([]BackupFile, error) {
 	var backups []BackupFile
 	for _, file := range files {
 		if !file.IsDir() && strings.HasPrefix(file.Name(), "backup_") && strings.HasSuffix(file.Name(), ".db") {
-			// Parse timestamp from filename (format: backup_20060102_150405.db)
-			timePart := strings.TrimPrefix(strings.TrimSuffix(file.Name(), ".db"), "backup_")
-			createdAt, err := time.Parse("20060102_150405", timePart)
+			createdAt, err := parseBackupTimestamp(file.Name())
 			if err != nil {
 				if d.logger != nil {
 					d.logger.Warn("Skipping backup with invalid timestamp: %s", file.Name())
@@ -249,11 +278,17 @@ func (d *Database) ListBackups(backupDir string) ([]BackupFile, error) {
 			// Determine backup type
 			backupType := determineBackupType(createdAt)
 
+			var size int64
+			if info, err := file.Info(); err == nil {
+				size = info.Size()
+			}
+
 			backups = append(backups, BackupFile{
 				Name:       file.Name(),
 				Path:       filepath.Join(backupDir, file.Name()),
 				BackupType: backupType,
 				CreatedAt:  createdAt,
+				Size:       size,
 			})
 		}
 	}
@@ -266,6 +301,13 @@ func (d *Database) ListBackups(backupDir string) ([]BackupFile, error) {
 	return backups, nil
 }
 
+// parseBackupTimestamp extracts the creation time embedded in a backup filename
+// (format: backup_20060102_150405.db)
+func parseBackupTimestamp(name string) (time.Time, error) {
+	timePart := strings.TrimPrefix(strings.TrimSuffix(name, ".db"), "backup_")
+	return time.Parse("20060102_150405", timePart)
+}
+
 // PromptSelection displays backups and prompts the user to select one
 func (d *Database) PromptSelection(backups []BackupFile) (string, error) {
 	if len(backups) == 0 {
@@ -293,6 +335,10 @@ func (d *Database) PromptSelection(backups []BackupFile) (string, error) {
 	return backups[choice-1].Path, nil
 }
 
+// ErrIntegrityCheckFailed is returned by ValidateBackup when SQLite reports the
+// file as corrupted, as opposed to the check itself failing to run
+var ErrIntegrityCheckFailed = errors.New("integrity check failed")
+
 // ValidateBackup checks if a backup file is valid and not corrupted
 func (d *Database) ValidateBackup(backupFile string) error {
 	stat, err := os.Stat(backupFile)
@@ -313,6 +359,10 @@ func (d *Database) ValidateBackup(backupFile string) error {
 		if d.logger != nil {
 			d.logger.Warn("SQLite integrity check failed: %s", stderr.String())
 		}
+		// sqlite3 refuses to open a file that is damaged beyond the check
+		if msg := stderr.String(); strings.Contains(msg, "malformed") || strings.Contains(msg, "not a database") {
+			return fmt.Errorf("%w: %s", ErrIntegrityCheckFailed, strings.TrimSpace(msg))
+		}
 		return fmt.Errorf("backup may be corrupted: %w", err)
 	}
 
@@ -322,7 +372,7 @@ func (d *Database) ValidateBackup(backupFile string) error {
 		if d.logger != nil {
 			d.logger.Warn("SQLite integrity check returned issues: %s", output)
 		}
-		return fmt.Errorf("backup integrity issues detected")
+		return fmt.Errorf("%w: backup integrity issues detected: %s", ErrIntegrityCheckFailed, output)
 	}
 
 	if d.logger != nil {
@@ -331,6 +381,115 @@ func (d *Database) ValidateBackup(backupFile string) error {
 	return nil
 }
 
+// VerifyBackups runs the integrity check on every backup
+func (d *Database) VerifyBackups(backups []BackupFile) []BackupStatus {
+	statuses := make([]BackupStatus, 0, len(backups))
+	for _, backup := range backups {
+		status := BackupStatus{BackupFile: backup, Valid: true}
+		if err := d.ValidateBackup(backup.Path); err != nil {
+			status.Valid = false
+			status.Error = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// TableCount is the number of rows in a table
+type TableCount struct {
+	Table string
+	Rows  int64
+}
+
+// DatabaseSummary describes a database file so a restore can be confirmed
+type DatabaseSummary struct {
+	Path      string
+	Size      int64
+	ModTime   time.Time
+	CreatedAt time.Time    // Embedded in backup filenames, zero for other files
+	RowCounts []TableCount // Nil if the tables could not be counted
+}
+
+// Summarize returns the size, timestamps and per-table row counts of a database file.
+// Row counts are best effort: a failing sqlite3 query only leaves them empty.
+func (d *Database) Summarize(dbPath string) (DatabaseSummary, error) {
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return DatabaseSummary{}, fmt.Errorf("cannot access database: %w", err)
+	}
+
+	summary := DatabaseSummary{
+		Path:    dbPath,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if createdAt, err := parseBackupTimestamp(filepath.Base(dbPath)); err == nil {
+		summary.CreatedAt = createdAt
+	}
+
+	counts, err := d.countRows(dbPath)
+	if err != nil {
+		if d.logger != nil {
+			d.logger.Debug("Could not count rows in %s: %v", dbPath, err)
+		}
+	} else {
+		summary.RowCounts = counts
+	}
+	return summary, nil
+}
+
+// countRows counts the rows of every user table with sqlite3
+func (d *Database) countRows(dbPath string) ([]TableCount, error) {
+	tablesOut, err := runSQLite(dbPath, "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name;")
+	if err != nil {
+		return nil, err
+	}
+
+	var tables, selects []string
+	for _, table := range strings.Split(tablesOut, "\n") {
+		if table = strings.TrimSpace(table); table == "" {
+			continue
+		}
+		tables = append(tables, table)
+		quoted := strings.ReplaceAll(table, `"`, `""`)
+		selects = append(selects, fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, quoted))
+	}
+	if len(tables) == 0 {
+		return []TableCount{}, nil
+	}
+
+	countsOut, err := runSQLite(dbPath, strings.Join(selects, " UNION ALL ")+";")
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(countsOut), "\n")
+	if len(lines) != len(tables) {
+		return nil, fmt.Errorf("expected %d row counts, got %d", len(tables), len(lines))
+	}
+
+	counts := make([]TableCount, len(tables))
+	for i, table := range tables {
+		rows, err := strconv.ParseInt(strings.TrimSpace(lines[i]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse row count for %s: %w", table, err)
+		}
+		counts[i] = TableCount{Table: table, Rows: rows}
+	}
+	return counts, nil
+}
+
+// runSQLite runs a read-only query and returns its output
+func runSQLite(dbPath, query string) (string, error) {
+	cmd := exec.Command("sqlite3", "-readonly", dbPath, query)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("sqlite3 query failed: %w - %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
 // GetAdminUser reads the first user email from the users table
 func (d *Database) GetAdminUser(dbPath string) (string, error) {
 	// Check if the database file exists
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -78,6 +80,29 @@ func TestValidateBackup_ZeroSize(t *testing.T) {
 	}
 }
 
+func TestValidateBackup_IntegrityVerdict(t *testing.T) {
+	db := NewDatabase(nil)
+	file := filepath.Join(t.TempDir(), "garbage.db")
+	_ = os.WriteFile(file, []byte("not a sqlite database"), 0o644)
+
+	t.Run("corrupted file", func(t *testing.T) {
+		if _, err := exec.LookPath("sqlite3"); err != nil {
+			t.Skip("sqlite3 not available")
+		}
+		if err := db.ValidateBackup(file); !errors.Is(err, ErrIntegrityCheckFailed) {
+			t.Errorf("Expected ErrIntegrityCheckFailed, got %v", err)
+		}
+	})
+
+	t.Run("check cannot run", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		err := db.ValidateBackup(file)
+		if err == nil || errors.Is(err, ErrIntegrityCheckFailed) {
+			t.Errorf("Expected an error that is not ErrIntegrityCheckFailed without sqlite3, got %v", err)
+		}
+	})
+}
+
 func setupTestDB(t *testing.T) (*Database, string, string) {
 	// Create a temporary directory for test database and backups
 	tmpDir := t.TempDir()
@@ -223,6 +248,15 @@ func TestRetentionConfigUpdate(t *testing.T) {
 	assert.Equal(t, newConfig, updatedConfig)
 }
 
+func TestRetentionConfigFromDays(t *testing.T) {
+	assert.Equal(t, DefaultRetentionConfig(), RetentionConfigFromDays(0, 0, 0))
+
+	config := RetentionConfigFromDays(30, 0, 365)
+	assert.Equal(t, 30, config.DailyRetentionDays)
+	assert.Equal(t, 14, config.WeeklyRetentionDays, "unset weekly retention should keep the default")
+	assert.Equal(t, 365, config.MonthlyRetentionDays)
+}
+
 func TestDatabaseBackupCreation(t *testing.T) {
 	t.Run("CreateTimestampedBackupFromValidDatabase", func(t *testing.T) {
 		db, mainDBPath, backupDir := setupTestDB(t)
@@ -341,3 +375,112 @@ func TestBackupRestoreFlow(t *testing.T) {
 		assert.Contains(t, err.Error(), "validation failed", "Error should indicate validation failure")
 	})
 }
+
+func TestSummarize(t *testing.T) {
+	db, mainDBPath, backupDir := setupTestDB(t)
+
+	cmd := exec.Command("sqlite3", mainDBPath, `CREATE TABLE users(email TEXT); INSERT INTO users VALUES ('a@b.com'), ('c@d.com'); INSERT INTO test DEFAULT VALUES;`)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "Failed to populate test database: %s", string(output))
+
+	t.Run("CurrentDatabase", func(t *testing.T) {
+		summary, err := db.Summarize(mainDBPath)
+		require.NoError(t, err)
+		assert.Greater(t, summary.Size, int64(0))
+		assert.False(t, summary.ModTime.IsZero())
+		assert.True(t, summary.CreatedAt.IsZero(), "Only backups carry an embedded creation time")
+		assert.Equal(t, []TableCount{{Table: "test", Rows: 1}, {Table: "users", Rows: 2}}, summary.RowCounts)
+	})
+
+	t.Run("BackupIncludesCreationTime", func(t *testing.T) {
+		db.clock = fixedClock{t: time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)}
+		backupPath, err := db.BackupDatabase(mainDBPath, backupDir)
+		require.NoError(t, err)
+
+		summary, err := db.Summarize(backupPath)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC), summary.CreatedAt)
+		assert.Len(t, summary.RowCounts, 2)
+	})
+
+	t.Run("UnreadableDatabaseHasNoRowCounts", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "garbage.db")
+		require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o644))
+
+		summary, err := db.Summarize(path)
+		require.NoError(t, err)
+		assert.Nil(t, summary.RowCounts)
+	})
+
+	t.Run("MissingDatabase", func(t *testing.T) {
+		_, err := db.Summarize(filepath.Join(t.TempDir(), "missing.db"))
+		assert.Error(t, err)
+	})
+}
+
+func TestEnsureSQLiteInstalled_SkipInstall(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	db := NewDatabase(logger)
+
+	t.Setenv("SKIP_SQLITE_INSTALL", "1")
+	t.Setenv("PATH", t.TempDir())
+
+	err := db.EnsureSQLiteInstalled()
+	require.Error(t, err, "Should fail instead of installing when sqlite3 is missing")
+	assert.Contains(t, err.Error(), "SKIP_SQLITE_INSTALL")
+}
+
+func TestVerifyBackups(t *testing.T) {
+	db, dbPath, backupDir := setupTestDB(t)
+
+	validPath, err := db.BackupDatabase(dbPath, backupDir)
+	require.NoError(t, err)
+	corruptPath := filepath.Join(backupDir, "backup_20200101_030000.db")
+	require.NoError(t, os.WriteFile(corruptPath, []byte("not a sqlite database"), 0o644))
+
+	backups, err := db.ListBackups(backupDir)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+
+	statuses := db.VerifyBackups(backups)
+	require.Len(t, statuses, 2)
+	byPath := map[string]BackupStatus{}
+	for _, status := range statuses {
+		byPath[status.Path] = status
+	}
+	assert.True(t, byPath[validPath].Valid)
+	assert.Greater(t, byPath[validPath].Size, int64(0))
+	assert.False(t, byPath[corruptPath].Valid)
+	assert.NotEmpty(t, byPath[corruptPath].Error)
+
+	// The JSON field names are a scripting interface
+	encoded, err := json.Marshal(byPath[corruptPath])
+	require.NoError(t, err)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	for _, key := range []string{"name", "path", "type", "created_at", "size_bytes", "valid", "error"} {
+		assert.Contains(t, fields, key)
+	}
+}
+
+func TestSelfTestBackupRestore(t *testing.T) {
+	db, dbPath, _ := setupTestDB(t)
+	cmd := exec.Command("sqlite3", dbPath, `INSERT INTO test DEFAULT VALUES; INSERT INTO test DEFAULT VALUES;`)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "Failed to seed test database: %s", string(output))
+	before, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+
+	workDir := t.TempDir()
+	report, err := db.SelfTestBackupRestore(dbPath, workDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(workDir, "restored.db"), report.RestoredPath)
+	assert.Equal(t, []TableCount{{Table: "test", Rows: 2}}, report.RowCounts)
+
+	after, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "The live database must not be modified")
+
+	_, err = db.SelfTestBackupRestore(filepath.Join(workDir, "missing.db"), t.TempDir())
+	assert.Error(t, err, "A missing database should fail the self-test")
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+)
+
+// BackupRestoreReport describes a successful backup and restore self-test
+type BackupRestoreReport struct {
+	BackupPath   string       `json:"backup_path"`
+	RestoredPath string       `json:"restored_path"`
+	RowCounts    []TableCount `json:"row_counts,omitempty"` // Of the restored copy
+}
+
+// SelfTestBackupRestore exercises the recovery path on a copy of dbPath: it
+// backs the database up into workDir, validates the backup, restores it to a
+// separate file in workDir and checks the restored copy's integrity and row
+// counts. The live database is only read.
+func (d *Database) SelfTestBackupRestore(dbPath, workDir string) (BackupRestoreReport, error) {
+	backupPath, err := d.BackupDatabase(dbPath, filepath.Join(workDir, "backups"))
+	if err != nil {
+		return BackupRestoreReport{}, fmt.Errorf("backup: %w", err)
+	}
+	if err := d.ValidateBackup(backupPath); err != nil {
+		return BackupRestoreReport{}, fmt.Errorf("validate backup: %w", err)
+	}
+	backupCounts, err := d.countRows(backupPath)
+	if err != nil {
+		return BackupRestoreReport{}, fmt.Errorf("count rows in backup: %w", err)
+	}
+
+	report := BackupRestoreReport{
+		BackupPath:   backupPath,
+		RestoredPath: filepath.Join(workDir, "restored.db"),
+	}
+	if err := d.RestoreDatabase(report.RestoredPath, backupPath); err != nil {
+		return report, fmt.Errorf("restore: %w", err)
+	}
+	if err := d.ValidateBackup(report.RestoredPath); err != nil {
+		return report, fmt.Errorf("validate restored copy: %w", err)
+	}
+	report.RowCounts, err = d.countRows(report.RestoredPath)
+	if err != nil {
+		return report, fmt.Errorf("count rows in restored copy: %w", err)
+	}
+	if !reflect.DeepEqual(backupCounts, report.RowCounts) {
+		return report, fmt.Errorf("restored copy has rows %v, backup had %v", report.RowCounts, backupCounts)
+	}
+	return report, nil
+}
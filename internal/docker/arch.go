@@ -0,0 +1,123 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"infinity-metrics-installer/internal/httpclient"
+)
+
+// hostArchitecture is the architecture images must provide, overridden in tests
+var hostArchitecture = runtime.GOARCH
+
+// ImagePlatforms returns the platforms an image provides according to its
+// remote manifest: every entry of a multi-arch index, or the single platform
+// of a plain image. In offline mode the registry is not contacted and the
+// platform of the local image is returned instead.
+func (d *Docker) ImagePlatforms(image string) ([]v1.Platform, error) {
+	if d.offline {
+		return d.localImagePlatforms(image)
+	}
+	ref, err := d.parseReference(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpclient.Timeout())
+	defer cancel()
+
+	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(httpclient.Transport()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image descriptor: %w", err)
+	}
+
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image index: %w", err)
+		}
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image index: %w", err)
+		}
+		var platforms []v1.Platform
+		for _, entry := range manifest.Manifests {
+			// Build attestations are listed with an unknown/unknown platform
+			if entry.Platform != nil && entry.Platform.Architecture != "unknown" {
+				platforms = append(platforms, *entry.Platform)
+			}
+		}
+		return platforms, nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image manifest: %w", err)
+	}
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image config: %w", err)
+	}
+	return []v1.Platform{{OS: configFile.OS, Architecture: configFile.Architecture, Variant: configFile.Variant}}, nil
+}
+
+// localImagePlatforms returns the platform of the local copy of image
+func (d *Docker) localImagePlatforms(image string) ([]v1.Platform, error) {
+	out, err := d.RunCommand("image", "inspect", image, "--format", "{{.Os}} {{.Architecture}}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect local image: %w", err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected image inspect output %q", strings.TrimSpace(out))
+	}
+	return []v1.Platform{{OS: fields[0], Architecture: fields[1]}}, nil
+}
+
+// CheckImageArchitecture returns an error when the image has no linux variant
+// for the host architecture, which would otherwise surface as a crash loop
+// after deploy
+func (d *Docker) CheckImageArchitecture(image string) error {
+	platforms, err := d.ImagePlatforms(image)
+	if err != nil {
+		return err
+	}
+	return checkPlatforms(image, platforms)
+}
+
+// checkPlatforms returns an error unless platforms include linux on the host architecture
+func checkPlatforms(image string, platforms []v1.Platform) error {
+	for _, platform := range platforms {
+		if platform.OS == "linux" && platform.Architecture == hostArchitecture {
+			return nil
+		}
+	}
+
+	provided := make([]string, len(platforms))
+	for idx, platform := range platforms {
+		provided[idx] = platform.String()
+	}
+	return fmt.Errorf("image %s does not provide linux/%s (available: %s)", image, hostArchitecture, strings.Join(provided, ", "))
+}
+
+// warnImageArchitectures warns about images that cannot run on this host.
+// Registries that cannot be reached are only logged at debug level since the
+// pull that follows reports those problems.
+func (d *Docker) warnImageArchitectures(images ...string) {
+	for _, image := range images {
+		platforms, err := d.ImagePlatforms(image)
+		if err != nil {
+			d.logger.Debug("Could not check the architecture of %s: %v", image, err)
+			continue
+		}
+		if err := checkPlatforms(image, platforms); err != nil {
+			d.logger.Warn("%v: the container will fail to start on this host, use an image built for %s", err, hostArchitecture)
+		}
+	}
+}
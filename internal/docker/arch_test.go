@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// pushImage pushes a single-arch image for arch to the test registry and returns its reference
+func pushImage(t *testing.T, host, repo, arch string) string {
+	t.Helper()
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err = mutate.ConfigFile(img, &v1.ConfigFile{OS: "linux", Architecture: arch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := host + "/" + repo
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	return image
+}
+
+func TestCheckImageArchitecture(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	original := hostArchitecture
+	hostArchitecture = "amd64"
+	defer func() { hostArchitecture = original }()
+
+	d := &Docker{logger: testLogger(t)}
+
+	t.Run("SingleArchMatch", func(t *testing.T) {
+		image := pushImage(t, host, "app:amd64", "amd64")
+		if err := d.CheckImageArchitecture(image); err != nil {
+			t.Errorf("expected amd64 image to pass, got %v", err)
+		}
+	})
+
+	t.Run("SingleArchMismatch", func(t *testing.T) {
+		image := pushImage(t, host, "app:arm64", "arm64")
+		err := d.CheckImageArchitecture(image)
+		if err == nil || !strings.Contains(err.Error(), "linux/arm64") {
+			t.Errorf("expected mismatch listing linux/arm64, got %v", err)
+		}
+	})
+
+	t.Run("MultiArchIndex", func(t *testing.T) {
+		var index v1.ImageIndex = empty.Index
+		for _, arch := range []string{"amd64", "arm64"} {
+			img, err := random.Image(64, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			index = mutate.AppendManifests(index, mutate.IndexAddendum{
+				Add:        img,
+				Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: arch}},
+			})
+		}
+		image := host + "/app:multi"
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.WriteIndex(ref, index); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := d.CheckImageArchitecture(image); err != nil {
+			t.Errorf("expected index with amd64 to pass, got %v", err)
+		}
+		hostArchitecture = "riscv64"
+		defer func() { hostArchitecture = "amd64" }()
+		if err := d.CheckImageArchitecture(image); err == nil {
+			t.Error("expected index without riscv64 to fail")
+		}
+	})
+
+	t.Run("WarnsOnMismatch", func(t *testing.T) {
+		var buf bytes.Buffer
+		d.logger.SetOutput(&buf)
+		d.warnImageArchitectures(pushImage(t, host, "app:wrong", "arm64"), host+"/app:missing")
+		if !strings.Contains(buf.String(), "does not provide linux/amd64") {
+			t.Errorf("expected mismatch warning, got %q", buf.String())
+		}
+		if strings.Contains(buf.String(), "app:missing") {
+			t.Errorf("unreachable images should only be logged at debug level, got %q", buf.String())
+		}
+	})
+}
+
+func TestImagePlatformsOffline(t *testing.T) {
+	original := hostArchitecture
+	hostArchitecture = "amd64"
+	defer func() { hostArchitecture = original }()
+
+	d := &Docker{logger: testLogger(t), offline: true}
+	// The registry does not resolve, only the local image can be checked
+	image := "registry.invalid/app:v1"
+
+	argsFile := fakeDockerBinary(t, "linux arm64", 0)
+	var buf bytes.Buffer
+	d.logger.SetOutput(&buf)
+	d.warnImageArchitectures(image)
+	if !strings.Contains(buf.String(), "does not provide linux/amd64") {
+		t.Errorf("expected mismatch warning from the local image, got %q", buf.String())
+	}
+	args, _ := os.ReadFile(argsFile)
+	if !strings.HasPrefix(string(args), "image inspect "+image) {
+		t.Errorf("docker args = %q, want the local image inspected", args)
+	}
+
+	fakeDockerBinary(t, "Error: No such image", 1)
+	if _, err := d.ImagePlatforms(image); err == nil {
+		t.Error("expected an error for an image that is not loaded")
+	}
+}
@@ -0,0 +1,64 @@
+package docker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// caddyfileSamples are representative configurations the embedded template
+// must render a valid Caddyfile for
+var caddyfileSamples = []struct {
+	name string
+	env  string
+	data config.ConfigData
+}{
+	{"Production", "", config.ConfigData{Domain: "analytics.example.com"}},
+	{"ProductionWithAdminUser", "", config.ConfigData{Domain: "analytics.example.com", User: "admin@example.com"}},
+	{"InternalCA", "test", config.ConfigData{Domain: "localhost"}},
+	{"InternalHost", "test", config.ConfigData{Domain: "metrics.internal"}},
+	{"InternalCAOption", "", config.ConfigData{Domain: "metrics.internal", TLSInternal: true}},
+	{"CustomCertificate", "", config.ConfigData{Domain: "analytics.example.com", TLSCertPath: "/etc/ssl/site.crt", TLSKeyPath: "/etc/ssl/site.key"}},
+	{"Maintenance", "", config.ConfigData{Domain: "analytics.example.com", MaintenanceMode: true}},
+	{"GlobalOptions", "", config.ConfigData{Domain: "analytics.example.com", CaddyGlobalOptions: `debug\nservers {\n  protocols h1 h2\n}`}},
+}
+
+// TestCaddyfileTemplateSamples renders every sample and, when a caddy binary
+// is on PATH, parses it with `caddy adapt`. `caddy validate` would also
+// provision the log files and certificates, whose container paths do not exist
+// on the test host; adapting catches unknown directives and syntax errors.
+func TestCaddyfileTemplateSamples(t *testing.T) {
+	caddy, lookErr := exec.LookPath("caddy")
+	for _, sample := range caddyfileSamples {
+		t.Run(sample.name, func(t *testing.T) {
+			t.Setenv("ENV", sample.env)
+			d := &Docker{logger: testLogger(t)}
+			caddyfile, err := d.RenderCaddyfile(sample.data)
+			if err != nil {
+				t.Fatalf("RenderCaddyfile error: %v", err)
+			}
+			if strings.Contains(caddyfile, "<no value>") {
+				t.Errorf("Caddyfile references a field the template data does not have:\n%s", caddyfile)
+			}
+			if open, closed := strings.Count(caddyfile, "{"), strings.Count(caddyfile, "}"); open != closed {
+				t.Errorf("Caddyfile has %d opening and %d closing braces:\n%s", open, closed, caddyfile)
+			}
+
+			if lookErr != nil {
+				t.Skip("caddy not installed, skipping caddy adapt")
+			}
+			path := filepath.Join(t.TempDir(), "Caddyfile")
+			if err := os.WriteFile(path, []byte(caddyfile), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			out, err := exec.Command(caddy, "adapt", "--config", path, "--adapter", "caddyfile").CombinedOutput()
+			if err != nil {
+				t.Errorf("caddy adapt rejected the Caddyfile: %v\n%s\n%s", err, out, caddyfile)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package docker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"infinity-metrics-installer/internal/httpclient"
+)
+
+// CertificateExpiry dials the domain over TLS and returns when the served certificate expires
+func CertificateExpiry(domain string) (time.Time, error) {
+	return certificateExpiry(net.JoinHostPort(domain, "443"), domain)
+}
+
+func certificateExpiry(addr, domain string) (time.Time, error) {
+	network := "tcp"
+	if httpclient.ForceIPv4() {
+		network = "tcp4"
+	}
+	dialer := &net.Dialer{Timeout: httpclient.Timeout()}
+	conn, err := tls.DialWithDialer(dialer, network, addr, &tls.Config{
+		ServerName: domain,
+		// The test environment serves a certificate from Caddy's internal CA
+		InsecureSkipVerify: os.Getenv("ENV") == "test",
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("TLS connection to %s failed: %w", domain, err)
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return time.Time{}, fmt.Errorf("%s did not present a certificate", domain)
+	}
+	return certs[0].NotAfter, nil
+}
+
+// RenewCertificate forces Caddy to reload its configuration, which makes it
+// re-run certificate maintenance and renew certificates that are due
+func (d *Docker) RenewCertificate() error {
+	if !d.IsRunning(CaddyName) {
+		return fmt.Errorf("container %s is not running", CaddyName)
+	}
+	d.logger.Info("Forcing Caddy to reload and re-check certificates")
+	if _, err := d.RunCommand("exec", CaddyName, "caddy", "reload", "--config", "/etc/caddy/Caddyfile", "--force"); err != nil {
+		return fmt.Errorf("caddy reload failed: %w", err)
+	}
+	return nil
+}
+
+// Certificate authorities reported by CertificateStatus
+const (
+	AuthorityLetsEncrypt        = "Let's Encrypt (production)"
+	AuthorityLetsEncryptStaging = "Let's Encrypt (staging)"
+	AuthorityZeroSSL            = "ZeroSSL"
+	AuthorityCaddyInternal      = "Caddy internal CA (self-signed)"
+	AuthorityOther              = "other"
+)
+
+// CertStatus describes the certificate a domain currently serves
+type CertStatus struct {
+	Subject   string
+	DNSNames  []string
+	Issuer    string
+	Authority string
+	NotBefore time.Time
+	NotAfter  time.Time
+	Trusted   bool  // the chain verifies against the system roots for the domain
+	VerifyErr error // why the chain is not trusted
+}
+
+// CertificateStatus dials the domain over TLS and describes the served
+// certificate. Untrusted certificates, such as Caddy's internal CA on
+// localhost, are reported rather than rejected.
+func CertificateStatus(domain string) (CertStatus, error) {
+	return certificateStatus(net.JoinHostPort(domain, "443"), domain)
+}
+
+func certificateStatus(addr, domain string) (CertStatus, error) {
+	network := "tcp"
+	if httpclient.ForceIPv4() {
+		network = "tcp4"
+	}
+	dialer := &net.Dialer{Timeout: httpclient.Timeout()}
+	conn, err := tls.DialWithDialer(dialer, network, addr, &tls.Config{
+		ServerName: domain,
+		// Verified below so untrusted certificates can still be described
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return CertStatus{}, fmt.Errorf("TLS connection to %s failed: %w", domain, err)
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return CertStatus{}, fmt.Errorf("%s did not present a certificate", domain)
+	}
+	leaf := certs[0]
+	status := CertStatus{
+		Subject:   leaf.Subject.CommonName,
+		DNSNames:  leaf.DNSNames,
+		Issuer:    leaf.Issuer.String(),
+		Authority: certificateAuthority(leaf.Issuer),
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, status.VerifyErr = leaf.Verify(x509.VerifyOptions{DNSName: domain, Intermediates: intermediates})
+	status.Trusted = status.VerifyErr == nil
+	return status, nil
+}
+
+// certificateAuthority names the CA that issued a certificate from its issuer
+func certificateAuthority(issuer pkix.Name) string {
+	organization := strings.Join(issuer.Organization, " ")
+	switch {
+	case strings.Contains(organization, "(STAGING)"):
+		return AuthorityLetsEncryptStaging
+	case strings.Contains(organization, "Let's Encrypt"):
+		return AuthorityLetsEncrypt
+	case strings.Contains(organization, "ZeroSSL"):
+		return AuthorityZeroSSL
+	case strings.Contains(issuer.CommonName, "Caddy Local Authority"):
+		return AuthorityCaddyInternal
+	default:
+		return AuthorityOther
+	}
+}
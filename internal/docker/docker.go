@@ -4,10 +4,13 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"text/template"
 	"time"
 
@@ -24,14 +27,55 @@ const (
 	AppNameSecondary = "infinity-app-2"
 	MaxRetries       = 3
 	HealthCheckTries = 5
+
+	// Paths where a bring-your-own certificate is mounted in the Caddy container
+	caddyCertPath = "/etc/caddy/certs/cert.pem"
+	caddyKeyPath  = "/etc/caddy/certs/key.pem"
+
+	// defaultInstallDir is read for the Caddyfile before ApplySettings is called
+	defaultInstallDir = "/opt/infinity-metrics"
 )
 
 //go:embed templates/Caddyfile.tmpl
 var caddyfileTemplate string
 
 type Docker struct {
-	logger *logging.Logger
-	db     *database.Database
+	logger       *logging.Logger
+	db           *database.Database
+	deployPhases []PhaseTiming
+
+	insecureRegistries map[string]bool // registry hosts queried over plain HTTP
+
+	// REGISTRY_USERNAME and REGISTRY_PASSWORD, used once a pull is rate limited
+	registryUsername string
+	registryPassword string
+	registryLoggedIn bool
+
+	offline bool // OFFLINE_MODE: never pull or query a registry
+
+	// Health check and shutdown settings from .env, see ApplySettings
+	healthScheme  string
+	healthPath    string
+	healthTimeout time.Duration
+	healthTries   int
+	stopTimeout   time.Duration
+
+	installDir string // where the Caddyfile lives, defaultInstallDir until ApplySettings
+}
+
+// PhaseTiming records how long a deployment phase took
+type PhaseTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// DeployPhases returns the phase timings of the last Deploy call
+func (d *Docker) DeployPhases() []PhaseTiming {
+	return d.deployPhases
+}
+
+func (d *Docker) recordPhase(name string, start time.Time) {
+	d.deployPhases = append(d.deployPhases, PhaseTiming{Name: name, Duration: time.Since(start)})
 }
 
 func NewDocker(logger *logging.Logger, db *database.Database) *Docker {
@@ -59,8 +103,11 @@ func (d *Docker) RunCommand(args ...string) (string, error) {
 }
 
 func (d *Docker) EnsureInstalled() error {
-	if version, err := d.RunCommand("version"); err == nil {
-		d.logger.Success("Docker is installed (version: %s)", strings.TrimSpace(strings.Split(version, "\n")[0]))
+	if version, err := d.ServerVersion(); err == nil {
+		if err := CheckVersion(version); err != nil {
+			return err
+		}
+		d.logger.Success("Docker is installed (version: %s)", version)
 		return nil
 	}
 
@@ -81,32 +128,32 @@ func (d *Docker) EnsureInstalled() error {
 		}
 	}
 
-	version, err := d.RunCommand("version")
+	version, err := d.ServerVersion()
 	if err != nil {
 		return fmt.Errorf("verification failed: %w", err)
 	}
-	d.logger.InfoWithTime("Docker version: %s", strings.TrimSpace(strings.Split(version, "\n")[0]))
+	if err := CheckVersion(version); err != nil {
+		return err
+	}
+	d.logger.InfoWithTime("Docker version: %s", version)
 	return nil
 }
 
 func (d *Docker) Deploy(conf *config.Config) error {
 	data := conf.GetData()
 	dataDir := data.InstallDir
+	d.deployPhases = nil
+	d.SetInsecureRegistries(data.RegistryInsecure)
+	d.SetRegistryCredentials(data.RegistryUsername, data.RegistryPassword)
+	d.SetOffline(data.OfflineMode)
+	d.ApplySettings(data)
 
 	if d.IsRunning(CaddyName) && (d.IsRunning(AppNamePrimary) || d.IsRunning(AppNameSecondary)) {
 		return nil
 	}
 
-	for _, dir := range []string{
-		filepath.Join(dataDir, "storage"),
-		filepath.Join(dataDir, "logs"),
-		filepath.Join(dataDir, "caddy"),
-		filepath.Join(dataDir, "caddy", "config"),
-		filepath.Join(dataDir, "storage", "backups"),
-	} {
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			return fmt.Errorf("create dir %s: %w", dir, err)
-		}
+	if err := createDataDirs(data); err != nil {
+		return err
 	}
 
 	if _, err := d.RunCommand("network", "inspect", NetworkName); err != nil {
@@ -124,31 +171,47 @@ func (d *Docker) Deploy(conf *config.Config) error {
 		return fmt.Errorf("write Caddyfile: %w", err)
 	}
 
+	d.warnImageArchitectures(data.AppImage, data.CaddyImage)
+
+	phaseStart := time.Now()
 	for _, image := range []string{data.AppImage, data.CaddyImage} {
-		for i := 0; i < MaxRetries; i++ {
-			if _, err := d.RunCommand("pull", image); err == nil {
-				d.logImageDigest(image)
-				break
-			} else if i == MaxRetries-1 {
-				return fmt.Errorf("pull %s failed after %d retries: %w", image, MaxRetries, err)
-			}
-			d.logger.Warn("Pull %s failed, retrying (%d/%d)", image, i+1, MaxRetries)
-			time.Sleep(time.Duration(i+1) * 2 * time.Second)
+		if err := d.pullImage(image); err != nil {
+			return err
 		}
+		d.logImageDigest(image)
+	}
+	d.recordPhase("Image pull", phaseStart)
+
+	// CADDYFILE_TEMPLATE, CADDY_GLOBAL_OPTIONS and the TLS paths all shape the
+	// generated Caddyfile, so check it parses before starting anything
+	if err := d.validateCaddyfile(data, caddyContent); err != nil {
+		return errors.NewDockerError("validate_caddyfile", CaddyName, err)
 	}
 
 	// Deploy app first
+	phaseStart = time.Now()
 	if err := d.DeployApp(data, AppNamePrimary); err != nil {
 		return fmt.Errorf("initial app deploy failed: %w", err)
 	}
+	d.recordPhase("App start", phaseStart)
 
-	if err := d.waitForAppHealth(AppNamePrimary); err != nil {
+	phaseStart = time.Now()
+	err = d.waitForAppHealth(AppNamePrimary)
+	d.recordPhase("Health wait", phaseStart)
+	if err != nil {
 		if cleanupErr := d.StopAndRemove(AppNamePrimary); cleanupErr != nil {
 			d.logger.Error("Failed to cleanup unhealthy container %s: %v", AppNamePrimary, cleanupErr)
 		}
 		return errors.NewDockerError("health_check", AppNamePrimary, err)
 	}
+	if err := d.checkAppVolumesWritable(data, AppNamePrimary); err != nil {
+		if cleanupErr := d.StopAndRemove(AppNamePrimary); cleanupErr != nil {
+			d.logger.Error("Failed to cleanup container %s: %v", AppNamePrimary, cleanupErr)
+		}
+		return errors.NewDockerError("volume_check", AppNamePrimary, err)
+	}
 
+	phaseStart = time.Now()
 	if !d.IsRunning(CaddyName) {
 		if err := d.deployCaddy(data, caddyFile); err != nil {
 			return fmt.Errorf("deploy caddy: %w", err)
@@ -158,6 +221,7 @@ func (d *Docker) Deploy(conf *config.Config) error {
 			return fmt.Errorf("failed to ensure network for %s: %w", CaddyName, err)
 		}
 	}
+	d.recordPhase("Caddy start", phaseStart)
 
 	d.logCaddyVersion()
 	return nil
@@ -166,6 +230,10 @@ func (d *Docker) Deploy(conf *config.Config) error {
 func (d *Docker) Update(conf *config.Config) error {
 	data := conf.GetData()
 	dataDir := data.InstallDir
+	d.SetInsecureRegistries(data.RegistryInsecure)
+	d.SetRegistryCredentials(data.RegistryUsername, data.RegistryPassword)
+	d.SetOffline(data.OfflineMode)
+	d.ApplySettings(data)
 
 	if _, err := d.RunCommand("network", "inspect", NetworkName); err != nil {
 		d.logger.Info("Creating Docker network %s", NetworkName)
@@ -175,8 +243,16 @@ func (d *Docker) Update(conf *config.Config) error {
 		d.logger.Success("Network created")
 	}
 
+	// Render the Caddyfile before touching any container so a broken
+	// template aborts the update while the current deployment keeps serving
+	caddyContent, err := d.generateCaddyfile(data)
+	if err != nil {
+		return fmt.Errorf("generate Caddyfile: %w", err)
+	}
+
 	// Pull new images using the unified DockerImages struct
 	dockerImages := conf.GetDockerImages()
+	d.warnImageArchitectures(dockerImages.AppImage, dockerImages.CaddyImage)
 	for _, image := range []string{dockerImages.AppImage, dockerImages.CaddyImage} {
 		// Check if we need to pull the image
 		shouldPull, err := d.ShouldPullImage(image)
@@ -187,17 +263,11 @@ func (d *Docker) Update(conf *config.Config) error {
 
 		if shouldPull {
 			d.logger.Info("Pulling %s...", image)
-			for i := 0; i < MaxRetries; i++ {
-				if _, err := d.RunCommand("pull", image); err == nil {
-					d.logger.Success("%s pulled successfully", image)
-					d.logImageDigest(image)
-					break
-				} else if i == MaxRetries-1 {
-					return fmt.Errorf("pull %s failed after %d retries: %w", image, MaxRetries, err)
-				}
-				d.logger.Warn("Pull %s failed, retrying (%d/%d)", image, i+1, MaxRetries)
-				time.Sleep(time.Duration(i+1) * 2 * time.Second)
+			if err := d.pullImage(image); err != nil {
+				return err
 			}
+			d.logger.Success("%s pulled successfully", image)
+			d.logImageDigest(image)
 		} else {
 			d.logger.Success("Image %s is already up to date, skipping pull", image)
 			// Still log the digest for consistency in logs
@@ -205,13 +275,19 @@ func (d *Docker) Update(conf *config.Config) error {
 		}
 	}
 
-	// Determine current and new app instances
-	currentName := AppNamePrimary
-	newName := AppNameSecondary
-	if d.IsRunning(AppNameSecondary) && !d.IsRunning(AppNamePrimary) {
-		currentName, newName = AppNameSecondary, AppNamePrimary
+	// Validate before touching the app, a Caddyfile that fails to parse would
+	// otherwise make the reload fallback redeploy Caddy with it
+	if err := d.validateCaddyfile(data, caddyContent); err != nil {
+		return errors.NewDockerError("validate_caddyfile", CaddyName, err)
 	}
 
+	// Determine current and new app instances. A Caddyfile pinned by
+	// switch-slot decides, so the container serving traffic is never replaced
+	// before Caddy points away from it. A missing Caddyfile pins nothing.
+	previousCaddyfile, _ := os.ReadFile(filepath.Join(dataDir, "Caddyfile"))
+	currentName := d.currentSlot(string(previousCaddyfile))
+	newName := otherSlot(currentName)
+
 	// Deploy the new app instance
 	for i := 0; i < MaxRetries; i++ {
 		if err := d.DeployApp(data, newName); err == nil {
@@ -242,20 +318,31 @@ func (d *Docker) Update(conf *config.Config) error {
 		return errors.NewDockerError("network_connect", newName, err)
 	}
 
+	if data.MigrationCmd != "" {
+		if err := d.runMigrations(newName, data.MigrationCmd); err != nil {
+			if cleanupErr := d.StopAndRemove(newName); cleanupErr != nil {
+				d.logger.Error("Failed to cleanup container %s after failed migration: %v", newName, cleanupErr)
+			}
+			return errors.NewDockerError("migrate", newName, err)
+		}
+	}
+
 	if err := d.waitForAppHealth(newName); err != nil {
 		if cleanupErr := d.StopAndRemove(newName); cleanupErr != nil {
 			d.logger.Error("Failed to cleanup unhealthy container %s: %v", newName, cleanupErr)
 		}
 		return errors.NewDockerError("health_check", newName, err)
 	}
+	if err := d.checkAppVolumesWritable(data, newName); err != nil {
+		if cleanupErr := d.StopAndRemove(newName); cleanupErr != nil {
+			d.logger.Error("Failed to cleanup container %s: %v", newName, cleanupErr)
+		}
+		return errors.NewDockerError("volume_check", newName, err)
+	}
 
 	// Redeploy Caddy to ensure it uses the new image
 	d.logger.Info("Redeploying Caddy with new image...")
 	caddyFile := filepath.Join(dataDir, "Caddyfile")
-	caddyContent, err := d.generateCaddyfile(data)
-	if err != nil {
-		return fmt.Errorf("generate Caddyfile: %w", err)
-	}
 	if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
 		return fmt.Errorf("write Caddyfile: %w", err)
 	}
@@ -277,10 +364,16 @@ func (d *Docker) Update(conf *config.Config) error {
 	d.logCaddyVersion()
 	d.logContainerImage(newName)
 
-	// Clean up old app instance
+	// Clean up old app instance, keeping its image for rollback
+	previousImageID := d.containerImageID(currentName)
 	if cleanupErr := d.StopAndRemove(currentName); cleanupErr != nil {
 		d.logger.Error("Failed to cleanup old container %s: %v", currentName, cleanupErr)
 	}
+	keep := data.KeepImageVersions
+	if keep == 0 {
+		keep = DefaultKeepImageVersions
+	}
+	d.retainPreviousImage(data.AppImage, previousImageID, d.containerImageID(newName), keep)
 	if _, err := d.RunCommand("image", "prune", "-f"); err != nil {
 		d.logger.Warn("Failed to prune unused images: %v", err)
 	}
@@ -291,9 +384,22 @@ func (d *Docker) Update(conf *config.Config) error {
 func (d *Docker) Reload(conf *config.Config) error {
 	data := conf.GetData()
 	dataDir := data.InstallDir
+	d.ApplySettings(data)
 
 	d.logger.Info("Starting container reload with latest environment variables")
 
+	caddyContent, err := d.generateCaddyfile(data)
+	if err != nil {
+		return fmt.Errorf("generate Caddyfile: %w", err)
+	}
+
+	// Validate before restarting anything so a bad Caddyfile leaves the running site untouched
+	if d.IsRunning(CaddyName) {
+		if err := d.validateCaddyfile(data, caddyContent); err != nil {
+			return errors.NewDockerError("validate_caddyfile", CaddyName, err)
+		}
+	}
+
 	// Ensure network exists
 	if _, err := d.RunCommand("network", "inspect", NetworkName); err != nil {
 		d.logger.Info("Creating Docker network %s", NetworkName)
@@ -303,32 +409,47 @@ func (d *Docker) Reload(conf *config.Config) error {
 		d.logger.Success("Network created")
 	}
 
-	// Find which app container is running
-	currentName := ""
-	if d.IsRunning(AppNamePrimary) {
-		currentName = AppNamePrimary
+	// Find which app containers to restart. The reloaded Caddyfile proxies to
+	// both slots, so when switch-slot pinned Caddy to one, the standby is
+	// recreated too, or Caddy would balance between the new and the old .env.
+	var names []string
+	previousCaddyfile, _ := os.ReadFile(filepath.Join(dataDir, "Caddyfile"))
+	if pinned := pinnedSlot(string(previousCaddyfile)); pinned != "" {
+		if d.containerExists(otherSlot(pinned)) {
+			names = append(names, otherSlot(pinned))
+		}
+		names = append(names, pinned)
+		d.logger.Info("Caddy is pinned to %s, reloading routes to both slots again", pinned)
+	} else if d.IsRunning(AppNamePrimary) {
+		names = append(names, AppNamePrimary)
 	} else if d.IsRunning(AppNameSecondary) {
-		currentName = AppNameSecondary
+		names = append(names, AppNameSecondary)
 	} else {
 		d.logger.Warn("No app container running, will deploy primary")
-		currentName = AppNamePrimary
+		names = append(names, AppNamePrimary)
 	}
 
-	d.logger.Info("Restarting app container: %s", currentName)
-	if cleanupErr := d.StopAndRemove(currentName); cleanupErr != nil {
-		d.logger.Error("Failed to stop current container %s: %v", currentName, cleanupErr)
-	}
+	for _, currentName := range names {
+		d.logger.Info("Restarting app container: %s", currentName)
+		if cleanupErr := d.StopAndRemove(currentName); cleanupErr != nil {
+			d.logger.Error("Failed to stop current container %s: %v", currentName, cleanupErr)
+		}
 
-	// Deploy the app container
-	if err := d.DeployApp(data, currentName); err != nil {
-		return fmt.Errorf("failed to redeploy app container %s: %w", currentName, err)
-	}
+		// Deploy the app container
+		if err := d.DeployApp(data, currentName); err != nil {
+			return fmt.Errorf("failed to redeploy app container %s: %w", currentName, err)
+		}
 
-	if err := d.waitForAppHealth(currentName); err != nil {
-		if cleanupErr := d.StopAndRemove(currentName); cleanupErr != nil {
-			d.logger.Error("Failed to cleanup unhealthy container %s: %v", currentName, cleanupErr)
+		if err := d.waitForAppHealth(currentName); err != nil {
+			if cleanupErr := d.StopAndRemove(currentName); cleanupErr != nil {
+				d.logger.Error("Failed to cleanup unhealthy container %s: %v", currentName, cleanupErr)
+			}
+			return errors.NewDockerError("health_check", currentName, err)
+		}
+		if err := d.checkAppVolumesWritable(data, currentName); err != nil {
+			// There is no other container to serve from, so keep this one up
+			return errors.NewDockerError("volume_check", currentName, err)
 		}
-		return errors.NewDockerError("health_check", currentName, err)
 	}
 
 	// Restart Caddy container
@@ -336,10 +457,6 @@ func (d *Docker) Reload(conf *config.Config) error {
 		d.logger.Info("Restarting Caddy container")
 
 		caddyFile := filepath.Join(dataDir, "Caddyfile")
-		caddyContent, err := d.generateCaddyfile(data)
-		if err != nil {
-			return fmt.Errorf("generate Caddyfile: %w", err)
-		}
 
 		// Write the Caddyfile
 		if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
@@ -367,50 +484,181 @@ func (d *Docker) Reload(conf *config.Config) error {
 	return nil
 }
 
-func (d *Docker) deployCaddy(data config.ConfigData, caddyFile string) error {
-	if cleanupErr := d.StopAndRemove(CaddyName); cleanupErr != nil {
-		// Only log if it's not a "no such container" error
-		if !strings.Contains(cleanupErr.Error(), "No such container") {
-			d.logger.Warn("Failed to cleanup existing Caddy container: %v", cleanupErr)
+// RedeployCaddy recreates the Caddy container from data.CaddyImage without
+// touching the app. An in-place `caddy reload` only swaps the configuration,
+// so this is how a new Caddy image is picked up.
+func (d *Docker) RedeployCaddy(data config.ConfigData) error {
+	caddyContent, err := d.generateCaddyfile(data)
+	if err != nil {
+		return fmt.Errorf("generate Caddyfile: %w", err)
+	}
+
+	// Validate and pull with the new image while the old container keeps serving
+	if err := d.validateCaddyfile(data, caddyContent); err != nil {
+		return errors.NewDockerError("validate_caddyfile", CaddyName, err)
+	}
+	d.warnImageArchitectures(data.CaddyImage)
+	d.SetRegistryCredentials(data.RegistryUsername, data.RegistryPassword)
+	d.SetOffline(data.OfflineMode)
+	if err := d.pullImage(data.CaddyImage); err != nil {
+		return err
+	}
+	d.logImageDigest(data.CaddyImage)
+
+	if _, err := d.RunCommand("network", "inspect", NetworkName); err != nil {
+		d.logger.Info("Creating Docker network %s", NetworkName)
+		if _, err := d.RunCommand("network", "create", NetworkName); err != nil {
+			return fmt.Errorf("create network: %w", err)
 		}
 	}
-	_, err := d.RunCommand("run", "-d",
-		"--name", CaddyName,
-		"--network", NetworkName,
-		"--pull", "always",
-		"-p", "80:80", "-p", "443:443", "-p", "443:443/udp",
-		"-v", caddyFile+":/etc/caddy/Caddyfile:ro",
-		"-v", filepath.Join(data.InstallDir, "caddy")+":/data",
-		"-v", filepath.Join(data.InstallDir, "caddy", "config")+":/config",
-		"-v", filepath.Join(data.InstallDir, "logs")+":/data/logs",
-		"-e", "DOMAIN="+data.Domain,
-		"--memory=256m",
-		"--restart", "unless-stopped",
-		data.CaddyImage,
-	)
+
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
+		return fmt.Errorf("write Caddyfile: %w", err)
+	}
+
+	d.logger.Info("Recreating Caddy container with %s", data.CaddyImage)
+	if err := d.deployCaddy(data, caddyFile); err != nil {
+		return fmt.Errorf("redeploy caddy: %w", err)
+	}
+	d.logger.Success("Caddy redeployed with %s", data.CaddyImage)
+	return nil
+}
+
+// validateCaddyfile runs `caddy validate` on content in a throwaway Caddy
+// container with the same image, environment and certificate mounts
+func (d *Docker) validateCaddyfile(data config.ConfigData, content string) error {
+	candidate, err := os.CreateTemp(data.InstallDir, "Caddyfile.validate-*")
 	if err != nil {
+		return fmt.Errorf("write Caddyfile for validation: %w", err)
+	}
+	defer os.Remove(candidate.Name())
+	if _, err := candidate.WriteString(content); err != nil {
+		candidate.Close()
+		return fmt.Errorf("write Caddyfile for validation: %w", err)
+	}
+	if err := candidate.Close(); err != nil {
+		return fmt.Errorf("write Caddyfile for validation: %w", err)
+	}
+	// CreateTemp uses 0600 and Caddy may not run as root inside the container
+	if err := os.Chmod(candidate.Name(), 0o644); err != nil {
+		return fmt.Errorf("write Caddyfile for validation: %w", err)
+	}
+
+	relabel := selinuxRelabel(data)
+	args := []string{"run", "--rm",
+		"-v", bindMount(candidate.Name(), "/etc/caddy/Caddyfile", relabel, "ro"),
+		"-e", "DOMAIN=" + data.Domain,
+	}
+	if data.TLSCertPath != "" {
+		args = append(args,
+			"-v", bindMount(data.TLSCertPath, caddyCertPath, false, "ro"),
+			"-v", bindMount(data.TLSKeyPath, caddyKeyPath, false, "ro"),
+		)
+	}
+	args = append(args, data.CaddyImage, "caddy", "validate", "--config", "/etc/caddy/Caddyfile", "--adapter", "caddyfile")
+
+	d.logger.Info("Validating new Caddyfile")
+	if _, err := d.RunCommand(args...); err != nil {
+		return fmt.Errorf("new Caddyfile is invalid, keeping the running configuration: %w", err)
+	}
+	return nil
+}
+
+func (d *Docker) deployCaddy(data config.ConfigData, caddyFile string) error {
+	if err := d.removeExisting(CaddyName); err != nil {
+		return err
+	}
+	d.warnUnlabeledMount(data, "TLS_CERT_PATH", data.TLSCertPath)
+	d.warnUnlabeledMount(data, "TLS_KEY_PATH", data.TLSKeyPath)
+	if _, err := d.RunCommand(caddyRunArgs(data, caddyFile)...); err != nil {
 		return fmt.Errorf("start caddy: %w", err)
 	}
-	_, err = d.RunCommand("exec", CaddyName, "chmod", "-R", "755", "/data")
-	if err != nil {
+	if _, err := d.RunCommand("exec", CaddyName, "chmod", "-R", "755", "/data"); err != nil {
 		return fmt.Errorf("failed to set permissions on /data directory in %s container: %w", CaddyName, err)
 	}
 	return nil
 }
 
+// publishArgs publishes the HTTP, HTTPS and HTTP/3 ports on the host
+// addresses of the LISTEN_STACK address family, or on Docker's defaults
+func publishArgs(stack string) []string {
+	var hosts []string
+	switch stack {
+	case "ipv4":
+		hosts = []string{"0.0.0.0:"}
+	case "ipv6":
+		hosts = []string{"[::]:"}
+	case "dual":
+		hosts = []string{"0.0.0.0:", "[::]:"}
+	default:
+		hosts = []string{""}
+	}
+	var args []string
+	for _, host := range hosts {
+		args = append(args, "-p", host+"80:80", "-p", host+"443:443", "-p", host+"443:443/udp")
+	}
+	return args
+}
+
+// caddyRunArgs builds the docker run arguments of the Caddy container
+func caddyRunArgs(data config.ConfigData, caddyFile string) []string {
+	args := []string{"run", "-d",
+		"--name", CaddyName,
+		"--label", ManagedLabel + "=true",
+		"--network", NetworkName,
+		"--pull", pullPolicy(data),
+	}
+	args = append(args, publishArgs(data.ListenStack)...)
+	relabel := selinuxRelabel(data)
+	args = append(args, []string{
+		"-v", bindMount(caddyFile, "/etc/caddy/Caddyfile", relabel, "ro"),
+		"-v", bindMount(filepath.Join(data.InstallDir, "caddy"), "/data", relabel),
+		"-v", bindMount(filepath.Join(data.InstallDir, "caddy", "config"), "/config", relabel),
+		"-v", bindMount(filepath.Join(data.InstallDir, "logs"), "/data/logs", relabel),
+		"-e", "DOMAIN=" + data.Domain,
+		"--memory=256m",
+		"--restart", "unless-stopped",
+	}...)
+	if data.CaddyCPULimit != "" {
+		args = append(args, "--cpus", data.CaddyCPULimit)
+	}
+	if data.TLSCertPath != "" {
+		args = append(args,
+			"-v", bindMount(data.TLSCertPath, caddyCertPath, false, "ro"),
+			"-v", bindMount(data.TLSKeyPath, caddyKeyPath, false, "ro"),
+		)
+	}
+	return append(args, data.CaddyImage)
+}
+
 func (d *Docker) DeployApp(data config.ConfigData, name string) error {
-	if cleanupErr := d.StopAndRemove(name); cleanupErr != nil {
-		// Only log if it's not a "no such container" error
-		if !strings.Contains(cleanupErr.Error(), "No such container") {
-			d.logger.Warn("Failed to cleanup existing container %s: %v", name, cleanupErr)
+	if err := d.removeExisting(name); err != nil {
+		return err
+	}
+	if data.ContainerUser != "" {
+		if err := d.chownAppVolumes(data); err != nil {
+			return fmt.Errorf("prepare volumes for %s: %w", name, err)
 		}
 	}
+	d.warnUnlabeledMount(data, "DB_STORAGE_PATH", data.DBStoragePath)
+
+	if _, err := d.RunCommand(appRunArgs(data, name)...); err != nil {
+		return fmt.Errorf("deploy %s: %w", name, err)
+	}
+	return nil
+}
+
+// appRunArgs builds the docker run arguments of the app container name
+func appRunArgs(data config.ConfigData, name string) []string {
+	relabel := selinuxRelabel(data)
 	args := []string{"run", "-d",
 		"--name", name,
+		"--label", ManagedLabel + "=true",
 		"--network", NetworkName,
-		"--pull", "always",
-		"-v", filepath.Join(data.InstallDir, "storage") + ":/app/storage",
-		"-v", filepath.Join(data.InstallDir, "logs") + ":/app/logs",
+		"--pull", pullPolicy(data),
+		"-v", bindMount(data.StorageDir(), "/app/storage", relabel && data.DBStoragePath == ""),
+		"-v", bindMount(filepath.Join(data.InstallDir, "logs"), "/app/logs", relabel),
 		"-e", "INFINITY_METRICS_LOG_LEVEL=debug",
 		"-e", "INFINITY_METRICS_APP_PORT=8080",
 		"-e", "INFINITY_METRICS_DOMAIN=" + data.Domain,
@@ -419,12 +667,98 @@ func (d *Docker) DeployApp(data config.ConfigData, name string) error {
 		"-e", "INFINITY_METRICS_LICENSE_KEY=" + data.LicenseKey,
 		"--memory=512m",
 		"--restart", "unless-stopped",
-		data.AppImage,
 	}
-	
-	_, err := d.RunCommand(args...)
+	if data.ContainerUser != "" {
+		args = append(args, "--user", data.ContainerUser)
+	}
+	if data.AppEnvFile != "" {
+		args = append(args, "--env-file", data.AppEnvFile)
+	}
+	if data.AppCPULimit != "" {
+		args = append(args, "--cpus", data.AppCPULimit)
+	}
+	return append(args, data.AppImage)
+}
+
+// chownAppVolumes hands the bind-mounted storage and logs directories to
+// CONTAINER_USER so a non-root app container can still write to them. Only
+// entries owned by someone else are changed, so redeploys do not rewrite the
+// whole tree. Backups inside the storage directory stay root's, readable by
+// root only.
+func (d *Docker) chownAppVolumes(data config.ConfigData) error {
+	uid, gid, err := parseContainerUser(data.ContainerUser)
 	if err != nil {
-		return fmt.Errorf("deploy %s: %w", name, err)
+		return err
+	}
+
+	backupDir := filepath.Clean(data.BackupPath)
+	for _, dir := range []string{
+		data.StorageDir(),
+		filepath.Join(data.InstallDir, "logs"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create dir %s: %w", dir, err)
+		}
+		d.logger.Debug("Setting ownership of %s to %d:%d", dir, uid, gid)
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if data.BackupPath != "" && path == backupDir {
+				if err := os.Chmod(path, 0o700); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) == uid && int(stat.Gid) == gid {
+				return nil
+			}
+			return os.Lchown(path, uid, gid)
+		})
+		if err != nil {
+			return fmt.Errorf("chown %s to %s: %w", dir, data.ContainerUser, err)
+		}
+	}
+	return nil
+}
+
+// parseContainerUser splits a uid:gid string into numeric IDs
+func parseContainerUser(user string) (int, int, error) {
+	parts := strings.SplitN(user, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid container user %q: expected uid:gid", user)
+	}
+	uid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid uid in container user %q: %w", user, err)
+	}
+	gid, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid gid in container user %q: %w", user, err)
+	}
+	return uid, gid, nil
+}
+
+// removeExisting stops and removes any container called name before a
+// docker run reuses the name. IsRunning misses a container that crashed and
+// exited, but it still holds the name, so a failed removal is only tolerated
+// when no container, running or not, is left behind.
+func (d *Docker) removeExisting(name string) error {
+	if d.containerExists(name) && !d.IsRunning(name) {
+		d.logger.Info("Removing exited container %s before starting a new one", name)
+	}
+	if cleanupErr := d.StopAndRemove(name); cleanupErr != nil {
+		if d.containerExists(name) {
+			return fmt.Errorf("remove existing container %s: %w", name, cleanupErr)
+		}
+		// Only log if it's not a "no such container" error
+		if !strings.Contains(cleanupErr.Error(), "No such container") {
+			d.logger.Warn("Failed to cleanup existing container %s: %v", name, cleanupErr)
+		}
 	}
 	return nil
 }
@@ -436,8 +770,8 @@ func (d *Docker) StopAndRemove(name string) error {
 	
 	var stopErr, removeErr error
 	
-	// Attempt to stop the container
-	if _, err := d.RunCommand("stop", name); err != nil {
+	// Attempt to stop the container, giving it time to shut down cleanly
+	if _, err := d.RunCommand(stopArgs(name, StopTimeout(d.stopTimeout))...); err != nil {
 		// Only warn if it's not a "no such container" error
 		if !strings.Contains(err.Error(), "No such container") {
 			d.logger.Warn("Failed to stop container %s: %v", name, err)
@@ -470,13 +804,24 @@ func (d *Docker) IsRunning(name string) bool {
 	return err == nil && strings.TrimSpace(out) != ""
 }
 
+// ActiveAppContainer returns the name of the running app container Caddy
+// routes to. When switch-slot pinned Caddy to a slot that is not running, the
+// standby is not reported, so the watchdog recovers the pinned slot instead.
+func (d *Docker) ActiveAppContainer() (string, error) {
+	name := d.currentSlot(d.caddyfile())
+	if d.IsRunning(name) {
+		return name, nil
+	}
+	if pinned := pinnedSlot(d.caddyfile()); pinned != "" {
+		return "", fmt.Errorf("app container %s, which Caddy is pinned to, is not running", pinned)
+	}
+	return "", fmt.Errorf("no running app container found")
+}
+
 func (d *Docker) ExecuteCommand(command ...string) error {
-	containerName := AppNamePrimary
-	if !d.IsRunning(containerName) {
-		containerName = AppNameSecondary
-		if !d.IsRunning(containerName) {
-			return fmt.Errorf("no running app container found")
-		}
+	containerName, err := d.ActiveAppContainer()
+	if err != nil {
+		return err
 	}
 
 	args := []string{"exec", containerName}
@@ -500,6 +845,37 @@ func (d *Docker) ExecuteCommand(command ...string) error {
 	return nil
 }
 
+// LogOptions controls which container log lines StreamLogs prints
+type LogOptions struct {
+	Tail   string // Number of lines from the end, or "all"
+	Since  string // Duration (e.g. 10m) or timestamp passed to docker logs --since
+	Follow bool
+}
+
+// StreamLogs writes a container's logs straight to stdout/stderr
+func (d *Docker) StreamLogs(name string, opts LogOptions) error {
+	args := []string{"logs"}
+	if opts.Tail != "" {
+		args = append(args, "--tail", opts.Tail)
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+	args = append(args, name)
+
+	d.logger.Debug("Running docker %s", strings.Join(args, " "))
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.NewDockerError("logs", name, err)
+	}
+	return nil
+}
+
 func (d *Docker) ensureNetworkConnected(container, network string) error {
 	output, err := d.RunCommand("network", "inspect", network, "--format", "{{range .Containers}}{{.Name}}{{end}}")
 	if err != nil {
@@ -533,10 +909,28 @@ func (d *Docker) ensureNetworkConnected(container, network string) error {
 	return nil
 }
 
+// RenderCaddyfile renders the Caddyfile a deploy would write for data, from
+// the embedded or CADDYFILE_TEMPLATE template, without writing or validating
+// it, so template changes can be checked without a deploy
+func (d *Docker) RenderCaddyfile(data config.ConfigData) (string, error) {
+	return d.generateCaddyfile(data)
+}
+
 func (d *Docker) generateCaddyfile(data config.ConfigData) (string, error) {
+	return d.generateCaddyfileFor(data, AppNamePrimary, AppNameSecondary)
+}
+
+// generateCaddyfileFor renders the Caddyfile proxying to the given app containers
+func (d *Docker) generateCaddyfileFor(data config.ConfigData, upstreams ...string) (string, error) {
 	env := os.Getenv("ENV")
 	var tlsConfig string
-	if env == "test" {
+	if data.TLSCertPath != "" {
+		d.logger.Info("Using provided certificate %s", data.TLSCertPath)
+		tlsConfig = caddyCertPath + " " + caddyKeyPath
+	} else if data.TLSInternal {
+		d.logger.Info("Using a certificate from Caddy's internal CA (TLS_INTERNAL)")
+		tlsConfig = "internal"
+	} else if env == "test" {
 		d.logger.Info("Using self-signed certificate for test environment")
 		tlsConfig = "internal"
 	} else {
@@ -551,15 +945,40 @@ func (d *Docker) generateCaddyfile(data config.ConfigData) (string, error) {
 		}
 	}
 
+	maintenancePage, err := d.maintenancePageContent(data)
+	if err != nil {
+		return "", err
+	}
+
 	tplData := struct {
-		Domain     string
-		TLSConfig  string
+		Domain          string
+		TLSConfig       string
+		CustomCert      bool     // TLSConfig is "<cert> <key>" rather than an ACME email
+		Maintenance     bool     // respond with MaintenancePage instead of proxying to the app
+		MaintenancePage string   // HTML served with a 503 in maintenance mode
+		GlobalOptions   []string // CADDY_GLOBAL_OPTIONS directives, one per line
+		Upstreams       []string // app containers Caddy proxies to
 	}{
-		Domain:     data.Domain,
-		TLSConfig:  tlsConfig,
+		Domain:          data.Domain,
+		TLSConfig:       tlsConfig,
+		CustomCert:      data.TLSCertPath != "",
+		Maintenance:     data.MaintenanceMode,
+		MaintenancePage: maintenancePage,
+		GlobalOptions:   data.CaddyGlobalOptionLines(),
+		Upstreams:       upstreams,
+	}
+
+	templateText := caddyfileTemplate
+	if data.CaddyTemplate != "" {
+		content, err := os.ReadFile(data.CaddyTemplate)
+		if err != nil {
+			return "", fmt.Errorf("read Caddyfile template %s: %w", data.CaddyTemplate, err)
+		}
+		d.logger.Info("Using custom Caddyfile template %s", data.CaddyTemplate)
+		templateText = string(content)
 	}
 
-	tmpl, err := template.New("caddyfile").Parse(caddyfileTemplate)
+	tmpl, err := template.New("caddyfile").Parse(templateText)
 	if err != nil {
 		return "", fmt.Errorf("parse template: %w", err)
 	}
@@ -573,18 +992,80 @@ func (d *Docker) generateCaddyfile(data config.ConfigData) (string, error) {
 	return buf.String(), nil
 }
 
+// CheckAppHealth queries the app's health endpoint (appHealthURL) once from inside the container
+func (d *Docker) CheckAppHealth(name string) error {
+	url, err := d.appHealthURL()
+	if err != nil {
+		return err
+	}
+	_, err = d.RunCommand(healthCheckArgs(name, url, HealthCheckTimeout(d.healthTimeout))...)
+	return err
+}
+
+// RecoverApp redeploys the app container Caddy routes to in place, so the
+// blue-green rotation and a slot pinned by switch-slot are left untouched
+func (d *Docker) RecoverApp(data config.ConfigData) (string, error) {
+	d.ApplySettings(data)
+	name := d.currentSlot(d.caddyfile())
+
+	d.logger.Info("Redeploying app container %s", name)
+	if err := d.DeployApp(data, name); err != nil {
+		return name, fmt.Errorf("redeploy app container %s: %w", name, err)
+	}
+	if err := d.waitForAppHealth(name); err != nil {
+		return name, errors.NewDockerError("health_check", name, err)
+	}
+	return name, nil
+}
+
+// RecoverCaddy rewrites the Caddyfile and redeploys the Caddy container,
+// keeping a slot pinned by switch-slot
+func (d *Docker) RecoverCaddy(data config.ConfigData) error {
+	caddyContent, err := d.generateCaddyfileKeepingPin(data)
+	if err != nil {
+		return fmt.Errorf("generate Caddyfile: %w", err)
+	}
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
+		return fmt.Errorf("write Caddyfile: %w", err)
+	}
+
+	d.logger.Info("Redeploying Caddy container")
+	return d.deployCaddy(data, caddyFile)
+}
+
+// runMigrations runs MIGRATION_COMMAND inside the new app container and waits
+// for it to finish, so traffic only moves over once the schema is up to date
+func (d *Docker) runMigrations(name, command string) error {
+	d.logger.Info("Running migrations in %s: %s", name, command)
+	start := time.Now()
+	output, err := d.RunCommand("exec", name, "sh", "-c", command)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line != "" {
+			d.logger.Info("  %s", line)
+		}
+	}
+	if err != nil {
+		d.logger.Error("Migrations failed in %s, keeping traffic on the current container", name)
+		return fmt.Errorf("migration command failed: %w", err)
+	}
+	d.logger.Success("Migrations completed in %s", time.Since(start).Round(time.Second))
+	return nil
+}
+
 func (d *Docker) waitForAppHealth(name string) error {
 	d.logger.Info("Waiting for %s to become healthy...", name)
-	for i := 0; i < HealthCheckTries; i++ {
-		if _, err := d.RunCommand("exec", name, "curl", "-f", "http://localhost:8080/_health"); err == nil {
+	tries := HealthCheckAttempts(d.healthTries)
+	for i := 0; i < tries; i++ {
+		if err := d.CheckAppHealth(name); err == nil {
 			d.logger.Success("%s is healthy", name)
 			return nil
 		}
-		time.Sleep(2 * time.Second)
-		if i == HealthCheckTries-1 {
-			d.logger.Error("Container %s failed to become healthy after %d attempts", name, HealthCheckTries)
+		time.Sleep(healthCheckInterval)
+		if i == tries-1 {
+			d.logger.Error("Container %s failed to become healthy after %d attempts", name, tries)
 			d.logContainerLogs(name)
-			return fmt.Errorf("app %s not healthy after %d attempts", name, HealthCheckTries)
+			return fmt.Errorf("app %s not healthy after %d attempts", name, tries)
 		}
 	}
 	return nil
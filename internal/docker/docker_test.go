@@ -1,8 +1,17 @@
 package docker
 
 import (
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
 
 	"infinity-metrics-installer/internal/config"
 	"infinity-metrics-installer/internal/logging"
@@ -43,6 +52,94 @@ func TestGenerateCaddyfile_WithDatabaseUser(t *testing.T) {
 	}
 }
 
+func TestGenerateCaddyfile_CustomCertificate(t *testing.T) {
+	t.Setenv("ENV", "test")
+	d := &Docker{logger: testLogger(t)}
+	data := config.ConfigData{
+		Domain:      "example.com",
+		User:        "admin@mycompany.com",
+		TLSCertPath: "/etc/ssl/example.com.crt",
+		TLSKeyPath:  "/etc/ssl/example.com.key",
+	}
+	caddyfile, err := d.generateCaddyfile(data)
+	if err != nil {
+		t.Fatalf("generateCaddyfile error: %v", err)
+	}
+	if !strings.Contains(caddyfile, "tls "+caddyCertPath+" "+caddyKeyPath) {
+		t.Errorf("Caddyfile should serve the provided certificate, got: %s", caddyfile)
+	}
+	if strings.Contains(caddyfile, "email ") || strings.Contains(caddyfile, "tls internal") {
+		t.Errorf("Caddyfile should not configure ACME or the internal CA with a provided certificate, got: %s", caddyfile)
+	}
+}
+
+func TestGenerateCaddyfile_InternalCA(t *testing.T) {
+	t.Setenv("ENV", "")
+	d := &Docker{logger: testLogger(t)}
+	caddyfile, err := d.generateCaddyfile(config.ConfigData{Domain: "metrics.internal", TLSInternal: true})
+	if err != nil {
+		t.Fatalf("generateCaddyfile error: %v", err)
+	}
+	if !strings.Contains(caddyfile, "tls internal") {
+		t.Errorf("Caddyfile should use the internal CA with TLS_INTERNAL, got: %s", caddyfile)
+	}
+	if strings.Contains(caddyfile, "email ") {
+		t.Errorf("Caddyfile should not configure ACME with TLS_INTERNAL, got: %s", caddyfile)
+	}
+}
+
+func TestGenerateCaddyfile_CustomTemplate(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+	dir := t.TempDir()
+
+	t.Run("RendersUserTemplate", func(t *testing.T) {
+		path := filepath.Join(dir, "Caddyfile.tmpl")
+		if err := os.WriteFile(path, []byte("{{.Domain}} {\n\t{{.TLSConfig}}\n\treverse_proxy app:8080\n}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data := config.ConfigData{Domain: "example.com", CaddyTemplate: path}
+		caddyfile, err := d.generateCaddyfile(data)
+		if err != nil {
+			t.Fatalf("generateCaddyfile error: %v", err)
+		}
+		if !strings.HasPrefix(caddyfile, "example.com {") {
+			t.Errorf("Caddyfile should be rendered from custom template, got: %s", caddyfile)
+		}
+		if !strings.Contains(caddyfile, "admin-infinity-metrics@example.com") {
+			t.Errorf("Custom template should receive TLS config, got: %s", caddyfile)
+		}
+	})
+
+	t.Run("RejectsUnparsableTemplate", func(t *testing.T) {
+		path := filepath.Join(dir, "broken.tmpl")
+		if err := os.WriteFile(path, []byte("{{.Domain {\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data := config.ConfigData{Domain: "example.com", CaddyTemplate: path}
+		if _, err := d.generateCaddyfile(data); err == nil {
+			t.Error("expected parse error for broken template")
+		}
+	})
+
+	t.Run("RejectsUnknownField", func(t *testing.T) {
+		path := filepath.Join(dir, "unknown.tmpl")
+		if err := os.WriteFile(path, []byte("{{.Hostname}} {\n}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data := config.ConfigData{Domain: "example.com", CaddyTemplate: path}
+		if _, err := d.generateCaddyfile(data); err == nil {
+			t.Error("expected render error for unknown template field")
+		}
+	})
+
+	t.Run("MissingTemplateFile", func(t *testing.T) {
+		data := config.ConfigData{Domain: "example.com", CaddyTemplate: filepath.Join(dir, "missing.tmpl")}
+		if _, err := d.generateCaddyfile(data); err == nil {
+			t.Error("expected error for missing template file")
+		}
+	})
+}
+
 func TestCaddyFileGeneration(t *testing.T) {
 	t.Run("ProductionConfigIncludesSSLConfiguration", func(t *testing.T) {
 		d := &Docker{logger: testLogger(t)}
@@ -151,3 +248,665 @@ func TestGenerateAdminEmail(t *testing.T) {
 	}
 }
 
+
+func TestDiffSettings(t *testing.T) {
+	data := config.ConfigData{
+		Domain:     "new.example.com",
+		AppImage:   "karloscodes/infinity-metrics-beta:latest",
+		PrivateKey: "new-private-key",
+		LicenseKey: "IM-123",
+	}
+	running := map[string]string{
+		"image":                        "karloscodes/infinity-metrics-beta:latest",
+		"user":                         "",
+		"INFINITY_METRICS_DOMAIN":      "old.example.com",
+		"INFINITY_METRICS_PRIVATE_KEY": "old-private-key",
+		"INFINITY_METRICS_LICENSE_KEY": "IM-123",
+	}
+
+	drifts := diffSettings(AppNamePrimary, expectedAppSettings(data), running)
+	if len(drifts) != 2 {
+		t.Fatalf("expected 2 drifts, got %+v", drifts)
+	}
+	if drifts[0].Key != "INFINITY_METRICS_DOMAIN" || drifts[0].Running != "old.example.com" || drifts[0].Expected != "new.example.com" {
+		t.Errorf("unexpected domain drift: %+v", drifts[0])
+	}
+	if drifts[1].Key != "INFINITY_METRICS_PRIVATE_KEY" || !drifts[1].Secret {
+		t.Errorf("private key drift should be marked secret: %+v", drifts[1])
+	}
+
+	running["INFINITY_METRICS_DOMAIN"] = "new.example.com"
+	running["INFINITY_METRICS_PRIVATE_KEY"] = "new-private-key"
+	if drifts := diffSettings(AppNamePrimary, expectedAppSettings(data), running); len(drifts) != 0 {
+		t.Errorf("expected no drift, got %+v", drifts)
+	}
+}
+
+func TestDiffSettingsContainerUser(t *testing.T) {
+	data := config.ConfigData{AppImage: "karloscodes/infinity-metrics-beta:latest"}
+	running := map[string]string{
+		"image": "karloscodes/infinity-metrics-beta:latest",
+		"user":  "1000:1000", // the image's own USER
+	}
+
+	if drifts := diffSettings(AppNamePrimary, expectedAppSettings(data), running); len(drifts) != 0 {
+		t.Errorf("image USER without CONTAINER_USER should not be drift, got %+v", drifts)
+	}
+
+	data.ContainerUser = "2000:2000"
+	drifts := diffSettings(AppNamePrimary, expectedAppSettings(data), running)
+	if len(drifts) != 1 || drifts[0].Key != "user" || drifts[0].Expected != "2000:2000" {
+		t.Errorf("expected a user drift, got %+v", drifts)
+	}
+}
+
+func TestCertificateExpiry(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	addr := server.Listener.Addr().String()
+
+	t.Setenv("ENV", "test")
+	expiry, err := certificateExpiry(addr, "example.com")
+	if err != nil {
+		t.Fatalf("certificateExpiry error: %v", err)
+	}
+	if want := server.Certificate().NotAfter; !expiry.Equal(want) {
+		t.Errorf("expiry = %s, want %s", expiry, want)
+	}
+
+	// Outside the test environment the self-signed certificate must be rejected
+	t.Setenv("ENV", "")
+	if _, err := certificateExpiry(addr, "example.com"); err == nil {
+		t.Error("expected untrusted certificate to be rejected")
+	}
+}
+
+// fakeDockerBinary puts a docker script on PATH that records its arguments and
+// exits with the given code
+func fakeDockerBinary(t *testing.T, output string, exitCode int) string {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\necho '" + output + "'\nexit " + strconv.Itoa(exitCode) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return argsFile
+}
+
+func TestRunMigrations(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+
+	t.Run("Succeeds", func(t *testing.T) {
+		argsFile := fakeDockerBinary(t, "applied 3 migrations", 0)
+		if err := d.runMigrations(AppNameSecondary, "./bin/migrate up"); err != nil {
+			t.Fatalf("runMigrations error: %v", err)
+		}
+		args, _ := os.ReadFile(argsFile)
+		if want := "exec " + AppNameSecondary + " sh -c ./bin/migrate up"; strings.TrimSpace(string(args)) != want {
+			t.Errorf("docker args = %q, want %q", strings.TrimSpace(string(args)), want)
+		}
+	})
+
+	t.Run("FailsWhenCommandFails", func(t *testing.T) {
+		fakeDockerBinary(t, "migration 0042 failed", 1)
+		if err := d.runMigrations(AppNameSecondary, "./bin/migrate up"); err == nil {
+			t.Error("expected error when the migration command exits non-zero")
+		}
+	})
+}
+
+func TestDeployApp_CPULimit(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+	data := config.ConfigData{InstallDir: t.TempDir(), AppImage: "app:1.0.0"}
+
+	argsFile := fakeDockerBinary(t, "", 0)
+	if err := d.DeployApp(data, AppNamePrimary); err != nil {
+		t.Fatalf("DeployApp error: %v", err)
+	}
+	if args, _ := os.ReadFile(argsFile); strings.Contains(string(args), "--cpus") {
+		t.Errorf("--cpus should not be set by default, got: %s", args)
+	}
+
+	data.AppCPULimit = "1.5"
+	if err := d.DeployApp(data, AppNamePrimary); err != nil {
+		t.Fatalf("DeployApp error: %v", err)
+	}
+	if args, _ := os.ReadFile(argsFile); !strings.Contains(string(args), "--cpus 1.5 app:1.0.0") {
+		t.Errorf("expected --cpus 1.5 before the image, got: %s", args)
+	}
+}
+
+func TestPullRetrySettings(t *testing.T) {
+	t.Setenv(PullMaxRetriesEnvVar, "")
+	t.Setenv(PullBackoffMaxEnvVar, "")
+	if got := PullMaxRetries(); got != MaxRetries {
+		t.Errorf("PullMaxRetries() default = %d, want %d", got, MaxRetries)
+	}
+	if got := PullBackoffMax(); got != DefaultPullBackoffMax {
+		t.Errorf("PullBackoffMax() default = %s, want %s", got, DefaultPullBackoffMax)
+	}
+
+	t.Setenv(PullMaxRetriesEnvVar, "8")
+	t.Setenv(PullBackoffMaxEnvVar, "1m")
+	if got := PullMaxRetries(); got != 8 {
+		t.Errorf("PullMaxRetries() = %d, want 8", got)
+	}
+	if got := PullBackoffMax(); got != time.Minute {
+		t.Errorf("PullBackoffMax() = %s, want 1m", got)
+	}
+
+	t.Setenv(PullMaxRetriesEnvVar, "0")
+	t.Setenv(PullBackoffMaxEnvVar, "soon")
+	if got := PullMaxRetries(); got != MaxRetries {
+		t.Errorf("PullMaxRetries() with invalid value = %d, want %d", got, MaxRetries)
+	}
+	if got := PullBackoffMax(); got != DefaultPullBackoffMax {
+		t.Errorf("PullBackoffMax() with invalid value = %s, want %s", got, DefaultPullBackoffMax)
+	}
+
+	if got := pullBackoff(2, time.Minute); got != 2*pullRetryDelay {
+		t.Errorf("pullBackoff(2) = %s, want %s", got, 2*pullRetryDelay)
+	}
+	if got := pullBackoff(100, 5*time.Second); got != 5*time.Second {
+		t.Errorf("pullBackoff(100) = %s, want the 5s cap", got)
+	}
+}
+
+func TestPullImageRetries(t *testing.T) {
+	dir := t.TempDir()
+	callsFile := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + callsFile + "\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv(PullMaxRetriesEnvVar, "4")
+	t.Setenv(PullBackoffMaxEnvVar, "")
+
+	original := pullRetryDelay
+	pullRetryDelay = time.Millisecond
+	defer func() { pullRetryDelay = original }()
+
+	d := &Docker{logger: testLogger(t)}
+	err := d.pullImage("caddy:2")
+	if err == nil || !strings.Contains(err.Error(), "after 4 retries") {
+		t.Fatalf("pullImage error = %v, want failure after 4 retries", err)
+	}
+	calls, _ := os.ReadFile(callsFile)
+	if n := strings.Count(string(calls), "pull caddy:2"); n != 4 {
+		t.Errorf("docker pull called %d times, want 4", n)
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{"27.3.1", false},
+		{"20.10.0", false},
+		{"20.10.21+dfsg1", false},
+		{"24.0.7-1", false},
+		{"19.03.15", true},
+		{"1.13.1", true},
+		{"20.9", true},
+		{"", true},
+		{"unknown", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			err := CheckVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCleanDigest(t *testing.T) {
+	tests := map[string]string{
+		"sha256:abc123": "abc123",
+		"karloscodes/infinity-metrics@sha256:abc123": "abc123",
+		"abc123": "abc123",
+	}
+	for input, want := range tests {
+		if got := cleanDigest(input); got != want {
+			t.Errorf("cleanDigest(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestExplainPullWithoutLocalImage(t *testing.T) {
+	fakeDockerBinary(t, "", 0)
+	d := &Docker{logger: testLogger(t)}
+
+	decision := d.ExplainPull("caddy:2")
+	if !decision.Pull {
+		t.Error("expected a pull when the image is not present locally")
+	}
+	if decision.LocalErr == nil || decision.Reason != "the image is not present locally" {
+		t.Errorf("decision = %+v, want missing local image", decision)
+	}
+	if decision.RemoteDigest != "" || decision.RemoteCached {
+		t.Errorf("remote registry should not be consulted, got %+v", decision)
+	}
+}
+
+func TestCompareDigestsWithoutLocalImage(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	image := pushImage(t, strings.TrimPrefix(server.URL, "http://"), "app:compare", "amd64")
+
+	fakeDockerBinary(t, "", 0)
+	d := &Docker{logger: testLogger(t)}
+
+	comparison := d.CompareDigests(image)
+	if comparison.LocalErr == nil {
+		t.Error("expected the image to be missing locally")
+	}
+	if comparison.RemoteErr != nil || !strings.HasPrefix(comparison.RemoteDigest, "sha256:") {
+		t.Errorf("expected the remote digest to be looked up, got %+v", comparison)
+	}
+	if comparison.Match() {
+		t.Error("a missing local image must not match")
+	}
+
+	comparison.LocalDigest, comparison.LocalErr = "app@"+comparison.RemoteDigest, nil
+	if !comparison.Match() {
+		t.Errorf("expected %q to match %q", comparison.LocalDigest, comparison.RemoteDigest)
+	}
+}
+
+func TestParseReferenceInsecureRegistry(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+	d.SetInsecureRegistries("registry.internal:5000, 10.0.0.5")
+
+	ref, err := d.parseReference("registry.internal:5000/infinity-metrics:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheme := ref.Context().Scheme(); scheme != "http" {
+		t.Errorf("scheme for insecure registry = %q, want http", scheme)
+	}
+
+	ref, err = d.parseReference("registry.example.com/infinity-metrics:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheme := ref.Context().Scheme(); scheme != "https" {
+		t.Errorf("scheme for other registries = %q, want https", scheme)
+	}
+}
+
+func TestValidateCaddyfile(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+	data := config.ConfigData{
+		Domain:     "example.com",
+		CaddyImage: "caddy:2.7-alpine",
+		InstallDir: t.TempDir(),
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		argsFile := fakeDockerBinary(t, "Valid configuration", 0)
+		if err := d.validateCaddyfile(data, "example.com {\n}\n"); err != nil {
+			t.Fatalf("validateCaddyfile error: %v", err)
+		}
+		args, _ := os.ReadFile(argsFile)
+		if !strings.Contains(string(args), "caddy:2.7-alpine caddy validate --config /etc/caddy/Caddyfile") {
+			t.Errorf("docker args = %q, want a caddy validate run", strings.TrimSpace(string(args)))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		fakeDockerBinary(t, "Error: adapting config using caddyfile", 1)
+		if err := d.validateCaddyfile(data, "example.com {\n"); err == nil {
+			t.Error("expected error when caddy validate fails")
+		}
+	})
+
+	entries, err := os.ReadDir(data.InstallDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("validation should not leave files behind, found %d", len(entries))
+	}
+}
+
+func TestCertificateStatus(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	status, err := certificateStatus(server.Listener.Addr().String(), "example.com")
+	if err != nil {
+		t.Fatalf("certificateStatus error: %v", err)
+	}
+	if want := server.Certificate().NotAfter; !status.NotAfter.Equal(want) {
+		t.Errorf("NotAfter = %s, want %s", status.NotAfter, want)
+	}
+	if status.Trusted || status.VerifyErr == nil {
+		t.Error("self-signed test certificate should be reported as untrusted")
+	}
+	if len(status.DNSNames) == 0 {
+		t.Error("expected the certificate names to be reported")
+	}
+}
+
+func TestCertificateAuthority(t *testing.T) {
+	tests := []struct {
+		issuer pkix.Name
+		want   string
+	}{
+		{pkix.Name{Organization: []string{"Let's Encrypt"}, CommonName: "R11"}, AuthorityLetsEncrypt},
+		{pkix.Name{Organization: []string{"(STAGING) Let's Encrypt"}, CommonName: "(STAGING) Wannabe Watercress R11"}, AuthorityLetsEncryptStaging},
+		{pkix.Name{Organization: []string{"ZeroSSL"}, CommonName: "ZeroSSL ECC Domain Secure Site CA"}, AuthorityZeroSSL},
+		{pkix.Name{CommonName: "Caddy Local Authority - ECC Intermediate"}, AuthorityCaddyInternal},
+		{pkix.Name{Organization: []string{"Example Corp"}}, AuthorityOther},
+	}
+	for _, tt := range tests {
+		if got := certificateAuthority(tt.issuer); got != tt.want {
+			t.Errorf("certificateAuthority(%s) = %q, want %q", tt.issuer, got, tt.want)
+		}
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	tests := map[string]string{
+		"karloscodes/infinity-metrics-beta:latest":         "karloscodes/infinity-metrics-beta",
+		"karloscodes/infinity-metrics-beta":                "karloscodes/infinity-metrics-beta",
+		"registry.internal:5000/infinity-metrics:v1.2.0":   "registry.internal:5000/infinity-metrics",
+		"registry.internal:5000/infinity-metrics":          "registry.internal:5000/infinity-metrics",
+		"karloscodes/infinity-metrics-beta@sha256:abc1234": "karloscodes/infinity-metrics-beta",
+	}
+	for image, want := range tests {
+		if got := imageRepository(image); got != want {
+			t.Errorf("imageRepository(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestRollbackTagsToRemove(t *testing.T) {
+	tags := []string{"latest", "rollback-20240101000000", "rollback-20240301000000", "rollback-20240201000000"}
+
+	if got := rollbackTagsToRemove(tags, 2); strings.Join(got, ",") != "rollback-20240201000000,rollback-20240101000000" {
+		t.Errorf("keep 2: removed %v, want all but the newest rollback tag", got)
+	}
+	if got := rollbackTagsToRemove(tags, 1); len(got) != 3 {
+		t.Errorf("keep 1: removed %v, want every rollback tag", got)
+	}
+	if got := rollbackTagsToRemove(tags, 5); len(got) != 0 {
+		t.Errorf("keep 5: removed %v, want none", got)
+	}
+}
+
+func TestDiagnoseNetworkMissingNetwork(t *testing.T) {
+	fakeDockerBinary(t, "Error: No such network", 1)
+	d := &Docker{logger: testLogger(t)}
+
+	checks := d.DiagnoseNetwork()
+	if len(checks) != 1 || checks[0].Passed {
+		t.Fatalf("checks = %+v, want a single failed network check", checks)
+	}
+	if !strings.Contains(checks[0].Detail, "does not exist") {
+		t.Errorf("detail = %q, want a missing network explanation", checks[0].Detail)
+	}
+}
+
+func TestHealthCheckSettings(t *testing.T) {
+	t.Setenv(HealthCheckTimeoutEnvVar, "")
+	t.Setenv(HealthCheckTriesEnvVar, "")
+	t.Setenv(AppHealthSchemeEnvVar, "")
+	t.Setenv(AppHealthPathEnvVar, "")
+	if got := HealthCheckTimeout(0); got != DefaultHealthCheckTimeout {
+		t.Errorf("HealthCheckTimeout() default = %s, want %s", got, DefaultHealthCheckTimeout)
+	}
+	if got := HealthCheckAttempts(0); got != HealthCheckTries {
+		t.Errorf("HealthCheckAttempts() default = %d, want %d", got, HealthCheckTries)
+	}
+	if got := HealthCheckTimeout(20 * time.Second); got != 20*time.Second {
+		t.Errorf("HealthCheckTimeout() from .env = %s, want 20s", got)
+	}
+	if got := HealthCheckAttempts(30); got != 30 {
+		t.Errorf("HealthCheckAttempts() from .env = %d, want 30", got)
+	}
+
+	t.Setenv(HealthCheckTimeoutEnvVar, "1500ms")
+	t.Setenv(HealthCheckTriesEnvVar, "12")
+	if got := HealthCheckTimeout(20 * time.Second); got != 1500*time.Millisecond {
+		t.Errorf("HealthCheckTimeout() = %s, want the environment's 1.5s", got)
+	}
+	if got := HealthCheckAttempts(30); got != 12 {
+		t.Errorf("HealthCheckAttempts() = %d, want the environment's 12", got)
+	}
+
+	url, err := (&Docker{}).appHealthURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(healthCheckArgs(AppNamePrimary, url, 1500*time.Millisecond), " ")
+	if want := "exec " + AppNamePrimary + " curl -f --max-time 1.5 http://localhost:8080/_health"; args != want {
+		t.Errorf("health check args = %q, want %q", args, want)
+	}
+}
+
+func TestHealthCheckHTTPS(t *testing.T) {
+	t.Setenv(AppHealthSchemeEnvVar, "")
+	t.Setenv(AppHealthPathEnvVar, "")
+	d := &Docker{}
+	d.ApplySettings(config.ConfigData{AppHealthScheme: "HTTPS", AppHealthPath: "healthz"})
+	url, err := d.appHealthURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(healthCheckArgs(AppNamePrimary, url, 2*time.Second), " ")
+	if want := "exec " + AppNamePrimary + " curl -f --max-time 2 -k https://localhost:8080/healthz"; args != want {
+		t.Errorf("health check args = %q, want %q", args, want)
+	}
+
+	// The process environment overrides .env for a single run
+	t.Setenv(AppHealthPathEnvVar, "/ready")
+	if url, _ := d.appHealthURL(); url != "https://localhost:8080/ready" {
+		t.Errorf("appHealthURL() with APP_HEALTH_PATH set = %q", url)
+	}
+
+	t.Setenv(AppHealthSchemeEnvVar, "unix")
+	if _, err := d.appHealthURL(); err == nil || !strings.Contains(err.Error(), AppHealthSchemeEnvVar) {
+		t.Errorf("appHealthURL() with an unknown scheme error = %v, want it rejected", err)
+	}
+}
+
+func TestWaitForAppHealthGivesUp(t *testing.T) {
+	argsFile := fakeDockerBinary(t, "", 28)
+	t.Setenv(HealthCheckTriesEnvVar, "2")
+	original := healthCheckInterval
+	healthCheckInterval = time.Millisecond
+	defer func() { healthCheckInterval = original }()
+
+	d := &Docker{logger: testLogger(t)}
+	err := d.waitForAppHealth(AppNamePrimary)
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("waitForAppHealth error = %v, want failure after 2 attempts", err)
+	}
+	if _, statErr := os.Stat(argsFile); statErr != nil {
+		t.Errorf("expected docker to be invoked: %v", statErr)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	t.Setenv(StopTimeoutEnvVar, "")
+	if got := StopTimeout(0); got != DefaultStopTimeout {
+		t.Errorf("StopTimeout() default = %s, want %s", got, DefaultStopTimeout)
+	}
+	if got := StopTimeout(time.Minute); got != time.Minute {
+		t.Errorf("StopTimeout() from .env = %s, want 1m", got)
+	}
+	t.Setenv(StopTimeoutEnvVar, "90")
+	if got := StopTimeout(time.Minute); got != 90*time.Second {
+		t.Errorf("StopTimeout() = %s, want the environment's 1m30s", got)
+	}
+
+	args := strings.Join(stopArgs(AppNamePrimary, 1500*time.Millisecond), " ")
+	if want := "stop -t 2 " + AppNamePrimary; args != want {
+		t.Errorf("stop args = %q, want %q", args, want)
+	}
+}
+
+func TestContainerUsesImage(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+
+	fakeDockerBinary(t, "sha256:abc", 0)
+	if current, err := d.containerUsesImage(AppNamePrimary, "app:1.0.0"); err != nil || !current {
+		t.Errorf("containerUsesImage = %v, %v, want true when the IDs match", current, err)
+	}
+
+	fakeDockerBinary(t, "", 1)
+	if _, err := d.containerUsesImage(AppNamePrimary, "app:1.0.0"); err == nil {
+		t.Error("expected an error when the container cannot be inspected")
+	}
+}
+
+func TestPullImageRateLimited(t *testing.T) {
+	t.Setenv(PullMaxRetriesEnvVar, "3")
+	dir := t.TempDir()
+	callsFile := filepath.Join(dir, "calls")
+	loggedIn := filepath.Join(dir, "logged-in")
+	// Pulls are rate limited until docker login succeeds
+	script := "#!/bin/sh\necho \"$@\" >> " + callsFile + "\n" +
+		"if [ \"$1\" = login ]; then read -r pw; printf %s \"$pw\" > " + loggedIn + "; exit 0; fi\n" +
+		"if [ -f " + loggedIn + " ]; then exit 0; fi\n" +
+		"echo 'toomanyrequests: You have reached your pull rate limit.' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	t.Run("FailsWithoutCredentials", func(t *testing.T) {
+		d := &Docker{logger: testLogger(t)}
+		err := d.pullImage("caddy:2")
+		if err == nil || !strings.Contains(err.Error(), "'pull_rate_limited'") {
+			t.Fatalf("pullImage error = %v, want a pull_rate_limited error", err)
+		}
+		calls, _ := os.ReadFile(callsFile)
+		if n := strings.Count(string(calls), "pull caddy:2"); n != 1 {
+			t.Errorf("docker pull called %d times, a rate limit must not be retried anonymously", n)
+		}
+	})
+
+	t.Run("LogsInAndRetries", func(t *testing.T) {
+		os.Remove(callsFile)
+		d := &Docker{logger: testLogger(t)}
+		d.SetRegistryCredentials("deploy-bot", "s3cret")
+		if err := d.pullImage("caddy:2"); err != nil {
+			t.Fatalf("pullImage error = %v, want success after login", err)
+		}
+		password, _ := os.ReadFile(loggedIn)
+		if string(password) != "s3cret" {
+			t.Errorf("docker login got password %q on stdin", password)
+		}
+		calls, _ := os.ReadFile(callsFile)
+		if strings.Contains(string(calls), "s3cret") {
+			t.Error("the password must not be passed as an argument")
+		}
+		if !strings.Contains(string(calls), "login --username deploy-bot --password-stdin\n") {
+			t.Errorf("expected a Docker Hub login, calls:\n%s", calls)
+		}
+	})
+}
+
+func TestPullImageReauthenticates(t *testing.T) {
+	t.Setenv(PullMaxRetriesEnvVar, "1")
+	dir := t.TempDir()
+	callsFile := filepath.Join(dir, "calls")
+	token := filepath.Join(dir, "token")
+	// The first pull finds an expired token, a login issues a fresh one
+	script := "#!/bin/sh\necho \"$@\" >> " + callsFile + "\n" +
+		"if [ \"$1\" = login ]; then echo fresh > " + token + "; exit 0; fi\n" +
+		"if [ -f " + token + " ]; then read -r state < " + token + "; [ \"$state\" = fresh ] && [ \"$2\" != private/app:1 ] && exit 0; fi\n" +
+		"echo 'Error response from daemon: unauthorized: authentication required' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	t.Run("RetriesWithFreshToken", func(t *testing.T) {
+		d := &Docker{logger: testLogger(t)}
+		d.SetRegistryCredentials("deploy-bot", "s3cret")
+		if err := d.pullImage("registry.example.com/app:1"); err != nil {
+			t.Fatalf("pullImage error = %v, want success after logging in again", err)
+		}
+		calls, _ := os.ReadFile(callsFile)
+		if !strings.Contains(string(calls), "login --username deploy-bot --password-stdin registry.example.com\n") {
+			t.Errorf("expected a login to registry.example.com, calls:\n%s", calls)
+		}
+	})
+
+	t.Run("GivesUpAfterOneLogin", func(t *testing.T) {
+		os.WriteFile(callsFile, nil, 0o644)
+		d := &Docker{logger: testLogger(t)}
+		d.SetRegistryCredentials("deploy-bot", "s3cret")
+		err := d.pullImage("private/app:1")
+		if err == nil || !strings.Contains(err.Error(), "'pull_unauthorized'") {
+			t.Fatalf("pullImage error = %v, want a pull_unauthorized error", err)
+		}
+		calls, _ := os.ReadFile(callsFile)
+		if n := strings.Count(string(calls), "login "); n != 1 {
+			t.Errorf("docker login called %d times, want 1", n)
+		}
+	})
+}
+
+func TestDeployAppReplacesExitedContainer(t *testing.T) {
+	dir := t.TempDir()
+	callsFile := filepath.Join(dir, "calls")
+	state := filepath.Join(dir, "state")
+	// The container is exited: `ps` does not list it, `ps -a` does, and `run`
+	// hits a name conflict until it is removed. PATH only holds this script,
+	// so the state is kept in a file read with shell builtins.
+	script := "#!/bin/sh\necho \"$@\" >> " + callsFile + "\n" +
+		"read -r current < " + state + "\n" +
+		"case \"$1\" in\n" +
+		"ps) if [ \"$2\" = -a ] && [ \"$current\" != removed ]; then echo 3f2a1b; fi ;;\n" +
+		"rm) if [ \"$current\" = stuck ]; then echo 'removal of container is already in progress' >&2; exit 1; fi; echo removed > " + state + " ;;\n" +
+		"run) if [ \"$current\" != removed ]; then echo 'Conflict. The container name is already in use' >&2; exit 125; fi; echo running > " + state + " ;;\n" +
+		"esac\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	data := config.ConfigData{InstallDir: t.TempDir(), AppImage: "karloscodes/infinity-metrics:latest"}
+
+	t.Run("RemovesBeforeRun", func(t *testing.T) {
+		if err := os.WriteFile(state, []byte("exited\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Remove(callsFile)
+		d := &Docker{logger: testLogger(t)}
+		if err := d.DeployApp(data, AppNamePrimary); err != nil {
+			t.Fatalf("DeployApp error = %v, want the exited container replaced", err)
+		}
+		calls, _ := os.ReadFile(callsFile)
+		removed := strings.Index(string(calls), "rm -f "+AppNamePrimary)
+		run := strings.Index(string(calls), "run -d --name "+AppNamePrimary)
+		if removed < 0 || run < 0 || removed > run {
+			t.Errorf("expected the exited container to be removed before docker run, calls:\n%s", calls)
+		}
+	})
+
+	t.Run("FailsWhenRemovalFails", func(t *testing.T) {
+		if err := os.WriteFile(state, []byte("stuck\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Remove(callsFile)
+		d := &Docker{logger: testLogger(t)}
+		err := d.DeployApp(data, AppNamePrimary)
+		if err == nil || !strings.Contains(err.Error(), "remove existing container "+AppNamePrimary) {
+			t.Fatalf("DeployApp error = %v, want the failed removal reported", err)
+		}
+		calls, _ := os.ReadFile(callsFile)
+		if strings.Contains(string(calls), "run -d") {
+			t.Errorf("docker run must not be attempted while the old container holds the name, calls:\n%s", calls)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// DoctorCheck is the outcome of one doctor check. Fix, when set on a failed
+// check, is a remediation safe to apply without asking.
+type DoctorCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+	Fix    func() error
+}
+
+// Doctor checks the Docker daemon, network, install dir, Caddyfile and
+// containers of the installation described by data, returning every result.
+// Without a reachable daemon only that check is returned.
+func (d *Docker) Doctor(data config.ConfigData) []DoctorCheck {
+	d.ApplySettings(data)
+	version, err := d.ServerVersion()
+	if err != nil {
+		return []DoctorCheck{{
+			Name:   "Docker daemon",
+			Detail: fmt.Sprintf("not reachable: %v", err),
+			Fix:    startDockerDaemon,
+		}}
+	}
+	checks := []DoctorCheck{{Name: "Docker daemon", Passed: true, Detail: "version " + version}}
+
+	checks = append(checks, d.doctorNetwork()...)
+	checks = append(checks, d.doctorPermissions(data), d.doctorCaddyfile(data))
+	return append(checks, d.doctorContainers()...)
+}
+
+func startDockerDaemon() error {
+	if output, err := exec.Command("systemctl", "start", "docker").CombinedOutput(); err != nil {
+		return fmt.Errorf("systemctl start docker: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// doctorNetwork checks the network exists and the running containers are on it
+func (d *Docker) doctorNetwork() []DoctorCheck {
+	if _, err := d.RunCommand("network", "inspect", NetworkName, "--format", "{{.Name}}"); err != nil {
+		return []DoctorCheck{{
+			Name:   "Network " + NetworkName,
+			Detail: "does not exist",
+			Fix: func() error {
+				_, err := d.RunCommand("network", "create", NetworkName)
+				return err
+			},
+		}}
+	}
+	checks := []DoctorCheck{{Name: "Network " + NetworkName, Passed: true, Detail: "exists"}}
+
+	output, err := d.RunCommand("network", "inspect", NetworkName, "--format", "{{range .Containers}}{{.Name}} {{end}}")
+	if err != nil {
+		return append(checks, DoctorCheck{Name: "Connected containers", Detail: err.Error()})
+	}
+	connected := strings.Fields(output)
+	for _, name := range []string{AppNamePrimary, AppNameSecondary, CaddyName} {
+		if !d.IsRunning(name) {
+			continue
+		}
+		check := DoctorCheck{Name: name + " on " + NetworkName, Passed: containsString(connected, name)}
+		if !check.Passed {
+			container := name
+			check.Detail = "not connected"
+			check.Fix = func() error { return d.ensureNetworkConnected(container, NetworkName) }
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// doctorPermissions checks the data directories exist and are writable by
+// their owner and .env is readable by its owner only, as Deploy sets them up
+func (d *Docker) doctorPermissions(data config.ConfigData) DoctorCheck {
+	check := DoctorCheck{Name: "Install directory permissions"}
+	var problems []string
+	for _, dir := range dataDirs(data) {
+		info, err := os.Stat(dir)
+		switch {
+		case err != nil:
+			problems = append(problems, dir+" is missing")
+		case !info.IsDir():
+			problems = append(problems, dir+" is not a directory")
+		case info.Mode().Perm()&0o700 != 0o700:
+			problems = append(problems, fmt.Sprintf("%s has mode %o", dir, info.Mode().Perm()))
+		}
+	}
+	envFile := filepath.Join(data.InstallDir, ".env")
+	if info, err := os.Stat(envFile); err == nil && info.Mode().Perm()&0o077 != 0 {
+		problems = append(problems, fmt.Sprintf("%s is readable by other users (mode %o)", envFile, info.Mode().Perm()))
+	}
+	if len(problems) == 0 {
+		check.Passed = true
+		return check
+	}
+	check.Detail = strings.Join(problems, ", ")
+	check.Fix = func() error { return d.RepairPermissions(data) }
+	return check
+}
+
+// doctorCaddyfile checks the Caddyfile mounted into Caddy is a file. Docker
+// creates a directory in its place when the container starts without one.
+func (d *Docker) doctorCaddyfile(data config.ConfigData) DoctorCheck {
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	check := DoctorCheck{Name: "Caddyfile"}
+	info, err := os.Stat(caddyFile)
+	switch {
+	case err == nil && !info.IsDir():
+		check.Passed = true
+		return check
+	case err == nil:
+		check.Detail = caddyFile + " is a directory"
+	default:
+		check.Detail = caddyFile + " is missing"
+	}
+	check.Fix = func() error { return d.regenerateCaddyfile(data, caddyFile) }
+	return check
+}
+
+// regenerateCaddyfile writes caddyFile from data and restarts Caddy, which
+// keeps a missing or replaced bind-mounted file open until it restarts
+func (d *Docker) regenerateCaddyfile(data config.ConfigData, caddyFile string) error {
+	content, err := d.generateCaddyfile(data)
+	if err != nil {
+		return fmt.Errorf("generate Caddyfile: %w", err)
+	}
+	// Only an empty directory left by Docker is replaced
+	if info, err := os.Stat(caddyFile); err == nil && info.IsDir() {
+		if err := os.Remove(caddyFile); err != nil {
+			return fmt.Errorf("remove directory %s: %w", caddyFile, err)
+		}
+	}
+	if err := os.WriteFile(caddyFile, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("write Caddyfile: %w", err)
+	}
+	if d.containerExists(CaddyName) {
+		if _, err := d.RunCommand("restart", CaddyName); err != nil {
+			return fmt.Errorf("restart %s: %w", CaddyName, err)
+		}
+	}
+	return nil
+}
+
+// doctorContainers checks an app container is running and healthy and Caddy
+// is running. Existing containers are restarted as a fix; missing ones need
+// a reload, which is left to the user.
+func (d *Docker) doctorContainers() []DoctorCheck {
+	var checks []DoctorCheck
+
+	app := DoctorCheck{Name: "App container"}
+	appName, err := d.ActiveAppContainer()
+	switch {
+	case err == nil:
+		if healthErr := d.CheckAppHealth(appName); healthErr != nil {
+			app.Detail = fmt.Sprintf("%s is running but not healthy", appName)
+			app.Fix = func() error { return d.restartApp(appName) }
+		} else {
+			app.Passed, app.Detail = true, appName+" is healthy"
+		}
+	case d.containerExists(AppNamePrimary):
+		app.Detail = AppNamePrimary + " is stopped"
+		app.Fix = func() error { return d.restartApp(AppNamePrimary) }
+	case d.containerExists(AppNameSecondary):
+		app.Detail = AppNameSecondary + " is stopped"
+		app.Fix = func() error { return d.restartApp(AppNameSecondary) }
+	default:
+		app.Detail = "no app container exists, run 'infinity-metrics reload'"
+	}
+	checks = append(checks, app)
+
+	caddy := DoctorCheck{Name: "Caddy container"}
+	switch {
+	case d.IsRunning(CaddyName):
+		caddy.Passed, caddy.Detail = true, CaddyName+" is running"
+	case d.containerExists(CaddyName):
+		caddy.Detail = CaddyName + " is stopped"
+		caddy.Fix = func() error {
+			_, err := d.RunCommand("restart", CaddyName)
+			return err
+		}
+	default:
+		caddy.Detail = CaddyName + " does not exist, run 'infinity-metrics reload'"
+	}
+	return append(checks, caddy)
+}
+
+// restartApp restarts the app container name and waits for it to be healthy
+func (d *Docker) restartApp(name string) error {
+	if _, err := d.RunCommand("restart", name); err != nil {
+		return fmt.Errorf("restart %s: %w", name, err)
+	}
+	return d.waitForAppHealth(name)
+}
@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+func TestDoctorWithoutDaemon(t *testing.T) {
+	fakeDockerBinary(t, "Cannot connect to the Docker daemon", 1)
+	d := &Docker{logger: testLogger(t)}
+
+	checks := d.Doctor(config.ConfigData{InstallDir: t.TempDir(), Domain: "analytics.example.com"})
+	if len(checks) != 1 || checks[0].Name != "Docker daemon" || checks[0].Passed || checks[0].Fix == nil {
+		t.Errorf("checks = %+v, want only a failed, fixable Docker daemon check", checks)
+	}
+}
+
+func TestDoctorPermissions(t *testing.T) {
+	data := config.ConfigData{InstallDir: t.TempDir()}
+	d := &Docker{logger: testLogger(t)}
+
+	if check := d.doctorPermissions(data); check.Passed || check.Fix == nil || !strings.Contains(check.Detail, "storage is missing") {
+		t.Fatalf("check = %+v, want a fixable failure for the missing storage dir", check)
+	}
+	envFile := filepath.Join(data.InstallDir, ".env")
+	if err := os.WriteFile(envFile, []byte("INFINITY_METRICS_DOMAIN=example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.doctorPermissions(data).Fix(); err != nil {
+		t.Fatalf("fix: %v", err)
+	}
+	if check := d.doctorPermissions(data); !check.Passed {
+		t.Errorf("after the fix: %s", check.Detail)
+	}
+}
+
+func TestDoctorCaddyfile(t *testing.T) {
+	fakeDockerBinary(t, "", 0)
+	data := config.ConfigData{InstallDir: t.TempDir(), Domain: "analytics.example.com"}
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	// What docker run leaves behind when the bind-mounted file is missing
+	if err := os.Mkdir(caddyFile, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	d := &Docker{logger: testLogger(t)}
+
+	check := d.doctorCaddyfile(data)
+	if check.Passed || check.Fix == nil || !strings.Contains(check.Detail, "is a directory") {
+		t.Fatalf("check = %+v, want a fixable failure for the directory", check)
+	}
+	if err := check.Fix(); err != nil {
+		t.Fatalf("fix: %v", err)
+	}
+	if check := d.doctorCaddyfile(data); !check.Passed {
+		t.Errorf("after the fix: %s", check.Detail)
+	}
+	if content, _ := os.ReadFile(caddyFile); !strings.Contains(string(content), "analytics.example.com") {
+		t.Errorf("regenerated Caddyfile = %q", content)
+	}
+}
@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// maskedSecret replaces secret values in printed deploy commands
+const maskedSecret = "********"
+
+// secretEnvKeys are the container environment variables DeployCommands masks
+var secretEnvKeys = []string{"INFINITY_METRICS_PRIVATE_KEY", "INFINITY_METRICS_LICENSE_KEY"}
+
+// shellSafeArg matches arguments that need no quoting in a shell
+var shellSafeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// DeployCommands returns the docker run commands a deploy executes for the
+// app container appName and for Caddy, built from the same arguments as
+// DeployApp and deployCaddy, shell-quoted and with secrets masked. Nothing is
+// run.
+func DeployCommands(data config.ConfigData, appName string) []string {
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	return []string{
+		formatCommand(maskSecrets(appRunArgs(data, appName))),
+		formatCommand(maskSecrets(caddyRunArgs(data, caddyFile))),
+	}
+}
+
+func maskSecrets(args []string) []string {
+	masked := make([]string, len(args))
+	for idx, arg := range args {
+		masked[idx] = arg
+		for _, key := range secretEnvKeys {
+			if value, ok := strings.CutPrefix(arg, key+"="); ok && value != "" {
+				masked[idx] = key + "=" + maskedSecret
+			}
+		}
+	}
+	return masked
+}
+
+// formatCommand renders args as a docker command line that can be pasted into a shell
+func formatCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for idx, arg := range args {
+		if shellSafeArg.MatchString(arg) {
+			quoted[idx] = arg
+		} else {
+			quoted[idx] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return "docker " + strings.Join(quoted, " ")
+}
@@ -0,0 +1,129 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+func TestDeployCommands(t *testing.T) {
+	data := config.ConfigData{
+		Domain:        "analytics.example.com",
+		InstallDir:    "/opt/infinity-metrics",
+		AppImage:      "karloscodes/infinity-metrics-beta:latest",
+		CaddyImage:    "caddy:2.7-alpine",
+		PrivateKey:    "abcdefghijklmnopqrstuvwxyz123456",
+		LicenseKey:    "IM-SECRET-LICENSE",
+		AppEnvFile:    "/etc/infinity metrics/app.env",
+		ContainerUser: "1000:1000",
+	}
+
+	commands := DeployCommands(data, AppNamePrimary)
+	if len(commands) != 2 {
+		t.Fatalf("got %d commands, want app and Caddy", len(commands))
+	}
+	app, caddy := commands[0], commands[1]
+
+	for _, secret := range []string{data.PrivateKey, data.LicenseKey} {
+		if strings.Contains(app, secret) {
+			t.Errorf("app command leaks a secret: %s", app)
+		}
+	}
+	for _, want := range []string{
+		"docker run -d --name " + AppNamePrimary,
+		"INFINITY_METRICS_PRIVATE_KEY=" + maskedSecret,
+		"--env-file '/etc/infinity metrics/app.env'",
+		"--user 1000:1000",
+	} {
+		if !strings.Contains(app, want) {
+			t.Errorf("app command %q is missing %q", app, want)
+		}
+	}
+	if !strings.HasSuffix(app, " "+data.AppImage) {
+		t.Errorf("app command must end with the image: %s", app)
+	}
+	if !strings.Contains(caddy, "--name "+CaddyName) || !strings.HasSuffix(caddy, " "+data.CaddyImage) {
+		t.Errorf("unexpected Caddy command: %s", caddy)
+	}
+}
+
+func TestCaddyListenStack(t *testing.T) {
+	tests := map[string][]string{
+		"":     {"-p 80:80 ", "-p 443:443 ", "-p 443:443/udp "},
+		"ipv4": {"-p 0.0.0.0:80:80 ", "-p 0.0.0.0:443:443/udp "},
+		"ipv6": {"-p '[::]:80:80' ", "-p '[::]:443:443/udp' "},
+		"dual": {"-p 0.0.0.0:443:443 ", "-p '[::]:443:443' "},
+	}
+	for stack, want := range tests {
+		data := config.ConfigData{Domain: "analytics.example.com", InstallDir: "/opt/infinity-metrics", CaddyImage: "caddy:2.7-alpine", ListenStack: stack}
+		caddy := DeployCommands(data, AppNamePrimary)[1]
+		for _, publish := range want {
+			if !strings.Contains(caddy, publish) {
+				t.Errorf("LISTEN_STACK=%q: Caddy command %q is missing %q", stack, caddy, publish)
+			}
+		}
+		if stack == "ipv4" && strings.Contains(caddy, "[::]") {
+			t.Errorf("LISTEN_STACK=ipv4 must not publish on IPv6: %s", caddy)
+		}
+	}
+}
+
+func TestSELinuxRelabel(t *testing.T) {
+	enforce := filepath.Join(t.TempDir(), "enforce")
+	original := selinuxEnforcePath
+	selinuxEnforcePath = enforce
+	defer func() { selinuxEnforcePath = original }()
+
+	data := config.ConfigData{Domain: "analytics.example.com", InstallDir: "/opt/infinity-metrics", AppImage: "app:1", CaddyImage: "caddy:2.7-alpine", TLSCertPath: "/etc/ssl/site.crt", TLSKeyPath: "/etc/ssl/site.key"}
+	relabeled := []string{
+		"/opt/infinity-metrics/storage:/app/storage:z",
+		"/opt/infinity-metrics/logs:/app/logs:z",
+		"/opt/infinity-metrics/Caddyfile:/etc/caddy/Caddyfile:ro,z",
+		"/opt/infinity-metrics/logs:/data/logs:z",
+	}
+
+	tests := []struct {
+		mode    string
+		enforce string
+		want    bool
+	}{
+		{"", "1", true},
+		{"auto", "0", false},
+		{"", "", false},
+		{"true", "", true},
+		{"false", "1", false},
+	}
+	for _, tt := range tests {
+		if tt.enforce != "" {
+			if err := os.WriteFile(enforce, []byte(tt.enforce+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			os.Remove(enforce)
+		}
+		data.SELinuxRelabel = tt.mode
+		commands := strings.Join(DeployCommands(data, AppNamePrimary), "\n")
+		for _, mount := range relabeled {
+			if strings.Contains(commands, mount) != tt.want {
+				t.Errorf("SELINUX_RELABEL=%q, enforce=%q: mount %q present = %v, want %v", tt.mode, tt.enforce, mount, !tt.want, tt.want)
+			}
+		}
+	}
+
+	// Paths set in .env keep their labels
+	data.SELinuxRelabel = "true"
+	data.DBStoragePath = "/srv/infinity-metrics"
+	commands := strings.Join(DeployCommands(data, AppNamePrimary), "\n")
+	for _, mount := range []string{
+		"/srv/infinity-metrics:/app/storage ",
+		"/etc/ssl/site.crt:" + caddyCertPath + ":ro ",
+		"/etc/ssl/site.key:" + caddyKeyPath + ":ro ",
+	} {
+		if !strings.Contains(commands, mount) {
+			t.Errorf("mount %q missing or relabeled:\n%s", mount, commands)
+		}
+	}
+}
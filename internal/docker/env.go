@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// envPositiveInt returns the positive integer in the named environment
+// variable, or def when it is unset or invalid
+func envPositiveInt(name string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// envOr returns the named environment variable, or fallback when it is unset
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envDuration returns the duration in the named environment variable, given
+// as a duration ("45s", "2m") or a number of seconds, or def when it is unset
+// or invalid
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
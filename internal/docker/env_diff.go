@@ -0,0 +1,126 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// Keys used for non-environment settings compared by DiffEnv
+const (
+	settingImage = "image"
+	settingUser  = "user"
+)
+
+// EnvDrift is a setting whose value in a running container differs from .env
+type EnvDrift struct {
+	Container string
+	Key       string
+	Running   string
+	Expected  string
+	Secret    bool // values must not be printed
+}
+
+type expectedSetting struct {
+	key    string
+	value  string
+	secret bool
+}
+
+// expectedAppSettings mirrors the settings DeployApp derives from .env.
+// Without CONTAINER_USER no --user is passed and the image's USER applies,
+// so the user is only compared when it is set.
+func expectedAppSettings(data config.ConfigData) []expectedSetting {
+	settings := []expectedSetting{{key: settingImage, value: data.AppImage}}
+	if data.ContainerUser != "" {
+		settings = append(settings, expectedSetting{key: settingUser, value: data.ContainerUser})
+	}
+	return append(settings,
+		expectedSetting{key: "INFINITY_METRICS_DOMAIN", value: data.Domain},
+		expectedSetting{key: "INFINITY_METRICS_PRIVATE_KEY", value: data.PrivateKey, secret: true},
+		expectedSetting{key: "INFINITY_METRICS_LICENSE_KEY", value: data.LicenseKey, secret: true},
+	)
+}
+
+// expectedCaddySettings mirrors the settings deployCaddy derives from .env
+func expectedCaddySettings(data config.ConfigData) []expectedSetting {
+	return []expectedSetting{
+		{key: settingImage, value: data.CaddyImage},
+		{key: "DOMAIN", value: data.Domain},
+	}
+}
+
+// DiffEnv compares the running app and Caddy containers against the given
+// configuration and returns every setting that differs
+func (d *Docker) DiffEnv(data config.ConfigData) ([]EnvDrift, error) {
+	appName, err := d.ActiveAppContainer()
+	if err != nil {
+		return nil, err
+	}
+	if !d.IsRunning(CaddyName) {
+		return nil, fmt.Errorf("container %s is not running", CaddyName)
+	}
+
+	var drifts []EnvDrift
+	for _, target := range []struct {
+		name     string
+		expected []expectedSetting
+	}{
+		{appName, expectedAppSettings(data)},
+		{CaddyName, expectedCaddySettings(data)},
+	} {
+		running, err := d.inspectSettings(target.name)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, diffSettings(target.name, target.expected, running)...)
+	}
+	return drifts, nil
+}
+
+// inspectSettings returns the environment, image and user of a container
+func (d *Docker) inspectSettings(name string) (map[string]string, error) {
+	output, err := d.RunCommand("inspect", "--format", "{{json .Config}}", name)
+	if err != nil {
+		return nil, fmt.Errorf("inspect %s: %w", name, err)
+	}
+
+	var cfg struct {
+		Env   []string `json:"Env"`
+		Image string   `json:"Image"`
+		User  string   `json:"User"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &cfg); err != nil {
+		return nil, fmt.Errorf("parse inspect output for %s: %w", name, err)
+	}
+
+	settings := map[string]string{
+		settingImage: cfg.Image,
+		settingUser:  cfg.User,
+	}
+	for _, kv := range cfg.Env {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			settings[key] = value
+		}
+	}
+	return settings, nil
+}
+
+func diffSettings(container string, expected []expectedSetting, running map[string]string) []EnvDrift {
+	var drifts []EnvDrift
+	for _, setting := range expected {
+		if running[setting.key] == setting.value {
+			continue
+		}
+		drifts = append(drifts, EnvDrift{
+			Container: container,
+			Key:       setting.key,
+			Running:   running[setting.key],
+			Expected:  setting.value,
+			Secret:    setting.secret,
+		})
+	}
+	return drifts
+}
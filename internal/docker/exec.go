@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"infinity-metrics-installer/internal/errors"
+)
+
+// destructivePatterns match commands that delete data or stop the app, which
+// the exec command asks to confirm first
+var destructivePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(^|[\s;&|(])(rm|rmdir|shred|truncate|dd|mkfs(\.\w+)?|kill|killall|pkill|reboot|shutdown|halt)(\s|$)`),
+	regexp.MustCompile(`(?i)\b(drop\s+(table|index|view|trigger)|delete\s+from|truncate\s+table|alter\s+table|update\s+\S+\s+set)\b`),
+	regexp.MustCompile(`(^|\s)>\s*\S`), // overwriting a file through a shell redirect
+}
+
+// IsDestructiveCommand reports whether command looks like it deletes or
+// overwrites data, e.g. rm, a DROP TABLE passed to sqlite3 or a > redirect
+func IsDestructiveCommand(command []string) bool {
+	joined := strings.Join(command, " ")
+	for _, pattern := range destructivePatterns {
+		if pattern.MatchString(joined) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecInApp runs command in the active app container with the terminal
+// attached, allocating a TTY when tty is set so interactive shells work. It
+// returns the command's exit code; err is only set when it could not be run.
+func (d *Docker) ExecInApp(command []string, tty bool) (int, error) {
+	containerName, err := d.ActiveAppContainer()
+	if err != nil {
+		return 0, err
+	}
+
+	args := []string{"exec", "-i"}
+	if tty {
+		args = append(args, "-t")
+	}
+	args = append(args, containerName)
+	args = append(args, command...)
+
+	d.logger.Debug("Executing in app container %s: %s", containerName, strings.Join(command, " "))
+	cmd := exec.Command("docker", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, errors.NewDockerError("exec", containerName, err)
+	}
+	return 0, nil
+}
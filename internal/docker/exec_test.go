@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIsDestructiveCommand(t *testing.T) {
+	tests := []struct {
+		command []string
+		want    bool
+	}{
+		{[]string{"ls", "-la", "/app/storage"}, false},
+		{[]string{"sqlite3", "/app/storage/infinity-metrics-production.db"}, false},
+		{[]string{"sqlite3", "/app/storage/db.sqlite", "SELECT count(*) FROM events"}, false},
+		{[]string{"./bin/migrate", "status"}, false},
+		{[]string{"sh", "-c", "echo hello | grep -c hello"}, false},
+		{[]string{"rm", "-rf", "/app/storage"}, true},
+		{[]string{"sh", "-c", "cd /app && rm old.log"}, true},
+		{[]string{"sqlite3", "/app/storage/db.sqlite", "DROP TABLE events"}, true},
+		{[]string{"sqlite3", "/app/storage/db.sqlite", "delete from users where id = 1"}, true},
+		{[]string{"sqlite3", "/app/storage/db.sqlite", "UPDATE users SET email = 'x'"}, true},
+		{[]string{"sh", "-c", "echo '' > /app/storage/db.sqlite"}, true},
+		{[]string{"kill", "1"}, true},
+	}
+	for _, tt := range tests {
+		if got := IsDestructiveCommand(tt.command); got != tt.want {
+			t.Errorf("IsDestructiveCommand(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestExecInApp(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+
+	fakeDockerBinary(t, "infinity-app-1", 3)
+	if _, err := d.ExecInApp([]string{"false"}, false); err == nil {
+		t.Error("expected an error when no app container is running")
+	}
+
+	argsFile := fakeDockerBinary(t, "infinity-app-1", 0)
+	code, err := d.ExecInApp([]string{"ls", "/app"}, false)
+	if err != nil || code != 0 {
+		t.Fatalf("ExecInApp = %d, %v", code, err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if want := "exec -i " + AppNamePrimary + " ls /app"; strings.TrimSpace(string(args)) != want {
+		t.Errorf("docker args = %q, want %q", strings.TrimSpace(string(args)), want)
+	}
+}
@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/validation"
+)
+
+const (
+	HealthCheckTimeoutEnvVar  = "HEALTH_CHECK_TIMEOUT"
+	HealthCheckTriesEnvVar    = "HEALTH_CHECK_TRIES"
+	DefaultHealthCheckTimeout = 5 * time.Second
+	AppHealthSchemeEnvVar     = "APP_HEALTH_SCHEME"
+	AppHealthPathEnvVar       = "APP_HEALTH_PATH"
+	DefaultAppHealthPath      = "/_health"
+)
+
+// healthCheckInterval is the pause between health probes
+var healthCheckInterval = 2 * time.Second
+
+// HealthCheckTimeout returns the upper bound for a single health probe, so an
+// app that accepts the connection but never answers cannot stall a deploy:
+// HEALTH_CHECK_TIMEOUT from the environment, else configured, the .env value,
+// else DefaultHealthCheckTimeout. It accepts a duration ("10s") or a number
+// of seconds.
+func HealthCheckTimeout(configured time.Duration) time.Duration {
+	if configured <= 0 {
+		configured = DefaultHealthCheckTimeout
+	}
+	return envDuration(HealthCheckTimeoutEnvVar, configured)
+}
+
+// HealthCheckAttempts returns how many probes are made before a container is
+// declared unhealthy: HEALTH_CHECK_TRIES from the environment, else
+// configured, the .env value, else HealthCheckTries
+func HealthCheckAttempts(configured int) int {
+	if configured <= 0 {
+		configured = HealthCheckTries
+	}
+	return envPositiveInt(HealthCheckTriesEnvVar, configured)
+}
+
+// ApplySettings sets the health check and shutdown settings from .env, for
+// the operations that do not take the configuration themselves, such as the
+// health probes of the watchdog and of update --only-if-healthy. The process
+// environment still overrides them for a single run.
+func (d *Docker) ApplySettings(data config.ConfigData) {
+	d.healthScheme, d.healthPath = data.AppHealthScheme, data.AppHealthPath
+	// Invalid values are rejected when .env is loaded, here they keep the default
+	d.stopTimeout, _ = validation.ParseTimeout(data.StopTimeout)
+	d.healthTimeout, _ = validation.ParseTimeout(data.HealthCheckTimeout)
+	d.healthTries = data.HealthCheckTries
+	d.installDir = data.InstallDir
+}
+
+// appHealthURL returns the URL probed inside the app container. APP_HEALTH_SCHEME
+// selects http or https, for images serving TLS on the app port; any other
+// scheme is an error. APP_HEALTH_PATH replaces DefaultAppHealthPath.
+func (d *Docker) appHealthURL() (string, error) {
+	scheme := strings.ToLower(strings.TrimSpace(envOr(AppHealthSchemeEnvVar, d.healthScheme)))
+	if scheme == "" {
+		scheme = "http"
+	}
+	if err := validation.ValidateAppHealthScheme(scheme); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", AppHealthSchemeEnvVar, err)
+	}
+	path := strings.TrimSpace(envOr(AppHealthPathEnvVar, d.healthPath))
+	if path == "" {
+		path = DefaultAppHealthPath
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return scheme + "://localhost:8080" + path, nil
+}
+
+// healthCheckArgs builds the docker command probing the app's health endpoint.
+// HTTPS probes skip certificate verification since apps serve self-signed
+// certificates for localhost.
+func healthCheckArgs(name, url string, timeout time.Duration) []string {
+	args := []string{"exec", name, "curl", "-f", "--max-time", strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64)}
+	if strings.HasPrefix(url, "https://") {
+		args = append(args, "-k")
+	}
+	return append(args, url)
+}
@@ -10,8 +10,31 @@ import (
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"infinity-metrics-installer/internal/httpclient"
 )
 
+// SetInsecureRegistries sets the comma-separated registry hosts whose digests
+// are fetched over plain HTTP, as configured by REGISTRY_INSECURE
+func (d *Docker) SetInsecureRegistries(hosts string) {
+	d.insecureRegistries = make(map[string]bool)
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			d.insecureRegistries[host] = true
+		}
+	}
+}
+
+// parseReference parses an image reference, allowing plain HTTP for
+// registries listed in REGISTRY_INSECURE
+func (d *Docker) parseReference(image string) (name.Reference, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil || !d.insecureRegistries[ref.Context().RegistryStr()] {
+		return ref, err
+	}
+	return name.ParseReference(image, name.Insecure)
+}
+
 // GetLocalImageDigest returns the digest of a local image if it exists
 func (d *Docker) GetLocalImageDigest(image string) (string, error) {
 	start := time.Now()
@@ -60,17 +83,17 @@ func (d *Docker) GetLocalImageDigest(image string) (string, error) {
 	d.logger.Debug("Could not extract digest from RepoDigests, trying to get from remote registry")
 	
 	// Parse the image reference
-	ref, err := name.ParseReference(image)
+	ref, err := d.parseReference(image)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse image reference: %w", err)
 	}
 
 	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), httpclient.Timeout())
 	defer cancel()
 
 	// Get the digest from the remote registry
-	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(httpclient.Transport()))
 	if err != nil {
 		d.logger.Debug("Failed to get digest from remote registry: %v", err)
 		
@@ -122,17 +145,17 @@ func (d *Docker) GetRemoteImageDigest(image string) (string, error) {
 	d.logger.Debug("Getting remote digest for %s using go-containerregistry", image)
 
 	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), httpclient.Timeout())
 	defer cancel()
 
 	// Parse the image reference
-	ref, err := name.ParseReference(image)
+	ref, err := d.parseReference(image)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse image reference: %w", err)
 	}
 
 	// Get the image descriptor with timeout context
-	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain), remote.WithTransport(httpclient.Transport()))
 	if err != nil {
 		// Handle specific error types
 		if strings.Contains(err.Error(), "unauthorized") {
@@ -159,86 +182,155 @@ func (d *Docker) GetRemoteImageDigest(image string) (string, error) {
 	return digest, nil
 }
 
-// ShouldPullImage checks if the remote image is different from the local one
-// Returns true if the image should be pulled, false otherwise, and any error encountered
-func (d *Docker) ShouldPullImage(image string) (bool, error) {
-	start := time.Now()
-	defer func() {
-		d.logger.Debug("ShouldPullImage check for %s took %v", image, time.Since(start))
-	}()
+// PullDecision explains why ShouldPullImage would or would not pull an image
+type PullDecision struct {
+	Image        string
+	LocalDigest  string
+	LocalErr     error
+	RemoteDigest string
+	RemoteErr    error
+	RemoteCached bool // remote digest came from the in-memory digest cache
+	Pull         bool
+	Reason       string
+	Err          error // returned by ShouldPullImage alongside Pull
+}
+
+// cachedRemoteDigest reports whether an unexpired remote digest is cached for image
+func cachedRemoteDigest(image string) bool {
+	digestCacheMux.RLock()
+	defer digestCacheMux.RUnlock()
+	entry, found := digestCache[image]
+	return found && time.Now().Before(entry.expiresAt)
+}
+
+// cleanDigest reduces "repo@sha256:abc" and "sha256:abc" to "abc" for comparison
+func cleanDigest(digest string) string {
+	// If it contains a repo reference, extract just the digest part
+	if strings.Contains(digest, "@") {
+		parts := strings.Split(digest, "@")
+		if len(parts) > 1 {
+			digest = parts[1]
+		}
+	}
+
+	// If it has a sha256: prefix, extract just the hash
+	return strings.TrimPrefix(digest, "sha256:")
+}
+
+// ExplainPull runs the digest comparison behind ShouldPullImage and returns
+// every input along with the decision and its reason
+func (d *Docker) ExplainPull(image string) PullDecision {
+	decision := PullDecision{Image: image}
 
 	// Parse the image to ensure it's valid
-	_, err := name.ParseReference(image)
-	if err != nil {
-		return true, fmt.Errorf("invalid image reference %s: %w", image, err)
+	if _, err := d.parseReference(image); err != nil {
+		decision.Pull = true
+		decision.Reason = "the image reference is invalid"
+		decision.Err = fmt.Errorf("invalid image reference %s: %w", image, err)
+		return decision
 	}
 
-	// Try to get local digest
-	localDigest, localErr := d.GetLocalImageDigest(image)
-	
 	// If local image doesn't exist, we definitely need to pull
-	if localErr != nil {
-		d.logger.Info("Local image %s not found, will pull", image)
-		return true, nil
+	decision.LocalDigest, decision.LocalErr = d.GetLocalImageDigest(image)
+	if decision.LocalErr != nil {
+		decision.Pull = true
+		decision.Reason = "the image is not present locally"
+		return decision
 	}
-	
-	// Try to get remote digest
-	remoteDigest, remoteErr := d.GetRemoteImageDigest(image)
-	if remoteErr != nil {
+
+	decision.RemoteCached = cachedRemoteDigest(image)
+	decision.RemoteDigest, decision.RemoteErr = d.GetRemoteImageDigest(image)
+	if decision.RemoteErr != nil {
 		// Check for specific error types
-		if strings.Contains(remoteErr.Error(), "not found") {
-			d.logger.Error("Image %s not found in remote registry", image)
-			return false, fmt.Errorf("image not found in registry: %w", remoteErr)
-		} else if strings.Contains(remoteErr.Error(), "unauthorized") {
-			d.logger.Warn("Authentication error for %s: %v", image, remoteErr)
+		switch {
+		case strings.Contains(decision.RemoteErr.Error(), "not found"):
+			decision.Reason = "the image does not exist in the remote registry"
+			decision.Err = fmt.Errorf("image not found in registry: %w", decision.RemoteErr)
+		case strings.Contains(decision.RemoteErr.Error(), "unauthorized"):
 			// For auth errors, we might want to retry with credentials, but for now just pull
-			return true, fmt.Errorf("authentication error, will pull anyway: %w", remoteErr)
-		} else {
-			// For other errors, log warning but proceed with pull to be safe
-			d.logger.Warn("Could not get remote digest for %s: %v, will pull anyway", image, remoteErr)
-			return true, nil
+			decision.Pull = true
+			decision.Reason = "the registry rejected the credentials, pulling anyway"
+			decision.Err = fmt.Errorf("authentication error, will pull anyway: %w", decision.RemoteErr)
+		default:
+			// For other errors, proceed with pull to be safe
+			decision.Pull = true
+			decision.Reason = "the remote digest could not be fetched, pulling to be safe"
 		}
+		return decision
 	}
-	
-	// Clean up digests to ensure proper comparison
-	// Extract just the hash part if it's a full digest with algorithm prefix
-	cleanDigest := func(digest string) string {
-		// If it contains a repo reference, extract just the digest part
-		if strings.Contains(digest, "@") {
-			parts := strings.Split(digest, "@")
-			if len(parts) > 1 {
-				digest = parts[1]
-			}
-		}
-		
-		// If it has a sha256: prefix, extract just the hash
-		if strings.HasPrefix(digest, "sha256:") {
-			digest = strings.TrimPrefix(digest, "sha256:")
+
+	if cleanDigest(decision.LocalDigest) != cleanDigest(decision.RemoteDigest) {
+		decision.Pull = true
+		decision.Reason = "the remote digest differs from the local one"
+	} else {
+		decision.Reason = "the local image matches the remote digest"
+	}
+	return decision
+}
+
+// DigestComparison holds the local and remote digest of an image
+type DigestComparison struct {
+	Image        string
+	LocalDigest  string
+	LocalErr     error
+	RemoteDigest string
+	RemoteErr    error
+}
+
+// Match reports whether both digests are known and identical
+func (c DigestComparison) Match() bool {
+	return c.LocalErr == nil && c.RemoteErr == nil && cleanDigest(c.LocalDigest) == cleanDigest(c.RemoteDigest)
+}
+
+// CompareDigests looks up the local and remote digest of image. Unlike
+// ExplainPull it always asks the registry, even when the image is missing locally.
+func (d *Docker) CompareDigests(image string) DigestComparison {
+	comparison := DigestComparison{Image: image}
+	comparison.LocalDigest, comparison.LocalErr = d.GetLocalImageDigest(image)
+	comparison.RemoteDigest, comparison.RemoteErr = d.GetRemoteImageDigest(image)
+	return comparison
+}
+
+// ShouldPullImage checks if the remote image is different from the local one
+// Returns true if the image should be pulled, false otherwise, and any error encountered.
+// In offline mode the registry is not queried and the local image is kept.
+func (d *Docker) ShouldPullImage(image string) (bool, error) {
+	if d.offline {
+		return false, nil
+	}
+	start := time.Now()
+	defer func() {
+		d.logger.Debug("ShouldPullImage check for %s took %v", image, time.Since(start))
+	}()
+
+	decision := d.ExplainPull(image)
+	switch {
+	case decision.LocalErr != nil:
+		d.logger.Info("Local image %s not found, will pull", image)
+		return decision.Pull, decision.Err
+	case decision.RemoteErr != nil:
+		if decision.Pull && decision.Err == nil {
+			d.logger.Warn("Could not get remote digest for %s: %v, will pull anyway", image, decision.RemoteErr)
+		} else if decision.Pull {
+			d.logger.Warn("Authentication error for %s: %v", image, decision.RemoteErr)
+		} else {
+			d.logger.Error("Image %s not found in remote registry", image)
 		}
-		
-		return digest
+		return decision.Pull, decision.Err
+	case decision.Err != nil:
+		return decision.Pull, decision.Err
 	}
-	
-	localDigestClean := cleanDigest(localDigest)
-	remoteDigestClean := cleanDigest(remoteDigest)
-	
+
+	localDigestClean := cleanDigest(decision.LocalDigest)
+	remoteDigestClean := cleanDigest(decision.RemoteDigest)
+
 	// Log all digest formats for debugging
-	d.logger.Debug("Local digest (original): %s", localDigest)
+	d.logger.Debug("Local digest (original): %s", decision.LocalDigest)
 	d.logger.Debug("Local digest (cleaned): %s", localDigestClean)
-	d.logger.Debug("Remote digest (original): %s", remoteDigest)
+	d.logger.Debug("Remote digest (original): %s", decision.RemoteDigest)
 	d.logger.Debug("Remote digest (cleaned): %s", remoteDigestClean)
-	
-	// Compare cleaned digests
-	shouldPull := localDigestClean != remoteDigestClean
-	
-	// If we're using the remote registry method for local digest, they should match
-	// This is a special case where we know the digests should be the same
-	if strings.Contains(localDigest, "(from remote registry)") && shouldPull {
-		d.logger.Info("Local digest was obtained from remote registry but still differs from current remote digest")
-		d.logger.Info("This suggests the remote image has been updated since the local image was pulled")
-	}
-	
-	if shouldPull {
+
+	if decision.Pull {
 		d.logger.Info("Remote image %s has different digest, will pull", image)
 		d.logger.Info("Local digest: %s", localDigestClean)
 		d.logger.Info("Remote digest: %s", remoteDigestClean)
@@ -246,6 +338,6 @@ func (d *Docker) ShouldPullImage(image string) (bool, error) {
 		d.logger.Info("Image %s is up to date, skipping pull", image)
 		d.logger.Info("Digest: %s", localDigestClean)
 	}
-	
-	return shouldPull, nil
+
+	return decision.Pull, nil
 }
@@ -0,0 +1,68 @@
+package docker
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/errors"
+)
+
+//go:embed templates/maintenance.html
+var defaultMaintenancePage string
+
+// maintenancePageMarker closes the heredoc the page is embedded in
+const maintenancePageMarker = "MAINTENANCE_PAGE"
+
+// maintenancePageContent returns the HTML served in maintenance mode:
+// MAINTENANCE_PAGE when set, the built-in page otherwise
+func (d *Docker) maintenancePageContent(data config.ConfigData) (string, error) {
+	if !data.MaintenanceMode {
+		return "", nil
+	}
+	page := defaultMaintenancePage
+	if data.MaintenancePage != "" {
+		content, err := os.ReadFile(data.MaintenancePage)
+		if err != nil {
+			return "", fmt.Errorf("read maintenance page %s: %w", data.MaintenancePage, err)
+		}
+		page = string(content)
+	}
+	page = strings.TrimRight(page, "\n")
+	for _, line := range strings.Split(page, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), maintenancePageMarker) {
+			return "", fmt.Errorf("maintenance page cannot contain a line starting with %s", maintenancePageMarker)
+		}
+	}
+	return page, nil
+}
+
+// ReloadCaddy regenerates the Caddyfile from data and reloads the running
+// Caddy container without touching the app, as used to switch maintenance
+// mode. An invalid Caddyfile leaves the running configuration in place, and a
+// slot pinned by switch-slot stays pinned.
+func (d *Docker) ReloadCaddy(data config.ConfigData) error {
+	if !d.IsRunning(CaddyName) {
+		return fmt.Errorf("container %s is not running", CaddyName)
+	}
+
+	caddyContent, err := d.generateCaddyfileKeepingPin(data)
+	if err != nil {
+		return fmt.Errorf("generate Caddyfile: %w", err)
+	}
+	if err := d.validateCaddyfile(data, caddyContent); err != nil {
+		return errors.NewDockerError("validate_caddyfile", CaddyName, err)
+	}
+
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
+		return fmt.Errorf("write Caddyfile: %w", err)
+	}
+	if _, err := d.RunCommand("exec", CaddyName, "caddy", "reload", "--config", "/etc/caddy/Caddyfile"); err != nil {
+		return fmt.Errorf("caddy reload failed: %w", err)
+	}
+	return nil
+}
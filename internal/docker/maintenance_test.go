@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+func TestGenerateCaddyfile_Maintenance(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+
+	t.Run("ProxiesWhenOff", func(t *testing.T) {
+		caddyfile, err := d.generateCaddyfile(config.ConfigData{Domain: "example.com"})
+		if err != nil {
+			t.Fatalf("generateCaddyfile error: %v", err)
+		}
+		if strings.Contains(caddyfile, "respond <<") || !strings.Contains(caddyfile, "reverse_proxy") {
+			t.Errorf("Caddyfile should proxy to the app outside maintenance, got: %s", caddyfile)
+		}
+	})
+
+	t.Run("BuiltInPage", func(t *testing.T) {
+		caddyfile, err := d.generateCaddyfile(config.ConfigData{Domain: "example.com", MaintenanceMode: true})
+		if err != nil {
+			t.Fatalf("generateCaddyfile error: %v", err)
+		}
+		for _, want := range []string{"respond <<MAINTENANCE_PAGE", "Down for maintenance", "\nMAINTENANCE_PAGE 503"} {
+			if !strings.Contains(caddyfile, want) {
+				t.Errorf("Caddyfile missing %q, got: %s", want, caddyfile)
+			}
+		}
+		if strings.Contains(caddyfile, "reverse_proxy") {
+			t.Errorf("Caddyfile should not proxy in maintenance mode, got: %s", caddyfile)
+		}
+	})
+
+	t.Run("CustomPage", func(t *testing.T) {
+		page := filepath.Join(t.TempDir(), "maintenance.html")
+		if err := os.WriteFile(page, []byte("<p>Database upgrade until 14:00 UTC</p>\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data := config.ConfigData{Domain: "example.com", MaintenanceMode: true, MaintenancePage: page}
+		caddyfile, err := d.generateCaddyfile(data)
+		if err != nil {
+			t.Fatalf("generateCaddyfile error: %v", err)
+		}
+		if !strings.Contains(caddyfile, "<p>Database upgrade until 14:00 UTC</p>\nMAINTENANCE_PAGE 503") {
+			t.Errorf("Caddyfile should serve the custom page, got: %s", caddyfile)
+		}
+	})
+
+	t.Run("RejectsHeredocMarker", func(t *testing.T) {
+		page := filepath.Join(t.TempDir(), "maintenance.html")
+		if err := os.WriteFile(page, []byte("<p>hi</p>\nMAINTENANCE_PAGE 200\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data := config.ConfigData{Domain: "example.com", MaintenanceMode: true, MaintenancePage: page}
+		if _, err := d.generateCaddyfile(data); err == nil {
+			t.Error("expected a page containing the heredoc marker to be rejected")
+		}
+	})
+}
+
+func TestReloadCaddy(t *testing.T) {
+	installDir := t.TempDir()
+	argsFile := fakeDockerBinary(t, "caddy-container-id", 0)
+	d := &Docker{logger: testLogger(t)}
+
+	data := config.ConfigData{Domain: "example.com", InstallDir: installDir, CaddyImage: "caddy:2.7-alpine", MaintenanceMode: true}
+	if err := d.ReloadCaddy(data); err != nil {
+		t.Fatalf("ReloadCaddy error: %v", err)
+	}
+	caddyfile, err := os.ReadFile(filepath.Join(installDir, "Caddyfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(caddyfile), "respond <<MAINTENANCE_PAGE") {
+		t.Errorf("written Caddyfile is not in maintenance mode: %s", caddyfile)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if want := "exec " + CaddyName + " caddy reload"; !strings.HasPrefix(strings.TrimSpace(string(args)), want) {
+		t.Errorf("last docker call = %q, want %q", strings.TrimSpace(string(args)), want)
+	}
+}
+
+func TestRedeployCaddy(t *testing.T) {
+	t.Setenv(PullMaxRetriesEnvVar, "1")
+	installDir := t.TempDir()
+	d := &Docker{logger: testLogger(t)}
+	// An unreachable registry keeps the architecture check offline
+	data := config.ConfigData{Domain: "example.com", InstallDir: installDir, CaddyImage: "127.0.0.1:1/caddy:2.8-alpine"}
+
+	t.Run("RecreatesContainer", func(t *testing.T) {
+		argsFile := fakeDockerBinary(t, "", 0)
+		if err := d.RedeployCaddy(data); err != nil {
+			t.Fatalf("RedeployCaddy error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(installDir, "Caddyfile")); err != nil {
+			t.Errorf("Caddyfile not written: %v", err)
+		}
+		args, _ := os.ReadFile(argsFile)
+		if want := "exec " + CaddyName + " chmod -R 755 /data"; strings.TrimSpace(string(args)) != want {
+			t.Errorf("last docker call = %q, want the new container's setup %q", strings.TrimSpace(string(args)), want)
+		}
+	})
+
+	t.Run("KeepsRunningContainerWhenValidationFails", func(t *testing.T) {
+		argsFile := fakeDockerBinary(t, "invalid Caddyfile", 1)
+		if err := d.RedeployCaddy(data); err == nil {
+			t.Fatal("expected an error when the Caddyfile fails validation")
+		}
+		args, _ := os.ReadFile(argsFile)
+		if !strings.Contains(string(args), "caddy validate") {
+			t.Errorf("Caddy should not be touched after a failed validation, last call %q", args)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// appExternalTargets are the outside services the app container must reach
+var appExternalTargets = []string{"https://getinfinitymetrics.com"}
+
+// NetworkCheck is the outcome of one network diagnostic
+type NetworkCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// DiagnoseNetwork checks the Docker network and the connections between the
+// containers and to the outside, returning every result instead of stopping
+// at the first failure
+func (d *Docker) DiagnoseNetwork() []NetworkCheck {
+	var checks []NetworkCheck
+
+	if _, err := d.RunCommand("network", "inspect", NetworkName, "--format", "{{.Name}}"); err != nil {
+		return append(checks, NetworkCheck{
+			Name:   "Network " + NetworkName,
+			Detail: "does not exist, run 'infinity-metrics reload' to recreate it",
+		})
+	}
+	checks = append(checks, NetworkCheck{Name: "Network " + NetworkName, Passed: true, Detail: "exists"})
+
+	output, err := d.RunCommand("network", "inspect", NetworkName, "--format", "{{range .Containers}}{{.Name}} {{end}}")
+	if err != nil {
+		return append(checks, NetworkCheck{Name: "Connected containers", Detail: err.Error()})
+	}
+	connected := strings.Fields(output)
+	checks = append(checks, NetworkCheck{Name: "Connected containers", Passed: len(connected) > 0, Detail: strings.Join(connected, ", ")})
+
+	appName, appErr := d.ActiveAppContainer()
+	if appErr != nil {
+		checks = append(checks, NetworkCheck{Name: "App container", Detail: appErr.Error()})
+	}
+	caddyRunning := d.IsRunning(CaddyName)
+	if !caddyRunning {
+		checks = append(checks, NetworkCheck{Name: "Caddy container", Detail: fmt.Sprintf("%s is not running", CaddyName)})
+	}
+
+	for _, name := range []string{appName, CaddyName} {
+		if name == "" || (name == CaddyName && !caddyRunning) {
+			continue
+		}
+		check := NetworkCheck{Name: name + " on " + NetworkName, Passed: containsString(connected, name)}
+		if !check.Passed {
+			check.Detail = fmt.Sprintf("not connected, fix with 'docker network connect %s %s'", NetworkName, name)
+		}
+		checks = append(checks, check)
+	}
+
+	if appName != "" && caddyRunning {
+		check := NetworkCheck{Name: "Caddy to app"}
+		if _, err := d.RunCommand("exec", CaddyName, "wget", "-q", "-O", "/dev/null", "-T", "5", "http://"+appName+":8080/_health"); err != nil {
+			check.Detail = fmt.Sprintf("%s cannot reach http://%s:8080/_health: %v", CaddyName, appName, err)
+		} else {
+			check.Passed, check.Detail = true, fmt.Sprintf("http://%s:8080/_health responds", appName)
+		}
+		checks = append(checks, check)
+
+		check = NetworkCheck{Name: "App to Caddy"}
+		// Any HTTP response counts, Caddy redirects plain HTTP to HTTPS
+		if _, err := d.RunCommand("exec", appName, "curl", "-s", "-o", "/dev/null", "--max-time", "5", "http://"+CaddyName); err != nil {
+			check.Detail = fmt.Sprintf("%s cannot reach http://%s: %v", appName, CaddyName, err)
+		} else {
+			check.Passed, check.Detail = true, fmt.Sprintf("http://%s responds", CaddyName)
+		}
+		checks = append(checks, check)
+	}
+
+	if appName != "" {
+		for _, target := range appExternalTargets {
+			check := NetworkCheck{Name: "App to " + target}
+			if _, err := d.RunCommand("exec", appName, "curl", "-s", "-o", "/dev/null", "--max-time", "10", target); err != nil {
+				check.Detail = fmt.Sprintf("unreachable, check DNS and outbound firewall rules: %v", err)
+			} else {
+				check.Passed, check.Detail = true, "reachable"
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package docker
+
+import (
+	"fmt"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/errors"
+)
+
+// SetOffline makes image pulls and digest checks use only the local images,
+// as configured by OFFLINE_MODE for hosts without registry access
+func (d *Docker) SetOffline(offline bool) {
+	d.offline = offline
+}
+
+// pullPolicy returns the docker run --pull value for data: never when the
+// images are side-loaded with docker load, always otherwise
+func pullPolicy(data config.ConfigData) string {
+	if data.OfflineMode {
+		return "never"
+	}
+	return "always"
+}
+
+// requireLocalImage stands in for a pull in offline mode, failing early when
+// the image was not loaded instead of at docker run
+func (d *Docker) requireLocalImage(image string) error {
+	if _, err := d.RunCommand("image", "inspect", image, "--format", "{{.Id}}"); err != nil {
+		return errors.NewDockerError("image_missing_offline", image,
+			fmt.Errorf("OFFLINE_MODE is set and %s is not loaded locally: %w", image, err))
+	}
+	d.logger.Info("Offline mode: using local image %s", image)
+	return nil
+}
@@ -0,0 +1,53 @@
+package docker
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+func TestOfflinePullPolicy(t *testing.T) {
+	data := config.ConfigData{Domain: "analytics.example.com", InstallDir: "/opt/infinity-metrics", AppImage: "app:1", CaddyImage: "caddy:2.7-alpine", OfflineMode: true}
+	for _, command := range DeployCommands(data, AppNamePrimary) {
+		if !strings.Contains(command, "--pull never") || strings.Contains(command, "--pull always") {
+			t.Errorf("offline command %q should use --pull never", command)
+		}
+	}
+}
+
+func TestPullImageOffline(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+	d.SetOffline(true)
+
+	t.Run("LoadedImage", func(t *testing.T) {
+		argsFile := fakeDockerBinary(t, "sha256:abc", 0)
+		if err := d.pullImage("app:1"); err != nil {
+			t.Fatalf("pullImage error: %v", err)
+		}
+		args, _ := os.ReadFile(argsFile)
+		if want := "image inspect app:1 --format {{.Id}}"; strings.TrimSpace(string(args)) != want {
+			t.Errorf("docker args = %q, want %q", strings.TrimSpace(string(args)), want)
+		}
+	})
+
+	t.Run("MissingImage", func(t *testing.T) {
+		fakeDockerBinary(t, "Error: No such image: app:1", 1)
+		err := d.pullImage("app:1")
+		if err == nil || !strings.Contains(err.Error(), "'image_missing_offline'") {
+			t.Fatalf("pullImage error = %v, want an image_missing_offline error", err)
+		}
+	})
+
+	t.Run("NoRegistryCheck", func(t *testing.T) {
+		argsFile := fakeDockerBinary(t, "", 0)
+		pull, err := d.ShouldPullImage("registry.invalid/app:1")
+		if pull || err != nil {
+			t.Errorf("ShouldPullImage = %v, %v; want false, nil offline", pull, err)
+		}
+		if _, err := os.Stat(argsFile); err == nil {
+			t.Error("ShouldPullImage ran docker in offline mode")
+		}
+	})
+}
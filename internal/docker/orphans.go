@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ManagedLabel marks the containers the installer creates so leftovers can be
+// found by label instead of by name
+const ManagedLabel = "com.infinity-metrics.managed"
+
+// ManagedContainer is an installer-created container and its docker state
+// (running, exited, created, ...)
+type ManagedContainer struct {
+	Name  string
+	State string
+}
+
+// ManagedContainers lists every container, running or not, carrying
+// ManagedLabel or one of the fixed container names used before the label
+// was added
+func (d *Docker) ManagedContainers() ([]ManagedContainer, error) {
+	out, err := d.RunCommand("ps", "-a", "--format", "{{.Names}}|{{.State}}|{{.Label \""+ManagedLabel+"\"}}")
+	if err != nil {
+		return nil, fmt.Errorf("list containers: %w", err)
+	}
+	return parseManagedContainers(out), nil
+}
+
+func parseManagedContainers(out string) []ManagedContainer {
+	var containers []ManagedContainer
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "|", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		labeled := len(fields) == 3 && fields[2] != ""
+		if !labeled && !isManagedName(fields[0]) {
+			continue
+		}
+		containers = append(containers, ManagedContainer{Name: fields[0], State: fields[1]})
+	}
+	return containers
+}
+
+func isManagedName(name string) bool {
+	return name == CaddyName || name == AppNamePrimary || name == AppNameSecondary
+}
+
+// OrphanedContainers returns the managed containers left behind by an
+// interrupted install. A complete deployment, Caddy and exactly one app
+// container running, only leaves its stopped containers behind; any other
+// combination is partial and all of its containers are reported.
+func (d *Docker) OrphanedContainers() ([]ManagedContainer, error) {
+	containers, err := d.ManagedContainers()
+	if err != nil {
+		return nil, err
+	}
+	return orphanedContainers(containers), nil
+}
+
+func orphanedContainers(containers []ManagedContainer) []ManagedContainer {
+	caddyRunning, appsRunning := false, 0
+	for _, container := range containers {
+		if container.State != "running" {
+			continue
+		}
+		switch container.Name {
+		case CaddyName:
+			caddyRunning = true
+		case AppNamePrimary, AppNameSecondary:
+			appsRunning++
+		}
+	}
+
+	if !caddyRunning || appsRunning != 1 {
+		return containers
+	}
+	var stopped []ManagedContainer
+	for _, container := range containers {
+		if container.State != "running" {
+			stopped = append(stopped, container)
+		}
+	}
+	return stopped
+}
+
+// RemoveContainers stops and removes containers, returning the first failure
+// after trying them all
+func (d *Docker) RemoveContainers(containers []ManagedContainer) error {
+	var firstErr error
+	for _, container := range containers {
+		d.logger.Info("Removing container %s (%s)", container.Name, container.State)
+		if err := d.StopAndRemove(container.Name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
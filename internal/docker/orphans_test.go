@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseManagedContainers(t *testing.T) {
+	out := "infinity-app-1|exited|\ninfinity-caddy|running|true\nother-app|running|\ncustom-name|created|true\n"
+	want := []ManagedContainer{
+		{Name: "infinity-app-1", State: "exited"},
+		{Name: "infinity-caddy", State: "running"},
+		{Name: "custom-name", State: "created"},
+	}
+	if got := parseManagedContainers(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseManagedContainers() = %v, want %v", got, want)
+	}
+}
+
+func TestOrphanedContainers(t *testing.T) {
+	caddy := ManagedContainer{Name: CaddyName, State: "running"}
+	app1 := ManagedContainer{Name: AppNamePrimary, State: "running"}
+	app2 := ManagedContainer{Name: AppNameSecondary, State: "running"}
+	stoppedApp2 := ManagedContainer{Name: AppNameSecondary, State: "exited"}
+
+	tests := []struct {
+		name       string
+		containers []ManagedContainer
+		want       []ManagedContainer
+	}{
+		{"Nothing deployed", nil, nil},
+		{"Complete deployment", []ManagedContainer{caddy, app1}, nil},
+		{"Complete deployment with a stopped leftover", []ManagedContainer{caddy, app1, stoppedApp2}, []ManagedContainer{stoppedApp2}},
+		{"App without Caddy", []ManagedContainer{app1}, []ManagedContainer{app1}},
+		{"Duplicate app containers", []ManagedContainer{caddy, app1, app2}, []ManagedContainer{caddy, app1, app2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orphanedContainers(tt.containers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orphanedContainers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
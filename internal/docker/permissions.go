@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// dataDirs are the directories Deploy creates and bind-mounts into the containers.
+// Backups stay under the install dir when DB_STORAGE_PATH relocates the app storage.
+func dataDirs(data config.ConfigData) []string {
+	dirs := []string{
+		filepath.Join(data.InstallDir, "storage"),
+		filepath.Join(data.InstallDir, "logs"),
+		filepath.Join(data.InstallDir, "caddy"),
+		filepath.Join(data.InstallDir, "caddy", "config"),
+		filepath.Join(data.InstallDir, "storage", "backups"),
+	}
+	if data.DBStoragePath != "" {
+		dirs = append(dirs, data.DBStoragePath)
+	}
+	return dirs
+}
+
+func createDataDirs(data config.ConfigData) error {
+	for _, dir := range dataDirs(data) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create dir %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// RepairPermissions restores the ownership and modes Deploy sets up: the data
+// directories are recreated if missing, owned by the installer's user (or
+// CONTAINER_USER for storage and logs) with directories at 0755, and .env is
+// made readable by root only since it holds the private key
+func (d *Docker) RepairPermissions(data config.ConfigData) error {
+	if err := createDataDirs(data); err != nil {
+		return err
+	}
+
+	uid, gid := os.Geteuid(), os.Getegid()
+	dirs := []string{
+		filepath.Join(data.InstallDir, "storage"),
+		filepath.Join(data.InstallDir, "logs"),
+		filepath.Join(data.InstallDir, "caddy"),
+	}
+	if data.DBStoragePath != "" {
+		dirs = append(dirs, data.DBStoragePath)
+	}
+	for _, dir := range dirs {
+		d.logger.Info("Resetting ownership of %s to %d:%d", dir, uid, gid)
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if err := os.Lchown(path, uid, gid); err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				return os.Chmod(path, 0o755)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("repair %s: %w", dir, err)
+		}
+	}
+
+	// A non-root app container writes with its own uid
+	if data.ContainerUser != "" {
+		d.logger.Info("Handing storage and logs to CONTAINER_USER %s", data.ContainerUser)
+		if err := d.chownAppVolumes(data); err != nil {
+			return err
+		}
+	}
+
+	envFile := filepath.Join(data.InstallDir, ".env")
+	if err := os.Chmod(envFile, 0o600); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("restrict %s: %w", envFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+func TestRepairPermissions(t *testing.T) {
+	installDir := t.TempDir()
+	storage := filepath.Join(installDir, "storage")
+	if err := os.MkdirAll(storage, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Left behind by a restore done with a restrictive umask
+	if err := os.Chmod(storage, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	envFile := filepath.Join(installDir, ".env")
+	if err := os.WriteFile(envFile, []byte("INFINITY_METRICS_DOMAIN=example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := config.ConfigData{InstallDir: installDir, DBStoragePath: filepath.Join(t.TempDir(), "db")}
+	d := &Docker{logger: testLogger(t)}
+	if err := d.RepairPermissions(data); err != nil {
+		t.Fatalf("RepairPermissions: %v", err)
+	}
+
+	for _, dir := range dataDirs(data) {
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("%s was not created: %v", dir, err)
+		}
+		if info.Mode().Perm() != 0o755 {
+			t.Errorf("%s mode = %o, want 755", dir, info.Mode().Perm())
+		}
+	}
+	info, err := os.Stat(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf(".env mode = %o, want 600", info.Mode().Perm())
+	}
+}
+
+func TestChownAppVolumesSkipsBackups(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing ownership needs root")
+	}
+	installDir := t.TempDir()
+	backups := filepath.Join(installDir, "storage", "backups")
+	if err := os.MkdirAll(backups, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(backups, "backup_20240101_120000.db")
+	database := filepath.Join(installDir, "storage", "infinity-metrics-production.db")
+	for _, file := range []string{backup, database} {
+		if err := os.WriteFile(file, []byte("db"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := config.ConfigData{InstallDir: installDir, BackupPath: backups, ContainerUser: "1000:1000"}
+	d := &Docker{logger: testLogger(t)}
+	if err := d.chownAppVolumes(data); err != nil {
+		t.Fatalf("chownAppVolumes: %v", err)
+	}
+
+	owner := func(path string) uint32 {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Sys().(*syscall.Stat_t).Uid
+	}
+	if uid := owner(database); uid != 1000 {
+		t.Errorf("database owner = %d, want 1000", uid)
+	}
+	for _, path := range []string{backups, backup} {
+		if uid := owner(path); uid != 0 {
+			t.Errorf("%s owner = %d, want it left to root", path, uid)
+		}
+	}
+	if info, _ := os.Stat(backups); info.Mode().Perm() != 0o700 {
+		t.Errorf("backup dir mode = %o, want 700", info.Mode().Perm())
+	}
+}
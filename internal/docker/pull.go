@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"infinity-metrics-installer/internal/errors"
+)
+
+const (
+	PullMaxRetriesEnvVar  = "PULL_MAX_RETRIES"
+	PullBackoffMaxEnvVar  = "PULL_BACKOFF_MAX"
+	DefaultPullBackoffMax = 30 * time.Second
+)
+
+// pullRetryDelay is multiplied by the attempt number between pull attempts
+var pullRetryDelay = 2 * time.Second
+
+// PullMaxRetries returns how many times an image pull is attempted.
+// PULL_MAX_RETRIES must be a positive integer; other values fall back to MaxRetries.
+func PullMaxRetries() int {
+	return envPositiveInt(PullMaxRetriesEnvVar, MaxRetries)
+}
+
+// PullBackoffMax returns the longest wait between pull attempts. PULL_BACKOFF_MAX
+// accepts a duration ("45s", "2m") or a number of seconds; invalid values fall
+// back to DefaultPullBackoffMax.
+func PullBackoffMax() time.Duration {
+	return envDuration(PullBackoffMaxEnvVar, DefaultPullBackoffMax)
+}
+
+// pullBackoff returns the wait after the given failed attempt (1-based),
+// growing linearly up to max
+func pullBackoff(attempt int, max time.Duration) time.Duration {
+	delay := time.Duration(attempt) * pullRetryDelay
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// SetRegistryCredentials sets the account pulls log in with after an
+// anonymous pull is rate limited, as configured by REGISTRY_USERNAME and
+// REGISTRY_PASSWORD. Both must be set for a login to be attempted.
+func (d *Docker) SetRegistryCredentials(username, password string) {
+	d.registryUsername, d.registryPassword = username, password
+}
+
+// isPullRateLimited reports whether a pull failed because the registry
+// limits the pull rate, as Docker Hub does for anonymous pulls per IP
+func isPullRateLimited(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "toomanyrequests") || strings.Contains(message, "pull rate limit")
+}
+
+// isPullUnauthorized reports whether a pull was refused for lack of valid
+// credentials, as when a registry token expires after the digest check
+func isPullUnauthorized(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unauthorized") || strings.Contains(message, "authentication required")
+}
+
+// registryLogin runs docker login for the registry of image, passing the
+// password on stdin so it never shows up in the process list
+func (d *Docker) registryLogin(image string) error {
+	args := []string{"login", "--username", d.registryUsername, "--password-stdin"}
+	if ref, err := d.parseReference(image); err == nil && ref.Context().RegistryStr() != "index.docker.io" {
+		args = append(args, ref.Context().RegistryStr())
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdin = strings.NewReader(d.registryPassword)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w - %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// pullImage pulls an image, retrying with a capped backoff. A rate-limited
+// pull is retried once after logging in with the registry credentials, if
+// any, instead of waiting out the backoff against the same limit. An
+// unauthorized pull logs in again once with those credentials, in case the
+// token expired since the last login. In offline mode the image must already
+// be loaded and nothing is pulled.
+func (d *Docker) pullImage(image string) error {
+	if d.offline {
+		return d.requireLocalImage(image)
+	}
+	retries := PullMaxRetries()
+	backoffMax := PullBackoffMax()
+	reauthenticated := false
+	for i := 0; i < retries; i++ {
+		_, err := d.RunCommand("pull", image)
+		if err == nil {
+			return nil
+		}
+		if isPullRateLimited(err) {
+			if d.registryUsername == "" || d.registryPassword == "" {
+				d.logger.Error("Pull of %s was rate limited: the registry limits anonymous pulls from this IP. Set REGISTRY_USERNAME and REGISTRY_PASSWORD in .env to pull with an account, which has a higher limit", image)
+				return errors.NewDockerError("pull_rate_limited", image, err)
+			}
+			if d.registryLoggedIn {
+				d.logger.Error("Pull of %s is rate limited even when logged in as %s, retry later", image, d.registryUsername)
+				return errors.NewDockerError("pull_rate_limited", image, err)
+			}
+			d.logger.Warn("Pull of %s was rate limited, logging in to the registry as %s", image, d.registryUsername)
+			if loginErr := d.registryLogin(image); loginErr != nil {
+				return errors.NewDockerError("registry_login", image, loginErr)
+			}
+			d.registryLoggedIn = true
+			i-- // the authenticated retry does not count against PULL_MAX_RETRIES
+			continue
+		}
+		if isPullUnauthorized(err) && d.registryUsername != "" && d.registryPassword != "" {
+			if reauthenticated {
+				return errors.NewDockerError("pull_unauthorized", image, err)
+			}
+			d.logger.Warn("Pull of %s was unauthorized, logging in to the registry again as %s", image, d.registryUsername)
+			if loginErr := d.registryLogin(image); loginErr != nil {
+				return errors.NewDockerError("registry_login", image, loginErr)
+			}
+			d.registryLoggedIn = true
+			reauthenticated = true
+			i-- // as above, the retry with a fresh token is free
+			continue
+		}
+		if i == retries-1 {
+			return fmt.Errorf("pull %s failed after %d retries: %w", image, retries, err)
+		}
+		d.logger.Warn("Pull %s failed, retrying (%d/%d)", image, i+1, retries)
+		time.Sleep(pullBackoff(i+1, backoffMax))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultKeepImageVersions keeps the running app image and the one before it
+	DefaultKeepImageVersions = 2
+	// rollbackTagPrefix marks previous app images kept for rollback
+	rollbackTagPrefix = "rollback-"
+)
+
+// imageRepository strips the tag and digest from an image reference,
+// keeping registry ports such as registry.internal:5000/app
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// rollbackTagsToRemove returns the rollback tags outside the retention window.
+// keep counts the running image, so keep-1 rollback tags survive.
+func rollbackTagsToRemove(tags []string, keep int) []string {
+	var rollback []string
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, rollbackTagPrefix) {
+			rollback = append(rollback, tag)
+		}
+	}
+	// Tags embed a sortable timestamp, newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(rollback)))
+	if keep < 1 {
+		keep = 1
+	}
+	if len(rollback) <= keep-1 {
+		return nil
+	}
+	return rollback[keep-1:]
+}
+
+// containerImageID returns the ID of the image a container was created from
+func (d *Docker) containerImageID(name string) string {
+	output, err := d.RunCommand("inspect", "--format", "{{.Image}}", name)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// retainPreviousImage tags the image the replaced app container ran so
+// `image prune` keeps it, then drops rollback tags beyond the keep window
+func (d *Docker) retainPreviousImage(appImage, previousID, currentID string, keep int) {
+	repo := imageRepository(appImage)
+	if keep > 1 && previousID != "" && previousID != currentID {
+		tag := repo + ":" + rollbackTagPrefix + time.Now().UTC().Format("20060102150405")
+		if _, err := d.RunCommand("tag", previousID, tag); err != nil {
+			d.logger.Warn("Failed to keep previous app image for rollback: %v", err)
+		} else {
+			d.logger.Info("Kept previous app image as %s", tag)
+		}
+	}
+
+	output, err := d.RunCommand("images", "--format", "{{.Tag}}", repo)
+	if err != nil {
+		d.logger.Warn("Failed to list app images: %v", err)
+		return
+	}
+	for _, tag := range rollbackTagsToRemove(strings.Fields(output), keep) {
+		if _, err := d.RunCommand("rmi", repo+":"+tag); err != nil {
+			d.logger.Warn("Failed to remove old app image %s:%s: %v", repo, tag, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"os"
+	"strings"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// selinuxEnforcePath reads 1 when SELinux is enforcing, overridden in tests
+var selinuxEnforcePath = "/sys/fs/selinux/enforce"
+
+// selinuxEnforcing reports whether SELinux is loaded and enforcing
+func selinuxEnforcing() bool {
+	content, err := os.ReadFile(selinuxEnforcePath)
+	return err == nil && strings.TrimSpace(string(content)) == "1"
+}
+
+// selinuxRelabel reports whether bind mounts need relabeling for the
+// containers to access them: as SELINUX_RELABEL says, or when SELinux is
+// enforcing if it is unset or auto
+func selinuxRelabel(data config.ConfigData) bool {
+	switch data.SELinuxRelabel {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return selinuxEnforcing()
+}
+
+// bindMount returns the -v value mounting host at container with options
+// such as ro. With relabel set it adds the shared z label rather than the
+// private Z, since the logs directory is mounted into both containers. Paths
+// the user points .env at, TLS_CERT_PATH, TLS_KEY_PATH and DB_STORAGE_PATH,
+// are mounted without it: z would change the labels of files other services
+// on the host may rely on, such as a certificate shared with a web server.
+func bindMount(host, container string, relabel bool, options ...string) string {
+	if relabel {
+		options = append(options, "z")
+	}
+	mount := host + ":" + container
+	if len(options) > 0 {
+		mount += ":" + strings.Join(options, ",")
+	}
+	return mount
+}
+
+// warnUnlabeledMount warns that path, set by key in .env, is not relabeled
+// although the other mounts are, and how to label it for the containers
+func (d *Docker) warnUnlabeledMount(data config.ConfigData, key, path string) {
+	if path == "" || !selinuxRelabel(data) {
+		return
+	}
+	d.logger.Warn("%s %s is not relabeled for SELinux. If the container cannot access it, label it with: chcon -R -t container_file_t %s", key, path, path)
+}
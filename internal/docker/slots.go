@@ -0,0 +1,125 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// maintenanceUpstreams starts the line of a maintenance mode Caddyfile that
+// records the upstreams, so a pinned slot survives maintenance mode
+const maintenanceUpstreams = "# Upstreams after maintenance:"
+
+// pinnedSlot returns the only app container the reverse_proxy of caddyfile
+// points to, or "" when it proxies to both slots as after a deploy
+func pinnedSlot(caddyfile string) string {
+	for _, line := range strings.Split(caddyfile, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "reverse_proxy ") && !strings.HasPrefix(line, maintenanceUpstreams) {
+			continue
+		}
+		primary := strings.Contains(line, AppNamePrimary+":")
+		secondary := strings.Contains(line, AppNameSecondary+":")
+		switch {
+		case primary && !secondary:
+			return AppNamePrimary
+		case secondary && !primary:
+			return AppNameSecondary
+		}
+	}
+	return ""
+}
+
+// caddyfile returns the Caddyfile of the installation, or "" when it cannot be read
+func (d *Docker) caddyfile() string {
+	installDir := d.installDir
+	if installDir == "" {
+		installDir = defaultInstallDir
+	}
+	content, _ := os.ReadFile(filepath.Join(installDir, "Caddyfile"))
+	return string(content)
+}
+
+// generateCaddyfileKeepingPin renders the Caddyfile like generateCaddyfile,
+// but keeps proxying to a single slot when switch-slot pinned Caddy to it
+func (d *Docker) generateCaddyfileKeepingPin(data config.ConfigData) (string, error) {
+	previous, _ := os.ReadFile(filepath.Join(data.InstallDir, "Caddyfile"))
+	if pinned := pinnedSlot(string(previous)); pinned != "" {
+		d.logger.Info("Caddy stays pinned to %s, run 'infinity-metrics reload' to route to both slots again", pinned)
+		return d.generateCaddyfileFor(data, pinned)
+	}
+	return d.generateCaddyfile(data)
+}
+
+// otherSlot returns the app container name that is not name
+func otherSlot(name string) string {
+	if name == AppNamePrimary {
+		return AppNameSecondary
+	}
+	return AppNamePrimary
+}
+
+// currentSlot returns the app container Caddy currently proxies to: the slot
+// the Caddyfile is pinned to, or else the primary unless only the secondary runs
+func (d *Docker) currentSlot(caddyfile string) string {
+	if slot := pinnedSlot(caddyfile); slot != "" {
+		return slot
+	}
+	if d.IsRunning(AppNameSecondary) && !d.IsRunning(AppNamePrimary) {
+		return AppNameSecondary
+	}
+	return AppNamePrimary
+}
+
+// SwitchSlot points Caddy at the standby app container only, for testing it
+// or recovering when Caddy routes to a removed container. Both containers
+// must exist and the target must be healthy. Maintenance mode and the
+// watchdog keep the pin, the next reload or update proxies to both slots again.
+func (d *Docker) SwitchSlot(data config.ConfigData) (from, to string, err error) {
+	d.ApplySettings(data)
+	for _, name := range []string{AppNamePrimary, AppNameSecondary} {
+		if !d.containerExists(name) {
+			return "", "", fmt.Errorf("switching slots needs both %s and %s, %s does not exist", AppNamePrimary, AppNameSecondary, name)
+		}
+	}
+	if !d.IsRunning(CaddyName) {
+		return "", "", fmt.Errorf("%s is not running, run 'infinity-metrics reload' first", CaddyName)
+	}
+
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	previous, err := os.ReadFile(caddyFile)
+	if err != nil {
+		return "", "", fmt.Errorf("read Caddyfile: %w", err)
+	}
+	from = d.currentSlot(string(previous))
+	to = otherSlot(from)
+
+	if !d.IsRunning(to) {
+		return from, to, fmt.Errorf("%s is not running, start it before switching to it", to)
+	}
+	if err := d.CheckAppHealth(to); err != nil {
+		return from, to, fmt.Errorf("%s is not healthy, keeping traffic on %s: %w", to, from, err)
+	}
+
+	content, err := d.generateCaddyfileFor(data, to)
+	if err != nil {
+		return from, to, fmt.Errorf("generate Caddyfile: %w", err)
+	}
+	if pinnedSlot(content) != to {
+		return from, to, fmt.Errorf("the Caddyfile template %s does not proxy to {{.Upstreams}}, so the slot cannot be switched", data.CaddyTemplate)
+	}
+	if err := os.WriteFile(caddyFile, []byte(content), 0o644); err != nil {
+		return from, to, fmt.Errorf("write Caddyfile: %w", err)
+	}
+	d.logger.Info("Reloading Caddy configuration to point to %s...", to)
+	if _, err := d.RunCommand("exec", CaddyName, "caddy", "reload", "--config", "/etc/caddy/Caddyfile"); err != nil {
+		if restoreErr := os.WriteFile(caddyFile, previous, 0o644); restoreErr != nil {
+			d.logger.Error("Failed to restore the previous Caddyfile: %v", restoreErr)
+		}
+		return from, to, fmt.Errorf("caddy reload failed, traffic stays on %s: %w", from, err)
+	}
+	return from, to, nil
+}
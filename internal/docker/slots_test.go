@@ -0,0 +1,130 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+func TestPinnedSlot(t *testing.T) {
+	d := &Docker{logger: testLogger(t)}
+	data := config.ConfigData{Domain: "analytics.example.com"}
+
+	both, err := d.generateCaddyfile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot := pinnedSlot(both); slot != "" {
+		t.Errorf("pinnedSlot() = %q for a Caddyfile proxying to both slots", slot)
+	}
+
+	pinned, err := d.generateCaddyfileFor(data, AppNameSecondary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(pinned, "reverse_proxy "+AppNameSecondary+":8080 {") {
+		t.Errorf("Caddyfile should only proxy to %s:\n%s", AppNameSecondary, pinned)
+	}
+	if slot := pinnedSlot(pinned); slot != AppNameSecondary {
+		t.Errorf("pinnedSlot() = %q, want %q", slot, AppNameSecondary)
+	}
+	if other := otherSlot(AppNameSecondary); other != AppNamePrimary {
+		t.Errorf("otherSlot(%q) = %q", AppNameSecondary, other)
+	}
+}
+
+func TestCurrentSlotBothRunningPinnedToSecondary(t *testing.T) {
+	// Both app containers report as running
+	fakeDockerBinary(t, "abc123", 0)
+	d := &Docker{logger: testLogger(t)}
+	data := config.ConfigData{Domain: "analytics.example.com"}
+
+	both, err := d.generateCaddyfile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot := d.currentSlot(both); slot != AppNamePrimary {
+		t.Errorf("currentSlot() = %q for an unpinned Caddyfile, want %q", slot, AppNamePrimary)
+	}
+
+	pinned, err := d.generateCaddyfileFor(data, AppNameSecondary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot := d.currentSlot(pinned); slot != AppNameSecondary {
+		t.Errorf("currentSlot() = %q, want the pinned %q", slot, AppNameSecondary)
+	}
+	if slot := otherSlot(d.currentSlot(pinned)); slot != AppNamePrimary {
+		t.Errorf("update would deploy into %q, want %q", slot, AppNamePrimary)
+	}
+}
+
+func TestActiveAppContainerFollowsPinnedSlot(t *testing.T) {
+	dir := t.TempDir()
+	d := &Docker{logger: testLogger(t)}
+	data := config.ConfigData{Domain: "analytics.example.com", InstallDir: dir}
+	d.ApplySettings(data)
+	pinned, err := d.generateCaddyfileFor(data, AppNameSecondary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Caddyfile"), []byte(pinned), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both app containers run, Caddy only routes to the secondary
+	fakeDockerBinary(t, "abc123", 0)
+	if name, err := d.ActiveAppContainer(); err != nil || name != AppNameSecondary {
+		t.Errorf("ActiveAppContainer() = %q, %v, want the pinned %q", name, err, AppNameSecondary)
+	}
+
+	// The pinned slot crashed, the standby must not be reported in its place
+	bin := t.TempDir()
+	script := "#!/bin/sh\ncase \"$*\" in *name=" + AppNamePrimary + "*) echo abc123 ;; esac\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	if name, err := d.ActiveAppContainer(); err == nil {
+		t.Errorf("ActiveAppContainer() = %q, want an error for the stopped pinned slot", name)
+	}
+}
+
+func TestGenerateCaddyfileKeepingPin(t *testing.T) {
+	dir := t.TempDir()
+	d := &Docker{logger: testLogger(t)}
+	data := config.ConfigData{Domain: "analytics.example.com", InstallDir: dir}
+
+	content, err := d.generateCaddyfileKeepingPin(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot := pinnedSlot(content); slot != "" {
+		t.Errorf("without a pin the Caddyfile should proxy to both slots, got %q", slot)
+	}
+
+	pinned, err := d.generateCaddyfileFor(data, AppNameSecondary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Caddyfile"), []byte(pinned), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Maintenance on and off again must not drop the pin
+	for _, maintenance := range []bool{true, false} {
+		data.MaintenanceMode = maintenance
+		content, err = d.generateCaddyfileKeepingPin(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slot := pinnedSlot(content); slot != AppNameSecondary {
+			t.Errorf("pinnedSlot() = %q with maintenance %v, want %q kept", slot, maintenance, AppNameSecondary)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Caddyfile"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
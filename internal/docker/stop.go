@@ -0,0 +1,48 @@
+package docker
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"infinity-metrics-installer/internal/errors"
+)
+
+const (
+	StopTimeoutEnvVar  = "STOP_TIMEOUT"
+	DefaultStopTimeout = 30 * time.Second
+)
+
+// StopTimeout returns how long a stopping container gets to shut down before
+// Docker kills it: STOP_TIMEOUT from the environment, else configured, the
+// .env value, else a default long enough for the app to flush buffered writes
+// to SQLite. STOP_TIMEOUT accepts a duration ("1m") or a number of seconds.
+func StopTimeout(configured time.Duration) time.Duration {
+	if configured <= 0 {
+		configured = DefaultStopTimeout
+	}
+	return envDuration(StopTimeoutEnvVar, configured)
+}
+
+// stopArgs builds the docker stop command, rounding the timeout up to whole
+// seconds as `docker stop -t` requires
+func stopArgs(name string, timeout time.Duration) []string {
+	seconds := int(math.Ceil(timeout.Seconds()))
+	return []string{"stop", "-t", strconv.Itoa(seconds), name}
+}
+
+// StopApp stops the running app containers without removing them, so the
+// database can be replaced while nothing writes to it. The next install or
+// reload starts the app again.
+func (d *Docker) StopApp() error {
+	for _, name := range []string{AppNamePrimary, AppNameSecondary} {
+		if !d.IsRunning(name) {
+			continue
+		}
+		d.logger.Info("Stopping %s", name)
+		if _, err := d.RunCommand(stopArgs(name, StopTimeout(d.stopTimeout))...); err != nil {
+			return errors.NewDockerError("stop", name, err)
+		}
+	}
+	return nil
+}
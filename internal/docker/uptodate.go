@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// RunningImagesCurrent reports whether an update would change nothing: neither
+// image has a newer remote digest and the running app and Caddy containers
+// already use the local images. Otherwise it returns the first reason found.
+func (d *Docker) RunningImagesCurrent(images config.DockerImages) (bool, string) {
+	for _, image := range []string{images.AppImage, images.CaddyImage} {
+		if shouldPull, err := d.ShouldPullImage(image); err != nil || shouldPull {
+			return false, fmt.Sprintf("%s has a newer version or could not be checked", image)
+		}
+	}
+
+	appName, err := d.ActiveAppContainer()
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, running := range []struct{ container, image string }{
+		{appName, images.AppImage},
+		{CaddyName, images.CaddyImage},
+	} {
+		if current, err := d.containerUsesImage(running.container, running.image); err != nil {
+			return false, fmt.Sprintf("could not compare %s with %s: %v", running.container, running.image, err)
+		} else if !current {
+			return false, fmt.Sprintf("%s is not running the local %s", running.container, running.image)
+		}
+	}
+	return true, ""
+}
+
+// containerUsesImage reports whether the container was created from the image
+// the reference currently points to locally
+func (d *Docker) containerUsesImage(container, image string) (bool, error) {
+	containerImage, err := d.RunCommand("inspect", container, "--format", "{{.Image}}")
+	if err != nil {
+		return false, err
+	}
+	imageID, err := d.RunCommand("inspect", image, "--format", "{{.Id}}")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(containerImage) == strings.TrimSpace(imageID), nil
+}
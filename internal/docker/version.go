@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MinVersion is the oldest Docker Engine the installer supports. 20.10 added
+// `docker run --pull` and the network and restart behavior deployments rely on.
+const MinVersion = "20.10.0"
+
+// ServerVersion returns the version reported by the Docker daemon
+func (d *Docker) ServerVersion() (string, error) {
+	output, err := d.RunCommand("version", "--format", "{{.Server.Version}}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// CheckVersion returns an error with an upgrade suggestion when version is
+// older than MinVersion
+func CheckVersion(version string) error {
+	current, err := parseVersion(version)
+	if err != nil {
+		return err
+	}
+	minimum, _ := parseVersion(MinVersion)
+	if compareVersions(current, minimum) < 0 {
+		return fmt.Errorf("docker %s is too old, version %s or newer is required; upgrade with 'curl -fsSL https://get.docker.com | sh' or your distribution's docker-ce packages", version, MinVersion)
+	}
+	return nil
+}
+
+// parseVersion parses the numeric part of versions such as "24.0.7",
+// "20.10.21+dfsg1" or "27.3.1-1"
+func parseVersion(version string) ([3]int, error) {
+	var parts [3]int
+	numeric := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(numeric, "-+~ "); i >= 0 {
+		numeric = numeric[:i]
+	}
+	fields := strings.Split(numeric, ".")
+	if len(fields) < 2 || len(fields) > 3 {
+		return parts, fmt.Errorf("unrecognized docker version %q", version)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("unrecognized docker version %q", version)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package docker
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+// checkAppVolumesWritable creates and removes a file in every bind-mounted
+// directory of the app container, as the user the app runs as. A read-only
+// mount or a host directory that user cannot write to otherwise only shows up
+// later, when the app silently fails to persist data.
+func (d *Docker) checkAppVolumesWritable(data config.ConfigData, name string) error {
+	for _, volume := range []struct{ container, host string }{
+		{"/app/storage", data.StorageDir()},
+		{"/app/logs", filepath.Join(data.InstallDir, "logs")},
+	} {
+		probe := volume.container + "/.write-test-" + name
+		if _, err := d.RunCommand("exec", name, "sh", "-c", "touch "+probe+" && rm -f "+probe); err != nil {
+			return fmt.Errorf("%s is not writable in %s: check that %s is not mounted read-only and that the container user can write to it: %w",
+				volume.container, name, volume.host, err)
+		}
+	}
+	d.logger.Debug("Storage and logs volumes are writable in %s", name)
+	return nil
+}
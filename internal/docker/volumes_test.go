@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+)
+
+func TestCheckAppVolumesWritable(t *testing.T) {
+	data := config.ConfigData{InstallDir: "/opt/infinity-metrics", DBStoragePath: "/mnt/analytics"}
+	d := &Docker{logger: testLogger(t)}
+
+	t.Run("Writable", func(t *testing.T) {
+		argsFile := fakeDockerBinary(t, "", 0)
+		if err := d.checkAppVolumesWritable(data, AppNamePrimary); err != nil {
+			t.Fatalf("checkAppVolumesWritable error: %v", err)
+		}
+		args, _ := os.ReadFile(argsFile)
+		want := "exec " + AppNamePrimary + " sh -c touch /app/logs/.write-test-" + AppNamePrimary
+		if !strings.HasPrefix(string(args), want) {
+			t.Errorf("last docker call = %q, want it to probe the logs volume", args)
+		}
+	})
+
+	t.Run("ReadOnlyMount", func(t *testing.T) {
+		fakeDockerBinary(t, "touch: /app/storage/.write-test: Read-only file system", 1)
+		err := d.checkAppVolumesWritable(data, AppNamePrimary)
+		if err == nil {
+			t.Fatal("expected an error for a read-only storage mount")
+		}
+		for _, want := range []string{"/app/storage is not writable", "/mnt/analytics"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error = %q, want it to contain %q", err, want)
+			}
+		}
+	})
+}
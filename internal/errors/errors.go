@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"runtime/debug"
 	"time"
 )
 
@@ -126,11 +127,27 @@ func RetryWithBackoff(operation func() error, maxRetries int, baseDelay time.Dur
 	return fmt.Errorf("operation failed after %d retries: %w", maxRetries, lastErr)
 }
 
-// SafeExecute executes a function and returns an error if it panics
+// PanicError is a recovered panic with the stack where it happened
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic recovered: %v", e.Value)
+}
+
+// NewPanicError captures the current stack for a value returned by recover.
+// Call it from the deferred function so the stack includes the panic site.
+func NewPanicError(value interface{}) *PanicError {
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+// SafeExecute executes a function and returns a *PanicError if it panics
 func SafeExecute(fn func() error) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("panic recovered: %v", r)
+			err = NewPanicError(r)
 		}
 	}()
 	return fn()
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -406,4 +407,39 @@ func TestErrorsAs(t *testing.T) {
 	if target.Field != "test" {
 		t.Errorf("Unwrapped error field = %v, want %v", target.Field, "test")
 	}
-}
\ No newline at end of file
+}
+
+func TestUserMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantMessage string
+		wantHint    string
+	}{
+		{"Nil", nil, "", ""},
+		{"Untyped", fmt.Errorf("lock held by pid 42"), "lock held by pid 42", ""},
+		{
+			"WrappedDocker",
+			fmt.Errorf("update failed: %w", NewDockerError("health_check", "infinity-app-2", fmt.Errorf("exit status 1: curl: (7) Failed to connect"))),
+			"docker health check failed for infinity-app-2",
+			"logs app",
+		},
+		{"DockerDefault", NewDockerError("stop", "infinity-caddy", fmt.Errorf("daemon not running")), "docker stop failed for infinity-caddy", "systemctl status docker"},
+		{"Config", fmt.Errorf("invalid configuration: %w", NewConfigError("app_image", "x", "invalid image reference")), "invalid APP_IMAGE setting: invalid image reference", "Fix APP_IMAGE"},
+		{"Validation", NewValidationError("license", "abc", "license key too short"), "invalid license: license key too short", "Correct the value"},
+		{"Network", NewNetworkError("fetch", "https://api.github.com", fmt.Errorf("dial tcp: i/o timeout")), "could not reach https://api.github.com", "internet connection"},
+		{"Installation", NewInstallationError("docker", "install_docker", fmt.Errorf("apt failed")), "installing docker failed at install docker", "--resume"},
+		{"Panic", NewPanicError("nil map"), "an unexpected internal error occurred", "bug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, hint := UserMessage(tt.err)
+			if message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", message, tt.wantMessage)
+			}
+			if (tt.wantHint == "" && hint != "") || !strings.Contains(hint, tt.wantHint) {
+				t.Errorf("hint = %q, want it to mention %q", hint, tt.wantHint)
+			}
+		})
+	}
+}
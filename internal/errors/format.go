@@ -0,0 +1,69 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// dockerHints suggest a next step for Docker operations that have a more
+// specific remedy than checking the daemon
+var dockerHints = map[string]string{
+	"health_check":          "The new app container did not become healthy, see 'infinity-metrics logs app' for why",
+	"migrate":               "The database migration failed, see 'infinity-metrics logs app' and restore with 'infinity-metrics restore-db' if needed",
+	"validate_caddyfile":    "The generated Caddyfile is invalid, check CADDYFILE_TEMPLATE, CADDY_GLOBAL_OPTIONS and the TLS settings in .env",
+	"network_connect":       "Run 'infinity-metrics network-diagnostics' to check the container network",
+	"volume_check":          "Run 'infinity-metrics repair-permissions', and check CONTAINER_USER and DB_STORAGE_PATH in .env",
+	"pull_rate_limited":     "The registry limits anonymous pulls from this IP, set REGISTRY_USERNAME and REGISTRY_PASSWORD in .env to pull with an account, or retry later",
+	"registry_login":        "Check REGISTRY_USERNAME and REGISTRY_PASSWORD in .env",
+	"pull_unauthorized":     "The registry refused the credentials even after logging in again, check REGISTRY_USERNAME and REGISTRY_PASSWORD in .env and that the account can pull the image",
+	"image_missing_offline": "Load the image with 'docker load -i <archive>' first, or remove OFFLINE_MODE from .env to pull it",
+}
+
+// UserMessage renders err for end users from the first typed error in its
+// chain: a short message without Docker output or wrapped internals, and a
+// suggested next step. Errors without a typed cause keep their full text and
+// get no hint. Log the full chain separately for debugging.
+func UserMessage(err error) (message, hint string) {
+	if err == nil {
+		return "", ""
+	}
+
+	var validationErr *ValidationError
+	var configErr *ConfigError
+	var dockerErr *DockerError
+	var networkErr *NetworkError
+	var installErr *InstallationError
+	var panicErr *PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		return "an unexpected internal error occurred", "This is a bug in the installer, please report it with the output above"
+	case errors.As(err, &validationErr):
+		return fmt.Sprintf("invalid %s: %s", humanize(validationErr.Field), validationErr.Message),
+			"Correct the value and run the command again"
+	case errors.As(err, &configErr):
+		return fmt.Sprintf("invalid %s setting: %s", strings.ToUpper(configErr.Field), configErr.Message),
+			fmt.Sprintf("Fix %s in /opt/infinity-metrics/.env and run the command again", strings.ToUpper(configErr.Field))
+	case errors.As(err, &dockerErr):
+		message = fmt.Sprintf("docker %s failed", humanize(dockerErr.Operation))
+		if dockerErr.Container != "" {
+			message = fmt.Sprintf("docker %s failed for %s", humanize(dockerErr.Operation), dockerErr.Container)
+		}
+		if hint, ok := dockerHints[dockerErr.Operation]; ok {
+			return message, hint
+		}
+		return message, "Check that Docker is running with 'systemctl status docker', then retry"
+	case errors.As(err, &networkErr):
+		return fmt.Sprintf("could not reach %s", networkErr.URL),
+			"Check the server's internet connection, DNS and firewall, then retry"
+	case errors.As(err, &installErr):
+		return fmt.Sprintf("installing %s failed at %s", installErr.Component, humanize(installErr.Step)),
+			"Fix the problem above and run 'infinity-metrics install --resume' to continue"
+	}
+	return err.Error(), ""
+}
+
+// humanize turns identifiers such as health_check into "health check"
+func humanize(identifier string) string {
+	return strings.ReplaceAll(identifier, "_", " ")
+}
@@ -0,0 +1,107 @@
+package fleet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"infinity-metrics-installer/internal/utils"
+)
+
+// UpdateCommand is run on every host by fleet-update
+const UpdateCommand = "sudo infinity-metrics update"
+
+// sshBinary is replaced in tests
+var sshBinary = "ssh"
+
+// Result is the outcome of running the command on one host
+type Result struct {
+	Host     string
+	Output   string
+	Err      error
+	Duration time.Duration
+}
+
+// ParseHosts reads one SSH destination per line ("host", "user@host" or an
+// alias from ~/.ssh/config). Blank lines and # comments are ignored.
+func ParseHosts(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hosts file: %w", err)
+	}
+	defer file.Close()
+
+	var hosts []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.ContainsAny(line, " \t") || strings.HasPrefix(line, "-") {
+			return nil, fmt.Errorf("invalid host %q in %s", line, path)
+		}
+		if !seen[line] {
+			seen[line] = true
+			hosts = append(hosts, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hosts file: %w", err)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts found in %s", path)
+	}
+	return hosts, nil
+}
+
+// Run executes command on every host over SSH, at most concurrency at a time,
+// and returns the results in host order
+func Run(hosts []string, command string, concurrency int) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]Result, len(hosts))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, host string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = runOnHost(host, command)
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
+
+func runOnHost(host, command string) Result {
+	start := time.Now()
+	// BatchMode fails fast instead of prompting for a password on one host while others wait
+	cmd := exec.Command(sshBinary, "-o", "BatchMode=yes", "-o", "ConnectTimeout=15", host, command)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	if err != nil {
+		err = fmt.Errorf("%s: %w", host, err)
+	}
+	return Result{Host: host, Output: output.String(), Err: err, Duration: time.Since(start)}
+}
+
+// Summarize combines the per-host failures into one error, nil when every host succeeded
+func Summarize(results []Result) error {
+	errs := make([]error, 0, len(results))
+	for _, result := range results {
+		errs = append(errs, result.Err)
+	}
+	return utils.AggregateErrors("fleet update", errs...)
+}
@@ -0,0 +1,78 @@
+package fleet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.txt")
+	content := "# production\nweb1.example.com\n\nroot@web2.example.com\nweb1.example.com\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, err := ParseHosts(path)
+	if err != nil {
+		t.Fatalf("ParseHosts error: %v", err)
+	}
+	if want := []string{"web1.example.com", "root@web2.example.com"}; strings.Join(hosts, ",") != strings.Join(want, ",") {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+
+	if err := os.WriteFile(path, []byte("-oProxyCommand=evil\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseHosts(path); err == nil {
+		t.Error("expected error for a host that looks like an ssh option")
+	}
+
+	if err := os.WriteFile(path, []byte("# nothing here\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseHosts(path); err == nil {
+		t.Error("expected error for a hosts file without hosts")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	// The fake ssh fails for hosts starting with "bad"
+	script := "#!/bin/sh\nhost=$5\necho \"ran $6 on $host\"\ncase $host in bad*) exit 1;; esac\n"
+	sshPath := filepath.Join(dir, "ssh")
+	if err := os.WriteFile(sshPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	original := sshBinary
+	sshBinary = sshPath
+	defer func() { sshBinary = original }()
+
+	results := Run([]string{"web1", "bad1", "web2"}, UpdateCommand, 2)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for i, host := range []string{"web1", "bad1", "web2"} {
+		if results[i].Host != host {
+			t.Errorf("results[%d].Host = %q, want %q", i, results[i].Host, host)
+		}
+		if !strings.Contains(results[i].Output, "ran "+UpdateCommand+" on "+host) {
+			t.Errorf("results[%d].Output = %q", i, results[i].Output)
+		}
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("healthy hosts should succeed: %v, %v", results[0].Err, results[2].Err)
+	}
+	if results[1].Err == nil {
+		t.Error("expected bad1 to fail")
+	}
+
+	err := Summarize(results)
+	if err == nil || !strings.Contains(err.Error(), "bad1") {
+		t.Errorf("Summarize error = %v, want failure for bad1", err)
+	}
+	if err := Summarize(results[:1]); err != nil {
+		t.Errorf("Summarize with only successes = %v, want nil", err)
+	}
+}
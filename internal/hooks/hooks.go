@@ -0,0 +1,93 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/logging"
+)
+
+// Hook names, passed to the command as INFINITY_METRICS_HOOK
+const (
+	PostInstall = "post-install"
+	PreUpdate   = "pre-update"
+	PostUpdate  = "post-update"
+)
+
+// Timeout bounds how long a hook may run, overridden in tests
+var Timeout = 10 * time.Minute
+
+// Command returns the configured command for the named hook, "" if none
+func Command(name string, data config.ConfigData) string {
+	switch name {
+	case PostInstall:
+		return data.PostInstallHook
+	case PreUpdate:
+		return data.PreUpdateHook
+	case PostUpdate:
+		return data.PostUpdateHook
+	}
+	return ""
+}
+
+// Run executes the named hook, if configured, through sh -c with the domain,
+// install dir and app image in its environment, and logs its output. A
+// failing hook is logged as a warning and nil is returned, unless HOOKS_FATAL
+// is set and the error is returned to stop the install or update.
+func Run(logger *logging.Logger, name string, data config.ConfigData) error {
+	command := Command(name, data)
+	if command == "" {
+		return nil
+	}
+
+	logger.Info("Running %s hook: %s", name, command)
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = data.InstallDir
+	cmd.Env = append(os.Environ(),
+		"INFINITY_METRICS_HOOK="+name,
+		"INFINITY_METRICS_DOMAIN="+data.Domain,
+		"INFINITY_METRICS_INSTALL_DIR="+data.InstallDir,
+		"INFINITY_METRICS_APP_IMAGE="+data.AppImage,
+	)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Background processes started by the hook must not hold the install open
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil // the hook itself exited successfully
+	}
+
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), " \t"); line != "" {
+			logger.Info("  [%s] %s", name, line)
+		}
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", Timeout)
+	}
+	if err == nil {
+		logger.Success("%s hook completed", name)
+		return nil
+	}
+	err = fmt.Errorf("%s hook failed: %w", name, err)
+	if data.HooksFatal {
+		return err
+	}
+	logger.Warn("%v (continuing, set HOOKS_FATAL=true to stop instead)", err)
+	return nil
+}
@@ -0,0 +1,72 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/logging"
+)
+
+func testLogger(t *testing.T) *logging.Logger {
+	t.Helper()
+	return logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+}
+
+func TestRun(t *testing.T) {
+	installDir := t.TempDir()
+	data := config.ConfigData{Domain: "analytics.example.com", InstallDir: installDir}
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		if err := Run(testLogger(t), PostInstall, data); err != nil {
+			t.Errorf("Run without a hook: %v", err)
+		}
+	})
+
+	t.Run("PassesEnvironment", func(t *testing.T) {
+		data := data
+		data.PreUpdateHook = `echo "$INFINITY_METRICS_HOOK $INFINITY_METRICS_DOMAIN $INFINITY_METRICS_INSTALL_DIR" > hook.out`
+		if err := Run(testLogger(t), PreUpdate, data); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		out, err := os.ReadFile(filepath.Join(installDir, "hook.out"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "pre-update analytics.example.com " + installDir; strings.TrimSpace(string(out)) != want {
+			t.Errorf("hook saw %q, want %q", strings.TrimSpace(string(out)), want)
+		}
+	})
+
+	t.Run("FailureOnlyWarnsByDefault", func(t *testing.T) {
+		data := data
+		data.PostUpdateHook = "exit 3"
+		if err := Run(testLogger(t), PostUpdate, data); err != nil {
+			t.Errorf("a failing hook must not fail without HOOKS_FATAL: %v", err)
+		}
+	})
+
+	t.Run("FailureIsFatalWhenConfigured", func(t *testing.T) {
+		data := data
+		data.PostUpdateHook = "exit 3"
+		data.HooksFatal = true
+		if err := Run(testLogger(t), PostUpdate, data); err == nil {
+			t.Error("expected an error with HOOKS_FATAL set")
+		}
+	})
+
+	t.Run("TimesOut", func(t *testing.T) {
+		defer func(timeout time.Duration) { Timeout = timeout }(Timeout)
+		Timeout = 100 * time.Millisecond
+		data := data
+		data.PostInstallHook = "sleep 5"
+		data.HooksFatal = true
+		err := Run(testLogger(t), PostInstall, data)
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("expected a timeout error, got %v", err)
+		}
+	})
+}
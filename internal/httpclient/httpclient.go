@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	TimeoutEnvVar   = "HTTP_TIMEOUT"
+	ForceIPv4EnvVar = "FORCE_IPV4"
+	DefaultTimeout  = 60 * time.Second
+)
+
+// Timeout returns the timeout for outbound requests. HTTP_TIMEOUT accepts a
+// duration ("45s", "2m") or a number of seconds; invalid values fall back to
+// DefaultTimeout.
+func Timeout() time.Duration {
+	value := os.Getenv(TimeoutEnvVar)
+	if value == "" {
+		return DefaultTimeout
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return DefaultTimeout
+}
+
+// ForceIPv4 reports whether FORCE_IPV4=1 is set, for hosts where IPv6 is
+// half-configured and connection attempts stall before falling back
+func ForceIPv4() bool {
+	return os.Getenv(ForceIPv4EnvVar) == "1"
+}
+
+// Transport returns the transport used for all outbound HTTP requests
+func Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if ForceIPv4() {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp4", addr)
+		}
+	}
+	return transport
+}
+
+// New returns the client used for all outbound HTTP requests
+func New() *http.Client {
+	return &http.Client{Timeout: Timeout(), Transport: Transport()}
+}
@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", DefaultTimeout},
+		{"15", 15 * time.Second},
+		{"90s", 90 * time.Second},
+		{"2m", 2 * time.Minute},
+		{"0", DefaultTimeout},
+		{"-5s", DefaultTimeout},
+		{"soon", DefaultTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(TimeoutEnvVar, tt.value)
+			if got := Timeout(); got != tt.want {
+				t.Errorf("Timeout() with %s=%q = %s, want %s", TimeoutEnvVar, tt.value, got, tt.want)
+			}
+			if got := New().Timeout; got != tt.want {
+				t.Errorf("New().Timeout = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForceIPv4(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	ln, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	v6 := httptest.NewUnstartedServer(handler)
+	v6.Listener.Close()
+	v6.Listener = ln
+	v6.Start()
+	defer v6.Close()
+
+	v4 := httptest.NewServer(handler)
+	defer v4.Close()
+
+	t.Setenv(ForceIPv4EnvVar, "")
+	if _, err := New().Get(v6.URL); err != nil {
+		t.Fatalf("IPv6 request should succeed without %s: %v", ForceIPv4EnvVar, err)
+	}
+
+	t.Setenv(ForceIPv4EnvVar, "1")
+	if _, err := New().Get(v6.URL); err == nil {
+		t.Errorf("IPv6 request should fail with %s=1", ForceIPv4EnvVar)
+	}
+	if _, err := New().Get(v4.URL); err != nil {
+		t.Errorf("IPv4 request should succeed with %s=1: %v", ForceIPv4EnvVar, err)
+	}
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateUnits are the accepted size suffixes, as binary multiples
+var rateUnits = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseRate parses a transfer rate such as "5MB/s", "500K" or "1048576" (bytes
+// per second) and returns it in bytes per second
+func ParseRate(rate string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(rate))
+	value = strings.TrimSuffix(value, "/S")
+
+	multiplier := 1.0
+	for _, unit := range rateUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSuffix(value, unit.suffix), unit.multiplier
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid rate %q, expected a positive amount per second such as 5MB/s or 500KB/s", rate)
+	}
+	bytesPerSecond := int64(number * multiplier)
+	if bytesPerSecond < 1 {
+		return 0, fmt.Errorf("invalid rate %q, must be at least 1 byte per second", rate)
+	}
+	return bytesPerSecond, nil
+}
+
+// LimitReader returns a reader that reads from r at no more than
+// bytesPerSecond on average, or r itself when bytesPerSecond is not positive
+func LimitReader(r io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return r
+	}
+	return &throttledReader{reader: r, rate: bytesPerSecond, sleep: time.Sleep}
+}
+
+type throttledReader struct {
+	reader io.Reader
+	rate   int64 // bytes per second
+	start  time.Time
+	read   int64
+	sleep  func(time.Duration)
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	// Small reads keep the transfer smooth instead of bursting a full buffer
+	if chunk := t.rate/10 + 1; int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.reader.Read(p)
+	t.read += int64(n)
+
+	due := time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second))
+	if wait := due - time.Since(t.start); wait > 0 {
+		t.sleep(wait)
+	}
+	return n, err
+}
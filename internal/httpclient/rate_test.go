@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		rate    string
+		want    int64
+		wantErr bool
+	}{
+		{"5MB/s", 5 << 20, false},
+		{"500KB/s", 500 << 10, false},
+		{"1.5m", 3 << 19, false},
+		{"2MiB/s", 2 << 20, false},
+		{"1048576", 1 << 20, false},
+		{"100B/s", 100, false},
+		{"", 0, true},
+		{"fast", 0, true},
+		{"-5MB/s", 0, true},
+		{"0", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			got, err := ParseRate(tt.rate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRate(%q) error = %v, wantErr %v", tt.rate, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%q) = %d, want %d", tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 1000)
+	if r := LimitReader(bytes.NewReader(data), 0); r == nil {
+		t.Fatal("LimitReader without a rate returned nil")
+	}
+
+	var slept time.Duration
+	r := LimitReader(bytes.NewReader(data), 100)
+	r.(*throttledReader).sleep = func(d time.Duration) { slept += d }
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("throttled reader changed the data")
+	}
+	// 1000 bytes at 100 bytes/s must be paced over about 10 seconds
+	if slept < 9*time.Second {
+		t.Errorf("slept %s in total, want about 10s", slept)
+	}
+}
@@ -0,0 +1,95 @@
+package installer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// installDirEntries are the top-level files and directories an installation
+// creates in its install dir
+var installDirEntries = map[string]bool{
+	".env":                     true,
+	"Caddyfile":                true,
+	"caddy":                    true,
+	"logs":                     true,
+	"storage":                  true,
+	"backups":                  true, // BACKUP_PATH of a non-interactive install
+	StateFileName:              true,
+	".operation.lock":          true,
+	".watchdog-state.json":     true,
+	"last-run.json":            true,
+	"update-history.jsonl":     true,
+	"infinity-metrics-cli.log": true,
+}
+
+// isInstallDirEntry reports whether name is something the installer, updater
+// or their temporary files leave in the install dir
+func isInstallDirEntry(name string) bool {
+	return installDirEntries[name] ||
+		strings.HasPrefix(name, "Caddyfile.validate-") ||
+		strings.HasPrefix(name, ".env.backup.") ||
+		strings.HasSuffix(name, ".tmp")
+}
+
+// isInfinityEnvFile reports whether the .env at path was written by this
+// installer, judged by the keys only an installation sets
+func isInfinityEnvFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "INFINITY_METRICS_DOMAIN=") || strings.HasPrefix(line, "INFINITY_METRICS_PRIVATE_KEY=") {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// checkInstallDir returns an error when installDir already holds files of
+// another package or process, so an install does not overwrite its .env or
+// mix its data with ours. A missing or empty directory, or one holding a
+// previous installation, passes; unknown files next to an installation are
+// only reported.
+func (i *Installer) checkInstallDir(installDir string) error {
+	entries, err := os.ReadDir(installDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read install dir %s: %w", installDir, err)
+	}
+
+	var unknown []string
+	for _, entry := range entries {
+		if !isInstallDirEntry(entry.Name()) {
+			unknown = append(unknown, entry.Name())
+		}
+	}
+	sort.Strings(unknown)
+
+	envFile := filepath.Join(installDir, ".env")
+	ours, err := isInfinityEnvFile(envFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", envFile, err)
+	}
+	if err == nil && !ours {
+		return fmt.Errorf("%s does not belong to an Infinity Metrics installation, %s appears to be in use by another application: choose a different INSTALL_DIR or move its files away", envFile, installDir)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	if !ours {
+		return fmt.Errorf("%s already contains files that do not belong to Infinity Metrics (%s): choose a different INSTALL_DIR or move them away", installDir, strings.Join(unknown, ", "))
+	}
+	i.logger.Warn("Install directory %s contains files Infinity Metrics does not manage: %s", installDir, strings.Join(unknown, ", "))
+	return nil
+}
@@ -2,6 +2,7 @@ package installer
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -13,8 +14,11 @@ import (
 	"infinity-metrics-installer/internal/cron"
 	"infinity-metrics-installer/internal/database"
 	"infinity-metrics-installer/internal/docker"
+	"infinity-metrics-installer/internal/hooks"
 	"infinity-metrics-installer/internal/logging"
 	"infinity-metrics-installer/internal/requirements"
+
+	"golang.org/x/term"
 )
 
 const (
@@ -24,6 +28,15 @@ const (
 	DefaultCronSchedule = "0 3 * * *"
 )
 
+// ErrDatabaseCorrupted is returned by VerifyInstallation when the main
+// database exists but fails the SQLite integrity check
+var ErrDatabaseCorrupted = errors.New("database is corrupted")
+
+// IsDatabaseCorrupted reports whether err is caused by a corrupted main database
+func IsDatabaseCorrupted(err error) bool {
+	return errors.Is(err, ErrDatabaseCorrupted)
+}
+
 type Installer struct {
 	logger       *logging.Logger
 	config       *config.Config
@@ -31,6 +44,27 @@ type Installer struct {
 	database     *database.Database
 	binaryPath   string
 	portWarnings []string
+	step         string // Installation step in progress, reported when it fails
+	installDir   string
+	resume       bool
+	assumeYes    bool
+	tui          bool
+	dnsWait      time.Duration
+	progress     *progressView // multi-line step view, nil when plain output is used
+	stepNum      int
+	timings      []docker.PhaseTiming
+	timingLabel  string
+	timingStart  time.Time
+	verification ReportVerification // outcome of the verify step, for --report
+
+	// --confirm-domain, accepts a domain change without the prompt
+	confirmDomain string
+
+	// --clean-orphans, removes containers left by a failed install instead of refusing
+	cleanOrphans bool
+
+	// --env-file, a filled-in .env template installed from instead of prompting
+	envFile string
 }
 
 func NewInstaller(logger *logging.Logger) *Installer {
@@ -42,77 +76,207 @@ func NewInstaller(logger *logging.Logger) *Installer {
 		docker:     d,
 		database:   db,
 		binaryPath: DefaultBinaryPath,
+		installDir: DefaultInstallDir,
 	}
 }
 
+// SetAssumeYes makes RunCompleteInstallation accept the configuration summary without asking
+func (i *Installer) SetAssumeYes(assumeYes bool) {
+	i.assumeYes = assumeYes
+}
+
+// SetConfirmDomain confirms in advance that a reinstall may change the domain
+// of an existing installation to domain
+func (i *Installer) SetConfirmDomain(domain string) {
+	i.confirmDomain = domain
+}
+
+// SetCleanOrphans makes RunCompleteInstallation remove containers left behind
+// by a previous failed install, after confirmation, instead of stopping
+func (i *Installer) SetCleanOrphans(clean bool) {
+	i.cleanOrphans = clean
+}
+
+// SetTUI makes RunCompleteInstallation show every step with a live status
+// instead of one log line per step, when stdout is an interactive terminal
+func (i *Installer) SetTUI(tui bool) {
+	i.tui = tui
+}
+
+// SetDNSWait makes RunCompleteInstallation wait up to timeout for the domain
+// to resolve to this server before deploying, 0 deploys right away
+func (i *Installer) SetDNSWait(timeout time.Duration) {
+	i.dnsWait = timeout
+}
+
+// SetEnvFile makes RunCompleteInstallation take its configuration from a
+// filled-in .env template, such as dump-env-template prints, instead of prompting
+func (i *Installer) SetEnvFile(path string) {
+	i.envFile = path
+}
+
+// SetResume makes RunCompleteInstallation skip steps completed by a previous failed run
+func (i *Installer) SetResume(resume bool) {
+	i.resume = resume
+}
+
+// CurrentStep returns the installation step that was last started
+func (i *Installer) CurrentStep() string {
+	return i.step
+}
+
 func (i *Installer) GetConfig() *config.Config {
 	return i.config
 }
 
 func (i *Installer) GetMainDBPath() string {
-	data := i.config.GetData()
-	return filepath.Join(data.InstallDir, "storage", "infinity-metrics-production.db")
+	return i.config.GetData().MainDBPath()
 }
 
+// GetBackupDir returns BACKUP_PATH, the directory every backup is written to,
+// so listing and restoring find them. Without one it is storage/backups.
 func (i *Installer) GetBackupDir() string {
 	data := i.config.GetData()
+	if data.BackupPath != "" {
+		return data.BackupPath
+	}
 	return filepath.Join(data.InstallDir, "storage", "backups")
 }
 
+// LoadConfig loads the installation's .env, so commands run outside an install
+// use its paths, such as DB_STORAGE_PATH. Without the file the defaults stay.
+func (i *Installer) LoadConfig(envFile string) error {
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return nil
+	}
+	cfg := config.NewConfig(i.logger)
+	if err := cfg.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load configuration from %s: %w", envFile, err)
+	}
+	i.config = cfg
+	return nil
+}
+
 func (i *Installer) RunWithConfig(cfg *config.Config) error {
 	i.config = cfg
 	return i.Run()
 }
 
 // RunCompleteInstallation runs the complete installation process with proper coordination
-func (i *Installer) RunCompleteInstallation() error {
-	totalSteps := 7
+func (i *Installer) RunCompleteInstallation() (err error) {
+	state, err := i.prepareInstallState()
+	if err != nil {
+		return err
+	}
 
 	// Step 1: Display welcome message and collect ALL user input upfront
+	i.step = "collect_config"
 	i.displayWelcomeMessage()
-	fmt.Println("Please provide the required configuration details:")
-	reader := bufio.NewReader(os.Stdin)
-	i.config = config.NewConfig(i.logger)
-	if err := i.config.CollectFromUser(reader); err != nil {
-		return fmt.Errorf("failed to collect configuration: %w", err)
+	if i.resume && state.done("configure_system") {
+		if err := i.loadSavedConfig(); err != nil {
+			return fmt.Errorf("failed to load saved configuration: %w", err)
+		}
+	} else {
+		reader := bufio.NewReader(os.Stdin)
+		i.config = config.NewConfig(i.logger)
+		i.config.SetAssumeYes(i.assumeYes)
+		if i.envFile != "" {
+			if err := i.importEnvFile(); err != nil {
+				return fmt.Errorf("failed to load %s: %w", i.envFile, err)
+			}
+		} else {
+			fmt.Println("Please provide the required configuration details:")
+			if err := i.config.CollectFromUser(reader); err != nil {
+				return fmt.Errorf("failed to collect configuration: %w", err)
+			}
+		}
+		if err := i.confirmDomainChange(reader); err != nil {
+			return err
+		}
 	}
 
+	stopProgress := i.startProgressView()
+	defer func() { stopProgress(err) }()
+
 	// Step 2: Validate system requirements (no system changes yet)
-	i.logger.Info("Step 1/%d: Checking system requirements", totalSteps)
-	checker := requirements.NewChecker(i.logger)
-	if err := checker.CheckSystemRequirements(); err != nil {
-		return fmt.Errorf("system requirements check failed: %w", err)
+	i.beginStep(1)
+	i.step = "system_requirements"
+	i.startTiming("System requirements")
+	if i.resume && state.done(i.step) {
+		// Ports 80/443 may now be held by the partially deployed containers
+		i.logger.Info("System requirements already verified, skipping (--resume)")
+	} else {
+		checker := requirements.NewChecker(i.logger)
+		if err := checker.CheckSystemRequirements(); err != nil {
+			return fmt.Errorf("system requirements check failed: %w", err)
+		}
+		if err := checker.CheckConnectivity(i.config.GetData()); err != nil {
+			return fmt.Errorf("connectivity check failed: %w", err)
+		}
+		i.logger.Success("System requirements verified")
 	}
-	i.logger.Success("System requirements verified")
+	i.markStepDone(state)
 
 	// Step 3: Install SQLite
-	i.logger.Info("Step 2/%d: Installing SQLite", totalSteps)
-	if err := i.database.EnsureSQLiteInstalled(); err != nil {
-		return fmt.Errorf("failed to install SQLite: %w", err)
+	i.beginStep(2)
+	i.step = "install_sqlite"
+	i.startTiming("SQLite install")
+	if i.resume && state.done(i.step) {
+		i.logger.Info("SQLite already installed, skipping (--resume)")
+	} else {
+		if err := i.database.EnsureSQLiteInstalled(); err != nil {
+			return fmt.Errorf("failed to install SQLite: %w", err)
+		}
+		i.logger.Success("SQLite installed")
 	}
-	i.logger.Success("SQLite installed")
+	i.markStepDone(state)
 
 	// Step 4: Install Docker
-	i.logger.Info("Step 3/%d: Installing Docker", totalSteps)
-	progressChan := make(chan int, 1)
-	go i.showProgress(progressChan, "Docker installation")
-	if err := i.docker.EnsureInstalled(); err != nil {
+	i.beginStep(3)
+	i.step = "install_docker"
+	i.startTiming("Docker install")
+	if i.resume && state.done(i.step) {
+		i.logger.Info("Docker already installed, skipping (--resume)")
+	} else {
+		progressChan := make(chan int, 1)
+		go i.showProgress(progressChan, "Docker installation")
+		if err := i.docker.EnsureInstalled(); err != nil {
+			close(progressChan)
+			return fmt.Errorf("failed to install Docker: %w", err)
+		}
+		progressChan <- 100
 		close(progressChan)
-		return fmt.Errorf("failed to install Docker: %w", err)
+		i.logger.Success("Docker installed")
 	}
-	progressChan <- 100
-	close(progressChan)
-	i.logger.Success("Docker installed")
+	i.markStepDone(state)
 
 	// Step 5: Configure system
-	i.logger.Info("Step 4/%d: Configuring system", totalSteps)
-	if err := i.configureSystem(); err != nil {
-		return fmt.Errorf("failed to configure system: %w", err)
+	i.beginStep(4)
+	i.step = "configure_system"
+	i.startTiming("Configuration")
+	if i.resume && state.done(i.step) {
+		i.logger.Info("Configuration already saved, skipping (--resume)")
+	} else {
+		if err := i.configureSystem(); err != nil {
+			return fmt.Errorf("failed to configure system: %w", err)
+		}
+		i.logger.Success("System configured")
 	}
-	i.logger.Success("System configured")
+	i.markStepDone(state)
 
 	// Step 6: Deploy application
-	i.logger.Info("Step 5/%d: Deploying application", totalSteps)
+	i.beginStep(5)
+	i.step = "deploy"
+	if i.dnsWait > 0 {
+		i.startTiming("DNS wait")
+		if err := i.config.WaitForDNS(i.dnsWait); err != nil {
+			i.logger.Warn("%v, deploying anyway and Caddy will retry the certificate until DNS is ready", err)
+		}
+	}
+	if err := i.handleOrphanedContainers(i.resume && !state.done(i.step)); err != nil {
+		return err
+	}
+	i.startTiming("Deploy")
 	deployProgressChan := make(chan int, 1)
 	go i.showProgress(deployProgressChan, "Application deployment")
 	if err := i.docker.Deploy(i.config); err != nil {
@@ -122,21 +286,183 @@ func (i *Installer) RunCompleteInstallation() error {
 	deployProgressChan <- 100
 	close(deployProgressChan)
 	i.logger.Success("Application deployed")
+	i.markStepDone(state)
 
 	// Step 7: Setup maintenance
-	i.logger.Info("Step 6/%d: Setting up maintenance", totalSteps)
+	i.beginStep(6)
+	i.step = "setup_maintenance"
+	i.startTiming("Maintenance setup")
 	if err := i.setupMaintenance(); err != nil {
 		return fmt.Errorf("failed to setup maintenance: %w", err)
 	}
 	i.logger.Success("Maintenance configured")
+	i.markStepDone(state)
 
 	// Step 8: Verify installation
-	i.logger.Info("Step 7/%d: Verifying installation", totalSteps)
-	if _, err := i.VerifyInstallation(); err != nil {
+	i.beginStep(7)
+	i.step = "verify"
+	i.startTiming("Verification")
+	warnings, err := i.VerifyInstallation()
+	i.verification = ReportVerification{Ran: true, Passed: err == nil, Warnings: warnings}
+	for _, warning := range warnings {
+		i.logger.Warn("%s", warning)
+	}
+	if err != nil {
+		i.verification.Error = err.Error()
 		return fmt.Errorf("installation verification failed: %w", err)
 	}
 	i.logger.Success("Installation verified")
 
+	i.step = "post_install_hook"
+	if err := hooks.Run(i.logger, hooks.PostInstall, i.config.GetData()); err != nil {
+		return err
+	}
+	i.stopTiming()
+	if i.progress != nil {
+		i.progress.done(i.stepNum)
+	}
+
+	// The installation is complete, a later install starts from scratch
+	if err := state.clear(); err != nil {
+		i.logger.Warn("Failed to remove install state file: %v", err)
+	}
+
+	return nil
+}
+
+// startTiming ends the step being timed and starts timing the next one
+func (i *Installer) startTiming(label string) {
+	i.stopTiming()
+	i.timingLabel = label
+	i.timingStart = time.Now()
+}
+
+func (i *Installer) stopTiming() {
+	if i.timingLabel == "" {
+		return
+	}
+	i.timings = append(i.timings, docker.PhaseTiming{Name: i.timingLabel, Duration: time.Since(i.timingStart)})
+	i.timingLabel = ""
+}
+
+// LogTimingSummary prints how long each installation step took, including the
+// deploy phases, so slow installs can be traced to a specific step. A step that
+// failed is included with the time spent until the failure.
+func (i *Installer) LogTimingSummary() {
+	i.stopTiming()
+	if len(i.timings) == 0 {
+		return
+	}
+
+	i.logger.Info("Installation timing breakdown:")
+	for _, timing := range i.timings {
+		i.logger.Info("  %-22s %s", timing.Name, timing.Duration.Round(100*time.Millisecond))
+		if timing.Name == "Deploy" {
+			for _, phase := range i.docker.DeployPhases() {
+				i.logger.Info("    %-20s %s", phase.Name, phase.Duration.Round(100*time.Millisecond))
+			}
+		}
+	}
+}
+
+// prepareInstallState loads the recorded progress for --resume, or starts a fresh record
+func (i *Installer) prepareInstallState() (*installState, error) {
+	state, err := loadInstallState(i.installDir)
+	if err != nil {
+		if i.resume {
+			return nil, err
+		}
+		i.logger.Warn("Ignoring unreadable install state: %v", err)
+		state = &installState{path: filepath.Join(i.installDir, StateFileName)}
+	}
+
+	if !i.resume {
+		if err := state.clear(); err != nil {
+			i.logger.Warn("Failed to reset install state: %v", err)
+		}
+		return state, nil
+	}
+
+	if len(state.Completed) == 0 {
+		i.logger.Warn("No previous install progress found, running all steps")
+	} else {
+		i.logger.Info("Resuming installation, completed steps: %s", strings.Join(state.Completed, ", "))
+	}
+	return state, nil
+}
+
+// beginStep logs the start of install step n (1-based) and shows it as running
+func (i *Installer) beginStep(n int) {
+	i.stepNum = n
+	i.logger.Info("Step %d/%d: %s", n, len(installSteps), installSteps[n-1])
+	if i.progress != nil {
+		i.progress.begin(n)
+	}
+}
+
+// startProgressView switches to the multi-line step view when --tui was given
+// and stdout is an interactive terminal, routing log output above it. The
+// returned function stops the view, marking the running step failed on error.
+func (i *Installer) startProgressView() func(err error) {
+	if !i.tui {
+		return func(error) {}
+	}
+	if i.logger.GetQuiet() || !term.IsTerminal(int(os.Stdout.Fd())) {
+		i.logger.Debug("Not an interactive terminal, using plain progress output")
+		return func(error) {}
+	}
+
+	view := newProgressView(os.Stdout, installSteps)
+	previous := i.logger.Out
+	i.logger.SetOutput(view)
+	i.progress = view
+	go view.run()
+	return func(err error) {
+		if err != nil {
+			view.fail()
+		}
+		view.close()
+		i.logger.SetOutput(previous)
+		i.progress = nil
+	}
+}
+
+// markStepDone records the current step as completed. Failing to record
+// progress only costs a slower resume, so it does not abort the install.
+func (i *Installer) markStepDone(state *installState) {
+	if i.progress != nil {
+		i.progress.done(i.stepNum)
+	}
+	if err := state.markDone(i.step); err != nil {
+		i.logger.Warn("Failed to record install progress: %v", err)
+	}
+}
+
+// loadSavedConfig loads the configuration saved by a previous run instead of prompting again
+func (i *Installer) loadSavedConfig() error {
+	envFile := filepath.Join(i.installDir, ".env")
+	i.logger.Info("Using configuration saved by the previous run from %s", envFile)
+	i.config = config.NewConfig(i.logger)
+	if err := i.config.LoadFromFile(envFile); err != nil {
+		return err
+	}
+	return i.config.Validate()
+}
+
+// importEnvFile applies the --env-file settings on top of the existing .env,
+// so a reinstall keeps the keys the file leaves out. Validation runs in
+// configureSystem once the server config has set the version.
+func (i *Installer) importEnvFile() error {
+	existing := filepath.Join(i.installDir, ".env")
+	if _, err := os.Stat(existing); err == nil {
+		if err := i.config.LoadFromFile(existing); err != nil {
+			return fmt.Errorf("failed to load existing config from %s: %w", existing, err)
+		}
+	}
+	if err := i.config.LoadFromFile(i.envFile); err != nil {
+		return fmt.Errorf("failed to load %s: %w", i.envFile, err)
+	}
+	i.config.CheckDNSAndStoreWarnings(i.config.GetData().Domain)
 	return nil
 }
 
@@ -161,13 +487,13 @@ func (i *Installer) configureSystem() error {
 	
 	// Handle .env file configuration
 	envFile := filepath.Join(data.InstallDir, ".env")
-	if _, err := os.Stat(envFile); os.IsNotExist(err) {
-		// No existing .env file - save the user-provided configuration
+	if _, err := os.Stat(envFile); os.IsNotExist(err) || i.envFile != "" {
+		// No existing .env file, or --env-file already applied on top of it - save the configuration
 		if err := i.config.SaveToFile(envFile); err != nil {
 			return fmt.Errorf("failed to save config to %s: %w", envFile, err)
 		}
 	} else {
-		// Existing .env file found - preserve only system-generated values
+		// Existing .env file found - keep its settings, with the new domain
 		if err := i.updateExistingConfig(envFile); err != nil {
 			return fmt.Errorf("failed to update existing config: %w", err)
 		}
@@ -188,7 +514,71 @@ func (i *Installer) configureSystem() error {
 	return nil
 }
 
-// updateExistingConfig preserves system values but uses fresh user input
+// confirmDomainChange asks for confirmation when a reinstall would replace
+// the domain of the existing installation
+func (i *Installer) confirmDomainChange(reader *bufio.Reader) error {
+	data := i.config.GetData()
+	existing := config.NewConfig(i.logger)
+	if err := existing.LoadFromFile(filepath.Join(data.InstallDir, ".env")); err != nil {
+		return nil // Nothing installed yet, or nothing readable to protect
+	}
+	interactive := !i.assumeYes && os.Getenv("NONINTERACTIVE") != "1"
+	return config.ConfirmDomainChange(reader, existing.GetData().Domain, data.Domain, i.confirmDomain, interactive)
+}
+
+// handleOrphanedContainers stops the install when a previous failed run left
+// containers behind, or removes them after confirmation with --clean-orphans.
+// A resumed install whose deploy did not finish removes them unasked.
+func (i *Installer) handleOrphanedContainers(resumingDeploy bool) error {
+	orphans, err := i.docker.OrphanedContainers()
+	if err != nil {
+		i.logger.Warn("Could not check for leftover containers: %v", err)
+		return nil
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(orphans))
+	for idx, container := range orphans {
+		names[idx] = container.Name
+	}
+	// A resumed install left them itself when its deploy failed, so they go
+	// without asking and the deploy starts over
+	if resumingDeploy {
+		i.logger.Info("Removing %s left by the interrupted deploy (--resume)", strings.Join(names, ", "))
+		if err := i.docker.RemoveContainers(orphans); err != nil {
+			return fmt.Errorf("failed to remove leftover containers: %w", err)
+		}
+		return nil
+	}
+
+	for _, container := range orphans {
+		i.logger.Warn("Found container %s (%s) left by a previous install", container.Name, container.State)
+	}
+	if !i.cleanOrphans {
+		return fmt.Errorf("containers from a previous install are still present (%s): re-run install with --clean-orphans to remove them, or remove them with: docker rm -f %s",
+			strings.Join(names, ", "), strings.Join(names, " "))
+	}
+
+	if !i.assumeYes && os.Getenv("NONINTERACTIVE") != "1" {
+		fmt.Printf("Remove %s? [y/N]: ", strings.Join(names, ", "))
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return fmt.Errorf("leftover containers were not removed, installation stopped")
+		}
+	}
+	if err := i.docker.RemoveContainers(orphans); err != nil {
+		return fmt.Errorf("failed to remove leftover containers: %w", err)
+	}
+	i.logger.Success("Removed %d leftover container(s)", len(orphans))
+	return nil
+}
+
+// updateExistingConfig keeps the existing .env and applies the fresh user input on top
 func (i *Installer) updateExistingConfig(envFile string) error {
 	i.logger.InfoWithTime("Found existing .env file at %s", envFile)
 	oldConfig := config.NewConfig(i.logger)
@@ -196,19 +586,10 @@ func (i *Installer) updateExistingConfig(envFile string) error {
 		return fmt.Errorf("failed to load existing config from %s: %w", envFile, err)
 	}
 	
-	// Preserve only the private key from old config, use fresh user input for everything else
-	oldData := oldConfig.GetData()
-	currentData := i.config.GetData()
-	if oldData.PrivateKey != "" {
-		// Update config with preserved private key
-		preservedData := currentData
-		preservedData.PrivateKey = oldData.PrivateKey
-		newConfig := config.NewConfig(i.logger)
-		newConfig.SetData(preservedData)
-		i.config = newConfig
-	}
+	// Keep every existing setting, only what the user just entered replaces it
+	i.config.SetData(preserveExistingValues(oldConfig.GetData(), i.config.GetData()))
 	
-	// Save the updated configuration (fresh user input + preserved private key)
+	// Save the updated configuration (fresh user input + preserved values)
 	if err := i.config.SaveToFile(envFile); err != nil {
 		return fmt.Errorf("failed to save updated config to %s: %w", envFile, err)
 	}
@@ -217,6 +598,31 @@ func (i *Installer) updateExistingConfig(envFile string) error {
 	return nil
 }
 
+// preserveExistingValues returns the settings of a previous installation, or
+// of an imported .env, with the values collected for this install on top: the
+// domain, the license key and admin user when provided, and OFFLINE_MODE and
+// TLS_INTERNAL when set in the environment. The private key is always kept.
+func preserveExistingValues(oldData, currentData config.ConfigData) config.ConfigData {
+	data := oldData
+	data.Domain = currentData.Domain
+	if data.PrivateKey == "" {
+		data.PrivateKey = currentData.PrivateKey
+	}
+	if currentData.LicenseKey != "" {
+		data.LicenseKey = currentData.LicenseKey
+	}
+	if currentData.User != "" {
+		data.User = currentData.User
+	}
+	if os.Getenv("OFFLINE_MODE") != "" {
+		data.OfflineMode = currentData.OfflineMode
+	}
+	if os.Getenv("TLS_INTERNAL") != "" {
+		data.TLSInternal = currentData.TLSInternal
+	}
+	return data
+}
+
 // setupMaintenance handles maintenance setup (no admin user creation)
 func (i *Installer) setupMaintenance() error {
 	// Install binary for updates (non-critical)
@@ -316,31 +722,11 @@ func (i *Installer) Run() error {
 			return fmt.Errorf("failed to save config to %s: %w", envFile, err)
 		}
 	} else {
-		// Existing .env file found - preserve only system-generated values (like private key)
-		// but use fresh user-provided values for domain, email, and license
-		i.logger.InfoWithTime("Found existing .env file at %s", envFile)
-		oldConfig := config.NewConfig(i.logger)
-		if err := oldConfig.LoadFromFile(envFile); err != nil {
-			return fmt.Errorf("failed to load existing config from %s: %w", envFile, err)
-		}
-		
-		// Preserve only the private key from old config, use fresh user input for everything else
-		oldData := oldConfig.GetData()
-		currentData := i.config.GetData()
-		if oldData.PrivateKey != "" {
-			// Update config with preserved private key
-			preservedData := currentData
-			preservedData.PrivateKey = oldData.PrivateKey
-			newConfig := config.NewConfig(i.logger)
-			newConfig.SetData(preservedData)
-			i.config = newConfig
-		}
-		
-		// Save the updated configuration (fresh user input + preserved private key)
-		if err := i.config.SaveToFile(envFile); err != nil {
-			return fmt.Errorf("failed to save updated config to %s: %w", envFile, err)
+		// Existing .env file found - keep its settings, including the private key,
+		// with the fresh user-provided domain on top
+		if err := i.updateExistingConfig(envFile); err != nil {
+			return err
 		}
-		i.logger.InfoWithTime("Updated configuration with fresh user input")
 	}
 
 	i.logger.Info("Fetching server configuration...")
@@ -394,6 +780,17 @@ func (i *Installer) ListBackups() ([]database.BackupFile, error) {
 	return i.database.ListBackups(backupDir)
 }
 
+// VerifyBackups runs the integrity check on every backup
+func (i *Installer) VerifyBackups(backups []database.BackupFile) []database.BackupStatus {
+	return i.database.VerifyBackups(backups)
+}
+
+// SelfTestBackupRestore backs up the live database and restores the backup
+// into workDir, leaving the live database untouched
+func (i *Installer) SelfTestBackupRestore(workDir string) (database.BackupRestoreReport, error) {
+	return i.database.SelfTestBackupRestore(i.GetMainDBPath(), workDir)
+}
+
 // PromptBackupSelection allows user to select from available backups
 func (i *Installer) PromptBackupSelection(backups []database.BackupFile) (string, error) {
 	return i.database.PromptSelection(backups)
@@ -404,6 +801,11 @@ func (i *Installer) ValidateBackup(backupPath string) error {
 	return i.database.ValidateBackup(backupPath)
 }
 
+// SummarizeDatabase returns size, timestamps and row counts of a database file
+func (i *Installer) SummarizeDatabase(dbPath string) (database.DatabaseSummary, error) {
+	return i.database.Summarize(dbPath)
+}
+
 // RestoreFromBackup restores database from a specific backup file
 func (i *Installer) RestoreFromBackup(backupPath string) error {
 	mainDBPath := i.GetMainDBPath()
@@ -429,7 +831,36 @@ func (i *Installer) RestoreFromBackup(backupPath string) error {
 	return nil
 }
 
+// RestoreLatestGoodBackup stops the app and restores the newest backup that
+// passes the integrity check, returning its path. The current database is
+// kept next to the main database as <name>.bak.<timestamp>.
+func (i *Installer) RestoreLatestGoodBackup() (string, error) {
+	backups, err := i.ListBackups()
+	if err != nil {
+		return "", fmt.Errorf("failed to list backups: %w", err)
+	}
+	// ListBackups returns the newest backup first
+	for _, backup := range backups {
+		if err := i.database.ValidateBackup(backup.Path); err != nil {
+			i.logger.Warn("Skipping backup %s: %v", backup.Name, err)
+			continue
+		}
+		// The app must not write to the database while it is replaced
+		if err := i.docker.StopApp(); err != nil {
+			return "", fmt.Errorf("failed to stop the app before restoring: %w", err)
+		}
+		if err := i.RestoreFromBackup(backup.Path); err != nil {
+			return "", err
+		}
+		return backup.Path, nil
+	}
+	return "", fmt.Errorf("no valid backup found in %s", i.GetBackupDir())
+}
+
 func (i *Installer) createInstallDir(installDir string) error {
+	if err := i.checkInstallDir(installDir); err != nil {
+		return err
+	}
 	i.logger.InfoWithTime("Creating installation directory: %s", installDir)
 	if err := os.MkdirAll(installDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
@@ -455,6 +886,15 @@ func (i *Installer) VerifyInstallation() ([]string, error) {
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		return warnings, fmt.Errorf("database file not found: %w", err)
 	}
+	// A crash can leave a partially written database that makes the app crash-loop.
+	// Only SQLite's verdict marks it corrupted; a missing sqlite3 or a locked
+	// database says nothing about the file.
+	if err := i.database.ValidateBackup(dbPath); err != nil {
+		if errors.Is(err, database.ErrIntegrityCheckFailed) {
+			return warnings, fmt.Errorf("%w: %s: %v", ErrDatabaseCorrupted, dbPath, err)
+		}
+		warnings = append(warnings, fmt.Sprintf("could not check the integrity of %s: %v", dbPath, err))
+	}
 	// Ports are now checked as hard requirements before installation
 	return warnings, nil
 }
@@ -471,6 +911,13 @@ func checkPort(port int) bool {
 
 // showProgress displays a progress indicator for long-running operations
 func (i *Installer) showProgress(progressChan <-chan int, operationName string) {
+	if i.progress != nil {
+		// The step view already animates the running step
+		for range progressChan {
+		}
+		return
+	}
+
 	ticker := time.NewTicker(300 * time.Millisecond)
 	defer ticker.Stop()
 
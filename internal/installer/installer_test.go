@@ -1,14 +1,22 @@
 package installer
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/database"
 	"infinity-metrics-installer/internal/logging"
 )
 
@@ -70,6 +78,25 @@ func TestGetBackupDir(t *testing.T) {
 	assert.Equal(t, expectedDir, backupDir)
 }
 
+func TestGetBackupDirBackupPath(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	installDir, backups := t.TempDir(), t.TempDir()
+	envFile := filepath.Join(installDir, ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("INFINITY_METRICS_DOMAIN=analytics.example.com\n"+
+		"INSTALL_DIR="+installDir+"\n"+
+		"BACKUP_PATH="+backups+"\n"), 0o600))
+
+	installer := NewInstaller(logger)
+	require.NoError(t, installer.LoadConfig(envFile))
+	assert.Equal(t, backups, installer.GetBackupDir())
+
+	require.NoError(t, os.WriteFile(filepath.Join(backups, "backup_20240101_120000.db"), []byte("test db content"), 0644))
+	listed, err := installer.ListBackups()
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, filepath.Join(backups, "backup_20240101_120000.db"), listed[0].Path)
+}
+
 func TestConstants(t *testing.T) {
 	assert.Equal(t, "/opt/infinity-metrics", DefaultInstallDir)
 	assert.Equal(t, "/usr/local/bin/infinity-metrics", DefaultBinaryPath)
@@ -149,3 +176,364 @@ func TestBackupValidation(t *testing.T) {
 		assert.Contains(t, err.Error(), "backup file is empty", "Error should indicate empty file")
 	})
 }
+
+func TestInstallState(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "infinity-metrics")
+
+	state, err := loadInstallState(dir)
+	require.NoError(t, err)
+	assert.False(t, state.done("install_sqlite"))
+
+	require.NoError(t, state.markDone("install_sqlite"))
+	require.NoError(t, state.markDone("install_docker"))
+	require.NoError(t, state.markDone("install_sqlite"))
+
+	reloaded, err := loadInstallState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"install_sqlite", "install_docker"}, reloaded.Completed)
+
+	require.NoError(t, reloaded.clear())
+	_, err = os.Stat(filepath.Join(dir, StateFileName))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPrepareInstallState(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+
+	newInstallerWithState := func(t *testing.T) *Installer {
+		inst := NewInstaller(logger)
+		inst.installDir = t.TempDir()
+		state, err := loadInstallState(inst.installDir)
+		require.NoError(t, err)
+		require.NoError(t, state.markDone("install_docker"))
+		return inst
+	}
+
+	t.Run("ResumeKeepsCompletedSteps", func(t *testing.T) {
+		inst := newInstallerWithState(t)
+		inst.SetResume(true)
+		state, err := inst.prepareInstallState()
+		require.NoError(t, err)
+		assert.True(t, state.done("install_docker"))
+	})
+
+	t.Run("FreshInstallStartsOver", func(t *testing.T) {
+		inst := newInstallerWithState(t)
+		state, err := inst.prepareInstallState()
+		require.NoError(t, err)
+		assert.False(t, state.done("install_docker"))
+	})
+
+	t.Run("ResumeRejectsCorruptState", func(t *testing.T) {
+		inst := NewInstaller(logger)
+		inst.installDir = t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(inst.installDir, StateFileName), []byte("{"), 0o600))
+		inst.SetResume(true)
+		_, err := inst.prepareInstallState()
+		assert.Error(t, err)
+	})
+}
+
+func TestRunLoad(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1)%10 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	report := runLoad(server.Client(), server.URL+"/_health", 50, 5)
+
+	assert.Equal(t, 50, report.Requests)
+	assert.Equal(t, 5, report.Failures)
+	assert.Error(t, report.FirstError)
+	assert.Greater(t, report.Throughput, 0.0)
+	assert.LessOrEqual(t, report.P50, report.P95)
+	assert.LessOrEqual(t, report.P95, report.Max)
+}
+
+func TestUpdateExistingConfigPreservesValues(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	envFile := filepath.Join(t.TempDir(), ".env")
+	existing := "INFINITY_METRICS_DOMAIN=old.example.com\n" +
+		"INFINITY_METRICS_PRIVATE_KEY=existing-private-key-that-is-long-enough-to-pass\n" +
+		"INFINITY_METRICS_LICENSE_KEY=IM-LICENSE-1234\n" +
+		"INFINITY_METRICS_USER=admin@example.com\n"
+	require.NoError(t, os.WriteFile(envFile, []byte(existing), 0o600))
+
+	t.Run("KeepsLicenseAndUserWhenNotProvided", func(t *testing.T) {
+		inst := NewInstaller(logger)
+		data := inst.config.GetData()
+		data.Domain = "new.example.com"
+		inst.config.SetData(data)
+
+		require.NoError(t, inst.updateExistingConfig(envFile))
+
+		got := inst.config.GetData()
+		assert.Equal(t, "new.example.com", got.Domain)
+		assert.Equal(t, "existing-private-key-that-is-long-enough-to-pass", got.PrivateKey)
+		assert.Equal(t, "IM-LICENSE-1234", got.LicenseKey)
+		assert.Equal(t, "admin@example.com", got.User)
+	})
+
+	t.Run("KeepsOtherSettings", func(t *testing.T) {
+		storage := t.TempDir()
+		reinstall := filepath.Join(t.TempDir(), ".env")
+		require.NoError(t, os.WriteFile(reinstall, []byte(existing+
+			"DB_STORAGE_PATH="+storage+"\n"+
+			"TLS_CERT_PATH=/etc/ssl/analytics.crt\n"+
+			"TLS_KEY_PATH=/etc/ssl/analytics.key\n"), 0o600))
+
+		inst := NewInstaller(logger)
+		data := inst.config.GetData()
+		data.Domain = "old.example.com"
+		inst.config.SetData(data)
+
+		require.NoError(t, inst.updateExistingConfig(reinstall))
+
+		for _, cfg := range []*config.Config{inst.config, loadEnvFile(t, logger, reinstall)} {
+			got := cfg.GetData()
+			assert.Equal(t, storage, got.DBStoragePath)
+			assert.Equal(t, "/etc/ssl/analytics.crt", got.TLSCertPath)
+			assert.Equal(t, "/etc/ssl/analytics.key", got.TLSKeyPath)
+		}
+	})
+
+	t.Run("FreshValuesWin", func(t *testing.T) {
+		old := config.ConfigData{PrivateKey: "old-key", LicenseKey: "OLD", User: "old@example.com"}
+		current := config.ConfigData{PrivateKey: "new-key", LicenseKey: "NEW", User: "new@example.com"}
+
+		got := preserveExistingValues(old, current)
+		assert.Equal(t, "old-key", got.PrivateKey)
+		assert.Equal(t, "NEW", got.LicenseKey)
+		assert.Equal(t, "new@example.com", got.User)
+	})
+}
+
+func loadEnvFile(t *testing.T, logger *logging.Logger, envFile string) *config.Config {
+	t.Helper()
+	cfg := config.NewConfig(logger)
+	require.NoError(t, cfg.LoadFromFile(envFile))
+	return cfg
+}
+
+func TestImportEnvFile(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	installDir, storage := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(installDir, ".env"), []byte("INFINITY_METRICS_DOMAIN=localhost\n"+
+		"INSTALL_DIR="+installDir+"\n"+
+		"INFINITY_METRICS_PRIVATE_KEY=existing-private-key-that-is-long-enough-to-pass\n"+
+		"DB_STORAGE_PATH="+storage+"\n"), 0o600))
+	template := filepath.Join(t.TempDir(), "infinity-metrics.env")
+	require.NoError(t, os.WriteFile(template, []byte("INFINITY_METRICS_DOMAIN=localhost\n"+
+		"INSTALL_DIR="+installDir+"\n"+
+		"HOOKS_FATAL=true\n"), 0o600))
+
+	inst := NewInstaller(logger)
+	inst.installDir = installDir
+	inst.SetEnvFile(template)
+	require.NoError(t, inst.importEnvFile())
+
+	got := inst.config.GetData()
+	assert.True(t, got.HooksFatal, "settings from --env-file should apply")
+	assert.Equal(t, storage, got.DBStoragePath, "settings the file leaves out should keep their installed values")
+	assert.Equal(t, "existing-private-key-that-is-long-enough-to-pass", got.PrivateKey)
+}
+
+func TestStepTimings(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	inst := NewInstaller(logger)
+
+	inst.startTiming("SQLite install")
+	time.Sleep(5 * time.Millisecond)
+	inst.startTiming("Docker install")
+	inst.LogTimingSummary()
+
+	require.Len(t, inst.timings, 2)
+	assert.Equal(t, "SQLite install", inst.timings[0].Name)
+	assert.GreaterOrEqual(t, inst.timings[0].Duration, 5*time.Millisecond)
+	assert.Equal(t, "Docker install", inst.timings[1].Name, "A failed step is still recorded")
+
+	// Summarizing twice must not duplicate the last step
+	inst.LogTimingSummary()
+	assert.Len(t, inst.timings, 2)
+}
+
+func TestRestoreLatestGoodBackup(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not available")
+	}
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	installer := NewInstaller(logger)
+	cfg := config.NewConfig(logger)
+	cfg.SetInstallDir(t.TempDir())
+	installer.config = cfg
+
+	backupDir := installer.GetBackupDir()
+	require.NoError(t, os.MkdirAll(backupDir, 0755))
+	mainDBPath := installer.GetMainDBPath()
+	require.NoError(t, os.WriteFile(mainDBPath, []byte("not a sqlite database"), 0644))
+
+	goodBackup := filepath.Join(backupDir, "backup_20240101_120000.db")
+	require.NoError(t, exec.Command("sqlite3", goodBackup, "CREATE TABLE events(id INTEGER PRIMARY KEY);").Run())
+	// The newest backup is corrupted and must be skipped
+	require.NoError(t, os.WriteFile(filepath.Join(backupDir, "backup_20240102_120000.db"), []byte("garbage"), 0644))
+
+	err := installer.database.ValidateBackup(mainDBPath)
+	require.Error(t, err, "corrupted main database should fail the integrity check")
+	assert.ErrorIs(t, err, database.ErrIntegrityCheckFailed)
+
+	restored, err := installer.RestoreLatestGoodBackup()
+	require.NoError(t, err)
+	assert.Equal(t, goodBackup, restored)
+	assert.NoError(t, installer.database.ValidateBackup(mainDBPath))
+
+	_, err = installer.RestoreLatestGoodBackup()
+	assert.Error(t, err, "no valid backup should remain after the good one was moved into place")
+}
+
+func TestRestoreFromBackupDBStoragePath(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not available")
+	}
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	installDir, storage := t.TempDir(), t.TempDir()
+	envFile := filepath.Join(installDir, ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("INFINITY_METRICS_DOMAIN=analytics.example.com\n"+
+		"INSTALL_DIR="+installDir+"\n"+
+		"DB_STORAGE_PATH="+storage+"\n"), 0o600))
+
+	installer := NewInstaller(logger)
+	require.NoError(t, installer.LoadConfig(envFile))
+	assert.Equal(t, filepath.Join(storage, "infinity-metrics-production.db"), installer.GetMainDBPath())
+
+	backupDir := installer.GetBackupDir()
+	require.NoError(t, os.MkdirAll(backupDir, 0755))
+	backup := filepath.Join(backupDir, "backup_20240101_120000.db")
+	require.NoError(t, exec.Command("sqlite3", backup, "CREATE TABLE events(id INTEGER PRIMARY KEY);").Run())
+
+	require.NoError(t, installer.RestoreFromBackup(backup))
+	assert.FileExists(t, filepath.Join(storage, "infinity-metrics-production.db"))
+	assert.NoFileExists(t, filepath.Join(installDir, "storage", "infinity-metrics-production.db"))
+
+	missing := NewInstaller(logger)
+	require.NoError(t, missing.LoadConfig(filepath.Join(t.TempDir(), ".env")))
+	assert.Equal(t, filepath.Join(DefaultInstallDir, "storage", "infinity-metrics-production.db"), missing.GetMainDBPath())
+}
+
+func TestVerifyInstallationIntegrityCheckUnavailable(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	installer := NewInstaller(logger)
+	cfg := config.NewConfig(logger)
+	cfg.SetInstallDir(t.TempDir())
+	installer.config = cfg
+	require.NoError(t, os.MkdirAll(filepath.Dir(installer.GetMainDBPath()), 0755))
+	require.NoError(t, os.WriteFile(installer.GetMainDBPath(), []byte("database held by the app"), 0644))
+
+	// Both containers run, but sqlite3 is not on PATH to check the database
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker"), []byte("#!/bin/sh\necho infinity-app-1 infinity-caddy\n"), 0o755))
+	t.Setenv("PATH", bin)
+
+	warnings, err := installer.VerifyInstallation()
+	require.NoError(t, err, "an integrity check that could not run must not fail the install")
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "could not check the integrity")
+}
+
+func TestHandleOrphanedContainersOnResume(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	bin := t.TempDir()
+	argsFile := filepath.Join(bin, "args")
+	// A failed deploy left the first app container behind, without Caddy
+	script := "#!/bin/sh\necho \"$@\" >> " + argsFile + "\necho 'infinity-app-1|exited|'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0o755))
+	t.Setenv("PATH", bin)
+
+	installer := NewInstaller(logger)
+	err := installer.handleOrphanedContainers(false)
+	require.Error(t, err, "a fresh install must not remove containers without --clean-orphans")
+	assert.Contains(t, err.Error(), "--clean-orphans")
+
+	require.NoError(t, installer.handleOrphanedContainers(true))
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "rm -f infinity-app-1", "the resumed deploy should remove the leftover container")
+}
+
+func TestIsDatabaseCorrupted(t *testing.T) {
+	assert.True(t, IsDatabaseCorrupted(fmt.Errorf("%w: /tmp/db: bad", ErrDatabaseCorrupted)))
+	assert.False(t, IsDatabaseCorrupted(fmt.Errorf("database file not found")))
+}
+
+func TestInstallReport(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no docker, image digests are left out
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	inst := NewInstaller(logger)
+	data := inst.config.GetData()
+	data.Domain = "analytics.example.com"
+	data.PrivateKey = "abcdefghijklmnopqrstuvwxyz123456"
+	data.LicenseKey = "IM-SECRET-LICENSE"
+	inst.config.SetData(data)
+	inst.startTiming("Deploy")
+	inst.step = "deploy"
+
+	report := inst.BuildReport(time.Now(), fmt.Errorf("deploy failed"))
+	path := filepath.Join(t.TempDir(), "install-report.json")
+	require.NoError(t, WriteReport(path, report))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), data.PrivateKey)
+	assert.NotContains(t, string(content), data.LicenseKey)
+
+	var written InstallReport
+	require.NoError(t, json.Unmarshal(content, &written))
+	assert.False(t, written.Success)
+	assert.Equal(t, "deploy", written.FailedStep)
+	assert.Equal(t, "analytics.example.com", written.Config["INFINITY_METRICS_DOMAIN"])
+	require.Len(t, written.Steps, 1)
+	assert.Equal(t, "Deploy", written.Steps[0].Name)
+	assert.Len(t, written.Images, 2)
+	assert.False(t, written.Verification.Ran)
+}
+
+func TestCheckInstallDir(t *testing.T) {
+	inst := NewInstaller(logging.NewLogger(logging.Config{Level: "error", Quiet: true}))
+	write := func(dir, name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	assert.NoError(t, inst.checkInstallDir(filepath.Join(t.TempDir(), "missing")))
+	assert.NoError(t, inst.checkInstallDir(t.TempDir()))
+
+	previous := t.TempDir()
+	write(previous, ".env", "INFINITY_METRICS_DOMAIN=example.com\n")
+	write(previous, StateFileName, "{}")
+	write(previous, "notes.txt", "kept by the admin")
+	require.NoError(t, os.Mkdir(filepath.Join(previous, "storage"), 0o755))
+	assert.NoError(t, inst.checkInstallDir(previous), "unknown files next to an installation only warn")
+
+	foreignEnv := t.TempDir()
+	write(foreignEnv, ".env", "DATABASE_URL=postgres://localhost/app\n")
+	err := inst.checkInstallDir(foreignEnv)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not belong to an Infinity Metrics installation")
+
+	foreignFiles := t.TempDir()
+	write(foreignFiles, "docker-compose.yml", "services: {}")
+	write(foreignFiles, "infinity-metrics-cli.log", "")
+	err = inst.checkInstallDir(foreignFiles)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "docker-compose.yml")
+	assert.NotContains(t, err.Error(), "infinity-metrics-cli.log")
+
+	// Left by an earlier install whose .env is gone
+	leftovers := t.TempDir()
+	write(leftovers, ".env.backup.20240101-120000", "INFINITY_METRICS_DOMAIN=example.com\n")
+	require.NoError(t, os.Mkdir(filepath.Join(leftovers, "backups"), 0o755))
+	assert.NoError(t, inst.checkInstallDir(leftovers))
+}
@@ -0,0 +1,166 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// installSteps are the steps RunCompleteInstallation reports, in order
+var installSteps = []string{
+	"Checking system requirements",
+	"Installing SQLite",
+	"Installing Docker",
+	"Configuring system",
+	"Deploying application",
+	"Setting up maintenance",
+	"Verifying installation",
+}
+
+type stepStatus int
+
+const (
+	stepPending stepStatus = iota
+	stepRunning
+	stepDone
+	stepFailed
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progressView draws every install step with its status below the log output
+// and redraws it in place. Log lines written through it are printed above the
+// step list so the two never interleave.
+type progressView struct {
+	mu       sync.Mutex
+	out      io.Writer
+	steps    []string
+	statuses []stepStatus
+	frame    int
+	drawn    bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+func newProgressView(out io.Writer, steps []string) *progressView {
+	return &progressView{
+		out:      out,
+		steps:    steps,
+		statuses: make([]stepStatus, len(steps)),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// run animates the spinner on the active step until close is called
+func (v *progressView) run() {
+	defer close(v.stopped)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	v.redraw()
+	for {
+		select {
+		case <-v.stop:
+			return
+		case <-ticker.C:
+			v.mu.Lock()
+			v.frame = (v.frame + 1) % len(spinnerFrames)
+			v.mu.Unlock()
+			v.redraw()
+		}
+	}
+}
+
+// close stops the animation and leaves the final step list on screen
+func (v *progressView) close() {
+	close(v.stop)
+	<-v.stopped
+	v.redraw()
+}
+
+// begin marks step n (1-based) as running
+func (v *progressView) begin(n int) {
+	v.set(n, stepRunning)
+}
+
+// done marks step n (1-based) as completed
+func (v *progressView) done(n int) {
+	v.set(n, stepDone)
+}
+
+// fail marks the running step, if any, as failed
+func (v *progressView) fail() {
+	v.mu.Lock()
+	for idx, status := range v.statuses {
+		if status == stepRunning {
+			v.statuses[idx] = stepFailed
+		}
+	}
+	v.mu.Unlock()
+	v.redraw()
+}
+
+func (v *progressView) set(n int, status stepStatus) {
+	if n < 1 || n > len(v.statuses) {
+		return
+	}
+	v.mu.Lock()
+	v.statuses[n-1] = status
+	v.mu.Unlock()
+	v.redraw()
+}
+
+// Write prints log output above the step list
+func (v *progressView) Write(p []byte) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.clear()
+	if _, err := v.out.Write(p); err != nil {
+		return 0, err
+	}
+	v.draw()
+	return len(p), nil
+}
+
+func (v *progressView) redraw() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.clear()
+	v.draw()
+}
+
+// clear moves the cursor back to the first line of the step list and erases it
+func (v *progressView) clear() {
+	if v.drawn {
+		fmt.Fprintf(v.out, "\033[%dA\r\033[J", len(v.steps))
+		v.drawn = false
+	}
+}
+
+func (v *progressView) draw() {
+	fmt.Fprint(v.out, strings.Join(v.lines(), "\n")+"\n")
+	v.drawn = true
+}
+
+// lines renders one line per step
+func (v *progressView) lines() []string {
+	lines := make([]string, len(v.steps))
+	for idx, name := range v.steps {
+		var marker string
+		switch v.statuses[idx] {
+		case stepRunning:
+			marker = "\033[36m" + spinnerFrames[v.frame] + "\033[0m"
+		case stepDone:
+			marker = "\033[32m✔\033[0m"
+		case stepFailed:
+			marker = "\033[31m✖\033[0m"
+		default:
+			marker = "\033[2m○\033[0m"
+		}
+		lines[idx] = fmt.Sprintf("%s Step %d/%d: %s", marker, idx+1, len(v.steps), name)
+	}
+	return lines
+}
@@ -0,0 +1,53 @@
+package installer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProgressView(t *testing.T) {
+	var out bytes.Buffer
+	view := newProgressView(&out, []string{"First", "Second", "Third"})
+
+	view.begin(1)
+	view.done(1)
+	view.begin(2)
+	if _, err := view.Write([]byte("a log line\n")); err != nil {
+		t.Fatal(err)
+	}
+	view.fail()
+
+	lines := view.lines()
+	for idx, want := range []string{"✔", "✖", "○"} {
+		if !strings.Contains(lines[idx], want) {
+			t.Errorf("line %d = %q, want status %s", idx+1, lines[idx], want)
+		}
+	}
+	if !strings.Contains(lines[1], "Step 2/3: Second") {
+		t.Errorf("line 2 = %q, want step label", lines[1])
+	}
+
+	// The log line is written after erasing the step list, then the list is redrawn below it
+	output := out.String()
+	logAt := strings.Index(output, "a log line")
+	if logAt < 0 {
+		t.Fatalf("log line missing from output %q", output)
+	}
+	if !strings.Contains(output[:logAt], "\033[3A\r\033[J") {
+		t.Errorf("step list was not erased before the log line: %q", output[:logAt])
+	}
+	if !strings.Contains(output[logAt:], "Step 3/3: Third") {
+		t.Errorf("step list was not redrawn after the log line: %q", output[logAt:])
+	}
+}
+
+func TestProgressViewIgnoresOutOfRangeSteps(t *testing.T) {
+	var out bytes.Buffer
+	view := newProgressView(&out, []string{"Only"})
+	view.begin(0)
+	view.done(2)
+	if view.statuses[0] != stepPending {
+		t.Errorf("status = %v, want pending", view.statuses[0])
+	}
+}
@@ -0,0 +1,104 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/docker"
+)
+
+// InstallReport summarizes an install run for provisioning records and tickets
+type InstallReport struct {
+	GeneratedAt      time.Time          `json:"generated_at"`
+	InstallerVersion string             `json:"installer_version,omitempty"`
+	Success          bool               `json:"success"`
+	FailedStep       string             `json:"failed_step,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	DurationSeconds  float64            `json:"duration_seconds"`
+	Config           map[string]string  `json:"config"` // .env settings, secrets masked
+	Steps            []ReportTiming     `json:"steps"`
+	DeployPhases     []ReportTiming     `json:"deploy_phases,omitempty"`
+	DNSWarnings      []string           `json:"dns_warnings,omitempty"`
+	Images           []ReportImage      `json:"images"`
+	Verification     ReportVerification `json:"verification"`
+}
+
+// ReportTiming is how long one install step or deploy phase took
+type ReportTiming struct {
+	Name            string  `json:"name"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// ReportImage is a deployed image and the digest docker resolved it to
+type ReportImage struct {
+	Image  string `json:"image"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// ReportVerification is the outcome of the final verification step
+type ReportVerification struct {
+	Ran      bool     `json:"ran"`
+	Passed   bool     `json:"passed"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// BuildReport collects the report of the install that started at startedAt
+// and ended with err
+func (i *Installer) BuildReport(startedAt time.Time, err error) InstallReport {
+	i.stopTiming()
+	data := i.config.GetData()
+	report := InstallReport{
+		GeneratedAt:      time.Now().UTC(),
+		InstallerVersion: os.Getenv(config.InstallerVersionEnvVar),
+		Success:          err == nil,
+		DurationSeconds:  time.Since(startedAt).Round(time.Second).Seconds(),
+		Config:           i.config.RedactedSettings(),
+		Steps:            reportTimings(i.timings),
+		DeployPhases:     reportTimings(i.docker.DeployPhases()),
+		DNSWarnings:      data.DNSWarnings,
+		Verification:     i.verification,
+	}
+	if err != nil {
+		report.FailedStep = i.step
+		report.Error = err.Error()
+	}
+
+	for _, image := range []string{data.AppImage, data.CaddyImage} {
+		entry := ReportImage{Image: image}
+		if digest, digestErr := i.docker.GetLocalImageDigest(image); digestErr == nil {
+			entry.Digest = digest
+		}
+		report.Images = append(report.Images, entry)
+	}
+	return report
+}
+
+func reportTimings(timings []docker.PhaseTiming) []ReportTiming {
+	entries := make([]ReportTiming, len(timings))
+	for idx, timing := range timings {
+		entries[idx] = ReportTiming{Name: timing.Name, DurationSeconds: timing.Duration.Round(100 * time.Millisecond).Seconds()}
+	}
+	return entries
+}
+
+// WriteReport writes report as indented JSON to path. It is written to a
+// temporary name first so a failure never leaves a truncated report behind.
+func WriteReport(path string, report InstallReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode install report: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write install report: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write install report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package installer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"infinity-metrics-installer/internal/httpclient"
+)
+
+const (
+	SmokeLoadRequests    = 200
+	SmokeLoadConcurrency = 10
+)
+
+// LoadReport summarizes a smoke-load run
+type LoadReport struct {
+	Requests   int
+	Failures   int
+	Duration   time.Duration
+	Throughput float64 // successful requests per second
+	P50        time.Duration
+	P95        time.Duration
+	Max        time.Duration
+	AppHealthy bool
+	FirstError error
+}
+
+// smokeURL returns the public health endpoint served through Caddy
+func (i *Installer) smokeURL() string {
+	return fmt.Sprintf("https://%s/_health", i.config.GetData().Domain)
+}
+
+// smokeClient returns an HTTPS client for the installed instance. In the test
+// environment Caddy serves a self-signed certificate, so verification is skipped.
+func smokeClient() *http.Client {
+	client := httpclient.New()
+	if os.Getenv("ENV") == "test" {
+		transport := httpclient.Transport()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		client.Transport = transport
+	}
+	return client
+}
+
+// SmokeTest performs a single HTTPS request against the health endpoint
+func (i *Installer) SmokeTest() error {
+	_, err := smokeRequest(smokeClient(), i.smokeURL())
+	return err
+}
+
+// RunSmokeLoad fires a short burst of concurrent requests at the health endpoint,
+// then checks the app container is still healthy
+func (i *Installer) RunSmokeLoad(requests, concurrency int) (LoadReport, error) {
+	url := i.smokeURL()
+	if err := i.SmokeTest(); err != nil {
+		return LoadReport{}, fmt.Errorf("smoke test against %s failed: %w", url, err)
+	}
+
+	i.logger.Info("Sending %d requests to %s with concurrency %d", requests, url, concurrency)
+	report := runLoad(smokeClient(), url, requests, concurrency)
+
+	if name, err := i.docker.ActiveAppContainer(); err != nil {
+		i.logger.Warn("Could not find app container after load: %v", err)
+	} else if err := i.docker.CheckAppHealth(name); err != nil {
+		i.logger.Warn("App container %s failed health check after load: %v", name, err)
+	} else {
+		report.AppHealthy = true
+	}
+	return report, nil
+}
+
+// LogLoadReport prints the smoke-load results
+func (i *Installer) LogLoadReport(report LoadReport) {
+	i.logger.Info("Smoke load: %d requests in %s (%.1f req/s), %d failed",
+		report.Requests, report.Duration.Round(time.Millisecond), report.Throughput, report.Failures)
+	i.logger.Info("Latency: p50 %s, p95 %s, max %s",
+		report.P50.Round(time.Millisecond), report.P95.Round(time.Millisecond), report.Max.Round(time.Millisecond))
+	if report.FirstError != nil {
+		i.logger.Warn("First failure: %v", report.FirstError)
+	}
+	if report.AppHealthy && report.Failures == 0 {
+		i.logger.Success("App stayed healthy under load")
+	} else if !report.AppHealthy {
+		i.logger.Warn("App was not healthy after the load test, check 'infinity-metrics logs app'")
+	}
+}
+
+func smokeRequest(client *http.Client, url string) (time.Duration, error) {
+	start := time.Now()
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	elapsed := time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		return elapsed, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return elapsed, nil
+}
+
+func runLoad(client *http.Client, url string, requests, concurrency int) LoadReport {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies []time.Duration
+		report    = LoadReport{Requests: requests}
+		jobs      = make(chan struct{})
+	)
+
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				elapsed, err := smokeRequest(client, url)
+				mu.Lock()
+				if err != nil {
+					report.Failures++
+					if report.FirstError == nil {
+						report.FirstError = err
+					}
+				} else {
+					latencies = append(latencies, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for n := 0; n < requests; n++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	wg.Wait()
+	report.Duration = time.Since(start)
+
+	if report.Duration > 0 {
+		report.Throughput = float64(len(latencies)) / report.Duration.Seconds()
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(a, b int) bool { return latencies[a] < latencies[b] })
+		report.P50 = latencies[len(latencies)*50/100]
+		report.P95 = latencies[len(latencies)*95/100]
+		report.Max = latencies[len(latencies)-1]
+	}
+	return report
+}
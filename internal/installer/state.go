@@ -0,0 +1,67 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StateFileName records completed installation steps inside the install dir
+const StateFileName = ".install-state.json"
+
+// installState tracks which installation steps have completed so that
+// `install --resume` can skip them after a failed run
+type installState struct {
+	path      string
+	Completed []string `json:"completed_steps"`
+}
+
+// loadInstallState reads the state file from installDir; a missing file yields an empty state
+func loadInstallState(installDir string) (*installState, error) {
+	s := &installState{path: filepath.Join(installDir, StateFileName)}
+	content, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read install state %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("parse install state %s: %w", s.path, err)
+	}
+	return s, nil
+}
+
+func (s *installState) done(step string) bool {
+	for _, completed := range s.Completed {
+		if completed == step {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *installState) markDone(step string) error {
+	if s.done(step) {
+		return nil
+	}
+	s.Completed = append(s.Completed, step)
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create install dir for state file: %w", err)
+	}
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, content, 0o600)
+}
+
+func (s *installState) clear() error {
+	s.Completed = nil
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PublicKey is the base64 Ed25519 key signed licenses are verified against.
+// Release builds set it with -ldflags "-X infinity-metrics-installer/internal/license.PublicKey=...".
+var PublicKey string
+
+// ErrNoPublicKey means this build carries no key to verify signed licenses offline
+var ErrNoPublicKey = errors.New("no license public key in this build")
+
+// Claims are the constraints a signed license carries
+type Claims struct {
+	Subject   string `json:"sub,omitempty"`
+	Domain    string `json:"domain,omitempty"` // Licensed domain, empty for any
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"` // Unix seconds, 0 never expires
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+}
+
+// IsSigned reports whether key is a signed token (header.payload.signature,
+// JWT style) rather than a plain license key
+func IsSigned(key string) bool {
+	parts := strings.Split(key, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	var h header
+	return decodeSegment(parts[0], &h) == nil && h.Algorithm != ""
+}
+
+// Verify checks the signature of a signed license against PublicKey, then its
+// validity period and, when domain is known, the licensed domain
+func Verify(key, domain string, now time.Time) (*Claims, error) {
+	if PublicKey == "" {
+		return nil, ErrNoPublicKey
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid license public key in this build")
+	}
+	return verify(ed25519.PublicKey(publicKey), key, domain, now)
+}
+
+func verify(publicKey ed25519.PublicKey, key, domain string, now time.Time) (*Claims, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("license is not a signed token")
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("malformed license header: %w", err)
+	}
+	if h.Algorithm != "EdDSA" {
+		return nil, fmt.Errorf("unsupported license signature algorithm %q", h.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed license signature: %w", err)
+	}
+	if !ed25519.Verify(publicKey, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("license signature is invalid, the key was altered or not issued for this product")
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed license claims: %w", err)
+	}
+	if claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, fmt.Errorf("license is not valid before %s", time.Unix(claims.NotBefore, 0).UTC().Format(time.DateOnly))
+	}
+	if claims.ExpiresAt != 0 && !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, fmt.Errorf("license expired on %s", time.Unix(claims.ExpiresAt, 0).UTC().Format(time.DateOnly))
+	}
+	if claims.Domain != "" && domain != "" && !strings.EqualFold(claims.Domain, domain) {
+		return nil, fmt.Errorf("license is issued for %s, not %s", claims.Domain, domain)
+	}
+	return &claims, nil
+}
+
+// decodeSegment decodes one base64url JSON segment of a token into v
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,100 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signToken(t *testing.T, privateKey ed25519.PrivateKey, alg string, claims Claims) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signed := encode(header{Algorithm: alg}) + "." + encode(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(signed)))
+}
+
+func TestIsSigned(t *testing.T) {
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	if !IsSigned(signToken(t, privateKey, "EdDSA", Claims{})) {
+		t.Error("IsSigned should accept a signed token")
+	}
+	for _, key := range []string{"ABC-123-DEF-456", "ABC.123.DEF", "ABC.123.DEF.456"} {
+		if IsSigned(key) {
+			t.Errorf("IsSigned(%q) = true for a plain license key", key)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	valid := Claims{Subject: "acme", Domain: "analytics.example.com", ExpiresAt: now.AddDate(1, 0, 0).Unix()}
+
+	tests := []struct {
+		name    string
+		token   string
+		domain  string
+		wantErr string
+	}{
+		{"Valid", signToken(t, privateKey, "EdDSA", valid), "analytics.example.com", ""},
+		{"UnknownDomain", signToken(t, privateKey, "EdDSA", valid), "", ""},
+		{"NoExpiry", signToken(t, privateKey, "EdDSA", Claims{Subject: "acme"}), "other.example.com", ""},
+		{"Expired", signToken(t, privateKey, "EdDSA", Claims{ExpiresAt: now.AddDate(0, 0, -1).Unix()}), "", "expired on 2025-05-31"},
+		{"NotYetValid", signToken(t, privateKey, "EdDSA", Claims{NotBefore: now.AddDate(0, 1, 0).Unix()}), "", "not valid before"},
+		{"OtherDomain", signToken(t, privateKey, "EdDSA", valid), "other.example.com", "issued for analytics.example.com"},
+		{"WrongSigner", signToken(t, otherKey, "EdDSA", valid), "", "signature is invalid"},
+		{"WrongAlgorithm", signToken(t, privateKey, "none", valid), "", "unsupported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verify(publicKey, tt.token, tt.domain, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("verify() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verify() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("TamperedClaims", func(t *testing.T) {
+		parts := strings.Split(signToken(t, privateKey, "EdDSA", valid), ".")
+		forged, _ := json.Marshal(Claims{Subject: "acme", ExpiresAt: now.AddDate(10, 0, 0).Unix()})
+		parts[1] = base64.RawURLEncoding.EncodeToString(forged)
+		if _, err := verify(publicKey, strings.Join(parts, "."), "", now); err == nil {
+			t.Error("verify() should reject claims changed after signing")
+		}
+	})
+}
+
+func TestVerifyPublicKey(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	token := signToken(t, privateKey, "EdDSA", Claims{})
+	original := PublicKey
+	defer func() { PublicKey = original }()
+
+	PublicKey = ""
+	if _, err := Verify(token, "", time.Now()); !errors.Is(err, ErrNoPublicKey) {
+		t.Errorf("Verify() without a key error = %v, want ErrNoPublicKey", err)
+	}
+
+	PublicKey = base64.StdEncoding.EncodeToString(publicKey)
+	if _, err := Verify(token, "", time.Now()); err != nil {
+		t.Errorf("Verify() with the build key error = %v", err)
+	}
+}
@@ -1,9 +1,11 @@
 package logging
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -15,6 +17,23 @@ type Config struct {
 	LogDir  string
 	Quiet   bool
 	LogFile string // Specify the log file name
+
+	// Timestamp layout and IANA timezone, e.g. "RFC3339" and "UTC". Empty
+	// values fall back to LOG_TIME_FORMAT and LOG_TIMEZONE, then to
+	// DefaultTimeFormat in local time.
+	TimeFormat string
+	Timezone   string
+}
+
+// DefaultTimeFormat is the short HH:MM:SS timestamp used unless configured
+const DefaultTimeFormat = "15:04:05"
+
+// timeFormatAliases lets LOG_TIME_FORMAT name common layouts instead of
+// spelling out Go's reference time
+var timeFormatAliases = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"DateTime":    time.DateTime,
 }
 
 type Logger struct {
@@ -24,11 +43,14 @@ type Logger struct {
 }
 
 func NewLogger(config Config) *Logger {
+	timeFormat := resolveTimeFormat(config.TimeFormat)
+	location, locationErr := resolveTimezone(config.Timezone)
+
 	logger := logrus.New()
 	logger.SetOutput(os.Stdout)
-	logger.SetFormatter(&logrus.TextFormatter{
+	logger.SetFormatter(&timezoneFormatter{location: location, Formatter: &logrus.TextFormatter{
 		DisableTimestamp:       false,      // Enable timestamps for console logs
-		TimestampFormat:        "15:04:05", // Use a short time format (HH:MM:SS)
+		TimestampFormat:        timeFormat, // Short HH:MM:SS unless configured
 		DisableColors:          false,      // Keep colors for console logs
 		DisableQuote:           true,
 		ForceColors:            true, // Ensure colors even if output is redirected
@@ -40,7 +62,7 @@ func NewLogger(config Config) *Logger {
 			logrus.FieldKeyMsg:   "", // Remove the msg prefix
 			logrus.FieldKeyTime:  "", // We'll prepend the timestamp manually
 		},
-	})
+	}})
 
 	switch config.Level {
 	case "debug":
@@ -60,6 +82,9 @@ func NewLogger(config Config) *Logger {
 	if config.Quiet {
 		logger.SetLevel(logrus.ErrorLevel)
 	}
+	if locationErr != nil {
+		logger.Warnf("Ignoring log timezone: %v", locationErr)
+	}
 
 	return &Logger{
 		Logger:      logger,
@@ -98,14 +123,66 @@ func NewFileLogger(config Config) *Logger {
 			MaxAge:     28,
 			Compress:   true,
 		},
-		Formatter: &logrus.JSONFormatter{
-			TimestampFormat: "15:04:05",
+		Formatter: &timezoneFormatter{
+			location:  logger.location(),
+			Formatter: &logrus.JSONFormatter{TimestampFormat: resolveTimeFormat(config.TimeFormat)},
 		},
 	})
 
 	return logger
 }
 
+// resolveTimeFormat returns the timestamp layout for format, LOG_TIME_FORMAT or
+// the default, translating layout aliases such as RFC3339
+func resolveTimeFormat(format string) string {
+	if format == "" {
+		format = os.Getenv("LOG_TIME_FORMAT")
+	}
+	if format == "" {
+		return DefaultTimeFormat
+	}
+	if layout, ok := timeFormatAliases[format]; ok {
+		return layout
+	}
+	return format
+}
+
+// resolveTimezone loads timezone or LOG_TIMEZONE. Unknown zones fall back to
+// local time with an error so the logger can still be created.
+func resolveTimezone(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		timezone = os.Getenv("LOG_TIMEZONE")
+	}
+	if timezone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Local, fmt.Errorf("unknown timezone %q, using local time", timezone)
+	}
+	return location, nil
+}
+
+// timezoneFormatter renders entry timestamps in location
+type timezoneFormatter struct {
+	logrus.Formatter
+	location *time.Location
+}
+
+func (f *timezoneFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	local := *entry
+	local.Time = entry.Time.In(f.location)
+	return f.Formatter.Format(&local)
+}
+
+// location returns the timezone the console formatter renders timestamps in
+func (l *Logger) location() *time.Location {
+	if formatter, ok := l.Formatter.(*timezoneFormatter); ok {
+		return formatter.location
+	}
+	return time.Local
+}
+
 type FileHook struct {
 	Writer    io.Writer
 	Formatter logrus.Formatter
@@ -140,6 +217,22 @@ func (l *Logger) Error(format string, args ...interface{}) {
 	l.Logger.Errorf(format, args...)
 }
 
+// Detail writes an Info entry to the log file only, whatever the console
+// level, for details such as a full error chain that would clutter the
+// console. Loggers without a log file drop it.
+func (l *Logger) Detail(format string, args ...interface{}) {
+	if !l.fileLogging {
+		return
+	}
+	entry := logrus.NewEntry(l.Logger)
+	entry.Time = time.Now()
+	entry.Level = logrus.InfoLevel
+	entry.Message = fmt.Sprintf(format, args...)
+	for _, hook := range l.Hooks[logrus.InfoLevel] {
+		_ = hook.Fire(entry)
+	}
+}
+
 func (l *Logger) Success(format string, args ...interface{}) {
 	l.Logger.Infof("✔ "+format, args...)
 	if l.fileLogging {
@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestResolveTimeFormat(t *testing.T) {
+	t.Setenv("LOG_TIME_FORMAT", "")
+	if got := resolveTimeFormat(""); got != DefaultTimeFormat {
+		t.Errorf("resolveTimeFormat(\"\") = %q, want %q", got, DefaultTimeFormat)
+	}
+	if got := resolveTimeFormat("RFC3339"); got != time.RFC3339 {
+		t.Errorf("resolveTimeFormat(RFC3339) = %q, want %q", got, time.RFC3339)
+	}
+	if got := resolveTimeFormat("2006-01-02 15:04"); got != "2006-01-02 15:04" {
+		t.Errorf("resolveTimeFormat should keep a Go layout, got %q", got)
+	}
+
+	t.Setenv("LOG_TIME_FORMAT", "RFC3339Nano")
+	if got := resolveTimeFormat(""); got != time.RFC3339Nano {
+		t.Errorf("resolveTimeFormat should fall back to LOG_TIME_FORMAT, got %q", got)
+	}
+}
+
+func TestResolveTimezone(t *testing.T) {
+	t.Setenv("LOG_TIMEZONE", "")
+	if location, err := resolveTimezone(""); err != nil || location != time.Local {
+		t.Errorf("resolveTimezone(\"\") = %v, %v, want local time", location, err)
+	}
+
+	t.Setenv("LOG_TIMEZONE", "UTC")
+	if location, err := resolveTimezone(""); err != nil || location.String() != "UTC" {
+		t.Errorf("resolveTimezone should fall back to LOG_TIMEZONE, got %v, %v", location, err)
+	}
+
+	if location, err := resolveTimezone("Mars/Olympus_Mons"); err == nil || location != time.Local {
+		t.Errorf("unknown timezone should fall back to local time with an error, got %v, %v", location, err)
+	}
+}
+
+func TestLoggerTimestampInTimezone(t *testing.T) {
+	logger := NewLogger(Config{TimeFormat: "RFC3339", Timezone: "Asia/Tokyo"})
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+
+	entry := logrus.NewEntry(logger.Logger)
+	entry.Time = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entry.Message = "hello"
+	line, err := logger.Formatter.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if !strings.Contains(string(line), "2024-05-01T21:00:00+09:00") {
+		t.Errorf("console timestamp should be RFC3339 in Tokyo time, got %q", line)
+	}
+	if !entry.Time.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) || entry.Time.Location() != time.UTC {
+		t.Error("formatting must not change the entry shared with hooks")
+	}
+
+	fileFormatter := &timezoneFormatter{location: logger.location(), Formatter: &logrus.JSONFormatter{TimestampFormat: time.RFC3339}}
+	line, err = fileFormatter.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		t.Fatalf("invalid JSON log line %q: %v", line, err)
+	}
+	if fields["time"] != "2024-05-01T21:00:00+09:00" {
+		t.Errorf("file timestamp = %v, want Tokyo time", fields["time"])
+	}
+}
+
+func TestDetailGoesToLogFileOnly(t *testing.T) {
+	dir := t.TempDir()
+	logger := NewFileLogger(Config{Level: "error", LogDir: dir, LogFile: "test.log"})
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+
+	logger.Detail("Full error: %s", "pull failed: connection reset")
+
+	if out.Len() != 0 {
+		t.Errorf("Detail should not print to the console, got %q", out.String())
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(content, &fields); err != nil {
+		t.Fatalf("invalid JSON log line %q: %v", content, err)
+	}
+	if fields["msg"] != "Full error: pull failed: connection reset" || fields["level"] != "info" {
+		t.Errorf("log file entry = %v, want the message at info level", fields)
+	}
+
+	NewLogger(Config{Quiet: true}).Detail("dropped without a log file")
+}
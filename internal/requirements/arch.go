@@ -0,0 +1,91 @@
+package requirements
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// hostArch is the architecture this binary runs as, overridden in tests
+var hostArch = runtime.GOARCH
+
+// userlandBits returns the word size of the installed operating system, 32
+// or 64, or 0 when it cannot be told. It works before Docker is installed:
+// getconf and dpkg are userland binaries, built for the userland's word size
+// whatever the kernel is. Replaced in tests.
+var userlandBits = func() int {
+	if output, err := exec.Command("getconf", "LONG_BIT").Output(); err == nil {
+		if bits, err := strconv.Atoi(strings.TrimSpace(string(output))); err == nil {
+			return bits
+		}
+	}
+	if output, err := exec.Command("dpkg", "--print-architecture").Output(); err == nil {
+		switch strings.TrimSpace(string(output)) {
+		case "armhf", "armel", "i386":
+			return 32
+		case "amd64", "arm64":
+			return 64
+		}
+	}
+	return 0
+}
+
+// kernelArch returns the machine name of the running kernel as uname -m
+// reports it, or "" when it cannot be read. Replaced in tests.
+var kernelArch = func() string {
+	output, err := exec.Command("uname", "-m").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// CheckArchitecture returns an error explaining what to do instead when arch
+// is not one the installer and the app image are published for. 32-bit ARM,
+// common on older Raspberry Pi OS installs, gets its own advice.
+func CheckArchitecture(arch string) error {
+	switch arch {
+	case "amd64", "arm64":
+		return nil
+	case "arm":
+		return fmt.Errorf("32-bit ARM is not supported, Infinity Metrics needs a 64-bit system (arm64 or amd64). " +
+			"On a Raspberry Pi 3 or newer, install Raspberry Pi OS (64-bit) or another arm64 distribution")
+	}
+	return fmt.Errorf("unsupported architecture %s, Infinity Metrics runs on amd64 and arm64 only", arch)
+}
+
+// checkArchitecture stops the install on unsupported architectures before
+// anything is downloaded
+func (c *Checker) checkArchitecture() error {
+	result := c.preflightArchitecture()
+	if !result.Passed {
+		fmt.Printf("❌ Error: %s\n", result.Detail)
+		return fmt.Errorf("unsupported architecture: %s", result.Detail)
+	}
+	fmt.Printf("✅ Architecture: %s\n", result.Detail)
+	return nil
+}
+
+func (c *Checker) preflightArchitecture() CheckResult {
+	result := CheckResult{Name: "Architecture"}
+	if err := CheckArchitecture(hostArch); err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	// A 64-bit kernel runs this binary even under a 32-bit userland, where
+	// Docker is 32-bit too and pulls images the app is not published for
+	if userlandBits() == 32 {
+		kernel := kernelArch()
+		if kernel == "" {
+			kernel = "64-bit"
+		}
+		result.Detail = fmt.Sprintf("the kernel is %s but the operating system is 32-bit. "+
+			"Docker and its containers would run as 32-bit, which the app image does not support: install a 64-bit OS, such as Raspberry Pi OS (64-bit)", kernel)
+		return result
+	}
+	result.Passed = true
+	result.Detail = hostArch
+	return result
+}
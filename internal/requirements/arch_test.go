@@ -0,0 +1,47 @@
+package requirements
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infinity-metrics-installer/internal/logging"
+)
+
+func TestCheckArchitecture(t *testing.T) {
+	assert.NoError(t, CheckArchitecture("amd64"))
+	assert.NoError(t, CheckArchitecture("arm64"))
+	if err := CheckArchitecture("arm"); assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "64-bit")
+	}
+	assert.Error(t, CheckArchitecture("386"))
+}
+
+func TestPreflightArchitecture(t *testing.T) {
+	checker := NewChecker(logging.NewLogger(logging.Config{Level: "error", Quiet: true}))
+	originalArch, originalBits, originalKernel := hostArch, userlandBits, kernelArch
+	t.Cleanup(func() { hostArch, userlandBits, kernelArch = originalArch, originalBits, originalKernel })
+	kernelArch = func() string { return "aarch64" }
+
+	tests := []struct {
+		name       string
+		host       string
+		bits       int
+		wantPassed bool
+		wantDetail string
+	}{
+		{"arm64", "arm64", 64, true, "arm64"},
+		{"word size unknown", "amd64", 0, true, "amd64"},
+		{"32-bit userland", "arm64", 32, false, "the kernel is aarch64 but the operating system is 32-bit"},
+		{"32-bit binary", "arm", 32, false, "32-bit ARM is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostArch = tt.host
+			userlandBits = func() int { return tt.bits }
+			result := checker.preflightArchitecture()
+			assert.Equal(t, tt.wantPassed, result.Passed)
+			assert.Contains(t, result.Detail, tt.wantDetail)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package requirements
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/httpclient"
+)
+
+const (
+	// ConnectivityTimeoutEnvVar overrides how long each connectivity check
+	// waits, as a duration ("5s") or a number of seconds
+	ConnectivityTimeoutEnvVar = "CONNECTIVITY_TIMEOUT"
+	// DefaultConnectivityTimeout keeps a blocked host from stalling the
+	// checks for the full HTTP timeout
+	DefaultConnectivityTimeout = 10 * time.Second
+	// SkipConnectivityEnvVar skips the connectivity checks before an install,
+	// for hosts where only the Docker daemon reaches the internet, through
+	// its own proxy
+	SkipConnectivityEnvVar = "SKIP_CONNECTIVITY_CHECK"
+)
+
+// ConnectivityTarget is a service an install or update depends on. The
+// target is reachable when any of its URLs answers. An optional target only
+// warns when unreachable.
+type ConnectivityTarget struct {
+	Name     string
+	URLs     []string
+	Optional bool
+}
+
+// ConnectivityTimeout returns the timeout of each connectivity check;
+// invalid values fall back to DefaultConnectivityTimeout
+func ConnectivityTimeout() time.Duration {
+	value := os.Getenv(ConnectivityTimeoutEnvVar)
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return DefaultConnectivityTimeout
+}
+
+// ConnectivityTargets returns the services reached for data: the release
+// API and GitHub downloads, or the configured release mirror, the registries
+// of the app and Caddy images, and the IP echo services the DNS check uses
+func ConnectivityTargets(data config.ConfigData) []ConnectivityTarget {
+	var targets []ConnectivityTarget
+	if data.ReleaseAPIEndpoint != "" {
+		targets = append(targets, ConnectivityTarget{Name: "Release API", URLs: []string{data.ReleaseAPIURL()}})
+	} else {
+		targets = append(targets,
+			ConnectivityTarget{Name: "GitHub API", URLs: []string{data.ReleaseAPIURL()}},
+			ConnectivityTarget{Name: "GitHub downloads", URLs: []string{"https://github.com"}},
+		)
+	}
+
+	seen := make(map[string]bool)
+	for _, image := range []string{data.AppImage, data.CaddyImage} {
+		target, ok := registryTarget(image, data.RegistryInsecure)
+		if !ok || seen[target.URLs[0]] {
+			continue
+		}
+		seen[target.URLs[0]] = true
+		targets = append(targets, target)
+	}
+
+	return append(targets, ConnectivityTarget{Name: "Public IP lookup", URLs: config.PublicIPServices, Optional: true})
+}
+
+// registryTarget returns the registry API endpoint image is pulled from,
+// over plain HTTP for hosts listed in insecure (REGISTRY_INSECURE)
+func registryTarget(image, insecure string) (ConnectivityTarget, bool) {
+	if image == "" {
+		return ConnectivityTarget{}, false
+	}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return ConnectivityTarget{}, false
+	}
+	host := ref.Context().RegistryStr()
+	scheme := "https"
+	for _, h := range strings.Split(insecure, ",") {
+		if strings.TrimSpace(h) == host {
+			scheme = "http"
+		}
+	}
+	if host == name.DefaultRegistry {
+		return ConnectivityTarget{Name: "Docker Hub", URLs: []string{"https://registry-1.docker.io/v2/"}}, true
+	}
+	return ConnectivityTarget{Name: "Registry " + host, URLs: []string{fmt.Sprintf("%s://%s/v2/", scheme, host)}}, true
+}
+
+// ConnectivityChecks checks every service ConnectivityTargets returns for
+// data, each with ConnectivityTimeout. Offline installs reach none of them.
+func (c *Checker) ConnectivityChecks(data config.ConfigData) []CheckResult {
+	if data.OfflineMode {
+		return []CheckResult{{Name: "Connectivity", Passed: true, Warning: true, Detail: "skipped (OFFLINE_MODE)"}}
+	}
+	var results []CheckResult
+	for _, target := range ConnectivityTargets(data) {
+		results = append(results, c.checkTarget(target))
+	}
+	return results
+}
+
+func (c *Checker) checkTarget(target ConnectivityTarget) CheckResult {
+	var result CheckResult
+	for _, url := range target.URLs {
+		result = c.preflightConnectivity(target.Name, url)
+		if result.Passed {
+			return result
+		}
+	}
+	if len(target.URLs) > 1 {
+		result.Detail = fmt.Sprintf("none of %s is reachable", strings.Join(target.URLs, ", "))
+	}
+	if target.Optional {
+		result.Passed, result.Warning = true, true
+	}
+	return result
+}
+
+func (c *Checker) preflightConnectivity(name, url string) CheckResult {
+	result := CheckResult{Name: "Connectivity to " + name}
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	client := httpclient.New()
+	client.Timeout = ConnectivityTimeout()
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Detail = fmt.Sprintf("%s is not reachable: %v", url, err)
+		return result
+	}
+	resp.Body.Close()
+	// Any HTTP response, even 401 from the registry, proves the service is reachable
+	result.Passed = true
+	result.Detail = fmt.Sprintf("%s answered in %s", url, time.Since(start).Round(time.Millisecond))
+	return result
+}
+
+// CheckConnectivity fails an install up front when a service it downloads
+// from is blocked, as egress filtering often does, instead of halfway
+// through. SKIP_CONNECTIVITY_CHECK=1 skips it.
+func (c *Checker) CheckConnectivity(data config.ConfigData) error {
+	if os.Getenv(SkipConnectivityEnvVar) == "1" {
+		fmt.Printf("⚠️  Connectivity: skipped (%s=1)\n", SkipConnectivityEnvVar)
+		return nil
+	}
+	var unreachable []string
+	for _, result := range c.ConnectivityChecks(data) {
+		switch {
+		case !result.Passed:
+			fmt.Printf("❌ %s: %s\n", result.Name, result.Detail)
+			unreachable = append(unreachable, strings.TrimPrefix(result.Name, "Connectivity to "))
+		case result.Warning:
+			fmt.Printf("⚠️  %s: %s\n", result.Name, result.Detail)
+		default:
+			fmt.Printf("✅ %s\n", result.Name)
+		}
+	}
+	if len(unreachable) > 0 {
+		return fmt.Errorf("cannot reach %s: allow outbound HTTPS to them, or set %s=1 if Docker reaches them through its own proxy",
+			strings.Join(unreachable, ", "), SkipConnectivityEnvVar)
+	}
+	return nil
+}
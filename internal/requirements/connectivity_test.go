@@ -0,0 +1,101 @@
+package requirements
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/logging"
+)
+
+func TestConnectivityTargets(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+
+	t.Run("defaults", func(t *testing.T) {
+		targets := ConnectivityTargets(config.NewConfig(logger).GetData())
+
+		var names []string
+		for _, target := range targets {
+			names = append(names, target.Name)
+		}
+		// The app and Caddy images both come from Docker Hub, checked once
+		assert.Equal(t, []string{"GitHub API", "GitHub downloads", "Docker Hub", "Public IP lookup"}, names)
+		assert.Equal(t, []string{"https://registry-1.docker.io/v2/"}, targets[2].URLs)
+		assert.True(t, targets[3].Optional)
+	})
+
+	t.Run("mirror and private registry", func(t *testing.T) {
+		data := config.NewConfig(logger).GetData()
+		data.ReleaseAPIEndpoint = "https://mirror.internal/releases/latest.json"
+		data.AppImage = "registry.internal:5000/infinity-metrics:v1.2.0"
+		data.RegistryInsecure = "registry.internal:5000"
+
+		targets := ConnectivityTargets(data)
+		require.Len(t, targets, 4)
+		assert.Equal(t, "Release API", targets[0].Name)
+		assert.Equal(t, []string{data.ReleaseAPIEndpoint}, targets[0].URLs)
+		assert.Equal(t, "Registry registry.internal:5000", targets[1].Name)
+		assert.Equal(t, []string{"http://registry.internal:5000/v2/"}, targets[1].URLs)
+		assert.Equal(t, "Docker Hub", targets[2].Name)
+	})
+}
+
+func TestConnectivityTimeout(t *testing.T) {
+	t.Setenv(ConnectivityTimeoutEnvVar, "")
+	assert.Equal(t, DefaultConnectivityTimeout, ConnectivityTimeout())
+
+	t.Setenv(ConnectivityTimeoutEnvVar, "3")
+	assert.Equal(t, 3*time.Second, ConnectivityTimeout())
+
+	t.Setenv(ConnectivityTimeoutEnvVar, "500ms")
+	assert.Equal(t, 500*time.Millisecond, ConnectivityTimeout())
+
+	t.Setenv(ConnectivityTimeoutEnvVar, "soon")
+	assert.Equal(t, DefaultConnectivityTimeout, ConnectivityTimeout())
+}
+
+func TestCheckTarget(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	checker := NewChecker(logger)
+
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downURL := down.URL
+	down.Close()
+
+	t.Run("any URL answering is enough", func(t *testing.T) {
+		result := checker.checkTarget(ConnectivityTarget{Name: "Echo", URLs: []string{downURL, up.URL}})
+		assert.True(t, result.Passed, result.Detail)
+		assert.False(t, result.Warning)
+	})
+
+	t.Run("unreachable optional target warns", func(t *testing.T) {
+		result := checker.checkTarget(ConnectivityTarget{Name: "Echo", URLs: []string{downURL, downURL}, Optional: true})
+		assert.True(t, result.Passed)
+		assert.True(t, result.Warning)
+		assert.Contains(t, result.Detail, "none of")
+	})
+
+	t.Run("unreachable required target fails", func(t *testing.T) {
+		result := checker.checkTarget(ConnectivityTarget{Name: "Registry", URLs: []string{downURL}})
+		assert.False(t, result.Passed)
+		assert.Contains(t, result.Detail, "not reachable")
+	})
+}
+
+func TestConnectivityChecksOffline(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	data := config.NewConfig(logger).GetData()
+	data.OfflineMode = true
+
+	results := NewChecker(logger).ConnectivityChecks(data)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Passed)
+	assert.Contains(t, results[0].Detail, "OFFLINE_MODE")
+}
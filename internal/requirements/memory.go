@@ -0,0 +1,122 @@
+package requirements
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MinMemoryEnvVar overrides the memory an install needs, in MiB; 0 disables the check
+	MinMemoryEnvVar = "MIN_MEMORY_MB"
+	// DefaultMinMemoryMB covers the 512 MiB app and 256 MiB Caddy limits plus
+	// headroom for Docker and the system. It stays below 1024 since a "1 GB"
+	// server reports 960-990 MiB once the kernel has reserved its share.
+	DefaultMinMemoryMB = 896
+	// RecommendedMemoryMB is the memory below which an install works but
+	// the containers may be OOM-killed under load
+	RecommendedMemoryMB = 1024
+)
+
+// meminfoPath is read for the host memory, overridden in tests
+var meminfoPath = "/proc/meminfo"
+
+// MemoryInfo is the host memory in bytes
+type MemoryInfo struct {
+	Total     uint64
+	Available uint64
+}
+
+// MinMemoryMB returns the memory an install needs in MiB. MIN_MEMORY_MB must
+// be a whole number; other values fall back to DefaultMinMemoryMB.
+func MinMemoryMB() int {
+	if mb, err := strconv.Atoi(os.Getenv(MinMemoryEnvVar)); err == nil && mb >= 0 {
+		return mb
+	}
+	return DefaultMinMemoryMB
+}
+
+// readMemInfo parses MemTotal and MemAvailable from a /proc/meminfo file
+func readMemInfo(path string) (MemoryInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+	defer file.Close()
+
+	var info MemoryInfo
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			info.Total = kb * 1024
+		case "MemAvailable:":
+			info.Available = kb * 1024
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return MemoryInfo{}, err
+	}
+	if info.Total == 0 {
+		return MemoryInfo{}, fmt.Errorf("no MemTotal in %s", path)
+	}
+	return info, nil
+}
+
+// checkMemory fails when the host has less memory than the containers are
+// allowed to use, which ends in OOM kills, and warns when it has less than
+// RecommendedMemoryMB or too little of it is available
+func (c *Checker) checkMemory() error {
+	result := c.preflightMemory()
+	switch {
+	case !result.Passed:
+		fmt.Printf("❌ Error: Not enough memory: %s\n", result.Detail)
+		return fmt.Errorf("not enough memory: %s", result.Detail)
+	case result.Warning:
+		fmt.Printf("⚠️  Memory: %s\n", result.Detail)
+	default:
+		fmt.Printf("✅ Memory: %s\n", result.Detail)
+	}
+	return nil
+}
+
+func (c *Checker) preflightMemory() CheckResult {
+	result := CheckResult{Name: "Memory"}
+	minMB := MinMemoryMB()
+	if minMB == 0 {
+		result.Passed, result.Warning, result.Detail = true, true, "skipped ("+MinMemoryEnvVar+"=0)"
+		return result
+	}
+	info, err := readMemInfo(meminfoPath)
+	if err != nil {
+		result.Passed, result.Warning, result.Detail = true, true, fmt.Sprintf("could not be checked: %v", err)
+		return result
+	}
+
+	minBytes := uint64(minMB) << 20
+	result.Detail = fmt.Sprintf("%d MiB total, %d MiB available", info.Total>>20, info.Available>>20)
+	if info.Total < minBytes {
+		result.Detail += fmt.Sprintf(", need at least %d MiB (set %s to override)", minMB, MinMemoryEnvVar)
+		return result
+	}
+	result.Passed = true
+	switch {
+	case info.Total < uint64(RecommendedMemoryMB)<<20:
+		result.Warning = true
+		result.Detail += fmt.Sprintf(", %d MiB or more is recommended to avoid OOM kills under load", RecommendedMemoryMB)
+	case info.Available < minBytes:
+		result.Warning = true
+		result.Detail += fmt.Sprintf(", other processes leave less than %d MiB for Infinity Metrics", minMB)
+	}
+	return result
+}
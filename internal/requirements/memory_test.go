@@ -0,0 +1,79 @@
+package requirements
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"infinity-metrics-installer/internal/logging"
+)
+
+// fakeMeminfo points meminfoPath at a file reporting total and available kB
+func fakeMeminfo(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "meminfo")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	original := meminfoPath
+	meminfoPath = path
+	t.Cleanup(func() { meminfoPath = original })
+}
+
+func TestReadMemInfo(t *testing.T) {
+	fakeMeminfo(t, "MemTotal:        2014232 kB\nMemFree:          120408 kB\nMemAvailable:    1537892 kB\n")
+
+	info, err := readMemInfo(meminfoPath)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2014232*1024), info.Total)
+	assert.Equal(t, uint64(1537892*1024), info.Available)
+
+	fakeMeminfo(t, "Buffers: 1 kB\n")
+	_, err = readMemInfo(meminfoPath)
+	assert.Error(t, err)
+}
+
+func TestPreflightMemory(t *testing.T) {
+	checker := NewChecker(logging.NewLogger(logging.Config{Level: "error", Quiet: true}))
+
+	t.Run("enough memory", func(t *testing.T) {
+		fakeMeminfo(t, "MemTotal: 2097152 kB\nMemAvailable: 1572864 kB\n")
+		result := checker.preflightMemory()
+		assert.True(t, result.Passed)
+		assert.False(t, result.Warning, result.Detail)
+	})
+
+	t.Run("too little installed", func(t *testing.T) {
+		fakeMeminfo(t, "MemTotal: 262144 kB\nMemAvailable: 200000 kB\n")
+		result := checker.preflightMemory()
+		assert.False(t, result.Passed)
+		assert.Contains(t, result.Detail, "need at least 896 MiB")
+		assert.Error(t, checker.checkMemory())
+	})
+
+	t.Run("1 GB server", func(t *testing.T) {
+		fakeMeminfo(t, "MemTotal: 1000448 kB\nMemAvailable: 800000 kB\n")
+		result := checker.preflightMemory()
+		assert.True(t, result.Passed, result.Detail)
+		assert.True(t, result.Warning)
+		assert.Contains(t, result.Detail, "1024 MiB or more is recommended")
+		assert.NoError(t, checker.checkMemory())
+	})
+
+	t.Run("too little available", func(t *testing.T) {
+		fakeMeminfo(t, "MemTotal: 2097152 kB\nMemAvailable: 524288 kB\n")
+		result := checker.preflightMemory()
+		assert.True(t, result.Passed)
+		assert.True(t, result.Warning)
+		assert.NoError(t, checker.checkMemory())
+	})
+
+	t.Run("threshold override", func(t *testing.T) {
+		fakeMeminfo(t, "MemTotal: 262144 kB\nMemAvailable: 200000 kB\n")
+		t.Setenv(MinMemoryEnvVar, "128")
+		assert.True(t, checker.preflightMemory().Passed)
+		t.Setenv(MinMemoryEnvVar, "0")
+		assert.Contains(t, checker.preflightMemory().Detail, "skipped")
+	})
+}
@@ -0,0 +1,153 @@
+package requirements
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/docker"
+)
+
+// MinFreeDiskBytes is the free space needed for images, the database and backups
+const MinFreeDiskBytes = 2 << 30 // 2 GiB
+
+// CheckResult is the outcome of one pre-flight check
+type CheckResult struct {
+	Name    string
+	Passed  bool
+	Warning bool // passed, but worth a look
+	Detail  string
+}
+
+// Preflight runs every read-only requirement check for the installation
+// described by data and returns all results instead of stopping at the first
+// failure. It never changes the system. data.Domain is optional; when empty,
+// the DNS check resolves github.com instead.
+func (c *Checker) Preflight(data config.ConfigData) []CheckResult {
+	results := []CheckResult{c.preflightRoot(), c.preflightArchitecture()}
+	for _, port := range []int{80, 443} {
+		results = append(results, c.preflightPort(port))
+	}
+	results = append(results,
+		c.preflightDiskSpace(data.InstallDir),
+		c.preflightMemory(),
+		c.preflightDocker(),
+		c.preflightDNS(data.Domain),
+	)
+	return append(results, c.ConnectivityChecks(data)...)
+}
+
+// HostChecks runs the checks 'doctor' reports but cannot fix on its own: free
+// disk space under the install dir, host memory, DNS resolution of the domain
+// and connectivity to the services updates download from
+func (c *Checker) HostChecks(data config.ConfigData) []CheckResult {
+	results := []CheckResult{c.preflightDiskSpace(data.InstallDir), c.preflightMemory(), c.preflightDNS(data.Domain)}
+	return append(results, c.ConnectivityChecks(data)...)
+}
+
+func (c *Checker) preflightRoot() CheckResult {
+	result := CheckResult{Name: "Root privileges"}
+	if os.Geteuid() != 0 && os.Getenv("ENV") != "test" {
+		result.Detail = "run the installer with sudo"
+		return result
+	}
+	result.Passed = true
+	return result
+}
+
+func (c *Checker) preflightPort(port int) CheckResult {
+	result := CheckResult{Name: fmt.Sprintf("Port %d", port)}
+	if os.Getenv("SKIP_PORT_CHECKING") == "1" {
+		result.Passed, result.Warning, result.Detail = true, true, "skipped (SKIP_PORT_CHECKING=1)"
+		return result
+	}
+	if !c.checkPort(port) {
+		result.Detail = "already in use, stop the service listening on it"
+		return result
+	}
+	result.Passed = true
+	return result
+}
+
+func (c *Checker) preflightDiskSpace(installDir string) CheckResult {
+	result := CheckResult{Name: "Disk space"}
+	path := existingParent(installDir)
+	free, err := freeDiskBytes(path)
+	if err != nil {
+		result.Detail = fmt.Sprintf("could not check %s: %v", path, err)
+		return result
+	}
+	result.Detail = fmt.Sprintf("%.1f GiB free on %s", float64(free)/(1<<30), path)
+	if free < MinFreeDiskBytes {
+		result.Detail += fmt.Sprintf(", need at least %d GiB", MinFreeDiskBytes>>30)
+		return result
+	}
+	result.Passed = true
+	return result
+}
+
+func (c *Checker) preflightDocker() CheckResult {
+	result := CheckResult{Name: "Docker"}
+	if _, err := exec.LookPath("docker"); err != nil {
+		// The installer installs Docker itself
+		result.Passed, result.Warning, result.Detail = true, true, "not installed, it will be installed"
+		return result
+	}
+	output, err := exec.Command("docker", "version", "--format", "{{.Server.Version}}").Output()
+	if err != nil {
+		result.Detail = "installed but the daemon is not reachable, check 'systemctl status docker'"
+		return result
+	}
+	version := strings.TrimSpace(string(output))
+	if err := docker.CheckVersion(version); err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	result.Passed = true
+	result.Detail = "server " + version
+	return result
+}
+
+func (c *Checker) preflightDNS(domain string) CheckResult {
+	host := domain
+	if host == "" {
+		host = "github.com"
+	}
+	result := CheckResult{Name: "DNS resolution"}
+	addrs, err := net.LookupHost(host)
+	if err != nil || len(addrs) == 0 {
+		result.Detail = fmt.Sprintf("%s does not resolve: %v", host, err)
+		return result
+	}
+	result.Passed = true
+	result.Detail = fmt.Sprintf("%s resolves to %s", host, strings.Join(addrs, ", "))
+	return result
+}
+
+// existingParent returns path or its closest existing ancestor, since the
+// install dir usually does not exist before the install
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+func freeDiskBytes(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,52 @@
+package requirements
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infinity-metrics-installer/internal/logging"
+)
+
+func TestExistingParent(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.Equal(t, dir, existingParent(dir))
+	assert.Equal(t, dir, existingParent(filepath.Join(dir, "opt", "infinity-metrics")))
+}
+
+func TestPreflightDiskSpace(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	checker := NewChecker(logger)
+
+	result := checker.preflightDiskSpace(filepath.Join(t.TempDir(), "not-created-yet"))
+	assert.Contains(t, result.Detail, "GiB free")
+}
+
+func TestPreflightConnectivity(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	checker := NewChecker(logger)
+
+	t.Run("any HTTP response counts as reachable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		result := checker.preflightConnectivity("Registry", server.URL)
+		assert.True(t, result.Passed, result.Detail)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := server.URL
+		server.Close()
+
+		result := checker.preflightConnectivity("Registry", url)
+		assert.False(t, result.Passed)
+		assert.Contains(t, result.Detail, "not reachable")
+	})
+}
@@ -28,11 +28,21 @@ func (c *Checker) CheckSystemRequirements() error {
 		return err
 	}
 
+	// Architecture check
+	if err := c.checkArchitecture(); err != nil {
+		return err
+	}
+
 	// Port availability check
 	if err := c.checkPortAvailability(); err != nil {
 		return err
 	}
 
+	// Memory check
+	if err := c.checkMemory(); err != nil {
+		return err
+	}
+
 	fmt.Println()
 	return nil
 }
@@ -0,0 +1,118 @@
+package runresult
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"infinity-metrics-installer/internal/cron"
+	customerrors "infinity-metrics-installer/internal/errors"
+)
+
+const (
+	// FileName is written to the install dir after every command that changes the installation
+	FileName = "last-run.json"
+	// EnvVar disables the result file when set to 0
+	EnvVar = "RUN_RESULT_FILE"
+)
+
+// Result describes the outcome of the last command, for monitoring
+type Result struct {
+	Command          string            `json:"command"`
+	Trigger          string            `json:"trigger"` // "cron" or "manual"
+	InstallerVersion string            `json:"installer_version"`
+	StartedAt        time.Time         `json:"started_at"`
+	FinishedAt       time.Time         `json:"finished_at"`
+	DurationSeconds  float64           `json:"duration_seconds"`
+	Success          bool              `json:"success"`
+	Error            *ErrorInfo        `json:"error,omitempty"`
+	Outputs          map[string]string `json:"outputs,omitempty"` // e.g. app_image, backup
+}
+
+// ErrorInfo is the typed form of a command error
+type ErrorInfo struct {
+	Type      string `json:"type"` // validation, config, docker, network, installation or other
+	Message   string `json:"message"`
+	Field     string `json:"field,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	Container string `json:"container,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Component string `json:"component,omitempty"`
+	Step      string `json:"step,omitempty"`
+}
+
+// New builds the result of a command that started at startedAt and finished now
+func New(command, version string, startedAt time.Time, err error, outputs map[string]string) Result {
+	trigger := "manual"
+	if cron.StartedByCron() {
+		trigger = cron.TriggerCron
+	}
+	finishedAt := time.Now()
+	return Result{
+		Command:          command,
+		Trigger:          trigger,
+		InstallerVersion: version,
+		StartedAt:        startedAt,
+		FinishedAt:       finishedAt,
+		DurationSeconds:  finishedAt.Sub(startedAt).Round(time.Second).Seconds(),
+		Success:          err == nil,
+		Error:            Describe(err),
+		Outputs:          outputs,
+	}
+}
+
+// Describe classifies err using the typed errors from the errors package.
+// It returns nil for a nil error.
+func Describe(err error) *ErrorInfo {
+	if err == nil {
+		return nil
+	}
+	info := &ErrorInfo{Type: "other", Message: err.Error()}
+
+	var validationErr *customerrors.ValidationError
+	var configErr *customerrors.ConfigError
+	var dockerErr *customerrors.DockerError
+	var networkErr *customerrors.NetworkError
+	var installErr *customerrors.InstallationError
+	switch {
+	case errors.As(err, &validationErr):
+		info.Type, info.Field = "validation", validationErr.Field
+	case errors.As(err, &configErr):
+		info.Type, info.Field = "config", configErr.Field
+	case errors.As(err, &dockerErr):
+		info.Type, info.Operation, info.Container = "docker", dockerErr.Operation, dockerErr.Container
+	case errors.As(err, &networkErr):
+		info.Type, info.Operation, info.URL = "network", networkErr.Operation, networkErr.URL
+	case errors.As(err, &installErr):
+		info.Type, info.Component, info.Step = "installation", installErr.Component, installErr.Step
+	}
+	return info
+}
+
+// Enabled reports whether the result file should be written
+func Enabled() bool {
+	return os.Getenv(EnvVar) != "0"
+}
+
+// Write replaces <installDir>/last-run.json with result. The file is written
+// to a temporary name first so readers never see a partial result.
+func Write(installDir string, result Result) error {
+	content, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode run result: %w", err)
+	}
+
+	path := filepath.Join(installDir, FileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write run result: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write run result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package runresult
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"infinity-metrics-installer/internal/cron"
+	customerrors "infinity-metrics-installer/internal/errors"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantType string
+		check    func(*ErrorInfo) bool
+	}{
+		{"validation", customerrors.NewValidationError("domain", "x", "invalid"), "validation",
+			func(i *ErrorInfo) bool { return i.Field == "domain" }},
+		{"wrapped docker", fmt.Errorf("deploy: %w", customerrors.NewDockerError("health_check", "infinity-app-1", fmt.Errorf("timeout"))), "docker",
+			func(i *ErrorInfo) bool { return i.Operation == "health_check" && i.Container == "infinity-app-1" }},
+		{"installation", customerrors.NewInstallationError("docker", "install_docker", fmt.Errorf("apt failed")), "installation",
+			func(i *ErrorInfo) bool { return i.Component == "docker" && i.Step == "install_docker" }},
+		{"plain", fmt.Errorf("something broke"), "other",
+			func(i *ErrorInfo) bool { return i.Message == "something broke" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Describe(tt.err)
+			if info.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", info.Type, tt.wantType)
+			}
+			if !tt.check(info) {
+				t.Errorf("unexpected details: %+v", info)
+			}
+		})
+	}
+
+	if Describe(nil) != nil {
+		t.Error("Describe(nil) should be nil")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Setenv(cron.TriggerEnvVar, cron.TriggerCron)
+	dir := t.TempDir()
+
+	started := time.Now().Add(-90 * time.Second)
+	result := New("update", "1.4.2", started, nil, map[string]string{"backup": "/backups/backup_20250811_030000.db"})
+	if err := Write(dir, result); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Result
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("last-run.json is not valid JSON: %v", err)
+	}
+	if got.Command != "update" || !got.Success || got.Error != nil {
+		t.Errorf("unexpected result: %+v", got)
+	}
+	if got.Trigger != cron.TriggerCron {
+		t.Errorf("Trigger = %q, want %q", got.Trigger, cron.TriggerCron)
+	}
+	if got.DurationSeconds != 90 {
+		t.Errorf("DurationSeconds = %v, want 90", got.DurationSeconds)
+	}
+	if got.Outputs["backup"] == "" {
+		t.Error("Outputs should include the backup path")
+	}
+	if _, err := os.Stat(filepath.Join(dir, FileName+".tmp")); !os.IsNotExist(err) {
+		t.Error("temporary file should not be left behind")
+	}
+}
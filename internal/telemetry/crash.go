@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	customerrors "infinity-metrics-installer/internal/errors"
+	"infinity-metrics-installer/internal/httpclient"
+	"infinity-metrics-installer/internal/logging"
+)
+
+const (
+	CrashEnabledEnvVar  = "CRASH_REPORTS_ENABLED"
+	CrashEndpointEnvVar = "CRASH_REPORT_ENDPOINT"
+)
+
+// redacted replaces anything Sanitize removes
+const redacted = "[redacted]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	ipv4Pattern  = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	// ipv6Candidate matches runs of hex digits, dots and at least two colons;
+	// net.ParseIP decides which are addresses, so times such as 15:04:05 stay
+	ipv6Candidate = regexp.MustCompile(`[0-9A-Fa-f.]*:[0-9A-Fa-f.]*:[0-9A-Fa-f:.]*`)
+)
+
+// CrashReport describes an installer panic or unexpected failure. The error
+// and stack are sanitized so they carry no secrets, domain, email or IP address.
+type CrashReport struct {
+	Command          string `json:"command"`
+	InstallerVersion string `json:"installer_version"`
+	OS               string `json:"os"`
+	Arch             string `json:"arch"`
+	Panic            bool   `json:"panic"`
+	Error            string `json:"error"`
+	Stack            string `json:"stack,omitempty"`
+}
+
+// NewCrashReport builds a report for err, removing every value in secrets,
+// such as the domain and keys from .env, along with emails and IP addresses.
+// A *errors.PanicError contributes its stack.
+func NewCrashReport(command, version string, err error, secrets []string) CrashReport {
+	report := CrashReport{
+		Command:          command,
+		InstallerVersion: version,
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		Error:            Sanitize(err.Error(), secrets),
+	}
+	var panicErr *customerrors.PanicError
+	if errors.As(err, &panicErr) {
+		report.Panic = true
+		report.Stack = Sanitize(string(panicErr.Stack), secrets)
+	}
+	return report
+}
+
+// Sanitize removes the given secrets, email addresses and IPv4 and IPv6
+// addresses from text
+func Sanitize(text string, secrets []string) string {
+	for _, secret := range secrets {
+		// Very short values would redact unrelated text
+		if len(secret) >= 4 {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	text = emailPattern.ReplaceAllString(text, redacted)
+	text = ipv6Candidate.ReplaceAllStringFunc(text, redactIPv6)
+	return ipv4Pattern.ReplaceAllString(text, redacted)
+}
+
+// redactIPv6 returns candidate with the IPv6 address it holds redacted,
+// keeping punctuation that ends a sentence
+func redactIPv6(candidate string) string {
+	address := strings.TrimRight(candidate, ".:")
+	if ip := net.ParseIP(address); ip != nil && strings.Contains(address, ":") {
+		return redacted + candidate[len(address):]
+	}
+	return candidate
+}
+
+// CrashReporter submits crash reports when the operator has opted in
+type CrashReporter struct {
+	logger     *logging.Logger
+	endpoint   string
+	enabled    bool
+	httpClient *http.Client
+}
+
+// NewCrashReporter reads the opt-in settings from the environment. Crash
+// reporting is only enabled when CRASH_REPORTS_ENABLED=1 and
+// CRASH_REPORT_ENDPOINT is set.
+func NewCrashReporter(logger *logging.Logger) *CrashReporter {
+	httpClient := httpclient.New()
+	if httpClient.Timeout > SendTimeout {
+		httpClient.Timeout = SendTimeout
+	}
+	return &CrashReporter{
+		logger:     logger,
+		endpoint:   os.Getenv(CrashEndpointEnvVar),
+		enabled:    os.Getenv(CrashEnabledEnvVar) == "1",
+		httpClient: httpClient,
+	}
+}
+
+// Enabled reports whether crash reports can be sent
+func (r *CrashReporter) Enabled() bool {
+	return r.enabled && r.endpoint != ""
+}
+
+// Endpoint returns where crash reports are sent
+func (r *CrashReporter) Endpoint() string {
+	return r.endpoint
+}
+
+// Send posts the report to the configured endpoint. It is a no-op unless crash reporting is enabled.
+func (r *CrashReporter) Send(report CrashReport) error {
+	if !r.Enabled() {
+		return nil
+	}
+	if err := postJSON(r.httpClient, r.endpoint, report); err != nil {
+		return fmt.Errorf("send crash report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	customerrors "infinity-metrics-installer/internal/errors"
+)
+
+func TestNewCrashReport(t *testing.T) {
+	secrets := []string{"analytics.example.com", "priv-key-123456", "", "ab"}
+
+	err := fmt.Errorf("deploy analytics.example.com: connect 203.0.113.7:443 as admin@example.org with key priv-key-123456")
+	report := NewCrashReport("install", "1.2.3", err, secrets)
+	for _, leaked := range []string{"analytics.example.com", "203.0.113.7", "admin@example.org", "priv-key-123456"} {
+		if strings.Contains(report.Error, leaked) {
+			t.Errorf("report error leaks %q: %s", leaked, report.Error)
+		}
+	}
+	if report.Panic || report.Stack != "" {
+		t.Errorf("plain errors carry no stack: %+v", report)
+	}
+
+	err = fmt.Errorf("dial tcp [2001:db8::7]:443 at 15:04:05: no route to ::1 or fe80::1ff:fe23:4567:890a.")
+	report = NewCrashReport("update", "1.2.3", err, nil)
+	for _, leaked := range []string{"2001:db8::7", "::1", "fe80::1ff:fe23:4567:890a"} {
+		if strings.Contains(report.Error, leaked) {
+			t.Errorf("report error leaks %q: %s", leaked, report.Error)
+		}
+	}
+	if !strings.Contains(report.Error, "15:04:05") || !strings.HasSuffix(report.Error, ".") {
+		t.Errorf("report error redacts more than the addresses: %s", report.Error)
+	}
+
+	panicErr := customerrors.SafeExecute(func() error {
+		panic("nil map for analytics.example.com")
+	})
+	report = NewCrashReport("update", "1.2.3", panicErr, secrets)
+	if !report.Panic || !strings.Contains(report.Stack, "goroutine") {
+		t.Errorf("panic report should include the stack: %+v", report)
+	}
+	if strings.Contains(report.Error+report.Stack, "analytics.example.com") {
+		t.Errorf("panic report leaks the domain: %+v", report)
+	}
+}
+
+func TestCrashReporterSend(t *testing.T) {
+	var received CrashReport
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	report := NewCrashReport("install", "dev", fmt.Errorf("boom"), nil)
+
+	t.Setenv(CrashEnabledEnvVar, "")
+	t.Setenv(CrashEndpointEnvVar, server.URL)
+	if err := NewCrashReporter(testLogger(t)).Send(report); err != nil || calls != 0 {
+		t.Fatalf("crash reporting must be opt-in, got err=%v calls=%d", err, calls)
+	}
+
+	t.Setenv(CrashEnabledEnvVar, "1")
+	if err := NewCrashReporter(testLogger(t)).Send(report); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls != 1 || received.Error != "boom" || received.Command != "install" {
+		t.Errorf("unexpected report received (%d calls): %+v", calls, received)
+	}
+}
@@ -0,0 +1,114 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+
+	"infinity-metrics-installer/internal/httpclient"
+	"infinity-metrics-installer/internal/logging"
+)
+
+const (
+	EnabledEnvVar  = "TELEMETRY_ENABLED"
+	EndpointEnvVar = "TELEMETRY_ENDPOINT"
+	SendTimeout    = 5 * time.Second // Upper bound so a slow endpoint never delays the installer
+)
+
+// Report is the anonymized payload sent after an install or update.
+// It deliberately has no field that could carry a domain, IP address,
+// email, license key or error message.
+type Report struct {
+	Event            string  `json:"event"`
+	InstallerVersion string  `json:"installer_version"`
+	OS               string  `json:"os"`
+	Arch             string  `json:"arch"`
+	Success          bool    `json:"success"`
+	FailedStep       string  `json:"failed_step,omitempty"`
+	DurationSeconds  float64 `json:"duration_seconds"`
+}
+
+// NewReport builds a report for the current machine
+func NewReport(event, version string, success bool, failedStep string, duration time.Duration) Report {
+	report := Report{
+		Event:            event,
+		InstallerVersion: version,
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		Success:          success,
+		DurationSeconds:  duration.Round(time.Second).Seconds(),
+	}
+	if !success {
+		report.FailedStep = failedStep
+	}
+	return report
+}
+
+// Client submits reports when the operator has opted in
+type Client struct {
+	logger     *logging.Logger
+	endpoint   string
+	enabled    bool
+	httpClient *http.Client
+}
+
+// NewClient reads the opt-in settings from the environment.
+// Telemetry is only enabled when TELEMETRY_ENABLED=1 and TELEMETRY_ENDPOINT is set.
+func NewClient(logger *logging.Logger) *Client {
+	httpClient := httpclient.New()
+	if httpClient.Timeout > SendTimeout {
+		httpClient.Timeout = SendTimeout
+	}
+	return &Client{
+		logger:     logger,
+		endpoint:   os.Getenv(EndpointEnvVar),
+		enabled:    os.Getenv(EnabledEnvVar) == "1",
+		httpClient: httpClient,
+	}
+}
+
+// Enabled reports whether reports will be sent
+func (c *Client) Enabled() bool {
+	return c.enabled && c.endpoint != ""
+}
+
+// Send posts the report to the configured endpoint. It is a no-op unless telemetry is enabled.
+func (c *Client) Send(report Report) error {
+	if !c.Enabled() {
+		if c.enabled {
+			c.logger.Debug("%s=1 but %s is not set, skipping telemetry", EnabledEnvVar, EndpointEnvVar)
+		}
+		return nil
+	}
+
+	c.logger.Info("Telemetry enabled: sending anonymized %s report (OS, architecture, installer version, outcome, failed step, duration) to %s",
+		report.Event, c.endpoint)
+
+	if err := postJSON(c.httpClient, c.endpoint, report); err != nil {
+		return fmt.Errorf("send telemetry report: %w", err)
+	}
+	return nil
+}
+
+// postJSON posts payload as JSON and fails unless the endpoint answers 2xx
+func postJSON(httpClient *http.Client, endpoint string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	resp, err := httpClient.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"infinity-metrics-installer/internal/logging"
+)
+
+func testLogger(t *testing.T) *logging.Logger {
+	return logging.NewLogger(logging.Config{Level: "error", LogDir: t.TempDir()})
+}
+
+func TestNewReport(t *testing.T) {
+	report := NewReport("install", "1.2.3", false, "deploy", 90*time.Second)
+	if report.OS != runtime.GOOS || report.Arch != runtime.GOARCH {
+		t.Errorf("unexpected platform %s/%s", report.OS, report.Arch)
+	}
+	if report.FailedStep != "deploy" || report.DurationSeconds != 90 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	success := NewReport("update", "1.2.3", true, "deploy", time.Second)
+	if success.FailedStep != "" {
+		t.Errorf("successful report should not carry a failed step, got %q", success.FailedStep)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		for key := range payload {
+			received = append(received, key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		t.Setenv(EnabledEnvVar, "")
+		t.Setenv(EndpointEnvVar, server.URL)
+		c := NewClient(testLogger(t))
+		if c.Enabled() {
+			t.Fatal("telemetry must be opt-in")
+		}
+		if err := c.Send(NewReport("install", "dev", true, "", time.Second)); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+		if len(received) != 0 {
+			t.Fatal("no report should be sent when disabled")
+		}
+	})
+
+	t.Run("EnabledWithoutEndpoint", func(t *testing.T) {
+		t.Setenv(EnabledEnvVar, "1")
+		t.Setenv(EndpointEnvVar, "")
+		if NewClient(testLogger(t)).Enabled() {
+			t.Fatal("telemetry requires an endpoint")
+		}
+	})
+
+	t.Run("SendsOnlyAnonymizedFields", func(t *testing.T) {
+		t.Setenv(EnabledEnvVar, "1")
+		t.Setenv(EndpointEnvVar, server.URL)
+		c := NewClient(testLogger(t))
+		if err := c.Send(NewReport("install", "dev", false, "deploy", time.Second)); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+		allowed := "event installer_version os arch success failed_step duration_seconds"
+		for _, key := range received {
+			if !strings.Contains(allowed, key) {
+				t.Errorf("unexpected field %q in telemetry payload", key)
+			}
+		}
+		if len(received) != 7 {
+			t.Errorf("expected 7 fields, got %v", received)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnhealthy is returned by Run with --only-if-healthy when the current
+// installation fails its health checks and the update was skipped
+var ErrUnhealthy = errors.New("update skipped, the current installation is unhealthy")
+
+// IsUnhealthy reports whether err means the update was skipped by --only-if-healthy
+func IsUnhealthy(err error) bool {
+	return errors.Is(err, ErrUnhealthy)
+}
+
+// healthChecker is the subset of docker operations the pre-update health check relies on
+type healthChecker interface {
+	VerifyContainersRunning() (bool, error)
+	ActiveAppContainer() (string, error)
+	CheckAppHealth(name string) error
+}
+
+// checkInstallationHealth returns an ErrUnhealthy error explaining what is
+// wrong when the containers are not running or the app fails its /_health probe
+func checkInstallationHealth(containers healthChecker) error {
+	running, err := containers.VerifyContainersRunning()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnhealthy, err)
+	}
+	if !running {
+		return fmt.Errorf("%w: the app and Caddy containers are not both running", ErrUnhealthy)
+	}
+	name, err := containers.ActiveAppContainer()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnhealthy, err)
+	}
+	if err := containers.CheckAppHealth(name); err != nil {
+		return fmt.Errorf("%w: app container %s failed its health check: %v", ErrUnhealthy, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCheckInstallationHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		supervisor *fakeSupervisor
+		wantErr    string
+	}{
+		{"Healthy", &fakeSupervisor{appRunning: true, appHealthy: true, caddyRunning: true}, ""},
+		{"CaddyDown", &fakeSupervisor{appRunning: true, appHealthy: true}, "not both running"},
+		{"AppUnhealthy", &fakeSupervisor{appRunning: true, caddyRunning: true}, "failed its health check"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkInstallationHealth(tt.supervisor)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected healthy installation, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to mention %q", err, tt.wantErr)
+			}
+			if !IsUnhealthy(err) || !IsUnhealthy(fmt.Errorf("update failed: %w", err)) {
+				t.Errorf("IsUnhealthy(%v) = false, want true", err)
+			}
+		})
+	}
+}
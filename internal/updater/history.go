@@ -0,0 +1,98 @@
+package updater
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryFileName is the append-only log of update attempts in the install dir
+const HistoryFileName = "update-history.jsonl"
+
+// HistoryEntry records one update attempt
+type HistoryEntry struct {
+	StartedAt       time.Time `json:"started_at"`
+	FromVersion     string    `json:"from_version,omitempty"`
+	ToVersion       string    `json:"to_version,omitempty"`
+	AppImage        string    `json:"app_image,omitempty"`
+	CaddyImage      string    `json:"caddy_image,omitempty"`
+	Success         bool      `json:"success"`
+	UpToDate        bool      `json:"up_to_date,omitempty"` // nothing changed, no backup or redeploy
+	DurationSeconds float64   `json:"duration_seconds"`
+	Step            string    `json:"step,omitempty"` // step that failed
+	Error           string    `json:"error,omitempty"`
+}
+
+// AppendHistory adds entry as one JSON line to the update history
+func AppendHistory(installDir string, entry HistoryEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode update history entry: %w", err)
+	}
+	path := filepath.Join(installDir, HistoryFileName)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open update history: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write update history: %w", err)
+	}
+	return nil
+}
+
+// ReadHistory returns the last limit entries of the update history, oldest
+// first, or all of them when limit is 0. Lines that cannot be parsed, such as
+// one cut short by a crash, are skipped.
+func ReadHistory(installDir string, limit int) ([]HistoryEntry, error) {
+	file, err := os.Open(filepath.Join(installDir, HistoryFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open update history: %w", err)
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read update history: %w", err)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// recordHistory appends the outcome of the update that started at startedAt.
+// A history that cannot be written is logged, it never fails the update.
+func (u *Updater) recordHistory(startedAt time.Time, err error) {
+	data := u.config.GetData()
+	entry := HistoryEntry{
+		StartedAt:       startedAt,
+		FromVersion:     u.fromVersion,
+		ToVersion:       data.Version,
+		AppImage:        data.AppImage,
+		CaddyImage:      data.CaddyImage,
+		Success:         err == nil,
+		UpToDate:        err == nil && u.upToDate,
+		DurationSeconds: time.Since(startedAt).Round(time.Second).Seconds(),
+	}
+	if err != nil {
+		entry.Step = u.step
+		entry.Error = err.Error()
+	}
+	if writeErr := AppendHistory(data.InstallDir, entry); writeErr != nil {
+		u.logger.Warn("Failed to record update history: %v", writeErr)
+	}
+}
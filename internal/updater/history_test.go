@@ -0,0 +1,80 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"infinity-metrics-installer/internal/logging"
+)
+
+func TestUpdateHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	entries, err := ReadHistory(dir, 0)
+	if err != nil || entries != nil {
+		t.Fatalf("missing history should read as empty, got %v, %v", entries, err)
+	}
+
+	for _, version := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		if err := AppendHistory(dir, HistoryEntry{ToVersion: version, Success: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A line cut short by a crash mid-write is skipped
+	f, err := os.OpenFile(filepath.Join(dir, HistoryFileName), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"to_version":"1.3`)
+	f.Close()
+
+	entries, err = ReadHistory(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("read %d entries, want 3", len(entries))
+	}
+
+	entries, err = ReadHistory(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ToVersion != "1.1.0" || entries[1].ToVersion != "1.2.0" {
+		t.Errorf("ReadHistory(2) = %+v, want the last two entries oldest first", entries)
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	u := NewUpdater(logging.NewLogger(logging.Config{Level: "error"}))
+	data := u.config.GetData()
+	data.InstallDir = t.TempDir()
+	data.Version = "1.2.0"
+	data.AppImage = "karloscodes/infinity-metrics-beta:1.2.0"
+	u.config.SetData(data)
+	u.fromVersion = "1.1.0"
+	u.step = "deploy"
+
+	u.recordHistory(time.Now().Add(-3*time.Second), errors.New("health check failed"))
+
+	entries, err := ReadHistory(data.InstallDir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("read %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Success || entry.Step != "deploy" || entry.Error != "health check failed" {
+		t.Errorf("failure not recorded: %+v", entry)
+	}
+	if entry.FromVersion != "1.1.0" || entry.ToVersion != "1.2.0" || entry.AppImage != data.AppImage {
+		t.Errorf("versions or images not recorded: %+v", entry)
+	}
+	if entry.DurationSeconds < 3 {
+		t.Errorf("duration = %v, want at least 3s", entry.DurationSeconds)
+	}
+}
@@ -0,0 +1,99 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"infinity-metrics-installer/internal/cron"
+)
+
+// LockFileName is the lock held by update, reload and backup, relative to the install dir
+const LockFileName = ".operation.lock"
+
+// LockInfo identifies the process holding the operation lock
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"`
+	Trigger   string    `json:"trigger"` // "cron" or "manual"
+	StartedAt time.Time `json:"started_at"`
+}
+
+// LockHeldError is returned when another update or reload holds the lock
+type LockHeldError struct {
+	Holder LockInfo
+}
+
+func (e *LockHeldError) Error() string {
+	if e.Holder.PID == 0 {
+		return "another update or reload is already running, please wait for it to finish"
+	}
+	started := e.Holder.StartedAt.Local().Format("15:04:05")
+	if e.Holder.Trigger == cron.TriggerCron {
+		return fmt.Sprintf("an automatic %s is currently running (pid %d, started %s), please wait for it to finish",
+			e.Holder.Command, e.Holder.PID, started)
+	}
+	return fmt.Sprintf("a manual %s is already running (pid %d, started %s), please wait for it to finish",
+		e.Holder.Command, e.Holder.PID, started)
+}
+
+// OperationLock serializes update, reload and backup. It is an flock on a file in the
+// install dir, so it is released automatically if the holder dies.
+type OperationLock struct {
+	file *os.File
+}
+
+// AcquireLock takes the operation lock for command without waiting. When the
+// lock is held, the returned *LockHeldError describes the holder.
+func AcquireLock(installDir, command string) (*OperationLock, error) {
+	path := filepath.Join(installDir, LockFileName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file %s: %w", path, err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		defer file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, &LockHeldError{Holder: readLockInfo(file)}
+		}
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	trigger := "manual"
+	if cron.StartedByCron() {
+		trigger = cron.TriggerCron
+	}
+	info, _ := json.Marshal(LockInfo{
+		PID:       os.Getpid(),
+		Command:   command,
+		Trigger:   trigger,
+		StartedAt: time.Now(),
+	})
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt(info, 0)
+	}
+	return &OperationLock{file: file}, nil
+}
+
+// Release clears the holder info and releases the lock
+func (l *OperationLock) Release() {
+	l.file.Truncate(0)
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	l.file.Close()
+}
+
+// readLockInfo returns the holder recorded in the lock file, or a zero
+// LockInfo if the holder has not written it yet
+func readLockInfo(file *os.File) LockInfo {
+	var info LockInfo
+	data, err := os.ReadFile(file.Name())
+	if err == nil {
+		json.Unmarshal(data, &info)
+	}
+	return info
+}
@@ -0,0 +1,51 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/cron"
+)
+
+func TestAcquireLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger string
+		want    string
+	}{
+		{"held by cron", cron.TriggerCron, "an automatic update is currently running"},
+		{"held by operator", "", "a manual update is already running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv(cron.TriggerEnvVar, tt.trigger)
+
+			lock, err := AcquireLock(dir, "update")
+			if err != nil {
+				t.Fatalf("AcquireLock() error = %v", err)
+			}
+
+			_, err = AcquireLock(dir, "reload")
+			var held *LockHeldError
+			if !errors.As(err, &held) {
+				t.Fatalf("second AcquireLock() error = %v, want *LockHeldError", err)
+			}
+			if held.Holder.PID != os.Getpid() {
+				t.Errorf("Holder.PID = %d, want %d", held.Holder.PID, os.Getpid())
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err.Error(), tt.want)
+			}
+
+			lock.Release()
+			relock, err := AcquireLock(dir, "reload")
+			if err != nil {
+				t.Fatalf("AcquireLock() after Release error = %v", err)
+			}
+			relock.Release()
+		})
+	}
+}
@@ -2,6 +2,7 @@ package updater
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"infinity-metrics-installer/internal/config"
@@ -10,11 +11,20 @@ import (
 	"infinity-metrics-installer/internal/logging"
 )
 
-// Reloader handles container reload operations without database backups or other update steps
+// Reloader handles container reload operations without the other update
+// steps. It backs up the database first only when asked to.
 type Reloader struct {
-	logger *logging.Logger
-	config *config.Config
-	docker *docker.Docker
+	logger   *logging.Logger
+	config   *config.Config
+	docker   *docker.Docker
+	database *database.Database
+
+	// Recreate only the Caddy container instead of reloading everything
+	forceCaddyRedeploy bool
+
+	// Back up the database before restarting the app container
+	backup     bool
+	backupPath string
 }
 
 // NewReloader creates a Reloader instance
@@ -29,26 +39,68 @@ func NewReloader(logger *logging.Logger) *Reloader {
 
 	db := database.NewDatabase(fileLogger) // Need database for Docker constructor
 	return &Reloader{
-		logger: fileLogger,
-		config: config.NewConfig(fileLogger),
-		docker: docker.NewDocker(fileLogger, db),
+		logger:   fileLogger,
+		config:   config.NewConfig(fileLogger),
+		docker:   docker.NewDocker(fileLogger, db),
+		database: db,
 	}
 }
 
+// SetBackup makes Run back up the database before restarting the app
+// container, in addition to when BACKUP_BEFORE_RELOAD is set
+func (r *Reloader) SetBackup(backup bool) {
+	r.backup = backup
+}
+
+// BackupPath returns the pre-reload backup created by Run, or "" if none was taken
+func (r *Reloader) BackupPath() string {
+	return r.backupPath
+}
+
+// SetForceCaddyRedeploy makes Run recreate the Caddy container from the
+// configured image and leave the app container running
+func (r *Reloader) SetForceCaddyRedeploy(force bool) {
+	r.forceCaddyRedeploy = force
+}
+
 // Run executes the reload operation
 func (r *Reloader) Run() error {
 	r.logger.Info("Starting container reload with latest config")
 
-	// Load configuration
 	data := r.config.GetData()
+	lock, err := AcquireLock(data.InstallDir, "reload")
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// Load configuration
 	envFile := filepath.Join(data.InstallDir, ".env")
 	r.logger.Info("Loading configuration from %s", envFile)
+	if err := r.config.MigrateFile(envFile); err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", envFile, err)
+	}
 	if err := r.config.LoadFromFile(envFile); err != nil {
 		return fmt.Errorf("failed to load config from %s: %w", envFile, err)
 	}
 
 	// Skip server fetch intentionally to just use local config
 
+	if r.forceCaddyRedeploy {
+		r.logger.Info("Redeploying Caddy only, the app container keeps running")
+		if err := r.docker.RedeployCaddy(r.config.GetData()); err != nil {
+			return fmt.Errorf("failed to redeploy Caddy: %w", err)
+		}
+		r.logger.Success("Caddy redeploy completed successfully")
+		return nil
+	}
+
+	if r.backup || r.config.GetData().BackupBeforeReload {
+		if err := r.backupBeforeReload(); err != nil {
+			return err
+		}
+	}
+
 	// Reload containers with our simpler method
 	r.logger.Info("Reloading Docker containers with latest config")
 	if err := r.docker.Reload(r.config); err != nil {
@@ -58,3 +110,28 @@ func (r *Reloader) Run() error {
 	r.logger.Success("Container reload completed successfully")
 	return nil
 }
+
+// backupBeforeReload takes a recovery point before the app container is
+// restarted. Like the pre-update backup, a failure aborts unless
+// REQUIRE_BACKUP=false.
+func (r *Reloader) backupBeforeReload() error {
+	data := r.config.GetData()
+	mainDBPath := data.MainDBPath()
+	if _, err := os.Stat(mainDBPath); os.IsNotExist(err) {
+		r.logger.Warn("Database %s does not exist yet, nothing to back up", mainDBPath)
+		return nil
+	}
+
+	r.database.SetRetentionConfig(database.RetentionConfigFromDays(
+		data.BackupDailyRetentionDays, data.BackupWeeklyRetentionDays, data.BackupMonthlyRetentionDays))
+	backupPath, err := r.database.BackupDatabase(mainDBPath, data.BackupPath)
+	if err != nil {
+		if !data.RequireBackup {
+			r.logger.Warn("Failed to back up the database before reload, reloading anyway (REQUIRE_BACKUP=false): %v", err)
+			return nil
+		}
+		return fmt.Errorf("pre-reload backup failed, aborting reload before any container was restarted: %w", err)
+	}
+	r.backupPath = backupPath
+	return nil
+}
@@ -0,0 +1,74 @@
+package updater
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/database"
+	"infinity-metrics-installer/internal/logging"
+)
+
+func TestBackupBeforeReload(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error"})
+
+	newTestReloader := func(t *testing.T, backupDir string, requireBackup bool) (*Reloader, string) {
+		storage := t.TempDir()
+		r := &Reloader{logger: logger, config: config.NewConfig(logger), database: database.NewDatabase(logger)}
+		data := r.config.GetData()
+		data.DBStoragePath = storage
+		data.BackupPath = backupDir
+		data.RequireBackup = requireBackup
+		r.config.SetData(data)
+		return r, filepath.Join(storage, config.MainDBFileName)
+	}
+
+	t.Run("CreatesBackup", func(t *testing.T) {
+		if _, err := exec.LookPath("sqlite3"); err != nil {
+			t.Skip("sqlite3 not installed")
+		}
+		backupDir := t.TempDir()
+		r, dbPath := newTestReloader(t, backupDir, true)
+		if out, err := exec.Command("sqlite3", dbPath, "CREATE TABLE events (id INTEGER);").CombinedOutput(); err != nil {
+			t.Fatalf("create database: %v: %s", err, out)
+		}
+		if err := r.backupBeforeReload(); err != nil {
+			t.Fatalf("backupBeforeReload() error = %v", err)
+		}
+		if filepath.Dir(r.BackupPath()) != backupDir {
+			t.Errorf("BackupPath() = %q, want a backup in %s", r.BackupPath(), backupDir)
+		}
+	})
+
+	t.Run("FailedBackupAbortsWhenRequired", func(t *testing.T) {
+		// A regular file as the backup dir makes the backup fail deterministically
+		blocker := filepath.Join(t.TempDir(), "backups")
+		if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		r, dbPath := newTestReloader(t, blocker, true)
+		if err := os.WriteFile(dbPath, []byte("not a sqlite database"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.backupBeforeReload(); err == nil || !strings.Contains(err.Error(), "aborting reload") {
+			t.Fatalf("expected the reload to be aborted, got %v", err)
+		}
+
+		data := r.config.GetData()
+		data.RequireBackup = false
+		r.config.SetData(data)
+		if err := r.backupBeforeReload(); err != nil {
+			t.Errorf("expected the reload to proceed with REQUIRE_BACKUP=false, got %v", err)
+		}
+	})
+
+	t.Run("MissingDatabase", func(t *testing.T) {
+		r, _ := newTestReloader(t, t.TempDir(), true)
+		if err := r.backupBeforeReload(); err != nil || r.BackupPath() != "" {
+			t.Errorf("expected a missing database to be skipped, got %v, %q", err, r.BackupPath())
+		}
+	})
+}
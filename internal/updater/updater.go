@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -17,20 +19,45 @@ import (
 	"infinity-metrics-installer/internal/cron"
 	"infinity-metrics-installer/internal/database"
 	"infinity-metrics-installer/internal/docker"
+	"infinity-metrics-installer/internal/hooks"
+	"infinity-metrics-installer/internal/httpclient"
 	"infinity-metrics-installer/internal/logging"
+	"infinity-metrics-installer/internal/requirements"
 )
 
 const (
-	GitHubRepo        = "karloscodes/infinity-metrics-installer"
-	GitHubAPIURL      = "https://api.github.com/repos/" + GitHubRepo + "/releases/latest"
 	BinaryInstallPath = "/usr/local/bin/infinity-metrics" // Standard installation path
+
+	DownloadAttempts = 3 // Attempts per binary download, later ones resume the partial file
 )
 
+// downloadRetryDelay is multiplied by the attempt number between download attempts
+var downloadRetryDelay = 2 * time.Second
+
+// downloadIdleTimeout aborts a download attempt that receives no data for this
+// long, so a stalled transfer is retried instead of holding the update lock
+var downloadIdleTimeout = 60 * time.Second
+
 type Updater struct {
-	logger   *logging.Logger
-	config   *config.Config
-	docker   *docker.Docker
-	database *database.Database
+	logger     *logging.Logger
+	config     *config.Config
+	docker     *docker.Docker
+	database   *database.Database
+	skipBackup bool
+	backupPath string // Pre-update backup created by Run, empty if none
+	step       string // Update step in progress, reported when it fails
+
+	// Version in .env before the update, for the update history
+	fromVersion string
+
+	// Skip the update when the running installation is unhealthy
+	onlyIfHealthy bool
+
+	// Redeploy even when nothing changed; installerCurrent is set by Run once
+	// the running binary is known to match the latest release
+	force            bool
+	installerCurrent bool
+	upToDate         bool
 }
 
 func NewUpdater(logger *logging.Logger) *Updater {
@@ -51,14 +78,83 @@ func NewUpdater(logger *logging.Logger) *Updater {
 	}
 }
 
+// SetSkipBackup makes the update proceed without taking a pre-update backup
+func (u *Updater) SetSkipBackup(skip bool) {
+	u.skipBackup = skip
+}
+
+// SetOnlyIfHealthy makes Run skip the update when the containers are down or
+// the app fails its health check, so cron does not update a broken install
+func (u *Updater) SetOnlyIfHealthy(onlyIfHealthy bool) {
+	u.onlyIfHealthy = onlyIfHealthy
+}
+
+// SetForce disables the "already up to date" fast path so the update always
+// takes a backup and redeploys
+func (u *Updater) SetForce(force bool) {
+	u.force = force
+}
+
+// UpToDate reports whether the last Run found nothing to update
+func (u *Updater) UpToDate() bool {
+	return u.upToDate
+}
+
+// GetConfig returns the configuration used by the update
+func (u *Updater) GetConfig() *config.Config {
+	return u.config
+}
+
+// BackupPath returns the pre-update backup created by Run, or "" if none was taken
+func (u *Updater) BackupPath() string {
+	return u.backupPath
+}
+
+// CurrentStep returns the update step that was last started
+func (u *Updater) CurrentStep() string {
+	return u.step
+}
+
+// Run updates the installation and records the attempt in the update history
 func (u *Updater) Run(currentVersion string) error {
+	startedAt := time.Now()
+	err := u.run(currentVersion)
+	u.recordHistory(startedAt, err)
+	return err
+}
+
+func (u *Updater) run(currentVersion string) error {
 	data := u.config.GetData()
 	envFile := filepath.Join(data.InstallDir, ".env")
 
+	u.step = "acquire_lock"
+	lock, err := AcquireLock(data.InstallDir, "update")
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// Loaded before the health check, which probes the endpoint configured in .env
+	u.step = "load_config"
 	u.logger.Info("Loading configuration")
+	if err := u.config.MigrateFile(envFile); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
 	if err := u.config.LoadFromFile(envFile); err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
+	u.fromVersion = u.config.GetData().Version
+	u.docker.ApplySettings(u.config.GetData())
+
+	if u.onlyIfHealthy {
+		u.step = "health_check"
+		if err := checkInstallationHealth(u.docker); err != nil {
+			u.logger.Warn("%v", err)
+			u.logger.Warn("Repair the installation first, see 'infinity-metrics logs' or run 'infinity-metrics reconcile'")
+			return err
+		}
+		u.logger.Info("Current installation is healthy, proceeding with the update")
+	}
 
 	u.logger.Info("Checking for updates from server")
 	if err := u.config.FetchFromServer(""); err != nil {
@@ -66,8 +162,11 @@ func (u *Updater) Run(currentVersion string) error {
 	}
 
 	// Fetch the latest version from GitHub
-	latestVersion, binaryURL, err := u.getLatestVersionAndBinaryURL()
-	if err != nil {
+	var latestVersion, binaryURL string
+	if u.config.GetData().OfflineMode {
+		u.logger.Info("Offline mode: skipping the installer version check")
+		u.installerCurrent = true
+	} else if latestVersion, binaryURL, err = u.getLatestVersionAndBinaryURL(); err != nil {
 		u.logger.Warn("Failed to fetch latest version from GitHub: %v", err)
 		latestVersion = extractVersionFromURL(u.config.GetData().InstallerURL)
 		if latestVersion == "" {
@@ -82,28 +181,30 @@ func (u *Updater) Run(currentVersion string) error {
 	}
 
 	// Compare versions and update binary if necessary
+	u.step = "update_binary"
 	if latestVersion != "" {
 		if compareVersions(currentVersion, latestVersion) < 0 {
 			u.logger.Info("Local version %s is older than latest %s, updating binary...", currentVersion, latestVersion)
 			arch := runtime.GOARCH
-			if arch != "amd64" && arch != "arm64" {
-				return fmt.Errorf("unsupported architecture: %s", arch)
+			if err := requirements.CheckArchitecture(arch); err != nil {
+				return err
 			}
 
 			downloadURL := binaryURL
 			if downloadURL == "" {
-				downloadURL = u.config.GetData().InstallerURL
-				if downloadURL == "" || downloadURL == fmt.Sprintf("https://github.com/%s/releases/latest", config.GithubRepo) {
+				data := u.config.GetData()
+				downloadURL = data.InstallerURL
+				// A releases/latest page is not a binary, upstream's default or a fork's
+				if downloadURL == "" || strings.HasSuffix(downloadURL, "/releases/latest") {
 					// Try new naming pattern first
-					downloadURL = fmt.Sprintf("https://github.com/%s/releases/download/v%s/infinity-metrics-installer-v%s-%s", GitHubRepo, latestVersion, latestVersion, arch)
+					downloadURL = data.ReleaseDownloadURL(latestVersion, fmt.Sprintf("infinity-metrics-installer-v%s-%s", latestVersion, arch))
 					u.logger.Info("Trying new naming pattern URL: %s", downloadURL)
 
 					// Test if the new pattern URL is accessible
-					client := &http.Client{Timeout: 10 * time.Second}
-					resp, err := client.Head(downloadURL)
+					resp, err := httpclient.New().Head(downloadURL)
 					if err != nil || resp.StatusCode != http.StatusOK {
 						// Fall back to old naming pattern
-						downloadURL = fmt.Sprintf("https://github.com/%s/releases/download/v%s/infinity-metrics-v%s-%s", GitHubRepo, latestVersion, latestVersion, arch)
+						downloadURL = data.ReleaseDownloadURL(latestVersion, fmt.Sprintf("infinity-metrics-v%s-%s", latestVersion, arch))
 						u.logger.Info("New pattern not accessible, falling back to old naming pattern URL: %s", downloadURL)
 					} else {
 						u.logger.Info("Using new naming pattern URL: %s", downloadURL)
@@ -128,12 +229,17 @@ func (u *Updater) Run(currentVersion string) error {
 			}
 		} else {
 			u.logger.Info("Current version %s matches or is newer than latest %s, no binary update needed", currentVersion, latestVersion)
+			u.installerCurrent = true
 		}
 	}
 
 	if err := u.update(); err != nil {
 		return fmt.Errorf("update failed: %w", err)
 	}
+	if u.upToDate {
+		return nil
+	}
+	u.step = "save_config"
 	if err := u.config.SaveToFile(envFile); err != nil {
 		return fmt.Errorf("save config: %w", err)
 	}
@@ -142,14 +248,34 @@ func (u *Updater) Run(currentVersion string) error {
 	return nil
 }
 
-func (u *Updater) getLatestVersionAndBinaryURL() (string, string, error) {
-	u.logger.Info("Fetching latest release from GitHub: %s", GitHubAPIURL)
+// CheckInstallerVersion returns the latest released installer version and
+// whether it is newer than current. Only the release listing is fetched, the
+// images are not checked. A local .env is read for RELEASE_SOURCE_REPO and
+// RELEASE_API_URL when present.
+func (u *Updater) CheckInstallerVersion(current string) (string, bool, error) {
+	envFile := filepath.Join(u.config.GetData().InstallDir, ".env")
+	if _, err := os.Stat(envFile); err == nil {
+		if err := u.config.LoadFromFile(envFile); err != nil {
+			return "", false, fmt.Errorf("load config: %w", err)
+		}
+	}
 
-	client := &http.Client{
-		Timeout: 60 * time.Second,
+	if u.config.GetData().OfflineMode {
+		return "", false, fmt.Errorf("OFFLINE_MODE is set, the latest release is not checked")
+	}
+	latest, _, err := u.getLatestVersionAndBinaryURL()
+	if latest == "" {
+		return "", false, err
 	}
+	// A release without a binary for this architecture still names the latest version
+	return latest, compareVersions(current, latest) < 0, nil
+}
+
+func (u *Updater) getLatestVersionAndBinaryURL() (string, string, error) {
+	releaseURL := u.config.GetData().ReleaseAPIURL()
+	u.logger.Info("Fetching latest release: %s", releaseURL)
 
-	resp, err := client.Get(GitHubAPIURL)
+	resp, err := httpclient.New().Get(releaseURL)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to fetch latest release: %w", err)
 	}
@@ -222,21 +348,34 @@ func (u *Updater) update() error {
 		return fmt.Errorf("failed to load config from %s: %w", envFile, err)
 	}
 
+	if err := u.checkBackupDirWritable(); err != nil {
+		return err
+	}
+
 	u.logger.Info("Step 2/%d: Checking for updates from server", totalSteps)
+	local := u.config.GetData()
 	if err := u.config.FetchFromServer(""); err != nil {
 		u.logger.Warn("Server config fetch failed, using local config: %v", err)
 	}
 
+	u.docker.SetOffline(u.config.GetData().OfflineMode)
+	if u.alreadyUpToDate(local) {
+		u.upToDate = true
+		u.logger.Success("Already up to date, skipping backup and redeploy")
+		return nil
+	}
+
 	u.logger.Info("Step 3/%d: Applying updates", totalSteps)
 
+	u.step = "pre_update_hook"
+	if err := hooks.Run(u.logger, hooks.PreUpdate, u.config.GetData()); err != nil {
+		return err
+	}
+
+	u.step = "backup"
 	mainDBPath := u.config.GetMainDBPath()
-	backupDir := u.config.GetData().BackupPath
-	// Always backup database before update
-	if _, err := u.database.BackupDatabase(mainDBPath, backupDir); err != nil {
-		u.logger.Warn("Failed to backup database before update: %v", err)
-		u.logger.Warn("Proceeding with update without backup")
-	} else {
-		u.logger.Success("Database backup created successfully")
+	if err := u.backupBeforeUpdate(mainDBPath); err != nil {
+		return err
 	}
 
 	// Read admin user from database and update config
@@ -249,6 +388,7 @@ func (u *Updater) update() error {
 		u.logger.Info("Updated configuration with admin user: %s", adminUser)
 	}
 
+	u.step = "deploy"
 	if err := u.docker.Update(u.config); err != nil {
 		return fmt.Errorf("failed to update Docker containers: %w", err)
 	}
@@ -265,10 +405,276 @@ func (u *Updater) update() error {
 		return fmt.Errorf("failed to save config to %s: %w", envFile, err)
 	}
 
+	u.step = "post_update_hook"
+	if err := hooks.Run(u.logger, hooks.PostUpdate, u.config.GetData()); err != nil {
+		return err
+	}
+
 	u.logger.Success("Update completed successfully")
 	return nil
 }
 
+// alreadyUpToDate reports whether an update would only repeat the current
+// deployment: the installer is the latest release, the release changes nothing
+// in the local configuration and the running containers use the newest images
+func (u *Updater) alreadyUpToDate(local config.ConfigData) bool {
+	if u.force || !u.installerCurrent {
+		return false
+	}
+	if changes := config.ReleaseChanges(local, u.config.GetData()); len(changes) > 0 {
+		u.logger.Info("The latest release changes %s, updating", changes[0].Key)
+		return false
+	}
+	current, reason := u.docker.RunningImagesCurrent(u.config.GetDockerImages())
+	if !current {
+		u.logger.Info("Update needed: %s", reason)
+	}
+	return current
+}
+
+// downloadWithResume downloads url to dest, logging progress as it goes. If the
+// connection drops mid-download, the transfer continues from where it stopped
+// using an HTTP range request instead of starting over.
+func (u *Updater) downloadWithResume(url, dest string) (int64, error) {
+	client := httpclient.New()
+	rate := u.downloadRateLimit()
+	if rate > 0 {
+		// A throttled body can take longer than the request timeout, bound
+		// the wait for the response headers; downloadIdleTimeout bounds
+		// a body that stops arriving
+		client.Timeout = 0
+		client.Transport.(*http.Transport).ResponseHeaderTimeout = httpclient.Timeout()
+		u.logger.Info("Limiting the download to %s (%d bytes/s)", u.config.GetData().DownloadRateLimit, rate)
+	}
+	validator := "" // ETag or Last-Modified of the first response, guards resumed ranges
+
+	var lastErr error
+	for attempt := 1; attempt <= DownloadAttempts; attempt++ {
+		written, retry, err := u.downloadAttempt(client, url, dest, &validator, rate)
+		if err == nil {
+			return written, nil
+		}
+		lastErr = err
+		if !retry || attempt == DownloadAttempts {
+			break
+		}
+		u.logger.Warn("Download interrupted after %d bytes (attempt %d/%d): %v", written, attempt, DownloadAttempts, err)
+		time.Sleep(time.Duration(attempt) * downloadRetryDelay)
+	}
+	return 0, lastErr
+}
+
+// downloadRateLimit returns the DOWNLOAD_RATE_LIMIT in bytes per second, or
+// 0 for an unlimited download when it is unset or invalid
+func (u *Updater) downloadRateLimit() int64 {
+	limit := u.config.GetData().DownloadRateLimit
+	if limit == "" {
+		return 0
+	}
+	rate, err := httpclient.ParseRate(limit)
+	if err != nil {
+		u.logger.Warn("Ignoring DOWNLOAD_RATE_LIMIT: %v", err)
+		return 0
+	}
+	return rate
+}
+
+// downloadAttempt performs one request, resuming from the bytes already in dest
+// when the server supports it. retry reports whether the failure is transient.
+func (u *Updater) downloadAttempt(client *http.Client, url, dest string, validator *string, rate int64) (written int64, retry bool, err error) {
+	var offset int64
+	if info, statErr := os.Stat(dest); statErr == nil && *validator != "" {
+		offset = info.Size()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", *validator)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return offset, true, err
+	}
+	defer resp.Body.Close()
+	body := newIdleReader(resp.Body, downloadIdleTimeout, cancel)
+	defer body.Stop()
+	u.logger.Info("HTTP response status: %s", resp.Status)
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		u.logger.Info("Resuming download at %d bytes", offset)
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// Full response: either the first attempt, or the server cannot resume
+		offset = 0
+		flags |= os.O_TRUNC
+		*validator = resp.Header.Get("ETag")
+		if *validator == "" {
+			*validator = resp.Header.Get("Last-Modified")
+		}
+	default:
+		return offset, resp.StatusCode >= 500, fmt.Errorf("download failed, status: %s", resp.Status)
+	}
+
+	out, err := os.OpenFile(dest, flags, 0o644)
+	if err != nil {
+		return offset, false, fmt.Errorf("create new binary: %w", err)
+	}
+	defer out.Close()
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	progress := &downloadProgress{logger: u.logger, total: total, written: offset}
+	n, err := io.Copy(io.MultiWriter(out, progress), httpclient.LimitReader(body, rate))
+	if body.Stalled() {
+		return offset + n, true, fmt.Errorf("download stalled: no data received for %s", downloadIdleTimeout)
+	}
+	if err != nil {
+		return offset + n, true, fmt.Errorf("write new binary: %w", err)
+	}
+	if total >= 0 && offset+n != total {
+		return offset + n, true, fmt.Errorf("incomplete download: got %d of %d bytes", offset+n, total)
+	}
+	return offset + n, false, nil
+}
+
+// idleReader cancels a request when its body delivers no data for timeout.
+// The client timeout cannot bound a throttled download, which may take
+// longer than any fixed limit.
+type idleReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	stalled atomic.Bool
+}
+
+func newIdleReader(r io.Reader, timeout time.Duration, cancel context.CancelFunc) *idleReader {
+	ir := &idleReader{r: r, timeout: timeout}
+	ir.timer = time.AfterFunc(timeout, func() {
+		ir.stalled.Store(true)
+		cancel()
+	})
+	return ir
+}
+
+func (ir *idleReader) Read(p []byte) (int, error) {
+	n, err := ir.r.Read(p)
+	if n > 0 {
+		ir.timer.Reset(ir.timeout)
+	}
+	return n, err
+}
+
+// Stop releases the timer once the body is no longer read
+func (ir *idleReader) Stop() {
+	ir.timer.Stop()
+}
+
+// Stalled reports whether the request was cancelled for lack of data
+func (ir *idleReader) Stalled() bool {
+	return ir.stalled.Load()
+}
+
+// downloadProgress logs download progress every 10%, or every 5MB when the size is unknown
+type downloadProgress struct {
+	logger   *logging.Logger
+	total    int64
+	written  int64
+	reported int64
+}
+
+func (p *downloadProgress) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if p.total > 0 {
+		percent := p.written * 100 / p.total
+		if percent/10 > p.reported/10 {
+			p.reported = percent
+			p.logger.Info("Downloaded %d%% (%d of %d bytes)", percent, p.written, p.total)
+		}
+	} else if p.written-p.reported >= 5*1024*1024 {
+		p.reported = p.written
+		p.logger.Info("Downloaded %d bytes", p.written)
+	}
+	return len(b), nil
+}
+
+// checkBackupDirWritable writes and removes a test file in the backup directory
+// so a permissions problem stops the update before any images are pulled,
+// instead of surfacing as a failed backup halfway through
+func (u *Updater) checkBackupDirWritable() error {
+	if u.skipBackup {
+		return nil
+	}
+	data := u.config.GetData()
+	err := ensureWritable(data.BackupPath)
+	if err == nil {
+		return nil
+	}
+	if !data.RequireBackup {
+		u.logger.Warn("Backup directory is not writable, the update will proceed without a backup (REQUIRE_BACKUP=false): %v", err)
+		return nil
+	}
+	return fmt.Errorf("backup directory %s is not writable, fix its permissions (e.g. chown root %s) or re-run with --skip-backup: %w", data.BackupPath, data.BackupPath, err)
+}
+
+// ensureWritable creates dir if needed and verifies files can be written in it
+func ensureWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// backupBeforeUpdate takes the pre-update backup. A failed backup aborts the
+// update unless REQUIRE_BACKUP=false or --skip-backup was given.
+func (u *Updater) backupBeforeUpdate(mainDBPath string) error {
+	if u.skipBackup {
+		u.logger.Warn("Skipping pre-update database backup (--skip-backup)")
+		return nil
+	}
+
+	if _, err := os.Stat(mainDBPath); os.IsNotExist(err) {
+		u.logger.Warn("Database %s does not exist yet, nothing to back up", mainDBPath)
+		return nil
+	}
+
+	data := u.config.GetData()
+	u.database.SetRetentionConfig(database.RetentionConfigFromDays(
+		data.BackupDailyRetentionDays, data.BackupWeeklyRetentionDays, data.BackupMonthlyRetentionDays))
+
+	backupDir := data.BackupPath
+	backupPath, err := u.database.BackupDatabase(mainDBPath, backupDir)
+	if err != nil {
+		if !u.config.GetData().RequireBackup {
+			u.logger.Warn("Failed to backup database before update: %v", err)
+			u.logger.Warn("Proceeding with update without backup (REQUIRE_BACKUP=false)")
+			return nil
+		}
+		u.logger.Error("Failed to backup database before update: %v", err)
+		return fmt.Errorf("pre-update backup failed, aborting update (re-run with --skip-backup to update without a backup): %w", err)
+	}
+
+	u.backupPath = backupPath
+	u.logger.Success("Database backup created successfully")
+	return nil
+}
+
 func (u *Updater) updateBinary(url, binaryPath string) error {
 	u.logger.InfoWithTime("Downloading new installer binary from %s", url)
 
@@ -293,22 +699,6 @@ func (u *Updater) updateBinary(url, binaryPath string) error {
 		os.Remove(testFile)
 	}
 
-	client := &http.Client{
-		Timeout: 60 * time.Second,
-	}
-
-	u.logger.Info("Starting HTTP request to download binary")
-	resp, err := client.Get(url)
-	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	u.logger.Info("HTTP response status: %s", resp.Status)
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed, status: %s", resp.Status)
-	}
-
 	newBinary := filepath.Join("/tmp", "infinity-metrics.new")
 	u.logger.Info("Attempting to create file at: %s", newBinary)
 
@@ -324,39 +714,20 @@ func (u *Updater) updateBinary(url, binaryPath string) error {
 		u.logger.Info("File does not exist yet: %v", err)
 	}
 
-	out, err := os.Create(newBinary)
+	u.logger.Info("Starting HTTP request to download binary")
+	written, err := u.downloadWithResume(url, newBinary)
 	if err != nil {
-		// Log detailed error information
-		u.logger.Info("Failed to create file: %v", err)
-		u.logger.Info("Error type: %T", err)
-
 		// Check parent directory permissions
 		tmpDir := filepath.Dir(newBinary)
-		u.logger.Info("Parent directory: %s", tmpDir)
-		if dirInfo, err := os.Stat(tmpDir); err != nil {
-			u.logger.Info("Failed to stat parent directory: %v", err)
+		if dirInfo, statErr := os.Stat(tmpDir); statErr != nil {
+			u.logger.Info("Failed to stat parent directory: %v", statErr)
 		} else {
 			u.logger.Info("Parent directory mode: %v", dirInfo.Mode())
 		}
-
-		return fmt.Errorf("create new binary: %w", err)
-	}
-
-	u.logger.Info("Successfully created file at %s", newBinary)
-	defer out.Close()
-
-	u.logger.Info("Copying response body to file")
-	written, err := io.Copy(out, resp.Body)
-	if err != nil {
-		u.logger.Info("Failed to write data: %v", err)
-		return fmt.Errorf("write new binary: %w", err)
+		return fmt.Errorf("download failed: %w", err)
 	}
 	u.logger.Info("Successfully wrote %d bytes to file", written)
 
-	// Close the file before chmod
-	out.Close()
-	u.logger.Info("Closed file after writing")
-
 	u.logger.Info("Setting file permissions to 0755")
 	if err := os.Chmod(newBinary, 0o755); err != nil {
 		u.logger.Info("Failed to set file permissions: %v", err)
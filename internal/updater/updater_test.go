@@ -1,10 +1,16 @@
 package updater
 
 import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"infinity-metrics-installer/internal/logging"
 )
@@ -78,3 +84,303 @@ func TestExtractVersionFromURL(t *testing.T) {
 		}
 	}
 }
+
+func TestBackupBeforeUpdate(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error"})
+
+	newTestUpdater := func(t *testing.T, requireBackup bool) (*Updater, string) {
+		tmpDir := t.TempDir()
+		dbPath := filepath.Join(tmpDir, "infinity-metrics-production.db")
+		if err := os.WriteFile(dbPath, []byte("not a sqlite database"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// A regular file as the backup dir makes the backup fail deterministically
+		blocker := filepath.Join(tmpDir, "backups")
+		if err := os.WriteFile(blocker, []byte{}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		u := NewUpdater(logger)
+		data := u.config.GetData()
+		data.BackupPath = blocker
+		data.RequireBackup = requireBackup
+		u.config.SetData(data)
+		return u, dbPath
+	}
+
+	t.Run("FailedBackupAbortsWhenRequired", func(t *testing.T) {
+		u, dbPath := newTestUpdater(t, true)
+		err := u.backupBeforeUpdate(dbPath)
+		if err == nil || !strings.Contains(err.Error(), "--skip-backup") {
+			t.Fatalf("expected abort error mentioning --skip-backup, got %v", err)
+		}
+	})
+
+	t.Run("FailedBackupProceedsWhenNotRequired", func(t *testing.T) {
+		u, dbPath := newTestUpdater(t, false)
+		if err := u.backupBeforeUpdate(dbPath); err != nil {
+			t.Fatalf("expected update to proceed, got %v", err)
+		}
+	})
+
+	t.Run("SkipBackupFlag", func(t *testing.T) {
+		u, dbPath := newTestUpdater(t, true)
+		u.SetSkipBackup(true)
+		if err := u.backupBeforeUpdate(dbPath); err != nil {
+			t.Fatalf("expected --skip-backup to bypass backup, got %v", err)
+		}
+	})
+
+	t.Run("MissingDatabase", func(t *testing.T) {
+		u, _ := newTestUpdater(t, true)
+		if err := u.backupBeforeUpdate(filepath.Join(t.TempDir(), "missing.db")); err != nil {
+			t.Fatalf("expected missing database to be skipped, got %v", err)
+		}
+	})
+}
+
+func TestDownloadWithResume(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error"})
+	downloadRetryDelay = time.Millisecond
+	t.Cleanup(func() { downloadRetryDelay = 2 * time.Second })
+
+	content := bytes.Repeat([]byte("infinity-metrics"), 4096)
+
+	tests := []struct {
+		name       string
+		etag       string
+		wantRanged bool
+	}{
+		{"resumes with range request", `"v1"`, true},
+		{"restarts without validator", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests, ranged atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if requests.Add(1) == 1 {
+					// Drop the connection halfway through the first response
+					w.Header().Set("Content-Length", "65536")
+					w.WriteHeader(http.StatusOK)
+					w.Write(content[:len(content)/2])
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err == nil {
+						conn.Close()
+					}
+					return
+				}
+				if r.Header.Get("Range") != "" {
+					ranged.Add(1)
+				}
+				http.ServeContent(w, r, "infinity-metrics", time.Time{}, bytes.NewReader(content))
+			}))
+			defer server.Close()
+
+			u := NewUpdater(logger)
+			dest := filepath.Join(t.TempDir(), "infinity-metrics.new")
+			written, err := u.downloadWithResume(server.URL, dest)
+			if err != nil {
+				t.Fatalf("downloadWithResume failed: %v", err)
+			}
+			if written != int64(len(content)) {
+				t.Errorf("written = %d, want %d", written, len(content))
+			}
+			got, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("downloaded file differs from served content (%d bytes)", len(got))
+			}
+			if (ranged.Load() > 0) != tt.wantRanged {
+				t.Errorf("range requests = %d, want ranged=%v", ranged.Load(), tt.wantRanged)
+			}
+		})
+	}
+}
+
+func TestDownloadStalled(t *testing.T) {
+	downloadRetryDelay = time.Millisecond
+	downloadIdleTimeout = 100 * time.Millisecond
+	t.Cleanup(func() {
+		downloadRetryDelay = 2 * time.Second
+		downloadIdleTimeout = 60 * time.Second
+	})
+
+	content := bytes.Repeat([]byte("infinity-metrics"), 4096)
+	release := make(chan struct{})
+	defer close(release)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if requests.Add(1) == 1 {
+			// Send half the body, then stop sending without closing the connection
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		http.ServeContent(w, r, "infinity-metrics", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	u := NewUpdater(logging.NewLogger(logging.Config{Level: "error"}))
+	dest := filepath.Join(t.TempDir(), "infinity-metrics.new")
+	done := make(chan error, 1)
+	go func() {
+		_, err := u.downloadWithResume(server.URL, dest)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("downloadWithResume failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("download blocked on a stalled body")
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+		t.Errorf("downloaded file differs from served content (%d bytes)", len(got))
+	}
+}
+
+func TestDownloadWithRateLimit(t *testing.T) {
+	content := bytes.Repeat([]byte("infinity-metrics"), 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "infinity-metrics", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	u := NewUpdater(logging.NewLogger(logging.Config{Level: "error"}))
+	data := u.config.GetData()
+	data.DownloadRateLimit = "256KB/s"
+	u.config.SetData(data)
+
+	start := time.Now()
+	dest := filepath.Join(t.TempDir(), "infinity-metrics.new")
+	if _, err := u.downloadWithResume(server.URL, dest); err != nil {
+		t.Fatalf("downloadWithResume failed: %v", err)
+	}
+	// 64KiB at 256KiB/s takes about a quarter of a second
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("download took %s, the rate limit was not applied", elapsed)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded file differs from served content (%d bytes)", len(got))
+	}
+}
+
+func TestCheckBackupDirWritable(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error"})
+
+	newTestUpdater := func(backupPath string, requireBackup bool) *Updater {
+		u := NewUpdater(logger)
+		data := u.config.GetData()
+		data.BackupPath = backupPath
+		data.RequireBackup = requireBackup
+		u.config.SetData(data)
+		return u
+	}
+
+	// A regular file in the path makes the directory unusable even when running as root
+	blocker := filepath.Join(t.TempDir(), "backups")
+	if err := os.WriteFile(blocker, []byte{}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Writable", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "backups")
+		if err := newTestUpdater(dir, true).checkBackupDirWritable(); err != nil {
+			t.Fatalf("expected writable directory to pass, got %v", err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("test file was left behind: %v", entries)
+		}
+	})
+
+	t.Run("NotWritableFailsWhenRequired", func(t *testing.T) {
+		err := newTestUpdater(blocker, true).checkBackupDirWritable()
+		if err == nil || !strings.Contains(err.Error(), "not writable") {
+			t.Fatalf("expected permissions error, got %v", err)
+		}
+	})
+
+	t.Run("NotWritableProceedsWhenNotRequired", func(t *testing.T) {
+		if err := newTestUpdater(blocker, false).checkBackupDirWritable(); err != nil {
+			t.Fatalf("expected update to proceed, got %v", err)
+		}
+	})
+
+	t.Run("SkipBackupFlag", func(t *testing.T) {
+		u := newTestUpdater(blocker, true)
+		u.SetSkipBackup(true)
+		if err := u.checkBackupDirWritable(); err != nil {
+			t.Fatalf("expected --skip-backup to bypass the check, got %v", err)
+		}
+	})
+}
+
+func TestAlreadyUpToDate_RequiresUnchangedRelease(t *testing.T) {
+	u := NewUpdater(logging.NewLogger(logging.Config{Level: "error"}))
+	local := u.config.GetData()
+
+	u.installerCurrent = false
+	if u.alreadyUpToDate(local) {
+		t.Error("an installer behind the latest release must take the full update")
+	}
+
+	u.installerCurrent = true
+	u.force = true
+	if u.alreadyUpToDate(local) {
+		t.Error("--force must take the full update")
+	}
+
+	u.force = false
+	released := local
+	released.AppImage = "karloscodes/infinity-metrics-beta:2.0.0"
+	u.config.SetData(released)
+	if u.alreadyUpToDate(local) {
+		t.Error("a release that changes the app image must take the full update")
+	}
+}
+
+func TestCheckInstallerVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name":"v1.4.0","assets":[]}`))
+	}))
+	defer server.Close()
+
+	u := NewUpdater(logging.NewLogger(logging.Config{Level: "error"}))
+	data := u.config.GetData()
+	data.InstallDir = t.TempDir()
+	data.ReleaseAPIEndpoint = server.URL
+	u.config.SetData(data)
+
+	latest, updateAvailable, err := u.CheckInstallerVersion("1.3.2")
+	if err != nil {
+		t.Fatalf("CheckInstallerVersion: %v", err)
+	}
+	if latest != "1.4.0" || !updateAvailable {
+		t.Errorf("got latest %q, update available %v, want 1.4.0 and true", latest, updateAvailable)
+	}
+
+	if _, updateAvailable, _ := u.CheckInstallerVersion("1.4.0"); updateAvailable {
+		t.Error("the latest version must not report an update")
+	}
+}
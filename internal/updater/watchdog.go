@@ -0,0 +1,206 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/database"
+	"infinity-metrics-installer/internal/docker"
+	"infinity-metrics-installer/internal/logging"
+)
+
+const (
+	WatchdogMaxRestarts   = 3                // Restarts allowed per container within WatchdogRestartWindow
+	WatchdogRestartWindow = 30 * time.Minute // Sliding window used to detect crash loops
+	WatchdogStateFile     = ".watchdog-state.json"
+)
+
+// containerSupervisor is the subset of docker operations the watchdog relies on
+type containerSupervisor interface {
+	VerifyContainersRunning() (bool, error)
+	ActiveAppContainer() (string, error)
+	IsRunning(name string) bool
+	CheckAppHealth(name string) error
+	RecoverApp(data config.ConfigData) (string, error)
+	RecoverCaddy(data config.ConfigData) error
+	ApplySettings(data config.ConfigData)
+}
+
+// Watchdog checks container health and restarts crashed or unhealthy containers
+type Watchdog struct {
+	logger     *logging.Logger
+	config     *config.Config
+	containers containerSupervisor
+	now        func() time.Time
+}
+
+// NewWatchdog creates a Watchdog instance
+func NewWatchdog(logger *logging.Logger) *Watchdog {
+	fileLogger := logging.NewFileLogger(logging.Config{
+		Level:   logger.Level.String(),
+		Verbose: logger.GetVerbose(),
+		Quiet:   logger.GetQuiet(),
+		LogDir:  "/opt/infinity-metrics/logs",
+		LogFile: "infinity-metrics-watchdog.log",
+	})
+
+	db := database.NewDatabase(fileLogger) // Need database for Docker constructor
+	return &Watchdog{
+		logger:     fileLogger,
+		config:     config.NewConfig(fileLogger),
+		containers: docker.NewDocker(fileLogger, db),
+		now:        time.Now,
+	}
+}
+
+// Run checks the containers every interval until a check fails to load config
+func (w *Watchdog) Run(interval time.Duration) error {
+	w.logger.Info("Starting watchdog, checking every %s", interval)
+	for {
+		if err := w.Check(); err != nil {
+			return err
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Check performs a single health pass, restarting containers that are down or unhealthy.
+// Recovery failures are logged rather than returned so the watchdog keeps running.
+func (w *Watchdog) Check() error {
+	return w.check("watchdog")
+}
+
+// Reconcile is Check for the boot service, named so in the operation lock
+func (w *Watchdog) Reconcile() error {
+	return w.check("reconcile")
+}
+
+// check skips the pass while an update, reload, backup or restore holds the
+// operation lock, since those stop and replace the containers themselves
+func (w *Watchdog) check(command string) error {
+	data := w.config.GetData()
+	envFile := filepath.Join(data.InstallDir, ".env")
+
+	lock, err := AcquireLock(data.InstallDir, command)
+	var held *LockHeldError
+	if errors.As(err, &held) {
+		w.logger.Info("Skipping the container check: %v", held)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	if err := w.config.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", envFile, err)
+	}
+	data = w.config.GetData()
+	w.containers.ApplySettings(data)
+
+	running, err := w.containers.VerifyContainersRunning()
+	if err != nil {
+		w.logger.Warn("Failed to check container status: %v", err)
+	}
+
+	appHealthy := false
+	if name, err := w.containers.ActiveAppContainer(); err != nil {
+		w.logger.Warn("App container is not running")
+	} else if err := w.containers.CheckAppHealth(name); err != nil {
+		w.logger.Warn("App container %s failed health check: %v", name, err)
+	} else {
+		appHealthy = true
+	}
+	caddyRunning := w.containers.IsRunning(docker.CaddyName)
+
+	if running && appHealthy && caddyRunning {
+		w.logger.Debug("All containers healthy")
+		return nil
+	}
+
+	limiter := loadRestartLimiter(filepath.Join(data.InstallDir, WatchdogStateFile))
+
+	if !appHealthy {
+		w.recover(limiter, "app", func() error {
+			name, err := w.containers.RecoverApp(data)
+			if err == nil {
+				w.logger.Success("App container %s recovered", name)
+			}
+			return err
+		})
+	}
+	if !caddyRunning {
+		w.recover(limiter, "caddy", func() error {
+			err := w.containers.RecoverCaddy(data)
+			if err == nil {
+				w.logger.Success("Caddy container recovered")
+			}
+			return err
+		})
+	}
+
+	if err := limiter.save(); err != nil {
+		w.logger.Warn("Failed to save watchdog state: %v", err)
+	}
+	return nil
+}
+
+func (w *Watchdog) recover(limiter *restartLimiter, service string, restart func() error) {
+	now := w.now()
+	if !limiter.allow(service, now) {
+		w.logger.Error("Not restarting %s: %d restarts within %s, possible crash loop. Check 'infinity-metrics logs %s'",
+			service, WatchdogMaxRestarts, WatchdogRestartWindow, service)
+		return
+	}
+
+	limiter.record(service, now)
+	w.logger.Info("Restarting %s container", service)
+	if err := restart(); err != nil {
+		w.logger.Error("Failed to restart %s container: %v", service, err)
+	}
+}
+
+// restartLimiter tracks recent restarts per service, persisted so the limit
+// also holds when the watchdog is invoked from cron
+type restartLimiter struct {
+	path     string
+	Restarts map[string][]time.Time `json:"restarts"`
+}
+
+func loadRestartLimiter(path string) *restartLimiter {
+	l := &restartLimiter{path: path}
+	if content, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(content, l)
+	}
+	if l.Restarts == nil {
+		l.Restarts = make(map[string][]time.Time)
+	}
+	return l
+}
+
+func (l *restartLimiter) allow(service string, now time.Time) bool {
+	recent := l.Restarts[service][:0]
+	for _, t := range l.Restarts[service] {
+		if now.Sub(t) < WatchdogRestartWindow {
+			recent = append(recent, t)
+		}
+	}
+	l.Restarts[service] = recent
+	return len(recent) < WatchdogMaxRestarts
+}
+
+func (l *restartLimiter) record(service string, now time.Time) {
+	l.Restarts[service] = append(l.Restarts[service], now)
+}
+
+func (l *restartLimiter) save() error {
+	content, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(l.path, content, 0o600)
+}
@@ -0,0 +1,167 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"infinity-metrics-installer/internal/config"
+	"infinity-metrics-installer/internal/docker"
+	"infinity-metrics-installer/internal/logging"
+)
+
+type fakeSupervisor struct {
+	appRunning    bool
+	appHealthy    bool
+	caddyRunning  bool
+	appRestarts   int
+	caddyRestarts int
+	settings      config.ConfigData
+}
+
+func (f *fakeSupervisor) VerifyContainersRunning() (bool, error) {
+	return f.appRunning && f.caddyRunning, nil
+}
+
+func (f *fakeSupervisor) ActiveAppContainer() (string, error) {
+	if !f.appRunning {
+		return "", fmt.Errorf("no running app container found")
+	}
+	return docker.AppNamePrimary, nil
+}
+
+func (f *fakeSupervisor) IsRunning(name string) bool {
+	return name == docker.CaddyName && f.caddyRunning
+}
+
+func (f *fakeSupervisor) CheckAppHealth(name string) error {
+	if !f.appHealthy {
+		return fmt.Errorf("unhealthy")
+	}
+	return nil
+}
+
+func (f *fakeSupervisor) RecoverApp(data config.ConfigData) (string, error) {
+	f.appRestarts++
+	return docker.AppNamePrimary, nil
+}
+
+func (f *fakeSupervisor) RecoverCaddy(data config.ConfigData) error {
+	f.caddyRestarts++
+	return nil
+}
+
+func (f *fakeSupervisor) ApplySettings(data config.ConfigData) {
+	f.settings = data
+}
+
+func newTestWatchdog(t *testing.T, fs *fakeSupervisor) (*Watchdog, string) {
+	tmpDir := t.TempDir()
+	envFile := filepath.Join(tmpDir, ".env")
+	content := fmt.Sprintf("INFINITY_METRICS_DOMAIN=localhost\nINSTALL_DIR=%s\n", tmpDir)
+	if err := os.WriteFile(envFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logging.NewLogger(logging.Config{Level: "error", LogDir: tmpDir})
+	cfg := config.NewConfig(logger)
+	cfg.SetInstallDir(tmpDir)
+	return &Watchdog{logger: logger, config: cfg, containers: fs, now: time.Now}, tmpDir
+}
+
+func TestWatchdogCheck(t *testing.T) {
+	t.Run("HealthyContainersAreLeftAlone", func(t *testing.T) {
+		fs := &fakeSupervisor{appRunning: true, appHealthy: true, caddyRunning: true}
+		w, _ := newTestWatchdog(t, fs)
+		if err := w.Check(); err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if fs.appRestarts != 0 || fs.caddyRestarts != 0 {
+			t.Errorf("expected no restarts, got app=%d caddy=%d", fs.appRestarts, fs.caddyRestarts)
+		}
+	})
+
+	t.Run("HealthSettingsComeFromEnvFile", func(t *testing.T) {
+		fs := &fakeSupervisor{appRunning: true, appHealthy: true, caddyRunning: true}
+		w, tmpDir := newTestWatchdog(t, fs)
+		envFile := filepath.Join(tmpDir, ".env")
+		content := fmt.Sprintf("INFINITY_METRICS_DOMAIN=localhost\nINSTALL_DIR=%s\nAPP_HEALTH_SCHEME=https\nAPP_HEALTH_PATH=/ready\n", tmpDir)
+		if err := os.WriteFile(envFile, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Check(); err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if fs.settings.AppHealthScheme != "https" || fs.settings.AppHealthPath != "/ready" {
+			t.Errorf("settings = %q %q, want the .env health endpoint applied", fs.settings.AppHealthScheme, fs.settings.AppHealthPath)
+		}
+	})
+
+	t.Run("SkippedWhileAnotherOperationHoldsTheLock", func(t *testing.T) {
+		fs := &fakeSupervisor{appRunning: false, caddyRunning: false}
+		w, tmpDir := newTestWatchdog(t, fs)
+		lock, err := AcquireLock(tmpDir, "update")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer lock.Release()
+		if err := w.Check(); err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if err := w.Reconcile(); err != nil {
+			t.Fatalf("Reconcile returned error: %v", err)
+		}
+		if fs.appRestarts != 0 || fs.caddyRestarts != 0 {
+			t.Errorf("expected no restarts while locked, got app=%d caddy=%d", fs.appRestarts, fs.caddyRestarts)
+		}
+	})
+
+	t.Run("UnhealthyAppIsRestarted", func(t *testing.T) {
+		fs := &fakeSupervisor{appRunning: true, appHealthy: false, caddyRunning: true}
+		w, _ := newTestWatchdog(t, fs)
+		if err := w.Check(); err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if fs.appRestarts != 1 || fs.caddyRestarts != 0 {
+			t.Errorf("expected only app restart, got app=%d caddy=%d", fs.appRestarts, fs.caddyRestarts)
+		}
+	})
+
+	t.Run("CrashedCaddyIsRestarted", func(t *testing.T) {
+		fs := &fakeSupervisor{appRunning: true, appHealthy: true, caddyRunning: false}
+		w, _ := newTestWatchdog(t, fs)
+		if err := w.Check(); err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if fs.appRestarts != 0 || fs.caddyRestarts != 1 {
+			t.Errorf("expected only caddy restart, got app=%d caddy=%d", fs.appRestarts, fs.caddyRestarts)
+		}
+	})
+
+	t.Run("RestartsAreRateLimited", func(t *testing.T) {
+		fs := &fakeSupervisor{appRunning: false, caddyRunning: true}
+		w, tmpDir := newTestWatchdog(t, fs)
+		for i := 0; i < WatchdogMaxRestarts+2; i++ {
+			if err := w.Check(); err != nil {
+				t.Fatalf("Check returned error: %v", err)
+			}
+		}
+		if fs.appRestarts != WatchdogMaxRestarts {
+			t.Errorf("expected %d restarts, got %d", WatchdogMaxRestarts, fs.appRestarts)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, WatchdogStateFile)); err != nil {
+			t.Errorf("expected watchdog state to be persisted: %v", err)
+		}
+
+		// Once the window has passed the container may be restarted again
+		w.now = func() time.Time { return time.Now().Add(WatchdogRestartWindow) }
+		if err := w.Check(); err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if fs.appRestarts != WatchdogMaxRestarts+1 {
+			t.Errorf("expected restart after window elapsed, got %d restarts", fs.appRestarts)
+		}
+	})
+}
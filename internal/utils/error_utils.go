@@ -81,7 +81,8 @@ func SafeFileWrite(logger *logging.Logger, filePath string, content []byte, perm
 	return nil
 }
 
-// BackupFile creates a backup of a file before modification
+// BackupFile creates a backup of a file before modification, readable by its
+// owner only
 func BackupFile(logger *logging.Logger, filePath string) (string, error) {
 	if filePath == "" {
 		return "", errors.NewValidationError("file_path", filePath, "file path cannot be empty")
@@ -103,7 +104,8 @@ func BackupFile(logger *logging.Logger, filePath string) (string, error) {
 		return "", errors.WrapWithContext(err, fmt.Sprintf("failed to read source file %s", filePath))
 	}
 
-	if err := os.WriteFile(backupPath, content, 0644); err != nil {
+	// The backed up files, like .env, can hold secrets
+	if err := os.WriteFile(backupPath, content, 0600); err != nil {
 		return "", errors.WrapWithContext(err, fmt.Sprintf("failed to write backup file %s", backupPath))
 	}
 
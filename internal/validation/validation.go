@@ -1,21 +1,34 @@
 package validation
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
 
 	"infinity-metrics-installer/internal/errors"
 )
 
 var (
-	emailRegex  = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	domainRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	emailRegex         = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	domainRegex        = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	containerUserRegex = regexp.MustCompile(`^(\d+):(\d+)$`)
+	cpuLimitRegex      = regexp.MustCompile(`^\d+(\.\d+)?$`)
+	repoSlugRegex      = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
 )
 
+// MaxRetentionDays caps backup retention at ten years
+const MaxRetentionDays = 3650
+
+// MaxKeepImageVersions caps how many app images are kept for rollback
+const MaxKeepImageVersions = 10
+
 // ValidateEmail validates email format and returns appropriate error
 func ValidateEmail(email string) error {
 	if email == "" {
@@ -82,6 +95,57 @@ func ValidateDomain(domain string) error {
 	return nil
 }
 
+// nonPublicTLDs are special-use or private-use top-level domains that public
+// certificate authorities such as Let's Encrypt will never issue certificates
+// for. They are checked before the public suffix list to give a clearer error.
+var nonPublicTLDs = map[string]bool{
+	"local":       true, // mDNS (RFC 6762)
+	"localhost":   true, // RFC 6761
+	"localdomain": true,
+	"test":        true, // RFC 6761
+	"example":     true, // RFC 6761
+	"invalid":     true, // RFC 6761
+	"onion":       true, // RFC 7686
+	"alt":         true, // RFC 9476
+	"arpa":        true, // infrastructure, includes home.arpa (RFC 8375)
+	"internal":    true, // reserved for private use by ICANN
+	"intranet":    true,
+	"private":     true,
+	"lan":         true,
+	"home":        true,
+	"corp":        true,
+}
+
+// ValidatePublicDomain checks that a certificate authority can issue a certificate
+// for the domain: its top-level domain must be in the ICANN section of the
+// public suffix list and it needs at least one label in front of its public suffix
+func ValidatePublicDomain(domain string) error {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if net.ParseIP(domain) != nil {
+		return errors.NewValidationError("domain", domain, "IP addresses cannot get a publicly trusted certificate")
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return errors.NewValidationError("domain", domain, "single-label domains are not publicly resolvable")
+	}
+
+	tld := labels[len(labels)-1]
+	if nonPublicTLDs[tld] {
+		return errors.NewValidationError("domain", domain, fmt.Sprintf(".%s is a special-use top-level domain that cannot get a publicly trusted certificate", tld))
+	}
+
+	// The TLD is looked up on its own so names under a private-section suffix,
+	// such as a dynamic DNS provider's, are still accepted
+	if _, icann := publicsuffix.PublicSuffix(tld); !icann {
+		return errors.NewValidationError("domain", domain, fmt.Sprintf(".%s is not a known public top-level domain", tld))
+	}
+	if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain {
+		return errors.NewValidationError("domain", domain, fmt.Sprintf("%s is a public suffix, add the name registered under it", domain))
+	}
+	return nil
+}
+
 // ValidatePort validates port number
 func ValidatePort(port string) error {
 	if port == "" {
@@ -149,8 +213,13 @@ func ValidateLicenseKey(license string) error {
 		return errors.NewValidationError("license", license, "license key too short (minimum 10 characters)")
 	}
 
-	if len(license) > 100 {
-		return errors.NewValidationError("license", license, "license key too long (maximum 100 characters)")
+	// Signed licenses (header.payload.signature) are verified by the license package
+	maxLength := 100
+	if strings.Count(license, ".") == 2 {
+		maxLength = 4096
+	}
+	if len(license) > maxLength {
+		return errors.NewValidationError("license", license, fmt.Sprintf("license key too long (maximum %d characters)", maxLength))
 	}
 
 	// Basic format validation - alphanumeric and common separators
@@ -242,3 +311,141 @@ func ValidateFilePath(path string) error {
 
 	return nil
 }
+
+// ValidateContainerUser validates a numeric uid:gid pair (e.g. 1000:1000)
+func ValidateContainerUser(user string) error {
+	if user == "" {
+		return errors.NewValidationError("container_user", user, "container user cannot be empty")
+	}
+
+	matches := containerUserRegex.FindStringSubmatch(user)
+	if matches == nil {
+		return errors.NewValidationError("container_user", user, "container user must be in uid:gid format (e.g., 1000:1000)")
+	}
+
+	for _, id := range matches[1:] {
+		if n, err := strconv.Atoi(id); err != nil || n > 2147483647 {
+			return errors.NewValidationError("container_user", user, "uid and gid must be valid numeric IDs")
+		}
+	}
+
+	return nil
+}
+
+// ValidateRepoSlug validates a GitHub owner/repo pair (e.g. acme/infinity-metrics-installer)
+func ValidateRepoSlug(repo string) error {
+	if repo == "" {
+		return errors.NewValidationError("repo", repo, "repository cannot be empty")
+	}
+	if !repoSlugRegex.MatchString(repo) || strings.HasSuffix(repo, "/.") || strings.HasSuffix(repo, "/..") {
+		return errors.NewValidationError("repo", repo, "repository must be in owner/repo format (e.g., acme/infinity-metrics-installer)")
+	}
+	return nil
+}
+
+// ValidateCertificatePair checks that certPath and keyPath are readable PEM
+// files holding a certificate and its matching private key
+func ValidateCertificatePair(certPath, keyPath string) error {
+	for _, path := range []string{certPath, keyPath} {
+		if err := ValidateFilePath(path); err != nil {
+			return err
+		}
+	}
+	if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+		return errors.NewValidationError("tls_certificate", certPath, fmt.Sprintf("certificate and key are not a valid pair: %v", err))
+	}
+	return nil
+}
+
+// ValidateCPULimit validates a docker --cpus value, a positive decimal such as 0.5 or 2
+func ValidateCPULimit(limit string) error {
+	if !cpuLimitRegex.MatchString(limit) {
+		return errors.NewValidationError("cpu_limit", limit, "CPU limit must be a positive decimal number (e.g., 0.5 or 2)")
+	}
+	if cpus, err := strconv.ParseFloat(limit, 64); err != nil || cpus <= 0 {
+		return errors.NewValidationError("cpu_limit", limit, "CPU limit must be greater than 0")
+	}
+	return nil
+}
+
+// ValidateListenStack validates the address family Caddy publishes its ports on
+func ValidateListenStack(stack string) error {
+	switch stack {
+	case "ipv4", "ipv6", "dual":
+		return nil
+	}
+	return errors.NewValidationError("listen_stack", stack, "listen stack must be ipv4, ipv6 or dual")
+}
+
+// ValidateSELinuxRelabel validates when bind mounts are relabeled for SELinux
+func ValidateSELinuxRelabel(mode string) error {
+	switch mode {
+	case "true", "false", "auto":
+		return nil
+	}
+	return errors.NewValidationError("selinux_relabel", mode, "SELinux relabel must be true, false or auto")
+}
+
+// ValidateAppHealthScheme validates the scheme of the app health endpoint
+func ValidateAppHealthScheme(scheme string) error {
+	switch strings.ToLower(scheme) {
+	case "http", "https":
+		return nil
+	}
+	return errors.NewValidationError("app_health_scheme", scheme, "app health scheme must be http or https")
+}
+
+// ValidateRetentionDays validates a backup retention period in days
+func ValidateRetentionDays(days int) error {
+	if days < 1 || days > MaxRetentionDays {
+		return errors.NewValidationError("retention_days", strconv.Itoa(days), fmt.Sprintf("retention must be between 1 and %d days", MaxRetentionDays))
+	}
+	return nil
+}
+
+// ValidateKeepImageVersions validates how many app image versions to keep, including the running one
+func ValidateKeepImageVersions(versions int) error {
+	if versions < 1 || versions > MaxKeepImageVersions {
+		return errors.NewValidationError("keep_image_versions", strconv.Itoa(versions), fmt.Sprintf("must be between 1 and %d", MaxKeepImageVersions))
+	}
+	return nil
+}
+
+// ParseTimeout parses a timeout setting, a duration such as 45s or 2m or a
+// number of seconds, which must be positive
+func ParseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, errors.NewValidationError("timeout", value, "must be a positive duration (e.g., 45s) or number of seconds")
+}
+
+// ValidateLogSince validates a docker logs --since value: a duration such as
+// 10m or 2h30m, a unix timestamp, or an RFC3339 / YYYY-MM-DD timestamp
+func ValidateLogSince(since string) error {
+	if since == "" {
+		return errors.NewValidationError("since", since, "since cannot be empty")
+	}
+
+	if d, err := time.ParseDuration(since); err == nil {
+		if d <= 0 {
+			return errors.NewValidationError("since", since, "duration must be positive")
+		}
+		return nil
+	}
+
+	if _, err := strconv.ParseInt(since, 10, 64); err == nil {
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if _, err := time.Parse(layout, since); err == nil {
+			return nil
+		}
+	}
+
+	return errors.NewValidationError("since", since, "must be a duration (e.g., 10m) or a timestamp (e.g., 2024-01-02T15:04:05Z)")
+}
@@ -1,8 +1,19 @@
 package validation
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	customerrors "infinity-metrics-installer/internal/errors"
 )
@@ -78,6 +89,38 @@ func TestValidateDomain(t *testing.T) {
 	}
 }
 
+func TestValidatePublicDomain(t *testing.T) {
+	tests := []struct {
+		name    string
+		domain  string
+		wantErr bool
+	}{
+		{"public domain", "example.com", false},
+		{"public subdomain", "analytics.example.co.uk", false},
+		{"uppercase", "Analytics.Example.COM", false},
+		{"single label", "intranet-server", true},
+		{"mdns domain", "analytics.local", true},
+		{"internal domain", "metrics.corp.internal", true},
+		{"home arpa", "router.home.arpa", true},
+		{"reserved test tld", "site.test", true},
+		{"localhost subdomain", "app.localhost", true},
+		{"ip address", "192.168.1.10", true},
+		{"unknown tld", "analytics.lcl", true},
+		{"misspelled tld", "site.comm", true},
+		{"bare public suffix", "co.uk", true},
+		{"private suffix subdomain", "analytics.duckdns.org", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePublicDomain(tt.domain)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePublicDomain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidatePort(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -176,6 +219,8 @@ func TestValidateLicenseKey(t *testing.T) {
 		{"empty license", "", true},
 		{"too short", "ABC123", true},
 		{"too long", string(make([]byte, 101)), true},
+		{"signed token", "eyJhbGciOiJFZERTQSJ9." + strings.Repeat("a", 200) + ".c2ln", false},
+		{"signed token too long", "eyJhbGciOiJFZERTQSJ9." + strings.Repeat("a", 4096) + ".c2ln", true},
 		{"invalid characters", "ABC@123#DEF", true},
 		{"spaces", "ABC 123 DEF", true},
 	}
@@ -413,4 +458,244 @@ func TestPasswordValidationFlow(t *testing.T) {
 			t.Error("Expected empty password to be rejected as required")
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestValidateContainerUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		wantErr bool
+	}{
+		{"valid uid:gid", "1000:1000", false},
+		{"root ids", "0:0", false},
+		{"empty", "", true},
+		{"uid only", "1000", true},
+		{"user name", "app:app", true},
+		{"negative id", "-1:1000", true},
+		{"extra segment", "1000:1000:1000", true},
+		{"id overflow", "99999999999:1000", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateContainerUser(tt.user)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateContainerUser(%q) error = %v, wantErr %v", tt.user, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRepoSlug(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    string
+		wantErr bool
+	}{
+		{"upstream", "karloscodes/infinity-metrics-installer", false},
+		{"dotted repo", "acme/metrics.installer", false},
+		{"empty", "", true},
+		{"owner only", "acme", true},
+		{"url", "https://github.com/acme/installer", true},
+		{"extra segment", "acme/installer/releases", true},
+		{"parent dir", "acme/..", true},
+		{"escaped path", "acme/..%2f", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRepoSlug(tt.repo)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRepoSlug(%q) error = %v, wantErr %v", tt.repo, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90", 90 * time.Second, false},
+		{"1m30s", 90 * time.Second, false},
+		{"1500ms", 1500 * time.Millisecond, false},
+		{"0", 0, true},
+		{"-5s", 0, true},
+		{"soon", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeout(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTimeout(%q) = %s, %v, want %s, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateLogSince(t *testing.T) {
+	tests := []struct {
+		name    string
+		since   string
+		wantErr bool
+	}{
+		{"minutes", "10m", false},
+		{"compound duration", "2h30m", false},
+		{"unix timestamp", "1704207845", false},
+		{"rfc3339", "2024-01-02T15:04:05Z", false},
+		{"date only", "2024-01-02", false},
+		{"empty", "", true},
+		{"negative duration", "-5m", true},
+		{"garbage", "yesterday", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLogSince(tt.since)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLogSince(%q) error = %v, wantErr %v", tt.since, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRetentionDays(t *testing.T) {
+	tests := []struct {
+		days    int
+		wantErr bool
+	}{
+		{1, false},
+		{30, false},
+		{MaxRetentionDays, false},
+		{0, true},
+		{-7, true},
+		{MaxRetentionDays + 1, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateRetentionDays(tt.days)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateRetentionDays(%d) error = %v, wantErr %v", tt.days, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateKeepImageVersions(t *testing.T) {
+	for versions, wantErr := range map[int]bool{1: false, 3: false, MaxKeepImageVersions: false, 0: true, -1: true, MaxKeepImageVersions + 1: true} {
+		err := ValidateKeepImageVersions(versions)
+		if (err != nil) != wantErr {
+			t.Errorf("ValidateKeepImageVersions(%d) error = %v, wantErr %v", versions, err, wantErr)
+		}
+	}
+}
+
+// writeCertificatePair writes a self-signed certificate and its key as PEM files
+func writeCertificatePair(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "analytics.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestValidateCertificatePair(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeCertificatePair(t, dir, "site")
+	_, otherKeyPath := writeCertificatePair(t, dir, "other")
+
+	tests := []struct {
+		name     string
+		certPath string
+		keyPath  string
+		wantErr  bool
+	}{
+		{"matching pair", certPath, keyPath, false},
+		{"mismatched key", certPath, otherKeyPath, true},
+		{"key as certificate", keyPath, keyPath, true},
+		{"missing file", filepath.Join(dir, "missing.crt"), keyPath, true},
+		{"empty path", "", keyPath, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCertificatePair(tt.certPath, tt.keyPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCertificatePair() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateListenStack(t *testing.T) {
+	for stack, wantErr := range map[string]bool{"ipv4": false, "ipv6": false, "dual": false, "IPv4": true, "both": true, "": true} {
+		if err := ValidateListenStack(stack); (err != nil) != wantErr {
+			t.Errorf("ValidateListenStack(%q) error = %v, wantErr %v", stack, err, wantErr)
+		}
+	}
+}
+
+func TestValidateSELinuxRelabel(t *testing.T) {
+	for mode, wantErr := range map[string]bool{"true": false, "false": false, "auto": false, "Z": true, "yes": true, "": true} {
+		if err := ValidateSELinuxRelabel(mode); (err != nil) != wantErr {
+			t.Errorf("ValidateSELinuxRelabel(%q) error = %v, wantErr %v", mode, err, wantErr)
+		}
+	}
+}
+
+func TestValidateAppHealthScheme(t *testing.T) {
+	for scheme, wantErr := range map[string]bool{"http": false, "https": false, "HTTPS": false, "unix": true, "tcp": true, "": true} {
+		if err := ValidateAppHealthScheme(scheme); (err != nil) != wantErr {
+			t.Errorf("ValidateAppHealthScheme(%q) error = %v, wantErr %v", scheme, err, wantErr)
+		}
+	}
+}
+
+func TestValidateCPULimit(t *testing.T) {
+	tests := []struct {
+		limit   string
+		wantErr bool
+	}{
+		{"1", false},
+		{"0.5", false},
+		{"2.25", false},
+		{"0", true},
+		{"0.0", true},
+		{"-1", true},
+		{".5", true},
+		{"1e2", true},
+		{"two", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateCPULimit(tt.limit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateCPULimit(%q) error = %v, wantErr %v", tt.limit, err, tt.wantErr)
+		}
+	}
+}